@@ -47,6 +47,23 @@ func (s Severity) String() string {
 	}
 }
 
+// MarshalText encodes the severity as its label.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity label.
+func (s *Severity) UnmarshalText(text []byte) error {
+	parsed, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+
+	*s = parsed
+
+	return nil
+}
+
 // ParseSeverity parses a severity string (case-insensitive).
 // Returns an error for unrecognised values.
 func ParseSeverity(s string) (Severity, error) {
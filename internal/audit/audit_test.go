@@ -2,6 +2,7 @@ package audit_test
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -61,6 +62,18 @@ func TestParseSeverity(t *testing.T) {
 	}
 }
 
+func TestSeverity_JSON(t *testing.T) {
+	data, err := json.Marshal(audit.Finding{RuleID: "SEC-001", Severity: audit.SeverityHigh})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"severity":"high"`)
+
+	var f audit.Finding
+	require.NoError(t, json.Unmarshal(data, &f))
+	assert.Equal(t, audit.SeverityHigh, f.Severity)
+
+	require.Error(t, json.Unmarshal([]byte(`{"severity":"urgent"}`), &f))
+}
+
 func TestResult_Passed(t *testing.T) {
 	t.Run("no findings passes any threshold", func(t *testing.T) {
 		r := &audit.Result{Summary: map[string]int{}}
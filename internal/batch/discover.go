@@ -0,0 +1,216 @@
+// Package batch discovers Helm charts for batch conversion and reports the
+// results of converting them.
+package batch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/repo"
+
+	"github.com/hupe1980/chart2kro/internal/helm/loader"
+)
+
+// ConfigFileName is the name of a chart's own config file.
+const ConfigFileName = ".chart2kro.yaml"
+
+// Chart is a discovered chart.
+type Chart struct {
+	// ID identifies the chart within the batch: its path relative to the
+	// searched directory, or its name in a repository index.
+	ID string `json:"id"`
+	// Name is the chart name.
+	Name string `json:"name"`
+	// Version is the chart version.
+	Version string `json:"version,omitempty"`
+	// Ref is the chart reference to convert.
+	Ref string `json:"ref"`
+	// RepoURL is the repository Ref resolves against, if any.
+	RepoURL string `json:"repoURL,omitempty"`
+	// ConfigFile is the chart's own .chart2kro.yaml, if any.
+	ConfigFile string `json:"configFile,omitempty"`
+}
+
+// OutputName returns the file name of the chart's RGD.
+func (c Chart) OutputName() string {
+	return strings.ReplaceAll(c.ID, "/", "-") + ".yaml"
+}
+
+// CheckOutputNames returns an error when two charts have the same output
+// name, e.g. the IDs "a/b-c" and "a-b/c".
+func CheckOutputNames(charts []Chart) error {
+	ids := make(map[string]string, len(charts))
+
+	for _, c := range charts {
+		name := c.OutputName()
+		if other, ok := ids[name]; ok {
+			return fmt.Errorf("charts %q and %q would both be written to %s", other, c.ID, name)
+		}
+
+		ids[name] = c.ID
+	}
+
+	return nil
+}
+
+// Discover finds the charts of source: a directory searched recursively for
+// chart directories, a local repository index file, or the URL of a Helm
+// repository (with or without the trailing /index.yaml). The charts are
+// sorted by ID; for repositories, the latest version of each chart is used.
+func Discover(ctx context.Context, source string, opts loader.LoadOptions) ([]Chart, error) {
+	if u, err := url.Parse(source); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		opts.RepoURL = strings.TrimSuffix(strings.TrimSuffix(source, "/index.yaml"), "/")
+
+		index, err := loader.FetchIndex(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return fromIndex(index, func(cv *repo.ChartVersion) (string, string) {
+			return cv.Name, opts.RepoURL
+		}), nil
+	}
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, fmt.Errorf("reading chart source: %w", err)
+	}
+
+	if !info.IsDir() {
+		index, err := loader.LoadIndexFile(source)
+		if err != nil {
+			return nil, err
+		}
+
+		return fromIndex(index, localIndexRef(filepath.Dir(source))), nil
+	}
+
+	return discoverDir(source)
+}
+
+// discoverDir finds the chart directories below root. Hidden directories,
+// library charts, and the subcharts of a chart are skipped.
+func discoverDir(root string) ([]Chart, error) {
+	var charts []Chart
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		if p != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		chartFile := filepath.Join(p, chartutil.ChartfileName)
+		if _, statErr := os.Stat(chartFile); statErr != nil {
+			return nil
+		}
+
+		metadata, loadErr := chartutil.LoadChartfile(chartFile)
+		if loadErr != nil {
+			return fmt.Errorf("reading %s: %w", chartFile, loadErr)
+		}
+
+		// Library charts cannot be converted on their own.
+		if metadata.Type == "library" {
+			return filepath.SkipDir
+		}
+
+		c := Chart{Name: metadata.Name, Version: metadata.Version, Ref: p}
+
+		rel, _ := filepath.Rel(root, p)
+		c.ID = filepath.ToSlash(rel)
+
+		if c.ID == "." {
+			c.ID = metadata.Name
+		}
+
+		if cfg := filepath.Join(p, ConfigFileName); fileExists(cfg) {
+			c.ConfigFile = cfg
+		}
+
+		charts = append(charts, c)
+
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, fmt.Errorf("discovering charts: %w", err)
+	}
+
+	if len(charts) == 0 {
+		return nil, fmt.Errorf("no charts found in %s", root)
+	}
+
+	sort.Slice(charts, func(i, j int) bool { return charts[i].ID < charts[j].ID })
+
+	return charts, nil
+}
+
+// fromIndex lists the latest version of every chart of the index. ref
+// returns the chart reference and repository URL of a chart version.
+func fromIndex(index *repo.IndexFile, ref func(*repo.ChartVersion) (string, string)) []Chart {
+	names := make([]string, 0, len(index.Entries))
+	for name, versions := range index.Entries {
+		if len(versions) > 0 {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	charts := make([]Chart, 0, len(names))
+
+	for _, name := range names {
+		cv := index.Entries[name][0]
+		chartRef, repoURL := ref(cv)
+
+		charts = append(charts, Chart{
+			ID:      name,
+			Name:    name,
+			Version: cv.Version,
+			Ref:     chartRef,
+			RepoURL: repoURL,
+		})
+	}
+
+	return charts
+}
+
+// localIndexRef resolves chart versions of an index file in dir: archives
+// with relative URLs are read next to the index, others are fetched from
+// the repository serving them.
+func localIndexRef(dir string) func(*repo.ChartVersion) (string, string) {
+	return func(cv *repo.ChartVersion) (string, string) {
+		if len(cv.URLs) == 0 {
+			return cv.Name, ""
+		}
+
+		u, err := url.Parse(cv.URLs[0])
+		if err != nil || u.Scheme == "" {
+			return filepath.Join(dir, filepath.FromSlash(cv.URLs[0])), ""
+		}
+
+		u.Path = path.Dir(u.Path)
+
+		return cv.Name, u.String()
+	}
+}
+
+func fileExists(p string) bool {
+	info, err := os.Stat(p)
+
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,115 @@
+package batch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/chart2kro/internal/helm/loader"
+)
+
+func TestDiscover_Directory(t *testing.T) {
+	root := filepath.Join("..", "..", "testdata", "charts")
+
+	charts, err := Discover(context.Background(), root, loader.LoadOptions{})
+	require.NoError(t, err)
+
+	ids := make([]string, 0, len(charts))
+	for _, c := range charts {
+		ids = append(ids, c.ID)
+	}
+
+	// The library chart and the vendored subcharts are skipped.
+	assert.Equal(t, []string{"simple", "with-aliases", "with-database", "with-hooks", "with-subchart"}, ids)
+	assert.Equal(t, "simple", charts[0].Name)
+	assert.Equal(t, "1.0.0", charts[0].Version)
+	assert.Equal(t, filepath.Join(root, "simple"), charts[0].Ref)
+	assert.Empty(t, charts[0].RepoURL)
+}
+
+func TestDiscover_ChartConfigFile(t *testing.T) {
+	root := t.TempDir()
+	writeChart(t, filepath.Join(root, "team-a", "api"), "api")
+	writeChart(t, filepath.Join(root, "team-b", "web"), "web")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "team-b", "web", ConfigFileName), []byte("{}"), 0o600))
+	writeChart(t, filepath.Join(root, ".hidden", "skipped"), "skipped")
+
+	charts, err := Discover(context.Background(), root, loader.LoadOptions{})
+	require.NoError(t, err)
+	require.Len(t, charts, 2)
+
+	assert.Equal(t, "team-a/api", charts[0].ID)
+	assert.Equal(t, "team-a-api.yaml", charts[0].OutputName())
+	assert.Empty(t, charts[0].ConfigFile)
+	assert.Equal(t, "team-b/web", charts[1].ID)
+	assert.Equal(t, filepath.Join(root, "team-b", "web", ConfigFileName), charts[1].ConfigFile)
+}
+
+func TestCheckOutputNames(t *testing.T) {
+	charts := []Chart{{ID: "a/b-c"}, {ID: "a/b"}, {ID: "team/web"}}
+	require.NoError(t, CheckOutputNames(charts))
+
+	err := CheckOutputNames(append(charts, Chart{ID: "a-b/c"}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `charts "a/b-c" and "a-b/c"`)
+	assert.Contains(t, err.Error(), "a-b-c.yaml")
+}
+
+func TestDiscover_RootChart(t *testing.T) {
+	root := t.TempDir()
+	writeChart(t, root, "app")
+
+	charts, err := Discover(context.Background(), root, loader.LoadOptions{})
+	require.NoError(t, err)
+	require.Len(t, charts, 1)
+	assert.Equal(t, "app", charts[0].ID)
+}
+
+func TestDiscover_IndexFile(t *testing.T) {
+	dir := t.TempDir()
+	index := filepath.Join(dir, "index.yaml")
+	require.NoError(t, os.WriteFile(index, []byte(`apiVersion: v1
+entries:
+  api:
+    - name: api
+      version: 1.1.0
+      urls: [api-1.1.0.tgz]
+    - name: api
+      version: 1.0.0
+      urls: [api-1.0.0.tgz]
+  web:
+    - name: web
+      version: 2.0.0
+      urls: [https://charts.example.com/stable/web-2.0.0.tgz]
+`), 0o600))
+
+	charts, err := Discover(context.Background(), index, loader.LoadOptions{})
+	require.NoError(t, err)
+	require.Len(t, charts, 2)
+
+	assert.Equal(t, Chart{ID: "api", Name: "api", Version: "1.1.0", Ref: filepath.Join(dir, "api-1.1.0.tgz")}, charts[0])
+	assert.Equal(t, Chart{
+		ID: "web", Name: "web", Version: "2.0.0", Ref: "web",
+		RepoURL: "https://charts.example.com/stable",
+	}, charts[1])
+}
+
+func TestDiscover_Errors(t *testing.T) {
+	_, err := Discover(context.Background(), filepath.Join(t.TempDir(), "missing"), loader.LoadOptions{})
+	require.Error(t, err)
+
+	_, err = Discover(context.Background(), t.TempDir(), loader.LoadOptions{})
+	require.ErrorContains(t, err, "no charts found")
+}
+
+func writeChart(t *testing.T, dir, name string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(dir, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Chart.yaml"),
+		[]byte("apiVersion: v2\nname: "+name+"\nversion: 0.1.0\n"), 0o600))
+}
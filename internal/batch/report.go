@@ -0,0 +1,164 @@
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hupe1980/chart2kro/pkg/chart2kro"
+)
+
+// Status values of a report entry.
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// severities orders audit severities from most to least severe.
+var severities = []string{"critical", "high", "medium", "low", "info"}
+
+// Report is the aggregated result of a batch conversion.
+type Report struct {
+	Total     int     `json:"total"`
+	Succeeded int     `json:"succeeded"`
+	Failed    int     `json:"failed"`
+	Charts    []Entry `json:"charts"`
+}
+
+// Entry is the result of converting a chart of the batch.
+type Entry struct {
+	Chart
+
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Output is the path the RGD was written to, if any.
+	Output       string `json:"output,omitempty"`
+	Resources    int    `json:"resources"`
+	SchemaFields int    `json:"schemaFields"`
+	// Warnings are hardening warnings and dependency cycle edges broken by
+	// the configured cycle strategy.
+	Warnings []string `json:"warnings,omitempty"`
+	// Audit counts the audit findings by severity.
+	Audit      map[string]int `json:"audit,omitempty"`
+	DurationMs int64          `json:"durationMs"`
+}
+
+// NewEntry builds the report entry of a converted chart.
+func NewEntry(c Chart, r chart2kro.BatchResult) Entry {
+	e := Entry{Chart: c, Status: StatusOK, DurationMs: r.Duration.Milliseconds()}
+
+	if r.Err != nil {
+		e.Status = StatusFailed
+		e.Error = r.Err.Error()
+
+		return e
+	}
+
+	res := r.Result
+	e.Resources = res.ResourceCount
+	e.SchemaFields = res.SchemaFieldCount
+
+	if res.ChartVersion != "" {
+		e.Version = res.ChartVersion
+	}
+
+	if res.HardenResult != nil {
+		e.Warnings = append(e.Warnings, res.HardenResult.Warnings...)
+	}
+
+	for _, b := range res.BrokenEdges {
+		e.Warnings = append(e.Warnings, fmt.Sprintf("dependency cycle broken: %s -> %s (%s)", b.From, b.To, strings.Join(b.Reasons, ", ")))
+	}
+
+	for _, f := range res.AuditFindings {
+		if e.Audit == nil {
+			e.Audit = make(map[string]int)
+		}
+
+		e.Audit[f.Severity]++
+	}
+
+	return e
+}
+
+// NewReport aggregates the entries.
+func NewReport(entries []Entry) *Report {
+	r := &Report{Total: len(entries), Charts: entries}
+
+	for _, e := range entries {
+		if e.Status == StatusOK {
+			r.Succeeded++
+		} else {
+			r.Failed++
+		}
+	}
+
+	return r
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+// WriteTable writes the report as a table followed by the failures and
+// warnings.
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(tw, "CHART\tVERSION\tSTATUS\tRESOURCES\tSCHEMA\tWARNINGS\tAUDIT\tDURATION")
+	_, _ = fmt.Fprintln(tw, "-----\t-------\t------\t---------\t------\t--------\t-----\t--------")
+
+	for _, e := range r.Charts {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s\n",
+			e.ID, e.Version, strings.ToUpper(e.Status), e.Resources, e.SchemaFields, len(e.Warnings),
+			auditSummary(e.Audit), (time.Duration(e.DurationMs) * time.Millisecond).String())
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	var notes []string
+
+	for _, e := range r.Charts {
+		if e.Error != "" {
+			notes = append(notes, fmt.Sprintf("%s: %s", e.ID, e.Error))
+		}
+
+		for _, warning := range e.Warnings {
+			notes = append(notes, fmt.Sprintf("%s: warning: %s", e.ID, warning))
+		}
+	}
+
+	if len(notes) > 0 {
+		_, _ = fmt.Fprintf(w, "\n%s\n", strings.Join(notes, "\n"))
+	}
+
+	_, _ = fmt.Fprintf(w, "\nCharts: %d total, %d succeeded, %d failed\n", r.Total, r.Succeeded, r.Failed)
+
+	return nil
+}
+
+// auditSummary formats audit counts as "1 high, 2 medium", or "-".
+func auditSummary(counts map[string]int) string {
+	parts := make([]string, 0, len(counts))
+
+	for _, sev := range severities {
+		if n := counts[sev]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, sev))
+		}
+	}
+
+	if len(parts) == 0 {
+		return "-"
+	}
+
+	return strings.Join(parts, ", ")
+}
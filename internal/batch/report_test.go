@@ -0,0 +1,83 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/chart2kro/pkg/chart2kro"
+)
+
+func testReport() *Report {
+	ok := NewEntry(Chart{ID: "api", Name: "api", Version: "0.1.0"}, chart2kro.BatchResult{
+		Result: &chart2kro.Result{
+			ChartVersion:     "1.0.0",
+			ResourceCount:    3,
+			SchemaFieldCount: 5,
+			HardenResult:     &chart2kro.HardenSummary{Warnings: []string{"image tag is not pinned"}},
+			AuditFindings: []chart2kro.AuditFinding{
+				{RuleID: "SEC-001", Severity: "high"},
+				{RuleID: "SEC-002", Severity: "low"},
+				{RuleID: "SEC-003", Severity: "low"},
+			},
+			BrokenEdges: []chart2kro.BrokenEdge{{From: "a", To: "b", Reasons: []string{"env"}}},
+		},
+		Duration: 20 * time.Millisecond,
+	})
+
+	failed := NewEntry(Chart{ID: "web", Name: "web"}, chart2kro.BatchResult{Err: errors.New("loading chart: not found")})
+
+	return NewReport([]Entry{ok, failed})
+}
+
+func TestNewEntry(t *testing.T) {
+	r := testReport()
+
+	ok := r.Charts[0]
+	assert.Equal(t, StatusOK, ok.Status)
+	assert.Equal(t, "1.0.0", ok.Version)
+	assert.Equal(t, 3, ok.Resources)
+	assert.Equal(t, 5, ok.SchemaFields)
+	assert.Equal(t, []string{"image tag is not pinned", "dependency cycle broken: a -> b (env)"}, ok.Warnings)
+	assert.Equal(t, map[string]int{"high": 1, "low": 2}, ok.Audit)
+	assert.Equal(t, int64(20), ok.DurationMs)
+
+	failed := r.Charts[1]
+	assert.Equal(t, StatusFailed, failed.Status)
+	assert.Equal(t, "loading chart: not found", failed.Error)
+
+	assert.Equal(t, 2, r.Total)
+	assert.Equal(t, 1, r.Succeeded)
+	assert.Equal(t, 1, r.Failed)
+}
+
+func TestReport_WriteTable(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testReport().WriteTable(&buf))
+
+	out := buf.String()
+	assert.Contains(t, out, "CHART")
+	assert.Contains(t, out, "1 high, 2 low")
+	assert.Contains(t, out, "FAILED")
+	assert.Contains(t, out, "web: loading chart: not found")
+	assert.Contains(t, out, "api: warning: image tag is not pinned")
+	assert.Contains(t, out, "Charts: 2 total, 1 succeeded, 1 failed")
+}
+
+func TestReport_WriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testReport().WriteJSON(&buf))
+
+	var decoded Report
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+
+	assert.Equal(t, 1, decoded.Failed)
+	require.Len(t, decoded.Charts, 2)
+	assert.Equal(t, "api", decoded.Charts[0].ID)
+	assert.Equal(t, 2, decoded.Charts[0].Audit["low"])
+}
@@ -174,12 +174,15 @@ func loadChartResources(ctx context.Context, ref string, opts *auditOptions) ([]
 	logger.Info("loading chart", slog.String("ref", ref))
 
 	multiLoader := loader.NewMultiLoader()
-	ch, err := multiLoader.Load(ctx, ref, loader.LoadOptions{
+	loadOpts := loader.LoadOptions{
 		Version:  opts.version,
 		RepoURL:  opts.repoURL,
 		Username: opts.username,
 		Password: opts.password,
-	})
+	}
+	applyCacheConfig(ctx, &loadOpts)
+
+	ch, err := multiLoader.Load(ctx, ref, loadOpts)
 	if err != nil {
 		return nil, &ExitError{Code: 1, Err: fmt.Errorf("loading chart: %w", err)}
 	}
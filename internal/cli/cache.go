@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hupe1980/chart2kro/internal/config"
+	"github.com/hupe1980/chart2kro/internal/helm/cache"
+	"github.com/hupe1980/chart2kro/internal/helm/loader"
+)
+
+// chartCache returns the chart cache configured in ctx, or nil when no cache
+// directory can be determined.
+func chartCache(ctx context.Context) *cache.Cache {
+	dir := config.FromContext(ctx).CacheDir
+	if dir == "" {
+		dir = cache.DefaultDir()
+	}
+
+	if dir == "" {
+		return nil
+	}
+
+	return cache.New(dir)
+}
+
+// applyCacheConfig sets the cache, offline mode, and index TTL from the
+// global configuration on opts.
+func applyCacheConfig(ctx context.Context, opts *loader.LoadOptions) {
+	cfg := config.FromContext(ctx)
+
+	opts.Cache = chartCache(ctx)
+	opts.Offline = cfg.Offline
+	opts.IndexTTL = cfg.IndexTTL
+}
+
+type cacheListOptions struct {
+	format string
+}
+
+type cachePruneOptions struct {
+	olderThan time.Duration
+	all       bool
+	dryRun    bool
+}
+
+func newCacheCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the local chart cache",
+		Long: `Manage the persistent chart cache.
+
+Chart archives and repository indexes downloaded by convert, inspect,
+plan, diff, watch, and audit are cached under --cache-dir (default:
+$XDG_CACHE_HOME/chart2kro). Archives are stored by content digest and
+looked up by repository, chart, and version. Use --offline to serve charts
+from the cache without network access.`,
+	}
+
+	cmd.AddCommand(newCacheListCommand(), newCachePruneCommand())
+
+	return cmd
+}
+
+func newCacheListCommand() *cobra.Command {
+	opts := &cacheListOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List cached charts and repository indexes",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runCacheList(cmd.Context(), cmd.OutOrStdout(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.format, "format", "table", "output format: table, json")
+
+	return cmd
+}
+
+func newCachePruneCommand() *cobra.Command {
+	opts := &cachePruneOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove stale cache entries",
+		Long: `Remove cached charts not used and repository indexes not refreshed within
+--older-than, and delete archive blobs no longer referenced.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runCachePrune(cmd.Context(), cmd.OutOrStdout(), opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.DurationVar(&opts.olderThan, "older-than", 30*24*time.Hour, "remove entries unused for longer than this")
+	f.BoolVar(&opts.all, "all", false, "remove all cache entries")
+	f.BoolVar(&opts.dryRun, "dry-run", false, "report what would be removed without deleting")
+
+	return cmd
+}
+
+type cacheListResult struct {
+	Dir     string              `json:"dir"`
+	Charts  []*cache.Entry      `json:"charts"`
+	Indexes []*cache.IndexEntry `json:"indexes"`
+}
+
+func runCacheList(ctx context.Context, w io.Writer, opts *cacheListOptions) error {
+	c := chartCache(ctx)
+	if c == nil {
+		return &ExitError{Code: 1, Err: fmt.Errorf("cannot determine cache directory; use --cache-dir")}
+	}
+
+	charts, err := c.List()
+	if err != nil {
+		return &ExitError{Code: 1, Err: fmt.Errorf("listing cache: %w", err)}
+	}
+
+	indexes, err := c.ListIndexes()
+	if err != nil {
+		return &ExitError{Code: 1, Err: fmt.Errorf("listing cached indexes: %w", err)}
+	}
+
+	result := cacheListResult{Dir: c.Dir(), Charts: charts, Indexes: indexes}
+
+	switch opts.format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(result)
+	case "table":
+		printCacheTable(w, result)
+		return nil
+	default:
+		return &ExitError{Code: 2, Err: fmt.Errorf("unknown format %q: expected table, json", opts.format)}
+	}
+}
+
+func printCacheTable(w io.Writer, result cacheListResult) {
+	_, _ = fmt.Fprintf(w, "Cache: %s\n", result.Dir)
+	_, _ = fmt.Fprintf(w, "\n--- Charts (%d) ---\n", len(result.Charts))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "REPOSITORY\tCHART\tVERSION\tDIGEST\tSIZE\tLAST USED")
+
+	for _, e := range result.Charts {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			e.Repository, e.Chart, e.Version, shortDigest(e.Digest), e.Size, e.LastUsed.Format(time.RFC3339))
+	}
+
+	_ = tw.Flush()
+
+	_, _ = fmt.Fprintf(w, "\n--- Repository Indexes (%d) ---\n", len(result.Indexes))
+
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "URL\tSIZE\tFETCHED")
+
+	for _, idx := range result.Indexes {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%s\n", idx.URL, idx.Size, idx.FetchedAt.Format(time.RFC3339))
+	}
+
+	_ = tw.Flush()
+}
+
+// shortDigest abbreviates a "sha256:<hex>" digest for display.
+func shortDigest(digest string) string {
+	const n = len("sha256:") + 12
+	if len(digest) > n {
+		return digest[:n]
+	}
+
+	return digest
+}
+
+func runCachePrune(ctx context.Context, w io.Writer, opts *cachePruneOptions) error {
+	c := chartCache(ctx)
+	if c == nil {
+		return &ExitError{Code: 1, Err: fmt.Errorf("cannot determine cache directory; use --cache-dir")}
+	}
+
+	result, err := c.Prune(cache.PruneOptions{
+		OlderThan: opts.olderThan,
+		All:       opts.all,
+		DryRun:    opts.dryRun,
+	})
+	if err != nil {
+		return &ExitError{Code: 1, Err: fmt.Errorf("pruning cache: %w", err)}
+	}
+
+	verb := "Removed"
+	if opts.dryRun {
+		verb = "Would remove"
+	}
+
+	for _, e := range result.Charts {
+		_, _ = fmt.Fprintf(w, "%s chart %s %s (%s)\n", verb, e.Chart, e.Version, e.Repository)
+	}
+
+	for _, idx := range result.Indexes {
+		_, _ = fmt.Fprintf(w, "%s index %s\n", verb, idx.URL)
+	}
+
+	_, _ = fmt.Fprintf(w, "%s %d chart(s) and %d index(es), %d bytes.\n",
+		verb, len(result.Charts), len(result.Indexes), result.Bytes)
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/chart2kro/internal/helm/cache"
+)
+
+func TestCacheList_Table(t *testing.T) {
+	dir := t.TempDir()
+	_, err := cache.New(dir).PutChart("https://charts.example.com", "redis", "18.1.0", []byte("archive"))
+	require.NoError(t, err)
+
+	stdout, _, err := executeCommand("cache", "list", "--cache-dir", dir)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Charts (1)")
+	assert.Contains(t, stdout, "redis")
+	assert.Contains(t, stdout, "18.1.0")
+}
+
+func TestCacheList_JSON(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, cache.New(dir).PutIndex("https://charts.example.com/index.yaml", []byte("x")))
+
+	stdout, _, err := executeCommand("cache", "list", "--cache-dir", dir, "--format", "json")
+	require.NoError(t, err)
+
+	var result cacheListResult
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, dir, result.Dir)
+	require.Len(t, result.Indexes, 1)
+	assert.Equal(t, "https://charts.example.com/index.yaml", result.Indexes[0].URL)
+}
+
+func TestCacheList_UnknownFormat(t *testing.T) {
+	_, _, err := executeCommand("cache", "list", "--cache-dir", t.TempDir(), "--format", "xml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown format")
+}
+
+func TestCachePrune_All(t *testing.T) {
+	dir := t.TempDir()
+	_, err := cache.New(dir).PutChart("https://charts.example.com", "redis", "18.1.0", []byte("archive"))
+	require.NoError(t, err)
+
+	stdout, _, err := executeCommand("cache", "prune", "--cache-dir", dir, "--all", "--dry-run")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Would remove chart redis 18.1.0")
+
+	stdout, _, err = executeCommand("cache", "prune", "--cache-dir", dir, "--all")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Removed 1 chart(s)")
+
+	entries, err := cache.New(dir).List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestConvert_OfflineRemoteChartNotCached(t *testing.T) {
+	_, _, err := executeCommand("convert", "oci://127.0.0.1:1/charts/redis", "--version", "1.0.0",
+		"--offline", "--cache-dir", t.TempDir())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--offline")
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hupe1980/chart2kro/internal/compose"
+	"github.com/hupe1980/chart2kro/internal/logging"
+	"github.com/hupe1980/chart2kro/internal/output"
+)
+
+type composeOptions struct {
+	output   string
+	comments bool
+}
+
+func newComposeCommand() *cobra.Command {
+	opts := &composeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "compose <manifest>",
+		Short: "Compose several charts into a single umbrella RGD",
+		Long: `Compose converts the charts listed in a composition manifest and merges
+them into one ResourceGraphDefinition.
+
+Each chart's schema fields nest under spec.<name> and its status fields under
+status.<name>; its resource IDs are prefixed with "<prefix>-" (default: the
+chart name). Wiring binds a chart's values to CEL expressions, such as the
+Service name of another chart:
+
+  apiVersion: chart2kro.io/v1alpha1
+  kind: Composition
+  metadata:
+    name: webstack
+  spec:
+    charts:
+      - name: app
+        chart: ./charts/app
+        values:
+          replicaCount: 2
+      - name: redis
+        chart: oci://registry-1.docker.io/bitnamicharts/redis
+        version: 19.x
+        profile: minimal
+    wiring:
+      - chart: app
+        value: redis.host
+        from:
+          chart: redis
+          resource: service-master
+
+Charts take the same options as the krm-fn ChartToRGD spec. Relative chart
+and values file paths resolve against the manifest's directory.
+
+Exit codes:
+  0  Success
+  1  Conversion error
+  2  Invalid manifest
+  6  Write error
+  7  The composed RGD is invalid`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCompose(cmd.Context(), cmd, args[0], opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&opts.output, "output", "o", "", "output file path (default: stdout)")
+	f.BoolVar(&opts.comments, "comments", false, "add inline comments on CEL expressions")
+
+	return cmd
+}
+
+func runCompose(ctx context.Context, cmd *cobra.Command, path string, opts *composeOptions) error {
+	logger := logging.FromContext(ctx)
+
+	manifest, err := compose.Load(path)
+	if err != nil {
+		return &ExitError{Code: 2, Err: err}
+	}
+
+	result, err := compose.Compose(ctx, manifest)
+	if err != nil {
+		return &ExitError{Code: 1, Err: fmt.Errorf("composing charts: %w", err)}
+	}
+
+	validation := output.ValidateRGD(result.RGDMap)
+	if validation.HasErrors() {
+		_, _ = fmt.Fprint(cmd.ErrOrStderr(), output.FormatValidationResult(validation))
+
+		return &ExitError{Code: 7, Err: fmt.Errorf("composed RGD failed validation with %d error(s)", len(validation.Errors()))}
+	}
+
+	yamlBytes, err := output.Serialize(result.RGDMap, output.SerializeOptions{Comments: opts.comments, Indent: 2})
+	if err != nil {
+		return &ExitError{Code: 1, Err: fmt.Errorf("serializing RGD: %w", err)}
+	}
+
+	if opts.output != "" {
+		w := output.NewFileWriter(opts.output, output.WithLogger(logger))
+		if err := w.Write(yamlBytes); err != nil {
+			return &ExitError{Code: 6, Err: fmt.Errorf("writing output: %w", err)}
+		}
+
+		logger.Info("RGD written", slog.String("path", opts.output))
+	} else if _, err := cmd.OutOrStdout().Write(yamlBytes); err != nil {
+		return &ExitError{Code: 6, Err: fmt.Errorf("writing output: %w", err)}
+	}
+
+	printComposeSummary(cmd.ErrOrStderr(), result)
+
+	return nil
+}
+
+// printComposeSummary prints the resources contributed by each chart.
+func printComposeSummary(w io.Writer, result *compose.Result) {
+	_, _ = fmt.Fprintf(w, "\n--- Composition Summary ---\n")
+
+	for _, c := range result.Charts {
+		_, _ = fmt.Fprintf(w, "%-12s %s %s: %d resources, %d schema fields (IDs %s-*)\n",
+			c.Name, c.Result.ChartName, c.Result.ChartVersion, c.Result.ResourceCount, c.Result.SchemaFieldCount, c.Prefix)
+	}
+
+	_, _ = fmt.Fprintf(w, "---------------------------\n")
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+func writeComposeManifest(t *testing.T, wiring string) string {
+	t.Helper()
+
+	charts := filepath.Join(testdataDir(t), "charts")
+	manifest := fmt.Sprintf(`apiVersion: chart2kro.io/v1alpha1
+kind: Composition
+metadata:
+  name: webstack
+spec:
+  charts:
+    - name: web
+      chart: %s
+      releaseName: web
+    - name: db
+      chart: %s
+%s`, filepath.Join(charts, "simple"), filepath.Join(charts, "with-database"), wiring)
+
+	path := filepath.Join(t.TempDir(), "compose.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(manifest), 0o600))
+
+	return path
+}
+
+func TestCompose(t *testing.T) {
+	path := writeComposeManifest(t, `  wiring:
+    - chart: web
+      value: image.repository
+      from:
+        chart: db
+        resource: service-postgresql
+`)
+
+	stdout, stderr, err := executeCommand("compose", path)
+	require.NoError(t, err)
+	assert.Contains(t, stderr, "Composition Summary")
+
+	var rgd map[string]interface{}
+	require.NoError(t, sigsyaml.Unmarshal([]byte(stdout), &rgd))
+
+	schema := rgd["spec"].(map[string]interface{})["schema"].(map[string]interface{})
+	assert.Equal(t, "Webstack", schema["kind"])
+
+	spec := schema["spec"].(map[string]interface{})
+	assert.Contains(t, spec["db"], "replicaCount")
+	assert.NotContains(t, spec["web"].(map[string]interface{})["image"], "repository")
+
+	ids := make(map[string]map[string]interface{})
+	for _, r := range rgd["spec"].(map[string]interface{})["resources"].([]interface{}) {
+		rm := r.(map[string]interface{})
+		ids[rm["id"].(string)] = rm
+	}
+
+	assert.Contains(t, ids, "web-deployment")
+	assert.Contains(t, ids, "db-statefulset")
+	assert.Equal(t, []interface{}{"db-statefulset"}, ids["db-service-postgresql"]["dependsOn"])
+	assert.Contains(t, stdout, "image: ${db-service-postgresql.metadata.name}:${schema.spec.web.image.tag}")
+}
+
+func TestCompose_OutputFile(t *testing.T) {
+	path := writeComposeManifest(t, "")
+	out := filepath.Join(t.TempDir(), "rgd.yaml")
+
+	_, _, err := executeCommand("compose", path, "-o", out)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(out) //nolint:gosec // test file
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "chart2kro.dev/composition: web,db")
+}
+
+func TestCompose_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		wiring   string
+		wantCode int
+	}{
+		{"invalid manifest", "  wiring:\n    - chart: api\n      value: x\n      expression: ${1}\n", 2},
+		{"unknown resource", "  wiring:\n    - chart: web\n      value: image.repository\n      from: {chart: db, resource: ingress}\n", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := executeCommand("compose", writeComposeManifest(t, tt.wiring))
+			require.Error(t, err)
+
+			var exitErr *ExitError
+			require.ErrorAs(t, err, &exitErr)
+			assert.Equal(t, tt.wantCode, exitErr.Code)
+		})
+	}
+}
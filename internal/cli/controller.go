@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"github.com/hupe1980/chart2kro/internal/config"
+	"github.com/hupe1980/chart2kro/internal/controller"
+	"github.com/hupe1980/chart2kro/internal/logging"
+	"github.com/hupe1980/chart2kro/pkg/chart2kro"
+)
+
+type controllerOptions struct {
+	kubeconfig    string
+	metricsAddr   string
+	probeAddr     string
+	leaderElect   bool
+	maxConcurrent int
+	allowLocal    bool
+	allowPlugins  bool
+	printCRD      bool
+}
+
+func newControllerCommand() *cobra.Command {
+	opts := &controllerOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "controller",
+		Short: "Reconcile ChartConversion resources in a cluster",
+		Long: `Controller runs chart2kro as a Kubernetes controller reconciling
+ChartConversion resources (chart2kro.io/v1alpha1, cluster-scoped).
+
+A ChartConversion points to a chart with the options of the krm-fn
+ChartToRGD spec, which mirror the pkg/chart2kro options:
+
+  apiVersion: chart2kro.io/v1alpha1
+  kind: ChartConversion
+  metadata:
+    name: nginx
+  spec:
+    chart: oci://ghcr.io/org/charts/nginx
+    version: "~1.2"
+    profile: enterprise
+    values:
+      replicaCount: 2
+    interval: 1h
+
+The controller converts the highest chart version matching the version
+constraint into an RGD named after the ChartConversion, applies it, and
+checks for new chart versions at the interval (default: 10m). RGDs with
+breaking schema or resource changes are only applied with
+allowBreakingChanges: true; otherwise the deployed RGD is kept. The status
+reports the chart version, a plan summary, breaking-change and hardening
+warnings, and a Ready condition. An existing RGD of the same name is only
+updated when it is labeled app.kubernetes.io/managed-by=chart2kro or
+already controlled by the ChartConversion.
+
+Configs with transformer plugins are rejected unless --allow-plugins is
+set, since plugins run executables and load WASM modules in the
+controller.
+
+Install the CRD with:
+
+  chart2kro controller --print-crd | kubectl apply -f -`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if opts.printCRD {
+				_, err := cmd.OutOrStdout().Write(controller.CRD)
+
+				return err
+			}
+
+			return runController(cmd.Context(), opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&opts.kubeconfig, "kubeconfig", "", "path to the kubeconfig file (default: in-cluster config, $KUBECONFIG, or ~/.kube/config)")
+	f.StringVar(&opts.metricsAddr, "metrics-bind-address", ":8080", "metrics listen address (0 disables metrics)")
+	f.StringVar(&opts.probeAddr, "health-probe-bind-address", ":8081", "health probe listen address")
+	f.BoolVar(&opts.leaderElect, "leader-elect", false, "enable leader election for running several replicas")
+	f.IntVar(&opts.maxConcurrent, "max-concurrent-reconciles", 1, "maximum number of ChartConversions reconciled at once")
+	f.BoolVar(&opts.allowLocal, "allow-local", false, "accept chart paths and values files on the controller's file system")
+	f.BoolVar(&opts.allowPlugins, "allow-plugins", false, "accept transformer plugins in spec.config (runs executables in the controller)")
+	f.BoolVar(&opts.printCRD, "print-crd", false, "print the ChartConversion CRD and exit")
+
+	return cmd
+}
+
+func runController(ctx context.Context, opts *controllerOptions) error {
+	ctrl.SetLogger(logr.FromSlogHandler(logging.FromContext(ctx).Handler()))
+
+	restConfig, err := kubeConfig(opts.kubeconfig)
+	if err != nil {
+		return &ExitError{Code: 2, Err: err}
+	}
+
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
+		Scheme:                 runtime.NewScheme(),
+		Metrics:                metricsserver.Options{BindAddress: opts.metricsAddr},
+		HealthProbeBindAddress: opts.probeAddr,
+		LeaderElection:         opts.leaderElect,
+		LeaderElectionID:       "chart2kro-controller." + controller.Group,
+	})
+	if err != nil {
+		return &ExitError{Code: 1, Err: fmt.Errorf("creating manager: %w", err)}
+	}
+
+	r := &controller.Reconciler{
+		Options:      controllerConvertOptions(ctx),
+		AllowLocal:   opts.allowLocal,
+		AllowPlugins: opts.allowPlugins,
+	}
+
+	if err := r.SetupWithManager(mgr, opts.maxConcurrent); err != nil {
+		return &ExitError{Code: 1, Err: fmt.Errorf("setting up controller: %w", err)}
+	}
+
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		return &ExitError{Code: 1, Err: err}
+	}
+
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		return &ExitError{Code: 1, Err: err}
+	}
+
+	sigCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := mgr.Start(sigCtx); err != nil {
+		return &ExitError{Code: 1, Err: err}
+	}
+
+	return nil
+}
+
+// kubeConfig returns the REST config of the kubeconfig file at path, or the
+// default config when path is empty.
+func kubeConfig(path string) (*rest.Config, error) {
+	if path == "" {
+		cfg, err := ctrl.GetConfig()
+		if err != nil {
+			return nil, fmt.Errorf("loading kubeconfig: %w", err)
+		}
+
+		return cfg, nil
+	}
+
+	cfg, err := clientcmd.BuildConfigFromFlags("", path)
+	if err != nil {
+		return nil, fmt.Errorf("loading kubeconfig %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// controllerConvertOptions returns the conversion options the controller
+// adds to every ChartConversion: the chart cache and offline mode.
+func controllerConvertOptions(ctx context.Context) []chart2kro.Option {
+	var opts []chart2kro.Option
+
+	if c := chartCache(ctx); c != nil {
+		opts = append(opts, chart2kro.WithCacheDir(c.Dir()))
+	}
+
+	if config.FromContext(ctx).Offline {
+		opts = append(opts, chart2kro.WithOffline())
+	}
+
+	return opts
+}
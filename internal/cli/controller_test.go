@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestController_PrintCRD(t *testing.T) {
+	stdout, _, err := executeCommand("controller", "--print-crd")
+	require.NoError(t, err)
+
+	assert.Contains(t, stdout, "kind: CustomResourceDefinition")
+	assert.Contains(t, stdout, "name: chartconversions.chart2kro.io")
+}
+
+func TestController_InvalidKubeconfig(t *testing.T) {
+	_, _, err := executeCommand("controller", "--kubeconfig", filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+
+	var exitErr *ExitError
+	require.True(t, errors.As(err, &exitErr))
+	assert.Equal(t, 2, exitErr.Code)
+}
@@ -6,22 +6,19 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/spf13/cobra"
-	sigsyaml "sigs.k8s.io/yaml"
 
 	"github.com/hupe1980/chart2kro/internal/config"
 	"github.com/hupe1980/chart2kro/internal/filter"
 	"github.com/hupe1980/chart2kro/internal/harden"
-	"github.com/hupe1980/chart2kro/internal/helm/chartmeta"
 	"github.com/hupe1980/chart2kro/internal/helm/hooks"
 	"github.com/hupe1980/chart2kro/internal/helm/loader"
-	"github.com/hupe1980/chart2kro/internal/helm/renderer"
-	"github.com/hupe1980/chart2kro/internal/k8s"
-	"github.com/hupe1980/chart2kro/internal/k8s/parser"
 	"github.com/hupe1980/chart2kro/internal/logging"
 	"github.com/hupe1980/chart2kro/internal/output"
+	"github.com/hupe1980/chart2kro/internal/plan"
 	"github.com/hupe1980/chart2kro/internal/transform"
 )
 
@@ -35,12 +32,23 @@ type convertOptions struct {
 	certFile string
 	keyFile  string
 
+	plainHTTP           bool
+	resolveDependencies bool
+	verify              bool
+	keyring             string
+	publicKey           string
+
 	// Template rendering.
 	releaseName string
 	namespace   string
 	strict      bool
 	timeout     time.Duration
 
+	// Post-rendering.
+	postRenderer     string
+	postRendererArgs []string
+	kustomizeDir     string
+
 	// Values merging.
 	valueFiles   []string
 	values       []string
@@ -49,6 +57,13 @@ type convertOptions struct {
 
 	// Hook handling.
 	includeHooks bool
+	hookMode     string
+	// extractTests builds the conformance suite from Helm test hooks.
+	extractTests bool
+
+	// captureRendered keeps a copy of the rendered resources before
+	// parameterisation.
+	captureRendered bool
 
 	// Transformation.
 	kind             string
@@ -67,6 +82,10 @@ type convertOptions struct {
 	outputDir      string
 	embedTimestamp bool
 
+	// Schema evolution.
+	existing        string
+	evolutionPolicy string
+
 	// Resource filtering.
 	excludeKinds       []string
 	excludeResources   []string
@@ -77,6 +96,13 @@ type convertOptions struct {
 	useExternalPattern []string
 	profile            string
 
+	// Subchart nesting.
+	nestedSubcharts bool
+
+	// configData replaces the config file, e.g. for request-scoped config
+	// of the serve command.
+	configData []byte
+
 	// Hardening.
 	harden                  bool
 	securityLevel           string
@@ -111,6 +137,13 @@ OCI registries, and Helm repositories.`,
 	f.StringVar(&opts.caFile, "ca-file", "", "TLS CA certificate file")
 	f.StringVar(&opts.certFile, "cert-file", "", "TLS client certificate file")
 	f.StringVar(&opts.keyFile, "key-file", "", "TLS client key file")
+	f.BoolVar(&opts.plainHTTP, "plain-http", false, "use insecure HTTP connections for OCI registries")
+	f.BoolVar(&opts.resolveDependencies, "resolve-dependencies", false,
+		"fetch dependencies missing from charts/ (file://, repository, and OCI sources)")
+	f.BoolVar(&opts.verify, "verify", false,
+		"verify the chart signature (.prov against --keyring, or cosign against --public-key for OCI)")
+	f.StringVar(&opts.keyring, "keyring", loader.DefaultKeyring(), "PGP keyring used to verify .prov files")
+	f.StringVar(&opts.publicKey, "public-key", "", "PEM public key used to verify cosign signatures of OCI charts")
 
 	// Rendering flags.
 	f.StringVar(&opts.releaseName, "release-name", "release", "Helm release name for rendering")
@@ -118,6 +151,12 @@ OCI registries, and Helm repositories.`,
 	f.BoolVar(&opts.strict, "strict", false, "fail on missing template values")
 	f.DurationVar(&opts.timeout, "timeout", 30*time.Second, "template rendering timeout")
 
+	// Post-render flags.
+	f.StringVar(&opts.postRenderer, "post-renderer", "",
+		"executable that patches rendered manifests (Helm contract: manifests on stdin, patched manifests on stdout)")
+	f.StringArrayVar(&opts.postRendererArgs, "post-renderer-args", nil, "argument passed to the post-renderer (can specify multiple)")
+	f.StringVar(&opts.kustomizeDir, "kustomize-dir", "", "Kustomize overlay directory applied to rendered manifests")
+
 	// Values flags.
 	f.StringArrayVarP(&opts.valueFiles, "values", "f", nil, "values YAML files (can specify multiple)")
 	f.StringArrayVar(&opts.values, "set", nil, "set values (key=value, can specify multiple)")
@@ -125,7 +164,10 @@ OCI registries, and Helm repositories.`,
 	f.StringArrayVar(&opts.fileValues, "set-file", nil, "set values from files (key=filepath)")
 
 	// Hook handling flags.
-	f.BoolVar(&opts.includeHooks, "include-hooks", false, "include hook resources (strip hook annotations)")
+	f.BoolVar(&opts.includeHooks, "include-hooks", false,
+		"include hook resources (strip hook annotations; same as --hook-mode=include)")
+	f.StringVar(&opts.hookMode, "hook-mode", "drop",
+		"hook handling: drop, include, or ordered (install/upgrade hooks as ordered RGD resources)")
 
 	// Transformation flags.
 	f.StringVar(&opts.kind, "kind", "", "override generated CRD kind (default: PascalCase chart name)")
@@ -144,10 +186,17 @@ OCI registries, and Helm repositories.`,
 	f.StringVar(&opts.outputDir, "output-dir", "", "output directory for --split")
 	f.BoolVar(&opts.embedTimestamp, "embed-timestamp", false, "add chart2kro.io/generated-at annotation")
 
+	// Schema evolution flags.
+	f.StringVar(&opts.existing, "existing", "", "existing RGD YAML file to check schema evolution against")
+	f.StringVar(&opts.evolutionPolicy, "evolution-policy", "allow",
+		"on breaking schema changes against --existing: strict (fail), bump (next schema version side by side), or allow")
+
 	// Resource filtering flags.
 	f.StringSliceVar(&opts.excludeKinds, "exclude-kinds", nil, "exclude resources by kind (comma-separated)")
 	f.StringSliceVar(&opts.excludeResources, "exclude-resources", nil, "exclude resources by assigned ID (comma-separated)")
 	f.StringSliceVar(&opts.excludeSubcharts, "exclude-subcharts", nil, "exclude all resources from named subcharts (comma-separated)")
+	f.BoolVar(&opts.nestedSubcharts, "nested-subcharts", false,
+		"convert each subchart into its own RGD and instantiate it from the parent RGD")
 	f.StringVar(&opts.excludeLabels, "exclude-labels", "", "exclude resources matching label selector (e.g., component=database)")
 	f.StringArrayVar(&opts.externalizeSecret, "externalize-secret", nil, "externalize a Secret (name=schemaField)")
 	f.StringArrayVar(&opts.externalizeService, "externalize-service", nil, "externalize a Service (name=schemaField)")
@@ -210,31 +259,10 @@ func runConvert(ctx context.Context, cmd *cobra.Command, ref string, opts *conve
 		}
 	}
 
-	if opts.harden {
-		provAnnotations, provErr := harden.GenerateProvenanceAnnotations(harden.ProvenanceConfig{
-			ChartRef:          ref,
-			Profile:           opts.profile,
-			HardeningLevel:    harden.SecurityLevel(opts.securityLevel),
-			ExcludedSubcharts: opts.excludeSubcharts,
-			EmbedTimestamp:    opts.embedTimestamp,
-		})
-		if provErr != nil {
-			return &ExitError{Code: 1, Err: fmt.Errorf("generating provenance annotations: %w", provErr)}
-		}
-
-		metaMap, _ := rgdMap["metadata"].(map[string]interface{})
-		if metaMap != nil {
-			annotations, _ := metaMap["annotations"].(map[string]interface{})
-			if annotations == nil {
-				annotations = make(map[string]interface{})
-			}
-
-			for k, v := range provAnnotations {
-				annotations[k] = v
-			}
-
-			metaMap["annotations"] = annotations
-		}
+	// 11b. Apply the schema evolution policy.
+	superseded, err := applyEvolutionPolicy(cmd, opts, rgdMap)
+	if err != nil {
+		return err
 	}
 
 	// 12. Serialize and output.
@@ -242,6 +270,10 @@ func runConvert(ctx context.Context, cmd *cobra.Command, ref string, opts *conve
 		return &ExitError{Code: 2, Err: err}
 	}
 
+	if opts.split && len(res.Nested) > 0 {
+		return &ExitError{Code: 2, Err: fmt.Errorf("--nested-subcharts cannot be combined with --split")}
+	}
+
 	serOpts := output.SerializeOptions{
 		Comments: opts.comments,
 		Indent:   2,
@@ -268,6 +300,32 @@ func runConvert(ctx context.Context, cmd *cobra.Command, ref string, opts *conve
 		return &ExitError{Code: 1, Err: fmt.Errorf("serializing RGD: %w", err)}
 	}
 
+	if superseded != nil {
+		// Emit the superseded version first so both are applied together.
+		supersededBytes, serErr := output.Serialize(superseded, serOpts)
+		if serErr != nil {
+			return &ExitError{Code: 1, Err: fmt.Errorf("serializing superseded RGD: %w", serErr)}
+		}
+
+		yamlBytes = append(append(supersededBytes, "---\n"...), yamlBytes...)
+	}
+
+	if len(res.Nested) > 0 {
+		// Emit the subchart RGDs first so their kinds exist for the parent.
+		var nestedBytes []byte
+
+		for _, n := range res.Nested {
+			b, serErr := output.Serialize(n.RGDMap, serOpts)
+			if serErr != nil {
+				return &ExitError{Code: 1, Err: fmt.Errorf("serializing subchart %s RGD: %w", n.Path, serErr)}
+			}
+
+			nestedBytes = append(append(nestedBytes, b...), "---\n"...)
+		}
+
+		yamlBytes = append(nestedBytes, yamlBytes...)
+	}
+
 	if opts.dryRun {
 		_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "# Dry-run mode — output preview:")
 	}
@@ -288,177 +346,129 @@ func runConvert(ctx context.Context, cmd *cobra.Command, ref string, opts *conve
 	// 13. Print summary.
 	printConvertSummary(cmd.ErrOrStderr(), res.Result, res.HookResult, res.HardenResult)
 
+	if len(res.Nested) > 0 {
+		printNestedSummary(cmd.ErrOrStderr(), res.Nested)
+	}
+
 	return nil
 }
 
-// printConvertSummary prints a human-readable summary of the conversion.
-func printConvertSummary(w io.Writer, result *transform.Result, hookResult *hooks.FilterResult, hardenResult *harden.Result) {
-	_, _ = fmt.Fprintf(w, "\n--- Conversion Summary ---\n")
-	_, _ = fmt.Fprintf(w, "Resources:     %d\n", len(result.Resources))
-	_, _ = fmt.Fprintf(w, "Schema fields: %d\n", len(result.SchemaFields))
-	_, _ = fmt.Fprintf(w, "Status fields: %d\n", len(result.StatusFields))
-
-	if len(hookResult.DroppedHooks) > 0 {
-		_, _ = fmt.Fprintf(w, "Hooks dropped:  %d\n", len(hookResult.DroppedHooks))
+// applyEvolutionPolicy compares rgdMap with the --existing RGD and applies
+// --evolution-policy to breaking schema changes. With the bump policy,
+// rgdMap is rewritten as the next schema version and the existing RGD is
+// returned so that both versions are written side by side; compatible
+// changes keep the existing schema version.
+func applyEvolutionPolicy(cmd *cobra.Command, opts *convertOptions, rgdMap map[string]interface{}) (map[string]interface{}, error) {
+	policy, err := plan.ParseEvolutionPolicy(opts.evolutionPolicy)
+	if err != nil {
+		return nil, &ExitError{Code: 2, Err: err}
 	}
 
-	if len(hookResult.IncludedHooks) > 0 {
-		_, _ = fmt.Fprintf(w, "Hooks included: %d\n", len(hookResult.IncludedHooks))
-	}
+	if opts.existing == "" {
+		if policy != plan.PolicyAllow {
+			return nil, &ExitError{Code: 2, Err: fmt.Errorf("--evolution-policy=%s requires --existing", policy)}
+		}
 
-	if hardenResult != nil {
-		_, _ = fmt.Fprintf(w, "Hardening:      %d changes, %d warnings\n",
-			len(hardenResult.Changes), len(hardenResult.Warnings))
+		return nil, nil
 	}
 
-	_, _ = fmt.Fprintf(w, "--------------------------\n")
-}
+	if policy == plan.PolicyBump && opts.split {
+		return nil, &ExitError{Code: 2, Err: fmt.Errorf("--evolution-policy=bump cannot be combined with --split")}
+	}
 
-// buildFilterChain assembles a filter chain from CLI flags and profiles.
-// Returns nil if no filters are configured.
-func buildFilterChain(
-	ctx context.Context,
-	opts *convertOptions,
-	meta *chartmeta.ChartMeta,
-	values map[string]interface{},
-	resources []*k8s.Resource,
-) (*filter.Chain, error) {
-	var filters []filter.Filter
-
-	// 1. Profile-based filters.
-	if opts.profile != "" {
-		if filter.IsMinimalProfile(opts.profile) {
-			filters = append(filters, filter.NewMinimalFilter())
-		} else {
-			// Try loading custom profiles from config file.
-			var customProfiles map[string]filter.ProfileConfig
-
-			configData, err := tryReadConfigFile(ctx)
-			if err == nil && configData != nil {
-				customProfiles, _ = filter.ParseCustomProfiles(configData)
-			}
+	existing, err := loadRGDFile(opts.existing, 7)
+	if err != nil {
+		return nil, err
+	}
 
-			p, err := filter.ResolveProfile(opts.profile, customProfiles)
-			if err != nil {
-				return nil, err
-			}
+	evolution := plan.Analyze(existing, rgdMap)
+	w := cmd.ErrOrStderr()
 
-			profileFilters, err := filter.BuildFiltersFromProfile(p)
-			if err != nil {
-				return nil, fmt.Errorf("building filters from profile %q: %w", opts.profile, err)
-			}
+	breakingSchema := 0
 
-			filters = append(filters, profileFilters...)
+	for _, c := range evolution.SchemaChanges {
+		if c.Breaking {
+			breakingSchema++
 		}
 	}
 
-	// 2. Smart external pattern detection.
-	for _, name := range opts.useExternalPattern {
-		pattern, found := filter.DetectExternalPatternsForSubchart(meta, values, name)
-		if !found {
-			return nil, fmt.Errorf("no external pattern detected for subchart %q", name)
+	if breakingSchema == 0 {
+		if policy == plan.PolicyBump {
+			plan.KeepVersion(existing, rgdMap)
 		}
 
-		patternFilters := filter.BuildFiltersFromPattern(pattern)
-		filters = append(filters, patternFilters...)
-	}
-
-	// 3. Explicit subchart exclusion.
-	if len(opts.excludeSubcharts) > 0 {
-		filters = append(filters, filter.NewSubchartFilter(opts.excludeSubcharts))
+		return nil, nil
 	}
 
-	// 4. Kind exclusion.
-	if len(opts.excludeKinds) > 0 {
-		filters = append(filters, filter.NewKindFilter(opts.excludeKinds))
-	}
+	plan.FormatTable(w, evolution)
 
-	// 5. Label exclusion.
-	if opts.excludeLabels != "" {
-		lf, err := filter.NewLabelFilter(opts.excludeLabels)
+	switch policy {
+	case plan.PolicyStrict:
+		return nil, &ExitError{Code: 8, Err: fmt.Errorf(
+			"%d breaking schema change(s) against %s (use --evolution-policy=bump to publish a new schema version)",
+			breakingSchema, opts.existing)}
+	case plan.PolicyBump:
+		version, err := plan.NextVersion(plan.SchemaVersion(existing))
 		if err != nil {
-			return nil, fmt.Errorf("parsing label selector: %w", err)
+			return nil, &ExitError{Code: 1, Err: err}
 		}
 
-		filters = append(filters, lf)
-	}
+		mapping := plan.BuildFieldMapping(existing, rgdMap)
 
-	// 6. Resource ID exclusion (needs IDs assigned first).
-	if len(opts.excludeResources) > 0 {
-		tempIDs, err := transform.AssignResourceIDs(resources, nil)
-		if err != nil {
-			return nil, fmt.Errorf("assigning IDs for resource exclusion: %w", err)
+		if err := plan.BumpVersion(existing, rgdMap, version, mapping); err != nil {
+			return nil, &ExitError{Code: 1, Err: fmt.Errorf("bumping schema version: %w", err)}
 		}
 
-		filters = append(filters, filter.NewResourceIDFilter(opts.excludeResources, tempIDs))
-	}
-
-	// 7. ExternalRef promotion.
-	var mappings []filter.ExternalMapping
+		_, _ = fmt.Fprintf(w, "\nSchema version bumped: %s -> %s\n", plan.SchemaAPIVersion(existing), plan.SchemaAPIVersion(rgdMap))
+		plan.FormatFieldMapping(w, mapping)
 
-	for _, expr := range opts.externalizeSecret {
-		m, err := filter.ParseExternalMapping("Secret", expr)
-		if err != nil {
-			return nil, err
-		}
-
-		mappings = append(mappings, m)
+		return existing, nil
+	default:
+		return nil, nil
 	}
+}
 
-	for _, expr := range opts.externalizeService {
-		m, err := filter.ParseExternalMapping("Service", expr)
-		if err != nil {
-			return nil, err
-		}
+// printConvertSummary prints a human-readable summary of the conversion.
+func printConvertSummary(w io.Writer, result *transform.Result, hookResult *hooks.FilterResult, hardenResult *harden.Result) {
+	_, _ = fmt.Fprintf(w, "\n--- Conversion Summary ---\n")
+	_, _ = fmt.Fprintf(w, "Resources:     %d\n", len(result.Resources))
+	_, _ = fmt.Fprintf(w, "Schema fields: %d\n", len(result.SchemaFields))
+	_, _ = fmt.Fprintf(w, "Status fields: %d\n", len(result.StatusFields))
 
-		mappings = append(mappings, m)
+	if len(hookResult.DroppedHooks) > 0 {
+		_, _ = fmt.Fprintf(w, "Hooks dropped:  %d\n", len(hookResult.DroppedHooks))
 	}
 
-	if len(mappings) > 0 {
-		filters = append(filters, filter.NewExternalRefFilter(mappings))
+	if len(hookResult.IncludedHooks) > 0 {
+		_, _ = fmt.Fprintf(w, "Hooks included: %d\n", len(hookResult.IncludedHooks))
 	}
 
-	if len(filters) == 0 {
-		return nil, nil //nolint:nilnil // intentional: no filters configured
+	if len(hookResult.OrderedHooks) > 0 {
+		_, _ = fmt.Fprintf(w, "Hooks ordered:  %d\n", len(hookResult.OrderedHooks))
 	}
 
-	return filter.NewChain(filters...), nil
-}
-
-// assignResourceSourcePaths maps parsed resources to their source template paths.
-// A single template file can produce multiple YAML documents, so we parse each
-// sourced manifest's content and match resources by GVK + name.
-func assignResourceSourcePaths(resources []*k8s.Resource, sourced []renderer.SourcedManifest) {
-	// Build a lookup from "Kind/Name" → template path.
-	pathLookup := make(map[string]string)
-
-	for _, sm := range sourced {
-		// Split each sourced manifest into individual YAML docs.
-		docs := parser.SplitDocuments([]byte(sm.Content))
-		for _, doc := range docs {
-			var obj map[string]interface{}
-			if err := sigsyaml.Unmarshal(doc, &obj); err != nil {
-				continue
-			}
+	if len(hookResult.UnsupportedHooks) > 0 {
+		_, _ = fmt.Fprintf(w, "Hooks unsupported: %d\n", len(hookResult.UnsupportedHooks))
+	}
 
-			kind, _ := obj["kind"].(string)
+	if hardenResult != nil {
+		_, _ = fmt.Fprintf(w, "Hardening:      %d changes, %d warnings\n",
+			len(hardenResult.Changes), len(hardenResult.Warnings))
+	}
 
-			meta, _ := obj["metadata"].(map[string]interface{})
-			name, _ := meta["name"].(string)
+	if len(result.BrokenEdges) > 0 {
+		_, _ = fmt.Fprintf(w, "Cycle edges broken: %d\n", len(result.BrokenEdges))
 
-			if kind != "" && name != "" {
-				pathLookup[kind+"/"+name] = sm.TemplatePath
+		for _, e := range result.BrokenEdges {
+			if e.Literal {
+				_, _ = fmt.Fprintf(w, "  %s (literal name kept)\n", e)
+			} else {
+				_, _ = fmt.Fprintf(w, "  %s\n", e)
 			}
 		}
 	}
 
-	// Assign source paths by matching Kind/Name.
-	for _, res := range resources {
-		key := res.Kind() + "/" + res.Name
-		if path, ok := pathLookup[key]; ok {
-			res.SourcePath = path
-		}
-	}
+	_, _ = fmt.Fprintf(w, "--------------------------\n")
 }
 
 // printFilterSummary prints a summary of filtered resources to stderr.
@@ -478,6 +488,16 @@ func printFilterSummary(w io.Writer, result *filter.Result) {
 	_, _ = fmt.Fprintf(w, "----------------------\n")
 }
 
+// configFileDir returns the directory relative paths in the config file
+// are resolved against.
+func configFileDir(ctx context.Context) string {
+	if cfg := config.FromContext(ctx); cfg.ConfigFile != "" {
+		return filepath.Dir(cfg.ConfigFile)
+	}
+
+	return "."
+}
+
 // tryReadConfigFile attempts to read the config file. It first checks for a
 // path resolved by the --config flag (stored in the Config struct); if not set,
 // it falls back to .chart2kro.yaml in the current directory.
@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hupe1980/chart2kro/internal/batch"
+	"github.com/hupe1980/chart2kro/internal/config"
+	"github.com/hupe1980/chart2kro/internal/helm/loader"
+	"github.com/hupe1980/chart2kro/internal/logging"
+	"github.com/hupe1980/chart2kro/internal/output"
+	"github.com/hupe1980/chart2kro/pkg/chart2kro"
+)
+
+type convertAllOptions struct {
+	concurrency int
+	outputDir   string
+	format      string
+
+	// Chart loading.
+	username  string
+	password  string
+	caFile    string
+	certFile  string
+	keyFile   string
+	plainHTTP bool
+
+	// Rendering.
+	releaseName string
+	namespace   string
+
+	// Transformation.
+	profile          string
+	includeAllValues bool
+	flatSchema       bool
+	fast             bool
+
+	// Hardening and auditing.
+	harden        bool
+	securityLevel string
+	audit         bool
+}
+
+func newConvertAllCommand() *cobra.Command {
+	opts := &convertAllOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "convert-all <dir|repo-index>",
+		Short: "Convert every chart of a directory or repository",
+		Long: `Convert all charts of a monorepo or Helm repository concurrently.
+
+The source is a directory searched recursively for charts (hidden
+directories, library charts, and subcharts are skipped), a local
+repository index.yaml, or the URL of a Helm repository. For repositories,
+the latest version of each chart is converted.
+
+Each chart is converted with its own .chart2kro.yaml when it has one, and
+with the global config file otherwise. With --output-dir, each RGD is
+written to <output-dir>/<chart-id>.yaml.
+
+The aggregated report lists each chart's status, resource and schema field
+counts, warnings, and audit findings by severity (with --audit), as a table
+or as JSON (--format json). Progress is printed to stderr.
+
+Exit codes:
+  0  All charts converted
+  1  One or more charts failed
+  2  Invalid arguments or chart source
+  6  Write error`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConvertAll(cmd.Context(), cmd, args[0], opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.IntVarP(&opts.concurrency, "concurrency", "j", 0, "maximum number of concurrent conversions (default: number of CPUs)")
+	f.StringVar(&opts.outputDir, "output-dir", "", "directory to write the RGDs to (default: report only)")
+	f.StringVar(&opts.format, "format", "table", "report format: table, json")
+
+	// Chart loading flags.
+	f.StringVar(&opts.username, "username", "", "repository/registry username")
+	f.StringVar(&opts.password, "password", "", "repository/registry password")
+	f.StringVar(&opts.caFile, "ca-file", "", "TLS CA certificate file")
+	f.StringVar(&opts.certFile, "cert-file", "", "TLS client certificate file")
+	f.StringVar(&opts.keyFile, "key-file", "", "TLS client key file")
+	f.BoolVar(&opts.plainHTTP, "plain-http", false, "use insecure HTTP connections for OCI registries")
+
+	// Rendering flags.
+	f.StringVar(&opts.releaseName, "release-name", "release", "Helm release name for rendering")
+	f.StringVar(&opts.namespace, "namespace", "default", "Kubernetes namespace for rendering")
+
+	// Transformation flags.
+	f.StringVar(&opts.profile, "profile", "", "apply a conversion profile")
+	f.BoolVar(&opts.includeAllValues, "include-all-values", false, "include all values in schema")
+	f.BoolVar(&opts.flatSchema, "flat-schema", false, "use flat camelCase schema field names")
+	f.BoolVar(&opts.fast, "fast", false, "use template AST analysis")
+
+	// Hardening and audit flags.
+	f.BoolVar(&opts.harden, "harden", false, "enable security hardening pipeline")
+	f.StringVar(&opts.securityLevel, "security-level", "restricted", "PSS enforcement level (none, baseline, restricted)")
+	f.BoolVar(&opts.audit, "audit", false, "audit each chart and summarize the findings")
+
+	return cmd
+}
+
+func runConvertAll(ctx context.Context, cmd *cobra.Command, source string, opts *convertAllOptions) error {
+	logger := logging.FromContext(ctx)
+
+	if opts.format != "table" && opts.format != "json" {
+		return &ExitError{Code: 2, Err: fmt.Errorf("unsupported format %q (supported: table, json)", opts.format)}
+	}
+
+	loadOpts := loader.LoadOptions{
+		Username:  opts.username,
+		Password:  opts.password,
+		CaFile:    opts.caFile,
+		CertFile:  opts.certFile,
+		KeyFile:   opts.keyFile,
+		PlainHTTP: opts.plainHTTP,
+	}
+	applyCacheConfig(ctx, &loadOpts)
+
+	charts, err := batch.Discover(ctx, source, loadOpts)
+	if err != nil {
+		return &ExitError{Code: 2, Err: err}
+	}
+
+	if opts.outputDir != "" {
+		if err := batch.CheckOutputNames(charts); err != nil {
+			return &ExitError{Code: 2, Err: err}
+		}
+	}
+
+	logger.Info("discovered charts", slog.Int("count", len(charts)))
+
+	// The global config applies to charts without a config file of their own.
+	globalConfig, _ := tryReadConfigFile(ctx)
+
+	batchCharts := make([]chart2kro.BatchChart, 0, len(charts))
+
+	for _, c := range charts {
+		chartOpts, err := chartBatchOptions(c, globalConfig)
+		if err != nil {
+			return &ExitError{Code: 2, Err: err}
+		}
+
+		batchCharts = append(batchCharts, chart2kro.BatchChart{Ref: c.Ref, Options: chartOpts})
+	}
+
+	stderr := cmd.ErrOrStderr()
+
+	results := chart2kro.ConvertBatch(ctx, batchCharts,
+		chart2kro.WithConcurrency(opts.concurrency),
+		chart2kro.WithSharedOptions(opts.sharedOptions(ctx)...),
+		chart2kro.WithProgress(func(p chart2kro.BatchProgress) {
+			status := "ok"
+			if p.Result.Err != nil {
+				status = "FAILED"
+			}
+
+			_, _ = fmt.Fprintf(stderr, "[%d/%d] %s: %s\n", p.Done, p.Total, p.Result.Ref, status)
+		}),
+	)
+
+	entries := make([]batch.Entry, len(results))
+
+	for i, res := range results {
+		entries[i] = batch.NewEntry(charts[i], res)
+
+		if opts.outputDir == "" || res.Err != nil {
+			continue
+		}
+
+		path := filepath.Join(opts.outputDir, charts[i].OutputName())
+
+		w := output.NewFileWriter(path, output.WithLogger(logger))
+		if err := w.Write(res.Result.YAML); err != nil {
+			return &ExitError{Code: 6, Err: fmt.Errorf("writing %s: %w", path, err)}
+		}
+
+		entries[i].Output = path
+	}
+
+	report := batch.NewReport(entries)
+
+	if opts.format == "json" {
+		err = report.WriteJSON(cmd.OutOrStdout())
+	} else {
+		err = report.WriteTable(cmd.OutOrStdout())
+	}
+
+	if err != nil {
+		return &ExitError{Code: 1, Err: fmt.Errorf("writing report: %w", err)}
+	}
+
+	if report.Failed > 0 {
+		return &ExitError{Code: 1, Err: fmt.Errorf("%d of %d charts failed", report.Failed, report.Total)}
+	}
+
+	return nil
+}
+
+// sharedOptions returns the conversion options common to all charts.
+func (o *convertAllOptions) sharedOptions(ctx context.Context) []chart2kro.Option {
+	opts := []chart2kro.Option{
+		chart2kro.WithUsername(o.username),
+		chart2kro.WithPassword(o.password),
+		chart2kro.WithCaFile(o.caFile),
+		chart2kro.WithCertFile(o.certFile),
+		chart2kro.WithKeyFile(o.keyFile),
+		chart2kro.WithReleaseName(o.releaseName),
+		chart2kro.WithNamespace(o.namespace),
+		chart2kro.WithProfile(o.profile),
+		chart2kro.WithSecurityLevel(o.securityLevel),
+	}
+
+	if c := chartCache(ctx); c != nil {
+		opts = append(opts, chart2kro.WithCacheDir(c.Dir()))
+	}
+
+	if config.FromContext(ctx).Offline {
+		opts = append(opts, chart2kro.WithOffline())
+	}
+
+	if o.plainHTTP {
+		opts = append(opts, chart2kro.WithPlainHTTP())
+	}
+
+	if o.includeAllValues {
+		opts = append(opts, chart2kro.WithIncludeAllValues())
+	}
+
+	if o.flatSchema {
+		opts = append(opts, chart2kro.WithFlatSchema())
+	}
+
+	if o.fast {
+		opts = append(opts, chart2kro.WithFast())
+	}
+
+	if o.harden {
+		opts = append(opts, chart2kro.WithHarden())
+	}
+
+	if o.audit {
+		opts = append(opts, chart2kro.WithAudit(o.securityLevel))
+	}
+
+	return opts
+}
+
+// chartBatchOptions returns the conversion options of a discovered chart:
+// its repository and version, and its own config file or else the global
+// one.
+func chartBatchOptions(c batch.Chart, globalConfig []byte) ([]chart2kro.Option, error) {
+	var opts []chart2kro.Option
+
+	if c.RepoURL != "" {
+		opts = append(opts, chart2kro.WithRepoURL(c.RepoURL), chart2kro.WithVersion(c.Version))
+	}
+
+	data := globalConfig
+
+	if c.ConfigFile != "" {
+		var err error
+
+		data, err = os.ReadFile(c.ConfigFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", c.ConfigFile, err)
+		}
+	}
+
+	if len(data) > 0 {
+		opts = append(opts, chart2kro.WithTransformConfigData(data))
+	}
+
+	return opts, nil
+}
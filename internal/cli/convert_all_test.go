@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/chart2kro/internal/batch"
+)
+
+// chartMonorepo copies the simple and with-database charts into a temporary
+// monorepo. The simple chart has a config file of its own.
+func chartMonorepo(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	charts := filepath.Join(testdataDir(t), "charts")
+
+	require.NoError(t, os.CopyFS(filepath.Join(root, "apps", "web"), os.DirFS(filepath.Join(charts, "simple"))))
+	require.NoError(t, os.CopyFS(filepath.Join(root, "apps", "db"), os.DirFS(filepath.Join(charts, "with-database"))))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "apps", "web", ".chart2kro.yaml"),
+		[]byte("resourceIdOverrides:\n  Deployment/release-simple: webserver\n"), 0o600))
+
+	return root
+}
+
+func TestConvertAll(t *testing.T) {
+	root := chartMonorepo(t)
+	outDir := filepath.Join(t.TempDir(), "rgds")
+
+	stdout, stderr, err := executeCommand("convert-all", root, "-j", "2", "--output-dir", outDir)
+	require.NoError(t, err)
+
+	assert.Contains(t, stderr, "[2/2]")
+	assert.Contains(t, stdout, "apps/db")
+	assert.Contains(t, stdout, "apps/web")
+	assert.Contains(t, stdout, "Charts: 2 total, 2 succeeded, 0 failed")
+
+	web, err := os.ReadFile(filepath.Join(outDir, "apps-web.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(web), "id: webserver")
+
+	db, err := os.ReadFile(filepath.Join(outDir, "apps-db.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(db), "kind: ResourceGraphDefinition")
+}
+
+func TestConvertAll_JSONAudit(t *testing.T) {
+	stdout, _, err := executeCommand("convert-all", chartMonorepo(t), "--format", "json", "--audit")
+	require.NoError(t, err)
+
+	var report batch.Report
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+
+	assert.Equal(t, 2, report.Succeeded)
+	require.Len(t, report.Charts, 2)
+	assert.Equal(t, "apps/db", report.Charts[0].ID)
+	assert.NotEmpty(t, report.Charts[0].Audit)
+	assert.Empty(t, report.Charts[0].Output)
+}
+
+func TestConvertAll_Failure(t *testing.T) {
+	root := chartMonorepo(t)
+	broken := filepath.Join(root, "apps", "broken")
+	require.NoError(t, os.MkdirAll(filepath.Join(broken, "templates"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(broken, "Chart.yaml"), []byte("apiVersion: v2\nname: broken\nversion: 0.1.0\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(broken, "templates", "cm.yaml"), []byte("{{ .Values.missing.key }}"), 0o600))
+
+	stdout, _, err := executeCommand("convert-all", root)
+	require.Error(t, err)
+
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 1, exitErr.Code)
+	assert.Contains(t, err.Error(), "1 of 3 charts failed")
+	assert.Contains(t, stdout, "apps/broken: ")
+	assert.Contains(t, stdout, "Charts: 3 total, 2 succeeded, 1 failed")
+}
+
+func TestConvertAll_Errors(t *testing.T) {
+	_, _, err := executeCommand("convert-all", chartMonorepo(t), "--format", "xml")
+
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 2, exitErr.Code)
+
+	_, _, err = executeCommand("convert-all", t.TempDir())
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 2, exitErr.Code)
+	assert.Contains(t, err.Error(), "no charts found")
+}
+
+func TestConvertAll_OutputNameCollision(t *testing.T) {
+	root := t.TempDir()
+	simple := os.DirFS(filepath.Join(testdataDir(t), "charts", "simple"))
+
+	require.NoError(t, os.CopyFS(filepath.Join(root, "a", "b-c"), simple))
+	require.NoError(t, os.CopyFS(filepath.Join(root, "a-b", "c"), simple))
+
+	outDir := filepath.Join(t.TempDir(), "rgds")
+
+	_, _, err := executeCommand("convert-all", root, "--output-dir", outDir)
+
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 2, exitErr.Code)
+	assert.Contains(t, err.Error(), "a-b-c.yaml")
+	assert.NoDirExists(t, outDir)
+
+	// Without --output-dir nothing is written, so the charts are converted.
+	stdout, _, err := executeCommand("convert-all", root)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Charts: 2 total, 2 succeeded, 0 failed")
+}
@@ -3,6 +3,8 @@ package cli
 import (
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -58,6 +60,108 @@ func TestConvert_WithHooks_Include(t *testing.T) {
 	assert.NotContains(t, stdout, "helm.sh/hook")
 }
 
+func TestConvert_WithHooks_Ordered(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "with-hooks")
+	stdout, stderr, err := executeCommand("convert", chartDir, "--hook-mode", "ordered")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "kind: Job")
+	assert.NotContains(t, stdout, "kind: Pod")
+	assert.NotContains(t, stdout, "helm.sh/hook")
+	assert.Contains(t, stderr, "Ordered as RGD resources: 1")
+	assert.Contains(t, stderr, "Pod/release-test (test)")
+}
+
+func TestConvert_HookModeConflict(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "with-hooks")
+	_, _, err := executeCommand("convert", chartDir, "--hook-mode", "ordered", "--include-hooks")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--include-hooks cannot be combined")
+
+	_, _, err = executeCommand("convert", chartDir, "--hook-mode", "sometimes")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid hook mode")
+}
+
+// writeExistingRGD converts the simple chart and writes the result with an
+// extra required schema field, so that the next conversion removes it.
+func writeExistingRGD(t *testing.T) string {
+	t.Helper()
+
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+	stdout, _, err := executeCommand("convert", chartDir)
+	require.NoError(t, err)
+
+	existing := filepath.Join(t.TempDir(), "existing.yaml")
+	require.NoError(t, os.WriteFile(existing,
+		[]byte(strings.Replace(stdout, "    spec:\n      image:", "    spec:\n      legacy: string\n      image:", 1)), 0o600))
+
+	return existing
+}
+
+func TestConvert_EvolutionPolicy(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+	existing := writeExistingRGD(t)
+
+	t.Run("allow", func(t *testing.T) {
+		stdout, stderr, err := executeCommand("convert", chartDir, "--existing", existing)
+		require.NoError(t, err)
+		assert.Contains(t, stderr, "legacy")
+		assert.Contains(t, stdout, "apiVersion: simple.kro.run/v1alpha1")
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		_, _, err := executeCommand("convert", chartDir, "--existing", existing, "--evolution-policy", "strict")
+
+		var exitErr *ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 8, exitErr.Code)
+		assert.Contains(t, err.Error(), "1 breaking schema change(s)")
+	})
+
+	t.Run("bump", func(t *testing.T) {
+		stdout, stderr, err := executeCommand("convert", chartDir, "--existing", existing, "--evolution-policy", "bump")
+		require.NoError(t, err)
+
+		docs := strings.Split(stdout, "\n---\n")
+		require.Len(t, docs, 2, "both versions are emitted")
+		assert.Contains(t, docs[0], "apiVersion: simple.kro.run/v1alpha1")
+		assert.Contains(t, docs[0], "legacy: string")
+		assert.Contains(t, docs[1], "name: simple-v1alpha2")
+		assert.Contains(t, docs[1], "apiVersion: simple-v1alpha2.kro.run/v1alpha2")
+		assert.Contains(t, docs[1], "chart2kro.io/supersedes: simple.kro.run/v1alpha1")
+		assert.Contains(t, docs[1], `{"from":"legacy","change":"removed"}`)
+		assert.Contains(t, stderr, "Schema version bumped: simple.kro.run/v1alpha1 -> simple-v1alpha2.kro.run/v1alpha2")
+	})
+
+	t.Run("bump without breaking changes keeps the version", func(t *testing.T) {
+		bumped := filepath.Join(t.TempDir(), "bumped.yaml")
+		_, _, err := executeCommand("convert", chartDir, "--existing", existing, "--evolution-policy", "bump", "-o", bumped)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(bumped) //nolint:gosec // test path
+		require.NoError(t, err)
+
+		latest := filepath.Join(t.TempDir(), "latest.yaml")
+		require.NoError(t, os.WriteFile(latest, []byte(strings.Split(string(data), "\n---\n")[1]), 0o600))
+
+		stdout, _, err := executeCommand("convert", chartDir, "--existing", latest, "--evolution-policy", "bump")
+		require.NoError(t, err)
+		assert.NotContains(t, stdout, "\n---\n")
+		assert.Contains(t, stdout, "name: simple-v1alpha2")
+		assert.Contains(t, stdout, "apiVersion: simple-v1alpha2.kro.run/v1alpha2")
+	})
+}
+
+func TestConvert_EvolutionPolicyFlags(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+
+	_, _, err := executeCommand("convert", chartDir, "--evolution-policy", "bump")
+	require.ErrorContains(t, err, "requires --existing")
+
+	_, _, err = executeCommand("convert", chartDir, "--existing", writeExistingRGD(t), "--evolution-policy", "loose")
+	require.ErrorContains(t, err, "invalid evolution policy")
+}
+
 func TestConvert_LibraryChart(t *testing.T) {
 	chartDir := filepath.Join(testdataDir(t), "charts", "library")
 	_, _, err := executeCommand("convert", chartDir)
@@ -219,6 +323,15 @@ func TestConvert_Golden_WithHooksIncluded(t *testing.T) {
 	assert.Equal(t, expected, stdout)
 }
 
+func TestConvert_Golden_WithHooksOrdered(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "with-hooks")
+	stdout, _, err := executeCommand("convert", chartDir, "--hook-mode", "ordered")
+	require.NoError(t, err)
+
+	expected := goldenFile(t, "with-hooks-ordered.yaml")
+	assert.Equal(t, expected, stdout)
+}
+
 func TestConvert_Golden_WithSubchart(t *testing.T) {
 	chartDir := filepath.Join(testdataDir(t), "charts", "with-subchart")
 	stdout, _, err := executeCommand("convert", chartDir)
@@ -309,6 +422,76 @@ func TestConvert_ExcludeSubcharts(t *testing.T) {
 	assert.Contains(t, stderr, "Excluded")
 }
 
+func TestConvert_Golden_WithSubchartNested(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "with-subchart")
+	stdout, stderr, err := executeCommand("convert", chartDir, "--nested-subcharts")
+	require.NoError(t, err, "stderr: %s", stderr)
+
+	expected := goldenFile(t, "with-subchart-nested.yaml")
+	assert.Equal(t, expected, stdout)
+	assert.Contains(t, stderr, "Nested Subcharts")
+}
+
+func TestConvert_Golden_WithAliasesNested(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "with-aliases")
+	stdout, stderr, err := executeCommand("convert", chartDir, "--nested-subcharts")
+	require.NoError(t, err, "stderr: %s", stderr)
+
+	// The cache chart is installed twice, under the aliases sessions and
+	// queue, each with the values below its alias.
+	expected := goldenFile(t, "with-aliases-nested.yaml")
+	assert.Equal(t, expected, stdout)
+	assert.Contains(t, stderr, "RGD with-aliases-sessions")
+	assert.Contains(t, stderr, "RGD with-aliases-queue")
+}
+
+func TestConvert_NestedSubcharts_Values(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "with-subchart")
+	stdout, stderr, err := executeCommand("convert", chartDir, "--nested-subcharts",
+		"--set", "backend.name=orders", "--set", "backend.enabled=false")
+	require.NoError(t, err, "stderr: %s", stderr)
+
+	// Parent values flow into the subchart RGD defaults.
+	assert.Contains(t, stdout, `name: string | default="orders"`)
+	assert.Contains(t, stdout, "enabled: boolean | default=false")
+}
+
+func TestConvert_NestedSubcharts_UmbrellaChart(t *testing.T) {
+	chartDir := filepath.Join(t.TempDir(), "umbrella")
+	require.NoError(t, os.CopyFS(chartDir, os.DirFS(filepath.Join(testdataDir(t), "charts", "with-subchart"))))
+	require.NoError(t, os.Remove(filepath.Join(chartDir, "templates", "frontend.yaml")))
+
+	stdout, stderr, err := executeCommand("convert", chartDir, "--nested-subcharts")
+	require.NoError(t, err, "stderr: %s", stderr)
+	assert.Contains(t, stdout, "name: with-subchart-backend")
+	assert.Contains(t, stdout, "kind: WithSubchartBackend")
+	assert.Contains(t, stdout, "id: backend")
+}
+
+func TestConvert_NestedSubcharts_ExcludedSubchart(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "with-subchart")
+	stdout, stderr, err := executeCommand("convert", chartDir, "--nested-subcharts", "--exclude-subcharts", "backend")
+	require.NoError(t, err, "stderr: %s", stderr)
+	assert.NotContains(t, stdout, "WithSubchartBackend")
+	assert.NotContains(t, stderr, "Nested Subcharts")
+}
+
+func TestConvert_NestedSubcharts_FlagConflicts(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "with-subchart")
+
+	for _, args := range [][]string{
+		{"--flat-schema"},
+		{"--split", "--output-dir", t.TempDir()},
+	} {
+		_, _, err := executeCommand(append([]string{"convert", chartDir, "--nested-subcharts"}, args...)...)
+		require.Error(t, err, "args: %v", args)
+
+		var exitErr *ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 2, exitErr.Code)
+	}
+}
+
 func TestConvert_ProfileEnterprise(t *testing.T) {
 	chartDir := filepath.Join(testdataDir(t), "charts", "with-subchart")
 	// The "enterprise" profile won't match "backend" subchart but should not error.
@@ -401,3 +584,119 @@ func TestConvert_FilterHelpFlags(t *testing.T) {
 	assert.Contains(t, stdout, "--use-external-pattern")
 	assert.Contains(t, stdout, "--profile")
 }
+
+func TestConvert_KustomizeOverlay(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+
+	overlay := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(overlay, "kustomization.yaml"), []byte(`commonLabels:
+  team: platform
+patches:
+  - target:
+      kind: Deployment
+    patch: |-
+      - op: add
+        path: /spec/template/spec/containers/-
+        value:
+          name: proxy
+          image: envoyproxy/envoy:v1.30
+      - op: add
+        path: /metadata/annotations
+        value: {}
+      - op: copy
+        from: /spec/template/spec/containers/0/image
+        path: /metadata/annotations/image-ref
+`), 0o644))
+
+	stdout, stderr, err := executeCommand("convert", chartDir, "--kustomize-dir", overlay)
+	require.NoError(t, err, "stderr: %s", stderr)
+	assert.Contains(t, stdout, "team: platform")
+	assert.Contains(t, stdout, "image: envoyproxy/envoy:v1.30")
+	assert.Contains(t, stdout, "replicas: ${schema.spec.replicaCount}")
+	// The copied image is rendered from values, so it is parameterised too.
+	assert.Contains(t, stdout, "image-ref: ${schema.spec.image.repository}:${schema.spec.image.tag}")
+}
+
+func TestConvert_PostRenderer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell post-renderers are not supported on Windows")
+	}
+
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+
+	script := filepath.Join(t.TempDir(), "post-render.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\nsed \"s/app: simple/app: simple-$1/\"\n"), 0o755)) //nolint:gosec // test script must be executable
+
+	stdout, stderr, err := executeCommand("convert", chartDir, "--post-renderer", script, "--post-renderer-args", "patched")
+	require.NoError(t, err, "stderr: %s", stderr)
+	assert.Contains(t, stdout, "app: simple-patched")
+	assert.NotContains(t, stdout, "app: simple\n")
+
+	_, _, err = executeCommand("convert", chartDir, "--post-renderer", filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 2, exitErr.Code)
+}
+
+func TestConvert_CycleStrategy(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+
+	// The Deployment and Service share their name, so a reference path from
+	// the Deployment's name to the Service closes a cycle with the Service's
+	// selector.
+	configPath := filepath.Join(t.TempDir(), ".chart2kro.yaml")
+	config := `dependencies:
+  references:
+    - match: {kind: Deployment}
+      path: metadata.name
+      targetKind: Service
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0o644))
+
+	_, _, err := executeCommand("--config", configPath, "convert", chartDir)
+	require.Error(t, err)
+
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 5, exitErr.Code)
+	assert.Contains(t, err.Error(), "deployment -[reference]-> service -[selector]-> deployment")
+
+	require.NoError(t, os.WriteFile(configPath, []byte(config+"  cycleStrategy: literal\n"), 0o644))
+
+	stdout, stderr, err := executeCommand("--config", configPath, "convert", chartDir)
+	require.NoError(t, err, "stderr: %s", stderr)
+	assert.Contains(t, stderr, "Cycle edges broken: 1")
+	assert.Contains(t, stderr, "deployment -> service (reference) in cycle deployment -> service -> deployment (literal name kept)")
+	assert.Contains(t, stdout, "dependsOn")
+}
+
+func TestConvert_PluginTransformer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell plugins are not supported on Windows")
+	}
+
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "plugins"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plugins", "service.sh"), []byte(`#!/bin/sh
+cat > /dev/null
+echo '{"apiVersion":"chart2kro.io/v1","kind":"TransformResponse","readyWhen":["${self.spec.clusterIP != \"None\"}"],"statusFields":[]}'
+`), 0o755)) //nolint:gosec // test plugin must be executable
+
+	configPath := filepath.Join(dir, ".chart2kro.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`transformers:
+  - match:
+      kind: Service
+    plugin:
+      exec: ./plugins/service.sh
+`), 0o644))
+
+	stdout, stderr, err := executeCommand("--config", configPath, "convert", chartDir)
+	require.NoError(t, err, "stderr: %s", stderr)
+	assert.Contains(t, stdout, `${self.spec.clusterIP != "None"}`)
+	assert.NotContains(t, stdout, "serviceClusterIP")
+	assert.Contains(t, stdout, "deploymentReadyReplicas")
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
 
@@ -16,33 +17,64 @@ type diffOptions struct {
 	// Existing RGD file to diff against.
 	existing string
 
-	// Output format: "unified" (default), "json".
+	// Output format: "tree" (default), "table", "json", "unified".
 	format string
 
 	// Disable ANSI color output.
 	noColor bool
+
+	// Chart references ("chart@version") of a chart upgrade to report on.
+	fromChart string
+	toChart   string
 }
 
 func newDiffCommand() *cobra.Command {
 	opts := &diffOptions{}
 
 	cmd := &cobra.Command{
-		Use:   "diff <chart-reference>",
+		Use:   "diff <chart-reference> | --from-chart <chart@version> --to-chart <chart@version>",
 		Short: "Compare generated RGD against a previous version",
 		Long: `Diff compares a newly generated ResourceGraphDefinition against a
-previous version to detect YAML-level and schema-level changes.
+previous version to detect structural and schema-level changes.
 
 When --existing is specified, the RGD file on disk is used as the
-baseline. Schema evolution analysis is included to highlight
-breaking changes.
+baseline. Resources are matched by ID and compared by JSON path, so
+reordered resources or keys produce no noise. The diff reports added,
+removed, and changed fields (flagging CEL expression changes),
+readyWhen/includeWhen changes, and dependency edge changes. Schema
+evolution analysis is included to highlight breaking changes.
+
+Formats:
+  tree     Colorised tree of resources and changed paths (default)
+  table    One row per change
+  json     Evolution analysis plus the structural diff under "diff"
+  unified  Line-based YAML diff
+
+With --from-chart and --to-chart, two versions of a chart are converted
+with the same values and config instead, and an upgrade impact report is
+written: schema evolution, resource additions and removals, changed
+container images, and hardening and audit deltas. A version can be given
+as chart@version or with --version (applied to both sides).
 
 Exit codes:
   0  No differences
   1  Error
   2  Invalid arguments
   8  Breaking schema changes detected`,
-		Args: cobra.ExactArgs(1),
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.fromChart != "" || opts.toChart != "" {
+				if len(args) > 0 {
+					return &ExitError{Code: 2, Err: fmt.Errorf("a chart argument cannot be combined with --from-chart and --to-chart")}
+				}
+
+				return runChartUpgradeDiff(cmd.Context(), cmd, opts)
+			}
+
+			if len(args) == 0 {
+				return &ExitError{Code: 2, Err: fmt.Errorf("requires a chart reference, or --from-chart and --to-chart")}
+			}
+
 			return runDiff(cmd.Context(), cmd, args[0], opts)
 		},
 	}
@@ -50,8 +82,10 @@ Exit codes:
 	// Diff-specific flags.
 	f := cmd.Flags()
 	f.StringVar(&opts.existing, "existing", "", "path to existing RGD YAML file to diff against")
-	f.StringVar(&opts.format, "format", "unified", "output format: unified, json")
+	f.StringVar(&opts.format, "format", "tree", "output format: tree, table, json, unified")
 	f.BoolVar(&opts.noColor, "no-color", false, "disable ANSI color output")
+	f.StringVar(&opts.fromChart, "from-chart", "", "chart reference (chart@version) to report an upgrade from")
+	f.StringVar(&opts.toChart, "to-chart", "", "chart reference (chart@version) to report an upgrade to")
 
 	// Shared pipeline flags (chart loading, rendering, values, transform, filtering).
 	registerPipelineFlags(cmd, &opts.convertOptions)
@@ -64,6 +98,12 @@ func runDiff(ctx context.Context, cmd *cobra.Command, ref string, opts *diffOpti
 		return &ExitError{Code: 2, Err: fmt.Errorf("--existing flag is required: specify the path to the existing RGD file")}
 	}
 
+	switch opts.format {
+	case "tree", "table", "json", "unified":
+	default:
+		return &ExitError{Code: 2, Err: fmt.Errorf("invalid format %q (must be tree, table, json, or unified)", opts.format)}
+	}
+
 	// Load existing RGD.
 	existingRGD, err := loadRGDFile(opts.existing, 7)
 	if err != nil {
@@ -76,47 +116,29 @@ func runDiff(ctx context.Context, cmd *cobra.Command, ref string, opts *diffOpti
 		return err
 	}
 
-	// Serialize both for YAML diff.
-	serOpts := output.SerializeOptions{Indent: 2}
-
-	existingYAML, err := output.Serialize(existingRGD, serOpts)
-	if err != nil {
-		return &ExitError{Code: 1, Err: fmt.Errorf("serializing existing RGD: %w", err)}
-	}
-
-	proposedYAML, err := output.Serialize(pResult.RGDMap, serOpts)
-	if err != nil {
-		return &ExitError{Code: 1, Err: fmt.Errorf("serializing proposed RGD: %w", err)}
-	}
-
-	// Run schema evolution analysis.
+	// Run schema evolution analysis and the structural diff.
 	evolution := plan.Analyze(existingRGD, pResult.RGDMap)
+	semantic := plan.ComputeSemanticDiff(existingRGD, pResult.RGDMap)
 
 	w := cmd.OutOrStdout()
 
 	switch opts.format {
 	case "json":
-		if err := plan.FormatJSON(w, evolution); err != nil {
+		if err := plan.FormatDiffJSON(w, evolution, semantic); err != nil {
 			return &ExitError{Code: 1, Err: fmt.Errorf("formatting JSON: %w", err)}
 		}
-	default:
-		// Unified diff.
-		diffOpts := plan.DefaultDiffOptions()
-		diffOpts.OldLabel = opts.existing
-		diffOpts.NewLabel = "proposed"
-
-		diffResult, diffErr := plan.ComputeDiff(string(existingYAML), string(proposedYAML), diffOpts)
-		if diffErr != nil {
-			return &ExitError{Code: 1, Err: fmt.Errorf("computing diff: %w", diffErr)}
+	case "table":
+		plan.FormatSemanticTable(w, semantic)
+		writeEvolutionSummary(w, evolution)
+	case "unified":
+		if err := writeUnifiedDiff(w, existingRGD, pResult.RGDMap, opts); err != nil {
+			return err
 		}
 
-		plan.WriteDiff(w, diffResult, !opts.noColor)
-
-		// Append evolution summary.
-		if evolution.HasChanges() {
-			_, _ = fmt.Fprintln(w)
-			plan.FormatTable(w, evolution)
-		}
+		writeEvolutionSummary(w, evolution)
+	default:
+		plan.FormatSemanticTree(w, semantic, !opts.noColor)
+		writeEvolutionSummary(w, evolution)
 	}
 
 	// Exit code 8 for breaking changes.
@@ -129,3 +151,40 @@ func runDiff(ctx context.Context, cmd *cobra.Command, ref string, opts *diffOpti
 
 	return nil
 }
+
+// writeUnifiedDiff writes a line-based diff of the serialized RGDs.
+func writeUnifiedDiff(w io.Writer, existingRGD, proposedRGD map[string]interface{}, opts *diffOptions) error {
+	serOpts := output.SerializeOptions{Indent: 2}
+
+	existingYAML, err := output.Serialize(existingRGD, serOpts)
+	if err != nil {
+		return &ExitError{Code: 1, Err: fmt.Errorf("serializing existing RGD: %w", err)}
+	}
+
+	proposedYAML, err := output.Serialize(proposedRGD, serOpts)
+	if err != nil {
+		return &ExitError{Code: 1, Err: fmt.Errorf("serializing proposed RGD: %w", err)}
+	}
+
+	diffOpts := plan.DefaultDiffOptions()
+	diffOpts.OldLabel = opts.existing
+	diffOpts.NewLabel = "proposed"
+
+	diffResult, err := plan.ComputeDiff(string(existingYAML), string(proposedYAML), diffOpts)
+	if err != nil {
+		return &ExitError{Code: 1, Err: fmt.Errorf("computing diff: %w", err)}
+	}
+
+	plan.WriteDiff(w, diffResult, !opts.noColor)
+
+	return nil
+}
+
+// writeEvolutionSummary appends the schema evolution table when there are
+// changes.
+func writeEvolutionSummary(w io.Writer, evolution *plan.EvolutionResult) {
+	if evolution.HasChanges() {
+		_, _ = fmt.Fprintln(w)
+		plan.FormatTable(w, evolution)
+	}
+}
@@ -1,12 +1,16 @@
 package cli
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/chart2kro/internal/plan"
 )
 
 // ---------------------------------------------------------------------------
@@ -67,6 +71,44 @@ func TestDiff_JSONFormat(t *testing.T) {
 	assert.Contains(t, stdout, "schemaChanges")
 }
 
+func TestDiff_SemanticFormats(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+	goldenPath := filepath.Join(testdataDir(t), "golden", "simple.yaml")
+
+	data, err := os.ReadFile(goldenPath)
+	require.NoError(t, err)
+
+	modified := strings.Replace(string(data), "replicas: ${schema.spec.replicaCount}", "replicas: 3", 1)
+	require.NotEqual(t, string(data), modified)
+
+	modifiedPath := filepath.Join(t.TempDir(), "modified.yaml")
+	require.NoError(t, os.WriteFile(modifiedPath, []byte(modified), 0o644))
+
+	stdout, _, err := executeCommand("diff", chartDir, "--existing", modifiedPath, "--no-color")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "~ deployment (Deployment)")
+	assert.Contains(t, stdout, "template.spec.replicas: 3 → ${schema.spec.replicaCount} [CEL]")
+	assert.NotContains(t, stdout, "\033[")
+
+	stdout, _, err = executeCommand("diff", chartDir, "--existing", modifiedPath, "--format", "table")
+	require.NoError(t, err)
+	assert.Regexp(t, `deployment\s+modified \(CEL\)\s+template\.spec\.replicas\s+3`, stdout)
+
+	stdout, _, err = executeCommand("diff", chartDir, "--existing", modifiedPath, "--format", "json")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, `"diff"`)
+	assert.Contains(t, stdout, `"path": "template.spec.replicas"`)
+}
+
+func TestDiff_InvalidFormat(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+	goldenPath := filepath.Join(testdataDir(t), "golden", "simple.yaml")
+
+	_, _, err := executeCommand("diff", chartDir, "--existing", goldenPath, "--format", "xml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid format")
+}
+
 func TestDiff_WithModifiedExisting(t *testing.T) {
 	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
 	goldenPath := filepath.Join(testdataDir(t), "golden", "simple.yaml")
@@ -122,6 +164,31 @@ func TestPlan_JSONFormat(t *testing.T) {
 	assert.Contains(t, stdout, `"resources"`)
 }
 
+func TestPlan_JSONDocument(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+	stdout, _, err := executeCommand("plan", chartDir, "--format", "json")
+	require.NoError(t, err)
+
+	var doc plan.Document
+	require.NoError(t, json.Unmarshal([]byte(stdout), &doc))
+
+	assert.Equal(t, plan.DocumentAPIVersion, doc.APIVersion)
+	assert.Equal(t, "simple", doc.Chart.Name)
+	assert.Equal(t, []string{"deployment", "service"}, doc.Order)
+	assert.Contains(t, doc.Resources[0].Bindings, plan.DocumentBinding{
+		FieldPath:  "spec.replicas",
+		Expression: "${schema.spec.replicaCount}",
+		SchemaRefs: []string{"replicaCount"},
+		Resources:  []string{},
+	})
+}
+
+func TestPlan_JSONSchema(t *testing.T) {
+	stdout, _, err := executeCommand("plan", "--json-schema")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, `"$id": "`+plan.DocumentSchemaID+`"`)
+}
+
 func TestPlan_CompactFormat(t *testing.T) {
 	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
 	stdout, _, err := executeCommand("plan", chartDir, "--format", "compact")
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hupe1980/chart2kro/internal/audit"
+	"github.com/hupe1980/chart2kro/internal/harden"
+	"github.com/hupe1980/chart2kro/internal/k8s"
+	"github.com/hupe1980/chart2kro/internal/plan"
+)
+
+// runChartUpgradeDiff converts two versions of a chart and writes the
+// upgrade impact report.
+func runChartUpgradeDiff(ctx context.Context, cmd *cobra.Command, opts *diffOptions) error {
+	if opts.fromChart == "" || opts.toChart == "" {
+		return &ExitError{Code: 2, Err: fmt.Errorf("--from-chart and --to-chart must be used together")}
+	}
+
+	if opts.existing != "" {
+		return &ExitError{Code: 2, Err: fmt.Errorf("--existing cannot be combined with --from-chart and --to-chart")}
+	}
+
+	switch opts.format {
+	case "tree", "table", "json":
+	default:
+		return &ExitError{Code: 2, Err: fmt.Errorf("invalid format %q for a chart upgrade report (must be tree, table, or json)", opts.format)}
+	}
+
+	secLevel, err := harden.ParseSecurityLevel(opts.securityLevel)
+	if err != nil {
+		return &ExitError{Code: 2, Err: err}
+	}
+
+	from, err := convertChartVersion(ctx, opts.fromChart, opts.convertOptions, secLevel)
+	if err != nil {
+		return err
+	}
+
+	to, err := convertChartVersion(ctx, opts.toChart, opts.convertOptions, secLevel)
+	if err != nil {
+		return err
+	}
+
+	report := plan.BuildUpgradeReport(from, to)
+
+	w := cmd.OutOrStdout()
+
+	if opts.format == "json" {
+		if err := plan.FormatUpgradeReportJSON(w, report); err != nil {
+			return &ExitError{Code: 1, Err: fmt.Errorf("formatting JSON: %w", err)}
+		}
+	} else {
+		plan.FormatUpgradeReport(w, report)
+	}
+
+	if report.Evolution.HasBreakingChanges() {
+		return &ExitError{
+			Code: 8,
+			Err:  fmt.Errorf("%d breaking change(s) detected", report.Evolution.BreakingCount()),
+		}
+	}
+
+	return nil
+}
+
+// convertChartVersion runs the pipeline for one side of a chart upgrade and
+// audits its rendered resources.
+func convertChartVersion(ctx context.Context, ref string, opts convertOptions, secLevel harden.SecurityLevel) (*plan.UpgradeInput, error) {
+	ref, version := splitChartVersion(ref)
+	if version != "" {
+		opts.version = version
+	}
+
+	opts.captureRendered = true
+
+	res, err := runPipeline(ctx, ref, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*k8s.Resource, 0, len(res.Rendered))
+	for _, id := range sortedResourceIDs(res.Rendered) {
+		resources = append(resources, res.Rendered[id])
+	}
+
+	findings := audit.New(audit.DefaultChecks(secLevel)...).Run(ctx, resources).Findings
+
+	input := &plan.UpgradeInput{
+		Chart: plan.ChartVersion{
+			Name:       res.Meta.Name,
+			Version:    res.Meta.Version,
+			AppVersion: res.Meta.AppVersion,
+		},
+		RGD:      res.RGDMap,
+		Rendered: res.Rendered,
+		Findings: findings,
+	}
+
+	if res.HardenResult != nil {
+		input.HardenChanges = append([]harden.Change{}, res.HardenResult.Changes...)
+	}
+
+	return input, nil
+}
+
+// splitChartVersion splits "chart@version" into the chart reference and
+// version. OCI digests ("@sha256:...") are kept as part of the reference.
+func splitChartVersion(ref string) (string, string) {
+	i := strings.LastIndex(ref, "@")
+	if i <= 0 || strings.ContainsAny(ref[i+1:], ":/") {
+		return ref, ""
+	}
+
+	return ref[:i], ref[i+1:]
+}
+
+// sortedResourceIDs returns the keys of resources in sorted order.
+func sortedResourceIDs(resources map[string]*k8s.Resource) []string {
+	ids := make([]string, 0, len(resources))
+	for id := range resources {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeUpgradedChart copies the simple chart as version 2.0.0 with a new
+// image tag, a removed service.type value, and an extra ConfigMap.
+func writeUpgradedChart(t *testing.T) string {
+	t.Helper()
+
+	src := filepath.Join(testdataDir(t), "charts", "simple")
+	dir := filepath.Join(t.TempDir(), "simple")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "templates"), 0o755))
+
+	replace := map[string][2]string{
+		"Chart.yaml":                {"version: 1.0.0", "version: 2.0.0"},
+		"values.yaml":               {`tag: "1.21"`, `tag: "1.25"`},
+		"templates/deployment.yaml": {"", ""},
+		"templates/service.yaml":    {"", ""},
+	}
+
+	for name, r := range replace {
+		data, err := os.ReadFile(filepath.Join(src, name))
+		require.NoError(t, err)
+
+		content := string(data)
+		if r[0] != "" {
+			content = strings.Replace(content, r[0], r[1], 1)
+		}
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	configMap := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}-config\ndata:\n  port: \"{{ .Values.service.port }}\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "templates", "configmap.yaml"), []byte(configMap), 0o600))
+
+	return dir
+}
+
+func TestDiff_ChartUpgrade(t *testing.T) {
+	from := filepath.Join(testdataDir(t), "charts", "simple")
+	to := writeUpgradedChart(t)
+
+	stdout, _, err := executeCommand("diff", "--from-chart", from, "--to-chart", to)
+	require.NoError(t, err)
+
+	assert.Contains(t, stdout, "Chart upgrade: simple@1.0.0 -> simple@2.0.0")
+	assert.Contains(t, stdout, "+ configmap (ConfigMap)")
+	assert.Regexp(t, `~ deployment/simple\s+nginx:1\.21 -> nginx:1\.25`, stdout)
+	assert.Contains(t, stdout, "Audit:")
+}
+
+func TestDiff_ChartUpgradeJSON(t *testing.T) {
+	from := filepath.Join(testdataDir(t), "charts", "simple")
+	to := writeUpgradedChart(t)
+
+	stdout, _, err := executeCommand("diff", "--from-chart", from, "--to-chart", to, "--format", "json", "--harden")
+	require.NoError(t, err)
+
+	var report struct {
+		From   map[string]string `json:"from"`
+		To     map[string]string `json:"to"`
+		Images []struct {
+			ResourceID string `json:"resourceId"`
+			New        string `json:"new"`
+		} `json:"images"`
+		ResourcesAdded []struct {
+			ID string `json:"id"`
+		} `json:"resourcesAdded"`
+		Hardening *struct{} `json:"hardening"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+
+	assert.Equal(t, "1.0.0", report.From["version"])
+	assert.Equal(t, "2.0.0", report.To["version"])
+	require.Len(t, report.Images, 1)
+	assert.Equal(t, "nginx:1.25", report.Images[0].New)
+	require.Len(t, report.ResourcesAdded, 1)
+	assert.Equal(t, "configmap", report.ResourcesAdded[0].ID)
+	assert.NotNil(t, report.Hardening)
+}
+
+func TestDiff_ChartUpgradeFlags(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"missing to-chart", []string{"--from-chart", chartDir}, "must be used together"},
+		{"chart argument", []string{chartDir, "--from-chart", chartDir, "--to-chart", chartDir}, "cannot be combined"},
+		{"existing", []string{"--from-chart", chartDir, "--to-chart", chartDir, "--existing", "rgd.yaml"}, "--existing cannot be combined"},
+		{"unified format", []string{"--from-chart", chartDir, "--to-chart", chartDir, "--format", "unified"}, "invalid format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := executeCommand(append([]string{"diff"}, tt.args...)...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+
+			var exitErr *ExitError
+			require.ErrorAs(t, err, &exitErr)
+			assert.Equal(t, 2, exitErr.Code)
+		})
+	}
+}
+
+func TestSplitChartVersion(t *testing.T) {
+	tests := []struct {
+		in, ref, version string
+	}{
+		{"bitnami/redis@19.0.0", "bitnami/redis", "19.0.0"},
+		{"redis", "redis", ""},
+		{"oci://registry.example.com/charts/redis@sha256:abc", "oci://registry.example.com/charts/redis@sha256:abc", ""},
+		{"oci://registry.example.com/charts/redis@18.1.0", "oci://registry.example.com/charts/redis", "18.1.0"},
+	}
+
+	for _, tt := range tests {
+		ref, version := splitChartVersion(tt.in)
+		assert.Equal(t, tt.ref, ref, tt.in)
+		assert.Equal(t, tt.version, version, tt.in)
+	}
+}
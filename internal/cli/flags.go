@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/hupe1980/chart2kro/internal/helm/loader"
 )
 
 // registerChartLoadingFlags adds the standard chart loading flags to a cobra command.
@@ -16,6 +18,11 @@ func registerChartLoadingFlags(cmd *cobra.Command, opts *convertOptions) {
 	f.StringVar(&opts.caFile, "ca-file", "", "TLS CA certificate file")
 	f.StringVar(&opts.certFile, "cert-file", "", "TLS client certificate file")
 	f.StringVar(&opts.keyFile, "key-file", "", "TLS client key file")
+	f.BoolVar(&opts.plainHTTP, "plain-http", false, "use insecure HTTP connections for OCI registries")
+	f.BoolVar(&opts.resolveDependencies, "resolve-dependencies", false, "fetch dependencies missing from charts/")
+	f.BoolVar(&opts.verify, "verify", false, "verify the chart signature before loading")
+	f.StringVar(&opts.keyring, "keyring", loader.DefaultKeyring(), "PGP keyring used to verify .prov files")
+	f.StringVar(&opts.publicKey, "public-key", "", "PEM public key used to verify cosign signatures of OCI charts")
 }
 
 // registerRenderingFlags adds the standard template rendering flags to a cobra command.
@@ -25,6 +32,9 @@ func registerRenderingFlags(cmd *cobra.Command, opts *convertOptions) {
 	f.StringVar(&opts.namespace, "namespace", "default", "Kubernetes namespace for rendering")
 	f.BoolVar(&opts.strict, "strict", false, "fail on missing template values")
 	f.DurationVar(&opts.timeout, "timeout", 30*time.Second, "template rendering timeout")
+	f.StringVar(&opts.postRenderer, "post-renderer", "", "executable that patches rendered manifests")
+	f.StringArrayVar(&opts.postRendererArgs, "post-renderer-args", nil, "argument passed to the post-renderer")
+	f.StringVar(&opts.kustomizeDir, "kustomize-dir", "", "Kustomize overlay directory applied to rendered manifests")
 }
 
 // registerValuesFlags adds the Helm --values/--set family of flags to a cobra command.
@@ -46,7 +56,8 @@ func registerTransformFlags(cmd *cobra.Command, opts *convertOptions) {
 	f.BoolVar(&opts.flatSchema, "flat-schema", false, "use flat camelCase schema field names")
 	f.StringVar(&opts.readyConditions, "ready-conditions", "", "custom readiness conditions file")
 	f.BoolVar(&opts.fast, "fast", false, "use template AST analysis")
-	f.BoolVar(&opts.includeHooks, "include-hooks", false, "include hook resources")
+	f.BoolVar(&opts.includeHooks, "include-hooks", false, "include hook resources (same as --hook-mode=include)")
+	f.StringVar(&opts.hookMode, "hook-mode", "drop", "hook handling: drop, include, or ordered")
 }
 
 // registerResourceFilterFlags adds resource filtering flags to a cobra command.
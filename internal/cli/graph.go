@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hupe1980/chart2kro/internal/filter"
+	"github.com/hupe1980/chart2kro/internal/graph"
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+type graphOptions struct {
+	convertOptions
+
+	// Output format: "dot" (default), "mermaid", "json".
+	format string
+
+	// Annotate conditional resources with their includeWhen conditions.
+	showConditions bool
+
+	// Add externalized resources as nodes.
+	showExternal bool
+}
+
+func newGraphCommand() *cobra.Command {
+	opts := &graphOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "graph <chart-reference>",
+		Short: "Export the resource dependency graph",
+		Long: `Graph exports the dependency graph of the resources a conversion would
+produce as Graphviz DOT, Mermaid, or JSON.
+
+Edges point from a resource to the resources it depends on and are labeled
+with the reasons they were detected (selector, volume, env, serviceAccount,
+...). Dependency cycles are highlighted in red. With --show-conditions,
+conditional resources are dashed and annotated with their includeWhen
+conditions; with --show-external, resources promoted to external references
+are added as grey nodes.
+
+  chart2kro graph ./my-chart | dot -Tsvg > deps.svg
+  chart2kro graph ./my-chart --format mermaid
+
+Exit codes:
+  0  Success
+  1  Error
+  2  Invalid arguments
+  5  Dependency cycle detected (the graph is still written)`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGraph(cmd.Context(), cmd, args[0], opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&opts.format, "format", graph.FormatDOT, "output format: dot, mermaid, json")
+	f.BoolVar(&opts.showConditions, "show-conditions", false, "annotate conditional resources with their includeWhen conditions")
+	f.BoolVar(&opts.showExternal, "show-external", false, "add externalized resources as nodes")
+	f.StringArrayVar(&opts.externalizeSecret, "externalize-secret", nil, "externalize a Secret (name=schemaField)")
+	f.StringArrayVar(&opts.externalizeService, "externalize-service", nil, "externalize a Service (name=schemaField)")
+	f.StringSliceVar(&opts.useExternalPattern, "use-external-pattern", nil, "auto-detect and apply external pattern for subchart")
+
+	registerPipelineFlags(cmd, &opts.convertOptions)
+
+	return cmd
+}
+
+func runGraph(ctx context.Context, cmd *cobra.Command, ref string, opts *graphOptions) error {
+	switch opts.format {
+	case graph.FormatDOT, graph.FormatMermaid, graph.FormatJSON:
+	default:
+		return &ExitError{Code: 2, Err: fmt.Errorf("unknown format %q: expected dot, mermaid, json", opts.format)}
+	}
+
+	pResult, err := runPipeline(ctx, ref, &opts.convertOptions)
+	if err != nil {
+		// A cycle aborts the conversion, but its graph is what the user
+		// needs to see.
+		var cycleErr *transform.CycleError
+		if !errors.As(err, &cycleErr) || cycleErr.Graph == nil {
+			return err
+		}
+
+		if writeErr := writeGraph(cmd, graph.Build(cycleErr.Graph, graph.Options{}), opts.format); writeErr != nil {
+			return writeErr
+		}
+
+		return err
+	}
+
+	var graphOpts graph.Options
+
+	if opts.showConditions {
+		graphOpts.IncludeWhen = rgdIncludeWhen(pResult.RGDMap)
+	}
+
+	if opts.showExternal && pResult.FilterResult != nil {
+		graphOpts.External = externalNodes(pResult.FilterResult.Externalized)
+	}
+
+	return writeGraph(cmd, graph.Build(pResult.Result.DependencyGraph, graphOpts), opts.format)
+}
+
+func writeGraph(cmd *cobra.Command, g *graph.Graph, format string) error {
+	if err := graph.Write(cmd.OutOrStdout(), g, format); err != nil {
+		return &ExitError{Code: 6, Err: fmt.Errorf("writing graph: %w", err)}
+	}
+
+	return nil
+}
+
+// rgdIncludeWhen returns the includeWhen conditions of the RGD resources by ID.
+func rgdIncludeWhen(rgdMap map[string]interface{}) map[string][]string {
+	spec, _ := rgdMap["spec"].(map[string]interface{})
+	resources, _ := spec["resources"].([]interface{})
+
+	conditions := make(map[string][]string)
+
+	for _, r := range resources {
+		rm, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		id, _ := rm["id"].(string)
+		list, _ := rm["includeWhen"].([]interface{})
+
+		for _, cond := range list {
+			if s, ok := cond.(string); ok {
+				conditions[id] = append(conditions[id], s)
+			}
+		}
+	}
+
+	return conditions
+}
+
+// externalNodes converts externalized resources into graph nodes.
+func externalNodes(externalized []filter.ExternalizedResource) []graph.External {
+	nodes := make([]graph.External, 0, len(externalized))
+
+	for _, ext := range externalized {
+		refs := make([]string, 0, len(ext.Rewirings))
+		for _, celRef := range ext.Rewirings {
+			refs = append(refs, celRef)
+		}
+
+		sort.Strings(refs)
+
+		node := graph.External{
+			ID:   ext.Resource.QualifiedName(),
+			Kind: ext.Resource.Kind(),
+			Name: ext.Resource.Name,
+		}
+
+		if len(refs) > 0 {
+			node.Ref = refs[0]
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	return nodes
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph_SimpleChart_DOT(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+	stdout, _, err := executeCommand("graph", chartDir)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "digraph dependencies {")
+	assert.Contains(t, stdout, `"service" -> "deployment" [label="selector"];`)
+}
+
+func TestGraph_SimpleChart_Mermaid(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+	stdout, _, err := executeCommand("graph", chartDir, "--format", "mermaid")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "flowchart LR")
+	assert.Contains(t, stdout, "service -->|selector| deployment")
+}
+
+func TestGraph_SimpleChart_JSON(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+	stdout, _, err := executeCommand("graph", chartDir, "--format", "json")
+	require.NoError(t, err)
+
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &out))
+	assert.Len(t, out["nodes"], 2)
+	assert.Len(t, out["edges"], 1)
+}
+
+func TestGraph_InvalidFormat(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+	_, _, err := executeCommand("graph", chartDir, "--format", "svg")
+	require.Error(t, err)
+
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 2, exitErr.Code)
+}
+
+func TestRGDIncludeWhen(t *testing.T) {
+	rgd := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"resources": []interface{}{
+				map[string]interface{}{"id": "deployment"},
+				map[string]interface{}{"id": "ingress", "includeWhen": []interface{}{"${schema.spec.ingress.enabled}"}},
+			},
+		},
+	}
+
+	assert.Equal(t, map[string][]string{"ingress": {"${schema.spec.ingress.enabled}"}}, rgdIncludeWhen(rgd))
+}
+
+func TestInspect_SimpleChart_DOT(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+	stdout, _, err := executeCommand("inspect", chartDir, "--format", "dot")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, `"service" -> "deployment" [label="selector"];`)
+}
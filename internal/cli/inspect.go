@@ -15,6 +15,7 @@ import (
 	sigsyaml "sigs.k8s.io/yaml"
 
 	"github.com/hupe1980/chart2kro/internal/filter"
+	"github.com/hupe1980/chart2kro/internal/graph"
 	"github.com/hupe1980/chart2kro/internal/helm/chartmeta"
 	"github.com/hupe1980/chart2kro/internal/helm/deps"
 	"github.com/hupe1980/chart2kro/internal/helm/hooks"
@@ -23,6 +24,7 @@ import (
 	"github.com/hupe1980/chart2kro/internal/k8s"
 	"github.com/hupe1980/chart2kro/internal/k8s/parser"
 	"github.com/hupe1980/chart2kro/internal/logging"
+	"github.com/hupe1980/chart2kro/internal/pipeline"
 	"github.com/hupe1980/chart2kro/internal/transform"
 )
 
@@ -87,7 +89,7 @@ and excludable infrastructure with suggested flags.`,
 	f.BoolVar(&opts.showValues, "show-values", false, "show only values/schema")
 	f.BoolVar(&opts.showDeps, "show-deps", false, "show only dependency graph")
 	f.BoolVar(&opts.showSchema, "show-schema", false, "show only generated schema")
-	f.StringVar(&opts.format, "format", "table", "output format: table, json, yaml")
+	f.StringVar(&opts.format, "format", "table", "output format: table, json, yaml, dot, mermaid")
 
 	return cmd
 }
@@ -137,26 +139,64 @@ type patternInfo struct {
 }
 
 func runInspect(ctx context.Context, cmd *cobra.Command, ref string, opts *inspectOptions) error {
+	result, resourceIDs, err := inspectChart(ctx, ref, opts)
+	if err != nil {
+		return err
+	}
+
+	// 7. Render output.
+	w := cmd.OutOrStdout()
+	showAll := !opts.showResources && !opts.showValues && !opts.showDeps && !opts.showSchema
+
+	switch opts.format {
+	case "json":
+		return renderJSON(w, result)
+	case "yaml":
+		return renderYAML(w, result)
+	case "table":
+		return renderTable(w, result, showAll, opts)
+	case graph.FormatDOT, graph.FormatMermaid:
+		g := graph.Build(transform.BuildDependencyGraph(resourceIDs), graph.Options{})
+		if err := graph.Write(w, g, opts.format); err != nil {
+			return &ExitError{Code: 1, Err: err}
+		}
+
+		return nil
+	default:
+		return &ExitError{Code: 2, Err: fmt.Errorf("unknown format %q: expected table, json, yaml, dot, mermaid", opts.format)}
+	}
+}
+
+// inspectChart loads, renders, and analyzes the chart (steps 1-6 of
+// runInspect). It returns the inspection result and the resource IDs.
+func inspectChart(
+	ctx context.Context,
+	ref string,
+	opts *inspectOptions,
+) (inspectResult, map[*k8s.Resource]string, error) {
 	logger := logging.FromContext(ctx)
 
 	// 1. Load chart.
 	logger.Info("loading chart", slog.String("ref", ref))
 
 	multiLoader := loader.NewMultiLoader()
-	ch, err := multiLoader.Load(ctx, ref, loader.LoadOptions{
+	loadOpts := loader.LoadOptions{
 		Version:  opts.version,
 		RepoURL:  opts.repoURL,
 		Username: opts.username,
 		Password: opts.password,
-	})
+	}
+	applyCacheConfig(ctx, &loadOpts)
+
+	ch, err := multiLoader.Load(ctx, ref, loadOpts)
 	if err != nil {
-		return &ExitError{Code: 1, Err: fmt.Errorf("loading chart: %w", err)}
+		return inspectResult{}, nil, &ExitError{Code: 1, Err: fmt.Errorf("loading chart: %w", err)}
 	}
 
 	meta := chartmeta.FromChart(ch)
 
 	if meta.IsLibrary() {
-		return &ExitError{Code: 1, Err: fmt.Errorf("chart %q is a library chart", meta.Name)}
+		return inspectResult{}, nil, &ExitError{Code: 1, Err: fmt.Errorf("chart %q is a library chart", meta.Name)}
 	}
 
 	// 2. Merge values and render.
@@ -168,7 +208,7 @@ func runInspect(ctx context.Context, cmd *cobra.Command, ref string, opts *inspe
 
 	mergedVals, err := renderer.MergeValues(ch, valOpts)
 	if err != nil {
-		return &ExitError{Code: 1, Err: fmt.Errorf("merging values: %w", err)}
+		return inspectResult{}, nil, &ExitError{Code: 1, Err: fmt.Errorf("merging values: %w", err)}
 	}
 
 	renderCtx, cancel := context.WithTimeout(ctx, opts.timeout)
@@ -182,7 +222,7 @@ func runInspect(ctx context.Context, cmd *cobra.Command, ref string, opts *inspe
 	// Use RenderWithSources to get source path info.
 	sourced, err := helmRenderer.RenderWithSources(renderCtx, ch, mergedVals)
 	if err != nil {
-		return &ExitError{Code: 1, Err: fmt.Errorf("rendering templates: %w", err)}
+		return inspectResult{}, nil, &ExitError{Code: 1, Err: fmt.Errorf("rendering templates: %w", err)}
 	}
 
 	// Combine for parsing.
@@ -191,7 +231,7 @@ func runInspect(ctx context.Context, cmd *cobra.Command, ref string, opts *inspe
 	// 3. Filter hooks and parse.
 	hookResult, err := hooks.Filter(combinedYAML, false, logger)
 	if err != nil {
-		return &ExitError{Code: 1, Err: fmt.Errorf("filtering hooks: %w", err)}
+		return inspectResult{}, nil, &ExitError{Code: 1, Err: fmt.Errorf("filtering hooks: %w", err)}
 	}
 
 	combined := hooks.CombineResources(hookResult)
@@ -200,16 +240,16 @@ func runInspect(ctx context.Context, cmd *cobra.Command, ref string, opts *inspe
 
 	resources, err := k8sParser.Parse(ctx, combined)
 	if err != nil {
-		return &ExitError{Code: 1, Err: fmt.Errorf("parsing resources: %w", err)}
+		return inspectResult{}, nil, &ExitError{Code: 1, Err: fmt.Errorf("parsing resources: %w", err)}
 	}
 
 	// Assign source paths to resources.
-	assignResourceSourcePaths(resources, sourced)
+	pipeline.AssignSourcePaths(resources, sourced)
 
 	// 4. Assign IDs and analyze.
 	resourceIDs, err := transform.AssignResourceIDs(resources, nil)
 	if err != nil {
-		return &ExitError{Code: 1, Err: fmt.Errorf("assigning IDs: %w", err)}
+		return inspectResult{}, nil, &ExitError{Code: 1, Err: fmt.Errorf("assigning IDs: %w", err)}
 	}
 
 	// 5. Detect dependencies.
@@ -218,22 +258,7 @@ func runInspect(ctx context.Context, cmd *cobra.Command, ref string, opts *inspe
 	}
 
 	// 6. Build result.
-	result := buildInspectResult(meta, resources, resourceIDs, mergedVals)
-
-	// 7. Render output.
-	w := cmd.OutOrStdout()
-	showAll := !opts.showResources && !opts.showValues && !opts.showDeps && !opts.showSchema
-
-	switch opts.format {
-	case "json":
-		return renderJSON(w, result)
-	case "yaml":
-		return renderYAML(w, result)
-	case "table":
-		return renderTable(w, result, showAll, opts)
-	default:
-		return &ExitError{Code: 2, Err: fmt.Errorf("unknown format %q: expected table, json, yaml", opts.format)}
-	}
+	return buildInspectResult(meta, resources, resourceIDs, mergedVals), resourceIDs, nil
 }
 
 func buildInspectResult(
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+
+	"github.com/hupe1980/chart2kro/internal/krmfn"
+)
+
+func newKRMFnCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "krm-fn",
+		Short: "Run as a KRM function (kpt, Kustomize generators)",
+		Long: `Run chart2kro as a KRM function.
+
+Reads a ResourceList from stdin and writes it to stdout with the generated
+ResourceGraphDefinition added to its items. The functionConfig selects the
+chart and mirrors the library options:
+
+  apiVersion: chart2kro.io/v1alpha1
+  kind: ChartToRGD
+  metadata:
+    name: my-app
+  spec:
+    chart: ./charts/my-app
+    values:
+      replicaCount: 3
+    profile: enterprise
+    harden:
+      enabled: true
+    audit:
+      enabled: true
+      failOn: high
+
+RGD validation findings, hardening warnings, and audit findings are reported
+as ResourceList results. The command exits with code 1 when a result has
+error severity.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			rw := &kio.ByteReadWriter{
+				Reader:                cmd.InOrStdin(),
+				Writer:                cmd.OutOrStdout(),
+				KeepReaderAnnotations: true,
+			}
+
+			if err := framework.Execute(krmfn.Processor(cmd.Context()), rw); err != nil {
+				return &ExitError{Code: 1, Err: err}
+			}
+
+			return nil
+		},
+	}
+
+	return cmd
+}
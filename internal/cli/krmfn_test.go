@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKRMFn_ResourceList(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+
+	input := `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: settings
+functionConfig:
+  apiVersion: chart2kro.io/v1alpha1
+  kind: ChartToRGD
+  metadata:
+    name: simple
+  spec:
+    chart: ` + chartDir + `
+    releaseName: web
+`
+
+	cmd := NewRootCommand()
+	outBuf := new(bytes.Buffer)
+	cmd.SetIn(strings.NewReader(input))
+	cmd.SetOut(outBuf)
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{"krm-fn"})
+
+	require.NoError(t, cmd.Execute())
+
+	out := outBuf.String()
+	assert.Contains(t, out, "kind: ResourceList")
+	assert.Contains(t, out, "name: settings")
+	assert.Contains(t, out, "kind: ResourceGraphDefinition")
+	assert.Contains(t, out, "name: web-simple")
+}
+
+func TestKRMFn_ErrorResult(t *testing.T) {
+	input := `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items: []
+functionConfig:
+  apiVersion: chart2kro.io/v1alpha1
+  kind: ChartToRGD
+  spec: {}
+`
+
+	cmd := NewRootCommand()
+	outBuf := new(bytes.Buffer)
+	cmd.SetIn(strings.NewReader(input))
+	cmd.SetOut(outBuf)
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{"krm-fn"})
+
+	err := cmd.Execute()
+	require.Error(t, err)
+
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 1, exitErr.Code)
+	assert.Contains(t, outBuf.String(), "spec.chart is required")
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hupe1980/chart2kro/internal/pipeline"
+)
+
+// printNestedSummary lists the subchart RGDs written before the parent RGD.
+func printNestedSummary(w io.Writer, rgds []pipeline.NestedRGD) {
+	_, _ = fmt.Fprintf(w, "\n--- Nested Subcharts ---\n")
+
+	for _, n := range rgds {
+		name := ""
+		if metadata, ok := n.RGDMap["metadata"].(map[string]interface{}); ok {
+			name, _ = metadata["name"].(string)
+		}
+
+		_, _ = fmt.Fprintf(w, "%-20s RGD %s: %d resources, %d schema fields\n",
+			n.Path, name, len(n.Result.Resources), len(n.Result.SchemaFields))
+	}
+
+	_, _ = fmt.Fprintf(w, "------------------------\n")
+}
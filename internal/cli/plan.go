@@ -17,13 +17,16 @@ type planOptions struct {
 
 	// Output format: "table" (default), "json", "compact".
 	format string
+
+	// Print the JSON Schema of the plan JSON format and exit.
+	jsonSchema bool
 }
 
 func newPlanCommand() *cobra.Command {
 	opts := &planOptions{}
 
 	cmd := &cobra.Command{
-		Use:   "plan <chart-reference>",
+		Use:   "plan <chart-reference> | --json-schema",
 		Short: "Preview what a conversion would produce",
 		Long: `Plan shows what a conversion would produce without writing any
 output. Displays schema fields, resources, status projections,
@@ -32,13 +35,27 @@ and optional schema evolution analysis.
 When --existing is specified, the plan includes change detection
 against the existing RGD file.
 
+With --format json, the plan is written in the versioned plan format
+(apiVersion chart2kro.io/v1, kind Plan): resources in dependency order
+with their CEL parameter bindings, schema fields with their source Helm
+values paths, status projections, and evolution results. The format is
+stable within its apiVersion; --json-schema prints its JSON Schema.
+
 Exit codes:
   0  Success (no breaking changes)
   1  Error
   2  Invalid arguments
   8  Breaking schema changes detected`,
-		Args: cobra.ExactArgs(1),
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.jsonSchema {
+				return writePlanJSONSchema(cmd)
+			}
+
+			if len(args) == 0 {
+				return &ExitError{Code: 2, Err: fmt.Errorf("requires a chart reference")}
+			}
+
 			return runPlan(cmd.Context(), cmd, args[0], opts)
 		},
 	}
@@ -47,6 +64,7 @@ Exit codes:
 	f := cmd.Flags()
 	f.StringVar(&opts.existing, "existing", "", "path to existing RGD YAML file for evolution analysis")
 	f.StringVar(&opts.format, "format", "table", "output format: table, json, compact")
+	f.BoolVar(&opts.jsonSchema, "json-schema", false, "print the JSON Schema of the plan JSON format and exit")
 
 	// Shared pipeline flags (chart loading, rendering, values, transform, filtering).
 	registerPipelineFlags(cmd, &opts.convertOptions)
@@ -80,7 +98,13 @@ func runPlan(ctx context.Context, cmd *cobra.Command, ref string, opts *planOpti
 
 	switch opts.format {
 	case "json":
-		if err := plan.FormatPlanJSON(w, p); err != nil {
+		doc := plan.BuildDocument(p, pResult.RGDMap, plan.ChartVersion{
+			Name:       pResult.Meta.Name,
+			Version:    pResult.Meta.Version,
+			AppVersion: pResult.Meta.AppVersion,
+		})
+
+		if err := plan.FormatDocumentJSON(w, doc); err != nil {
 			return &ExitError{Code: 1, Err: fmt.Errorf("formatting JSON: %w", err)}
 		}
 	case "compact":
@@ -99,3 +123,17 @@ func runPlan(ctx context.Context, cmd *cobra.Command, ref string, opts *planOpti
 
 	return nil
 }
+
+// writePlanJSONSchema writes the JSON Schema of the plan JSON format.
+func writePlanJSONSchema(cmd *cobra.Command) error {
+	data, err := plan.DocumentJSONSchema()
+	if err != nil {
+		return &ExitError{Code: 1, Err: fmt.Errorf("generating plan JSON Schema: %w", err)}
+	}
+
+	if _, err := cmd.OutOrStdout().Write(data); err != nil {
+		return &ExitError{Code: 6, Err: fmt.Errorf("writing output: %w", err)}
+	}
+
+	return nil
+}
@@ -97,6 +97,9 @@ Kubernetes resources as a reusable, composable platform abstraction.`,
 	pf.String("log-format", "text", "log format: text, json")
 	pf.Bool("no-color", false, "disable colored output")
 	pf.BoolP("quiet", "q", false, "suppress non-essential output")
+	pf.String("cache-dir", "", "chart cache directory (default: $XDG_CACHE_HOME/chart2kro)")
+	pf.Bool("offline", false, "refuse network access and serve charts from the cache")
+	pf.Duration("index-ttl", config.DefaultIndexTTL, "how long cached repository indexes are reused")
 
 	// Flag parsing errors return exit code 2.
 	cmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
@@ -114,8 +117,16 @@ Kubernetes resources as a reusable, composable platform abstraction.`,
 		newAuditCommand(),
 		newDocsCommand(),
 		newPlanCommand(),
+		newGraphCommand(),
+		newComposeCommand(),
+		newConvertAllCommand(),
+		newServeCommand(),
+		newControllerCommand(),
 		newWatchCommand(),
 		newCompletionCommand(),
+		newCacheCommand(),
+		newTestManifestsCommand(),
+		newKRMFnCommand(),
 	)
 
 	return cmd
@@ -33,7 +33,8 @@ func TestRootCommand_Help(t *testing.T) {
 	// Must list every planned subcommand.
 	for _, sub := range []string{
 		"convert", "inspect", "validate", "export", "diff",
-		"audit", "docs", "plan", "watch", "version", "completion",
+		"audit", "docs", "plan", "graph", "compose", "convert-all", "serve", "controller", "watch", "version", "completion",
+		"test-manifests", "krm-fn",
 	} {
 		assert.Contains(t, stdout, sub, "help should mention %q subcommand", sub)
 	}
@@ -0,0 +1,365 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/hupe1980/chart2kro/internal/audit"
+	"github.com/hupe1980/chart2kro/internal/harden"
+	"github.com/hupe1980/chart2kro/internal/krmfn"
+	"github.com/hupe1980/chart2kro/internal/logging"
+	"github.com/hupe1980/chart2kro/internal/plan"
+	"github.com/hupe1980/chart2kro/internal/server"
+	"github.com/hupe1980/chart2kro/pkg/chart2kro"
+)
+
+type serveOptions struct {
+	addr          string
+	maxConcurrent int
+	timeout       time.Duration
+	maxUploadSize int64
+	allowLocal    bool
+	allowPlugins  bool
+}
+
+func newServeCommand() *cobra.Command {
+	opts := &serveOptions{}
+	defaults := server.DefaultOptions()
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve conversions over an HTTP JSON API",
+		Long: `Serve runs a long-running server exposing convert, inspect, plan, audit,
+and validate over HTTP JSON:
+
+  POST /v1/convert    convert a chart into an RGD
+  POST /v1/inspect    inspect a chart (as inspect --format json)
+  POST /v1/plan       plan a conversion (as plan --format json)
+  POST /v1/audit      audit a chart (as audit --format json)
+  POST /v1/validate   validate an RGD
+  GET  /healthz       liveness
+  GET  /metrics       Prometheus metrics
+
+Chart requests are JSON documents with the options of the krm-fn
+ChartToRGD spec, which mirror the pkg/chart2kro options:
+
+  {"chart": "oci://ghcr.io/org/app", "version": "1.2.0",
+   "values": {"replicaCount": 2}, "harden": {"enabled": true}}
+
+Chart archives can be uploaded as multipart/form-data with the archive in
+the "chart" part and the JSON options in the "options" part. Unless
+--allow-local is set, only OCI references, repository charts (chart name
+with repoURL), and uploads are accepted. Request configs with transformer
+plugins are rejected unless --allow-plugins is set, since plugins run
+executables and load WASM modules on the server.
+
+At most --max-concurrent requests are processed at once; further requests
+wait for a slot. Requests exceeding --request-timeout fail with 504, or
+with 503 while still waiting for a slot.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runServe(cmd.Context(), opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&opts.addr, "addr", defaults.Addr, "listen address")
+	f.IntVar(&opts.maxConcurrent, "max-concurrent", defaults.MaxConcurrent, "maximum number of requests processed at once")
+	f.DurationVar(&opts.timeout, "request-timeout", defaults.Timeout, "maximum duration of a request")
+	f.Int64Var(&opts.maxUploadSize, "max-upload-size", defaults.MaxUploadSize, "maximum request body size in bytes")
+	f.BoolVar(&opts.allowLocal, "allow-local", false, "accept chart paths and values files on the server's file system")
+	f.BoolVar(&opts.allowPlugins, "allow-plugins", false, "accept transformer plugins in request configs (runs executables on the server)")
+
+	return cmd
+}
+
+func runServe(ctx context.Context, opts *serveOptions) error {
+	srv := server.New(server.Options{
+		Addr:          opts.addr,
+		MaxConcurrent: opts.maxConcurrent,
+		Timeout:       opts.timeout,
+		MaxUploadSize: opts.maxUploadSize,
+		AllowLocal:    opts.allowLocal,
+		AllowPlugins:  opts.allowPlugins,
+		Logger:        logging.FromContext(ctx),
+	}, serveOperations{})
+
+	if err := srv.Run(ctx); err != nil {
+		return &ExitError{Code: 1, Err: err}
+	}
+
+	return nil
+}
+
+// serveOperations performs the served chart operations with the code paths
+// of the corresponding commands.
+type serveOperations struct{}
+
+// convertResponse is the response body of a conversion.
+type convertResponse struct {
+	RGD              string             `json:"rgd"`
+	ChartName        string             `json:"chartName"`
+	ChartVersion     string             `json:"chartVersion"`
+	ResourceCount    int                `json:"resourceCount"`
+	SchemaFieldCount int                `json:"schemaFieldCount"`
+	DependencyEdges  int                `json:"dependencyEdges"`
+	Warnings         []string           `json:"warnings,omitempty"`
+	AuditFindings    []auditFinding     `json:"auditFindings,omitempty"`
+	Validation       *server.Validation `json:"validation"`
+}
+
+// auditFinding is an audit finding of a conversion, in the format of
+// audit --format json.
+type auditFinding struct {
+	RuleID       string `json:"ruleId"`
+	Severity     string `json:"severity"`
+	ResourceID   string `json:"resourceId"`
+	ResourceKind string `json:"resourceKind"`
+	Message      string `json:"message"`
+	Remediation  string `json:"remediation"`
+}
+
+func (serveOperations) Convert(ctx context.Context, req *server.Request) (interface{}, error) {
+	opts, cleanup, err := req.Options()
+	defer cleanup()
+
+	if err != nil {
+		return nil, server.BadRequest(err)
+	}
+
+	result, err := chart2kro.Convert(ctx, req.Chart, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &convertResponse{
+		RGD:              string(result.YAML),
+		ChartName:        result.ChartName,
+		ChartVersion:     result.ChartVersion,
+		ResourceCount:    result.ResourceCount,
+		SchemaFieldCount: result.SchemaFieldCount,
+		DependencyEdges:  result.DependencyEdges,
+		Validation:       server.Validate(result.RGDMap, false),
+	}
+
+	if result.HardenResult != nil {
+		resp.Warnings = result.HardenResult.Warnings
+	}
+
+	for _, f := range result.AuditFindings {
+		resp.AuditFindings = append(resp.AuditFindings, auditFinding{
+			RuleID:       f.RuleID,
+			Severity:     f.Severity,
+			ResourceID:   f.Resource,
+			ResourceKind: f.ResourceKind,
+			Message:      f.Message,
+			Remediation:  f.Remediation,
+		})
+	}
+
+	return resp, nil
+}
+
+func (serveOperations) Inspect(ctx context.Context, req *server.Request) (interface{}, error) {
+	valueFiles, cleanup, err := req.AllValueFiles()
+	defer cleanup()
+
+	if err != nil {
+		return nil, server.BadRequest(err)
+	}
+
+	opts := &inspectOptions{
+		repoURL:      req.RepoURL,
+		version:      req.Version,
+		releaseName:  stringOr(req.ReleaseName, "release"),
+		namespace:    stringOr(req.Namespace, "default"),
+		timeout:      specTimeout(&req.Spec),
+		valueFiles:   valueFiles,
+		values:       req.Set,
+		stringValues: req.SetString,
+	}
+
+	result, _, err := inspectChart(ctx, req.Chart, opts)
+	if err != nil {
+		return nil, serveError(err)
+	}
+
+	return result, nil
+}
+
+func (serveOperations) Plan(ctx context.Context, req *server.Request) (interface{}, error) {
+	opts, cleanup, err := specConvertOptions(&req.Spec)
+	defer cleanup()
+
+	if err != nil {
+		return nil, server.BadRequest(err)
+	}
+
+	var existing map[string]interface{}
+
+	if req.Existing != "" {
+		if err := sigsyaml.Unmarshal([]byte(req.Existing), &existing); err != nil {
+			return nil, server.BadRequest(fmt.Errorf("parsing existing: %w", err))
+		}
+	}
+
+	pResult, err := runPipeline(ctx, req.Chart, opts)
+	if err != nil {
+		return nil, serveError(err)
+	}
+
+	p := plan.BuildPlan(pResult.Result, pResult.RGDMap)
+
+	if existing != nil {
+		plan.ApplyEvolution(p, plan.Analyze(existing, pResult.RGDMap))
+	}
+
+	return plan.BuildDocument(p, pResult.RGDMap, plan.ChartVersion{
+		Name:       pResult.Meta.Name,
+		Version:    pResult.Meta.Version,
+		AppVersion: pResult.Meta.AppVersion,
+	}), nil
+}
+
+func (serveOperations) Audit(ctx context.Context, req *server.Request) (interface{}, error) {
+	valueFiles, cleanup, err := req.AllValueFiles()
+	defer cleanup()
+
+	if err != nil {
+		return nil, server.BadRequest(err)
+	}
+
+	level := string(harden.SecurityLevelRestricted)
+	if req.Audit != nil && req.Audit.SecurityLevel != "" {
+		level = req.Audit.SecurityLevel
+	}
+
+	secLevel, err := harden.ParseSecurityLevel(level)
+	if err != nil {
+		return nil, server.BadRequest(err)
+	}
+
+	opts := &auditOptions{
+		repoURL:      req.RepoURL,
+		version:      req.Version,
+		releaseName:  stringOr(req.ReleaseName, "release"),
+		namespace:    stringOr(req.Namespace, "default"),
+		timeout:      specTimeout(&req.Spec),
+		valueFiles:   valueFiles,
+		values:       req.Set,
+		stringValues: req.SetString,
+		includeHooks: req.IncludeHooks,
+	}
+
+	resources, err := loadChartResources(ctx, req.Chart, opts)
+	if err != nil {
+		return nil, serveError(err)
+	}
+
+	result := audit.New(audit.DefaultChecks(secLevel)...).Run(ctx, resources)
+
+	var buf bytes.Buffer
+	if err := (&audit.JSONFormatter{}).Format(&buf, result); err != nil {
+		return nil, fmt.Errorf("formatting results: %w", err)
+	}
+
+	return json.RawMessage(buf.Bytes()), nil
+}
+
+// specConvertOptions returns the pipeline options equivalent to the
+// conversion options of spec, with the defaults of the convert flags. The
+// returned cleanup removes the temporary file holding inline values.
+func specConvertOptions(spec *krmfn.Spec) (*convertOptions, func(), error) {
+	valueFiles, cleanup, err := spec.AllValueFiles()
+	if err != nil {
+		return nil, cleanup, err
+	}
+
+	opts := &convertOptions{
+		repoURL:            spec.RepoURL,
+		version:            spec.Version,
+		plainHTTP:          spec.PlainHTTP,
+		releaseName:        stringOr(spec.ReleaseName, "release"),
+		namespace:          stringOr(spec.Namespace, "default"),
+		strict:             spec.Strict,
+		timeout:            specTimeout(spec),
+		valueFiles:         valueFiles,
+		values:             spec.Set,
+		stringValues:       spec.SetString,
+		includeHooks:       spec.IncludeHooks,
+		hookMode:           "drop",
+		kind:               spec.Kind,
+		apiVersion:         stringOr(spec.APIVersion, "v1alpha1"),
+		group:              stringOr(spec.Group, "kro.run"),
+		includeAllValues:   spec.IncludeAllValues,
+		flatSchema:         spec.FlatSchema,
+		fast:               spec.Fast,
+		excludeKinds:       spec.ExcludeKinds,
+		excludeResources:   spec.ExcludeResources,
+		excludeSubcharts:   spec.ExcludeSubcharts,
+		excludeLabels:      spec.ExcludeLabels,
+		externalizeSecret:  spec.ExternalizeSecret,
+		externalizeService: spec.ExternalizeService,
+		useExternalPattern: spec.UseExternalPattern,
+		profile:            spec.Profile,
+		securityLevel:      string(harden.SecurityLevelRestricted),
+	}
+
+	if h := spec.Harden; h != nil && h.Enabled {
+		opts.harden = true
+		opts.securityLevel = stringOr(h.SecurityLevel, opts.securityLevel)
+		opts.generateNetworkPolicies = h.NetworkPolicies
+		opts.generateRBAC = h.RBAC
+		opts.resolveDigests = h.ResolveDigests
+	}
+
+	// An empty config keeps the server's config file from applying.
+	opts.configData = []byte("{}")
+
+	if len(spec.Config) > 0 {
+		data, err := sigsyaml.Marshal(spec.Config)
+		if err != nil {
+			return nil, cleanup, fmt.Errorf("config: %w", err)
+		}
+
+		opts.configData = data
+	}
+
+	return opts, cleanup, nil
+}
+
+// specTimeout returns the rendering timeout of spec (default: 30s). The
+// timeout was validated with the spec.
+func specTimeout(spec *krmfn.Spec) time.Duration {
+	if d, err := time.ParseDuration(spec.Timeout); err == nil {
+		return d
+	}
+
+	return 30 * time.Second
+}
+
+// serveError maps a command error to a server error: invalid arguments are
+// bad requests.
+func serveError(err error) error {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) && exitErr.Code == 2 {
+		return server.BadRequest(exitErr.Err)
+	}
+
+	return err
+}
+
+func stringOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+
+	return s
+}
@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/hupe1980/chart2kro/internal/server"
+)
+
+func newTestServer(t *testing.T, allowLocal bool) *httptest.Server {
+	t.Helper()
+
+	srv := server.New(server.Options{
+		AllowLocal: allowLocal,
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, serveOperations{})
+
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func postJSON(t *testing.T, url string, body interface{}) (int, []byte) {
+	t.Helper()
+
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(data)) //nolint:gosec,noctx // test server
+	require.NoError(t, err)
+
+	defer func() { _ = resp.Body.Close() }()
+
+	out, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp.StatusCode, out
+}
+
+func TestServe_Convert(t *testing.T) {
+	ts := newTestServer(t, true)
+	chart := filepath.Join(testdataDir(t), "charts", "simple")
+
+	code, body := postJSON(t, ts.URL+"/v1/convert", map[string]interface{}{
+		"chart":       chart,
+		"releaseName": "portal",
+		"values":      map[string]interface{}{"replicaCount": 4},
+		"audit":       map[string]interface{}{"enabled": true},
+	})
+	require.Equal(t, http.StatusOK, code, string(body))
+
+	var resp convertResponse
+	require.NoError(t, json.Unmarshal(body, &resp))
+
+	assert.Equal(t, "simple", resp.ChartName)
+	assert.Equal(t, 2, resp.ResourceCount)
+	assert.Contains(t, resp.RGD, "kind: ResourceGraphDefinition")
+	assert.Contains(t, resp.RGD, "default=4")
+	assert.True(t, resp.Validation.Valid)
+	assert.NotEmpty(t, resp.AuditFindings)
+}
+
+func TestServe_Upload(t *testing.T) {
+	ts := newTestServer(t, false)
+
+	ch, err := loader.Load(filepath.Join(testdataDir(t), "charts", "simple"))
+	require.NoError(t, err)
+
+	archive, err := chartutil.Save(ch, t.TempDir())
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(archive) //nolint:gosec // test path
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	mw := multipart.NewWriter(&buf)
+	require.NoError(t, mw.WriteField("options", `{"kind": "Portal"}`))
+
+	part, err := mw.CreateFormFile("chart", filepath.Base(archive))
+	require.NoError(t, err)
+
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	resp, err := http.Post(ts.URL+"/v1/convert", mw.FormDataContentType(), &buf) //nolint:gosec,noctx // test server
+	require.NoError(t, err)
+
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+
+	var out convertResponse
+	require.NoError(t, json.Unmarshal(body, &out))
+	assert.Contains(t, out.RGD, "kind: Portal")
+}
+
+func TestServe_LocalChartRejected(t *testing.T) {
+	ts := newTestServer(t, false)
+
+	code, body := postJSON(t, ts.URL+"/v1/convert", map[string]interface{}{
+		"chart": filepath.Join(testdataDir(t), "charts", "simple"),
+	})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, string(body), "upload local charts")
+}
+
+func TestServe_Inspect(t *testing.T) {
+	ts := newTestServer(t, true)
+
+	code, body := postJSON(t, ts.URL+"/v1/inspect", map[string]interface{}{
+		"chart": filepath.Join(testdataDir(t), "charts", "simple"),
+	})
+	require.Equal(t, http.StatusOK, code, string(body))
+
+	var result inspectResult
+	require.NoError(t, json.Unmarshal(body, &result))
+
+	assert.Equal(t, "simple", result.Chart.Name)
+	assert.Len(t, result.Resources, 2)
+}
+
+func TestServe_Plan(t *testing.T) {
+	ts := newTestServer(t, true)
+	chart := filepath.Join(testdataDir(t), "charts", "simple")
+
+	code, body := postJSON(t, ts.URL+"/v1/plan", map[string]interface{}{"chart": chart})
+	require.Equal(t, http.StatusOK, code, string(body))
+	assert.Contains(t, string(body), `"apiVersion"`)
+	assert.Contains(t, string(body), "replicaCount")
+
+	// A schema field of the existing RGD missing from the plan is breaking.
+	existing := `apiVersion: kro.run/v1alpha1
+kind: ResourceGraphDefinition
+metadata:
+  name: simple
+spec:
+  schema:
+    apiVersion: v1alpha1
+    kind: Simple
+    spec:
+      legacy: string
+`
+
+	code, body = postJSON(t, ts.URL+"/v1/plan", map[string]interface{}{"chart": chart, "existing": existing})
+	require.Equal(t, http.StatusOK, code, string(body))
+	assert.Contains(t, string(body), `"hasBreakingChanges": true`)
+
+	code, body = postJSON(t, ts.URL+"/v1/plan", map[string]interface{}{"chart": chart, "existing": "{"})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, string(body), "parsing existing")
+}
+
+func TestServe_Audit(t *testing.T) {
+	ts := newTestServer(t, true)
+
+	code, body := postJSON(t, ts.URL+"/v1/audit", map[string]interface{}{
+		"chart": filepath.Join(testdataDir(t), "charts", "simple"),
+		"audit": map[string]interface{}{"securityLevel": "baseline"},
+	})
+	require.Equal(t, http.StatusOK, code, string(body))
+
+	var result struct {
+		Findings []map[string]interface{} `json:"findings"`
+		Total    int                      `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(body, &result))
+
+	assert.Positive(t, result.Total)
+	assert.Len(t, result.Findings, result.Total)
+}
+
+func TestServe_ConversionError(t *testing.T) {
+	ts := newTestServer(t, true)
+
+	code, body := postJSON(t, ts.URL+"/v1/convert", map[string]interface{}{
+		"chart": filepath.Join(t.TempDir(), "missing"),
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.True(t, strings.Contains(string(body), "error"))
+}
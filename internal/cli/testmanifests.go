@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/hupe1980/chart2kro/internal/kro"
+	"github.com/hupe1980/chart2kro/internal/logging"
+	"github.com/hupe1980/chart2kro/internal/output"
+)
+
+type testManifestsOptions struct {
+	convertOptions
+
+	// Instance file of the application RGD to resolve the tests against.
+	instance string
+
+	// Output format: "rgd" or "manifests" (default: manifests with
+	// --instance, rgd otherwise).
+	format string
+}
+
+func newTestManifestsCommand() *cobra.Command {
+	opts := &testManifestsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "test-manifests <chart-reference>",
+		Short: "Extract Helm test hooks into a conformance suite",
+		Long: `Extract the chart's Helm test hooks (helm.sh/hook: test) into a
+conformance suite parameterised by the same schema as the converted RGD.
+
+With --format rgd (the default without --instance) a conformance RGD is
+written: its kind is <Kind>Conformance, its spec matches the application
+schema, and each test Pod is ready once it has succeeded. Create an
+instance with the same spec as the application instance to smoke-test it.
+
+With --instance (or --format manifests) the schema references are resolved
+against an instance of the application RGD, values missing from the
+instance falling back to the schema defaults, and plain test manifests are
+written in the instance namespace, ready for kubectl apply.
+
+Exit codes:
+  0  Success
+  1  Error (including charts without test hooks)
+  2  Invalid arguments
+  6  Output write failure`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTestManifests(cmd.Context(), cmd, args[0], opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&opts.instance, "instance", "", "instance YAML of the application RGD to resolve the tests against")
+	f.StringVar(&opts.format, "format", "", "output format: rgd, manifests (default: manifests with --instance, rgd otherwise)")
+	f.StringVarP(&opts.output, "output", "o", "", "output file path (default: stdout)")
+
+	registerPipelineFlags(cmd, &opts.convertOptions)
+
+	return cmd
+}
+
+func runTestManifests(ctx context.Context, cmd *cobra.Command, ref string, opts *testManifestsOptions) error {
+	logger := logging.FromContext(ctx)
+
+	format := opts.format
+	if format == "" {
+		format = "rgd"
+		if opts.instance != "" {
+			format = "manifests"
+		}
+	}
+
+	if format != "rgd" && format != "manifests" {
+		return &ExitError{Code: 2, Err: fmt.Errorf("invalid format %q (must be rgd or manifests)", format)}
+	}
+
+	if format == "rgd" && opts.instance != "" {
+		return &ExitError{Code: 2, Err: fmt.Errorf("--instance cannot be combined with --format=rgd")}
+	}
+
+	var instance map[string]interface{}
+
+	if opts.instance != "" {
+		var err error
+
+		instance, err = loadRGDFile(opts.instance, 7)
+		if err != nil {
+			return err
+		}
+	}
+
+	opts.extractTests = true
+
+	res, err := runPipeline(ctx, ref, &opts.convertOptions)
+	if err != nil {
+		return err
+	}
+
+	suite := res.Conformance
+	if suite == nil || len(suite.Tests) == 0 {
+		return &ExitError{Code: 1, Err: fmt.Errorf("chart %s has no Helm test hooks", res.Meta.Name)}
+	}
+
+	var data []byte
+
+	if format == "rgd" {
+		rgd, rgdErr := suite.RGD(kro.GeneratorConfig{
+			Name:             res.Meta.Name,
+			ChartName:        res.Meta.Name,
+			ChartVersion:     res.Meta.Version,
+			SchemaKind:       opts.kind,
+			SchemaAPIVersion: opts.apiVersion,
+			SchemaGroup:      opts.group,
+		})
+		if rgdErr != nil {
+			return &ExitError{Code: 1, Err: rgdErr}
+		}
+
+		data, err = output.Serialize(rgd.ToMap(), output.SerializeOptions{Indent: 2})
+		if err != nil {
+			return &ExitError{Code: 1, Err: fmt.Errorf("serializing conformance RGD: %w", err)}
+		}
+	} else {
+		if instance == nil {
+			instance = map[string]interface{}{
+				"metadata": map[string]interface{}{"name": opts.releaseName, "namespace": opts.namespace},
+			}
+		}
+
+		manifests, resolveErr := suite.Manifests(instance)
+		if resolveErr != nil {
+			return &ExitError{Code: 1, Err: resolveErr}
+		}
+
+		data, err = marshalManifests(manifests)
+		if err != nil {
+			return &ExitError{Code: 1, Err: err}
+		}
+	}
+
+	if opts.output != "" {
+		w := output.NewFileWriter(opts.output, output.WithLogger(logger))
+		if err := w.Write(data); err != nil {
+			return &ExitError{Code: 6, Err: fmt.Errorf("writing output: %w", err)}
+		}
+
+		logger.Info("test manifests written", slog.String("path", opts.output), slog.Int("tests", len(suite.Tests)))
+
+		return nil
+	}
+
+	if _, err := cmd.OutOrStdout().Write(data); err != nil {
+		return &ExitError{Code: 6, Err: fmt.Errorf("writing output: %w", err)}
+	}
+
+	return nil
+}
+
+// marshalManifests serializes manifests as a multi-document YAML stream.
+func marshalManifests(manifests []map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for i, m := range manifests {
+		doc, err := sigsyaml.Marshal(m)
+		if err != nil {
+			return nil, fmt.Errorf("serializing test manifest: %w", err)
+		}
+
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+
+		buf.Write(doc)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+func TestTestManifests_RGD(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "with-hooks")
+
+	stdout, _, err := executeCommand("test-manifests", chartDir, "--include-all-values")
+	require.NoError(t, err)
+
+	var rgd map[string]interface{}
+	require.NoError(t, sigsyaml.Unmarshal([]byte(stdout), &rgd))
+
+	assert.Equal(t, "with-hooks-conformance", rgd["metadata"].(map[string]interface{})["name"])
+	assert.Contains(t, stdout, "kind: WithHooksConformance")
+	assert.Contains(t, stdout, "image: ${schema.spec.image}")
+	assert.Contains(t, stdout, `image: string | default="busybox"`)
+	assert.Contains(t, stdout, `${self.status.phase == "Succeeded"}`)
+	assert.NotContains(t, stdout, "helm.sh/hook")
+	assert.NotContains(t, stdout, "migrate", "only test hooks are extracted")
+}
+
+func TestTestManifests_Instance(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "with-hooks")
+
+	instance := filepath.Join(t.TempDir(), "instance.yaml")
+	require.NoError(t, os.WriteFile(instance, []byte(
+		"apiVersion: kro.run/v1alpha1\nkind: WithHooks\nmetadata:\n  name: shop\n  namespace: prod\nspec:\n  image: alpine:3\n"), 0o600))
+
+	out := filepath.Join(t.TempDir(), "tests.yaml")
+
+	_, _, err := executeCommand("test-manifests", chartDir, "--include-all-values", "--instance", instance, "-o", out)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(out) //nolint:gosec // test path
+	require.NoError(t, err)
+
+	var pod map[string]interface{}
+	require.NoError(t, sigsyaml.Unmarshal(data, &pod))
+
+	assert.Equal(t, "Pod", pod["kind"])
+	assert.Equal(t, map[string]interface{}{"name": "release-test", "namespace": "prod"}, pod["metadata"])
+	assert.Contains(t, string(data), "image: alpine:3")
+}
+
+func TestTestManifests_NoTestHooks(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "simple")
+
+	_, _, err := executeCommand("test-manifests", chartDir)
+	require.ErrorContains(t, err, "has no Helm test hooks")
+}
+
+func TestTestManifests_InvalidFormat(t *testing.T) {
+	chartDir := filepath.Join(testdataDir(t), "charts", "with-hooks")
+
+	_, _, err := executeCommand("test-manifests", chartDir, "--format", "json")
+
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 2, exitErr.Code)
+}
@@ -0,0 +1,68 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hupe1980/chart2kro/pkg/chart2kro"
+)
+
+// Result is the outcome of a composition.
+type Result struct {
+	// RGDMap is the composed RGD.
+	RGDMap map[string]interface{}
+
+	// Charts are the conversion results of the charts in manifest order.
+	Charts []ChartResult
+}
+
+// ChartResult is the conversion result of a chart of the composition.
+type ChartResult struct {
+	Name   string
+	Prefix string
+	Result *chart2kro.Result
+}
+
+// Compose converts every chart of the manifest and merges the RGDs.
+func Compose(ctx context.Context, m *Manifest) (*Result, error) {
+	result := &Result{}
+	parts := make([]Part, 0, len(m.Spec.Charts))
+
+	for i := range m.Spec.Charts {
+		c := m.Spec.Charts[i]
+		c.resolvePaths(m.dir)
+
+		converted, err := convertChart(ctx, &c)
+		if err != nil {
+			return nil, fmt.Errorf("chart %q: %w", c.Name, err)
+		}
+
+		result.Charts = append(result.Charts, ChartResult{Name: c.Name, Prefix: c.IDPrefix(), Result: converted})
+		parts = append(parts, Part{Name: c.Name, Prefix: c.IDPrefix(), RGDMap: converted.RGDMap})
+	}
+
+	rgdMap, err := Merge(MergeConfig{
+		Name:       m.Metadata.Name,
+		Kind:       m.Spec.Kind,
+		APIVersion: m.Spec.APIVersion,
+		Group:      m.Spec.Group,
+		Wiring:     m.Spec.Wiring,
+	}, parts)
+	if err != nil {
+		return nil, err
+	}
+
+	result.RGDMap = rgdMap
+
+	return result, nil
+}
+
+func convertChart(ctx context.Context, c *Chart) (*chart2kro.Result, error) {
+	opts, cleanup, err := c.Options()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	return chart2kro.Convert(ctx, c.Chart, opts...)
+}
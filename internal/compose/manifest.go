@@ -0,0 +1,240 @@
+// Package compose combines several Helm charts into a single umbrella
+// ResourceGraphDefinition.
+//
+// A composition manifest lists the charts with their conversion options.
+// Each chart is converted on its own; the RGDs are then merged: the schema
+// fields of a chart nest under spec.<name>, its status fields under
+// status.<name>, and its resource IDs get the "<prefix>-" prefix, with the
+// CEL expressions of the chart rewritten accordingly. Wiring binds a chart's
+// values to CEL expressions over the other charts' resources, e.g. the app's
+// redis.host to the redis chart's Service name.
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/hupe1980/chart2kro/internal/krmfn"
+)
+
+// Manifest identification.
+const (
+	APIVersion = "chart2kro.io/v1alpha1"
+	Kind       = "Composition"
+)
+
+// Manifest is a composition manifest (compose.yaml).
+type Manifest struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec Spec `json:"spec"`
+
+	// dir is the directory relative chart and values paths resolve against.
+	dir string
+}
+
+// Spec describes the composed RGD.
+type Spec struct {
+	// Kind is the kind of the composed custom resource (default: PascalCase
+	// metadata.name).
+	Kind string `json:"kind,omitempty"`
+	// APIVersion is the schema version (default: "v1alpha1").
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Group is the schema group (default: "kro.run").
+	Group string `json:"group,omitempty"`
+
+	Charts []Chart `json:"charts"`
+	Wiring []Wire  `json:"wiring,omitempty"`
+}
+
+// Chart is a chart of the composition. The embedded spec holds the same
+// conversion options as the krm-fn ChartToRGD spec.
+type Chart struct {
+	// Name namespaces the chart: its schema fields nest under spec.<name>
+	// and its status fields under status.<name>.
+	Name string `json:"name"`
+
+	// Prefix is prepended to the chart's resource IDs as "<prefix>-<id>"
+	// (default: Name).
+	Prefix string `json:"prefix,omitempty"`
+
+	krmfn.Spec
+}
+
+// Wire binds a Helm value of a chart to a CEL expression. The value's schema
+// field is removed from the composed spec.
+type Wire struct {
+	// Chart is the name of the chart whose value is bound.
+	Chart string `json:"chart"`
+	// Value is the dotted Helm values path, e.g. "redis.host".
+	Value string `json:"value"`
+
+	// Expression is a CEL expression over the composed resource IDs, e.g.
+	// "${redis-service.metadata.name}".
+	Expression string `json:"expression,omitempty"`
+	// From references a resource field of another chart.
+	From *WireSource `json:"from,omitempty"`
+}
+
+// WireSource is a field of a chart resource.
+type WireSource struct {
+	// Chart is the name of the chart that owns the resource.
+	Chart string `json:"chart"`
+	// Resource is the resource ID within the chart, e.g. "service".
+	Resource string `json:"resource"`
+	// Field is the dotted field path (default: "metadata.name").
+	Field string `json:"field,omitempty"`
+}
+
+var (
+	namePattern   = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+	prefixPattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+	valuePattern  = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+)
+
+// Load reads and validates a composition manifest. Relative chart and
+// values file paths resolve against the manifest's directory.
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // user-specified manifest
+	if err != nil {
+		return nil, fmt.Errorf("reading composition manifest: %w", err)
+	}
+
+	m, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	m.dir = filepath.Dir(path)
+
+	return m, nil
+}
+
+// Parse parses and validates a composition manifest.
+func Parse(data []byte) (*Manifest, error) {
+	m := &Manifest{}
+	if err := sigsyaml.UnmarshalStrict(data, m); err != nil {
+		return nil, fmt.Errorf("parsing composition manifest: %w", err)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid composition manifest: %w", err)
+	}
+
+	return m, nil
+}
+
+// Validate checks the manifest for correctness.
+func (m *Manifest) Validate() error {
+	if m.APIVersion != APIVersion || m.Kind != Kind {
+		return fmt.Errorf("manifest must be %s/%s, got %s/%s", APIVersion, Kind, m.APIVersion, m.Kind)
+	}
+
+	if m.Metadata.Name == "" {
+		return errors.New("metadata.name is required")
+	}
+
+	if len(m.Spec.Charts) == 0 {
+		return errors.New("spec.charts must list at least one chart")
+	}
+
+	names := make(map[string]bool, len(m.Spec.Charts))
+	prefixes := make(map[string]bool, len(m.Spec.Charts))
+
+	for i := range m.Spec.Charts {
+		c := &m.Spec.Charts[i]
+
+		if !namePattern.MatchString(c.Name) {
+			return fmt.Errorf("spec.charts[%d]: name %q is invalid (must match %s)", i, c.Name, namePattern.String())
+		}
+
+		if names[c.Name] {
+			return fmt.Errorf("spec.charts[%d]: duplicate name %q", i, c.Name)
+		}
+
+		names[c.Name] = true
+
+		if c.Prefix != "" && !prefixPattern.MatchString(c.Prefix) {
+			return fmt.Errorf("spec.charts[%d]: prefix %q is invalid (must match %s)", i, c.Prefix, prefixPattern.String())
+		}
+
+		if prefixes[c.IDPrefix()] {
+			return fmt.Errorf("spec.charts[%d]: duplicate prefix %q", i, c.IDPrefix())
+		}
+
+		prefixes[c.IDPrefix()] = true
+
+		if err := c.Spec.Validate(); err != nil {
+			return fmt.Errorf("spec.charts[%d].%w", i, err)
+		}
+	}
+
+	for i, w := range m.Spec.Wiring {
+		if !names[w.Chart] {
+			return fmt.Errorf("spec.wiring[%d]: unknown chart %q", i, w.Chart)
+		}
+
+		if !valuePattern.MatchString(w.Value) {
+			return fmt.Errorf("spec.wiring[%d]: value %q is invalid (must match %s)", i, w.Value, valuePattern.String())
+		}
+
+		switch {
+		case (w.Expression == "") == (w.From == nil):
+			return fmt.Errorf("spec.wiring[%d]: exactly one of expression and from is required", i)
+		case w.From != nil && !names[w.From.Chart]:
+			return fmt.Errorf("spec.wiring[%d]: from: unknown chart %q", i, w.From.Chart)
+		case w.From != nil && w.From.Resource == "":
+			return fmt.Errorf("spec.wiring[%d]: from.resource is required", i)
+		case w.From != nil && w.From.Field != "" && !valuePattern.MatchString(w.From.Field):
+			return fmt.Errorf("spec.wiring[%d]: from.field %q is invalid", i, w.From.Field)
+		}
+	}
+
+	return nil
+}
+
+// IDPrefix returns the resource ID prefix of the chart.
+func (c *Chart) IDPrefix() string {
+	if c.Prefix != "" {
+		return c.Prefix
+	}
+
+	return c.Name
+}
+
+// resolvePaths makes the chart's relative local paths relative to dir.
+func (c *Chart) resolvePaths(dir string) {
+	if dir == "" {
+		return
+	}
+
+	resolve := func(p string) string {
+		if filepath.IsAbs(p) {
+			return p
+		}
+
+		joined := filepath.Join(dir, p)
+		if _, err := os.Stat(joined); err != nil {
+			return p // not a local path, e.g. oci:// or repo/chart
+		}
+
+		return joined
+	}
+
+	c.Chart = resolve(c.Chart)
+
+	files := make([]string, len(c.ValueFiles))
+	for i, f := range c.ValueFiles {
+		files[i] = resolve(f)
+	}
+
+	c.ValueFiles = files
+}
@@ -0,0 +1,94 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validManifest = `apiVersion: chart2kro.io/v1alpha1
+kind: Composition
+metadata:
+  name: webstack
+spec:
+  charts:
+    - name: app
+      chart: ./charts/app
+      valueFiles: [values-prod.yaml]
+    - name: redis
+      prefix: cache
+      chart: oci://registry-1.docker.io/bitnamicharts/redis
+  wiring:
+    - chart: app
+      value: redis.host
+      from:
+        chart: redis
+        resource: service-master
+`
+
+func TestParse(t *testing.T) {
+	m, err := Parse([]byte(validManifest))
+	require.NoError(t, err)
+
+	assert.Equal(t, "webstack", m.Metadata.Name)
+	require.Len(t, m.Spec.Charts, 2)
+	assert.Equal(t, "app", m.Spec.Charts[0].IDPrefix())
+	assert.Equal(t, "cache", m.Spec.Charts[1].IDPrefix())
+	require.Len(t, m.Spec.Wiring, 1)
+	assert.Equal(t, "service-master", m.Spec.Wiring[0].From.Resource)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	header := "apiVersion: chart2kro.io/v1alpha1\nkind: Composition\nmetadata: {name: webstack}\n"
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"wrong kind", "apiVersion: chart2kro.io/v1alpha1\nkind: Other\n", "manifest must be"},
+		{"unknown field", header + "spec: {charts: [{name: app, chart: ./app, bogus: 1}]}", "unknown field"},
+		{"no name", "apiVersion: chart2kro.io/v1alpha1\nkind: Composition\nspec: {charts: [{name: app, chart: ./app}]}", "metadata.name is required"},
+		{"no charts", header + "spec: {}", "at least one chart"},
+		{"invalid name", header + "spec: {charts: [{name: my-app, chart: ./app}]}", "name \"my-app\" is invalid"},
+		{"duplicate name", header + "spec: {charts: [{name: app, chart: ./a}, {name: app, chart: ./b}]}", "duplicate name"},
+		{"duplicate prefix", header + "spec: {charts: [{name: app, chart: ./a}, {name: web, prefix: app, chart: ./b}]}", "duplicate prefix"},
+		{"chart spec", header + "spec: {charts: [{name: app}]}", "spec.charts[0].chart is required"},
+		{"unknown wire chart", header + "spec: {charts: [{name: app, chart: ./a}], wiring: [{chart: db, value: x, expression: '${1}'}]}", "unknown chart \"db\""},
+		{"wire without source", header + "spec: {charts: [{name: app, chart: ./a}], wiring: [{chart: app, value: x}]}", "exactly one of expression and from"},
+		{"wire unknown from", header + "spec: {charts: [{name: app, chart: ./a}], wiring: [{chart: app, value: x, from: {chart: db, resource: service}}]}", "from: unknown chart"},
+		{"wire no resource", header + "spec: {charts: [{name: app, chart: ./a}], wiring: [{chart: app, value: x, from: {chart: app}}]}", "from.resource is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestLoad_ResolvesRelativePaths(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "charts", "app"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "values-prod.yaml"), []byte("{}"), 0o600))
+
+	path := filepath.Join(dir, "compose.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(validManifest), 0o600))
+
+	m, err := Load(path)
+	require.NoError(t, err)
+
+	app := m.Spec.Charts[0]
+	app.resolvePaths(m.dir)
+	assert.Equal(t, filepath.Join(dir, "charts", "app"), app.Chart)
+	assert.Equal(t, []string{filepath.Join(dir, "values-prod.yaml")}, app.ValueFiles)
+
+	redis := m.Spec.Charts[1]
+	redis.resolvePaths(m.dir)
+	assert.Equal(t, "oci://registry-1.docker.io/bitnamicharts/redis", redis.Chart)
+}
@@ -0,0 +1,390 @@
+package compose
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hupe1980/chart2kro/internal/kro"
+	"github.com/hupe1980/chart2kro/internal/maputil"
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+// Part is the converted RGD of a chart.
+type Part struct {
+	// Name namespaces the chart's schema and status fields.
+	Name string
+	// Prefix is prepended to the chart's resource IDs.
+	Prefix string
+	// RGDMap is the chart's RGD. It is not modified.
+	RGDMap map[string]interface{}
+}
+
+// MergeConfig configures the composed RGD.
+type MergeConfig struct {
+	// Name is the RGD metadata name.
+	Name string
+	// Kind overrides the schema kind (default: PascalCase Name).
+	Kind string
+	// APIVersion overrides the schema version (default: "v1alpha1").
+	APIVersion string
+	// Group overrides the schema group (default: "kro.run").
+	Group string
+	// Wiring binds chart values to CEL expressions.
+	Wiring []Wire
+}
+
+// Merge combines the RGDs of the parts into one. Each part's schema and
+// status fields nest under its name and its resource IDs are prefixed;
+// references in its CEL expressions are rewritten accordingly. The wiring
+// is applied last.
+func Merge(cfg MergeConfig, parts []Part) (map[string]interface{}, error) {
+	spec := make(map[string]interface{})
+	status := make(map[string]interface{})
+	resources := make([]interface{}, 0)
+
+	seen := make(map[string]string)                // composed ID -> chart
+	chartIDs := make(map[string]map[string]string) // chart -> local ID -> composed ID
+
+	for _, p := range parts {
+		rgdSpec, _ := p.RGDMap["spec"].(map[string]interface{})
+		schema, _ := rgdSpec["schema"].(map[string]interface{})
+		list, _ := rgdSpec["resources"].([]interface{})
+
+		ids := make(map[string]string, len(list))
+
+		for _, r := range list {
+			if rm, ok := r.(map[string]interface{}); ok {
+				id, _ := rm["id"].(string)
+				ids[id] = p.Prefix + "-" + id
+			}
+		}
+
+		chartIDs[p.Name] = ids
+		rw := newRewriter(p.Name, ids)
+
+		if s, ok := schema["spec"].(map[string]interface{}); ok && len(s) > 0 {
+			spec[p.Name] = maputil.DeepCopyMap(s)
+		}
+
+		if s, ok := schema["status"].(map[string]interface{}); ok && len(s) > 0 {
+			status[p.Name] = rw.value(maputil.DeepCopyMap(s))
+		}
+
+		for _, r := range list {
+			rm, ok := r.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			rm = maputil.DeepCopyMap(rm)
+
+			id, _ := rm["id"].(string)
+			composed := ids[id]
+
+			if owner, dup := seen[composed]; dup {
+				return nil, fmt.Errorf("resource ID %q of chart %q collides with chart %q", composed, p.Name, owner)
+			}
+
+			seen[composed] = p.Name
+			rm["id"] = composed
+
+			for _, key := range []string{"template", "readyWhen", "includeWhen"} {
+				if v, ok := rm[key]; ok {
+					rm[key] = rw.value(v)
+				}
+			}
+
+			if deps, ok := rm["dependsOn"].([]interface{}); ok {
+				for i, d := range deps {
+					if s, ok := d.(string); ok && ids[s] != "" {
+						deps[i] = ids[s]
+					}
+				}
+			}
+
+			resources = append(resources, rm)
+		}
+	}
+
+	for i, w := range cfg.Wiring {
+		if err := applyWire(w, spec, status, resources, chartIDs); err != nil {
+			return nil, fmt.Errorf("wiring[%d] %s.%s: %w", i, w.Chart, w.Value, err)
+		}
+	}
+
+	return composedRGD(cfg, parts, spec, status, resources), nil
+}
+
+// composedRGD assembles the composed RGD map.
+func composedRGD(cfg MergeConfig, parts []Part, spec, status map[string]interface{}, resources []interface{}) map[string]interface{} {
+	rgd := kro.NewGenerator(kro.GeneratorConfig{
+		Name:             cfg.Name,
+		ChartName:        cfg.Name,
+		SchemaKind:       cfg.Kind,
+		SchemaAPIVersion: cfg.APIVersion,
+		SchemaGroup:      cfg.Group,
+	}).Envelope(spec, status, resources)
+
+	charts := make([]string, len(parts))
+	for i, p := range parts {
+		charts[i] = p.Name
+	}
+
+	annotations := rgd["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+	annotations["chart2kro.dev/composition"] = strings.Join(charts, ",")
+
+	return rgd
+}
+
+// applyWire binds the wired value to its expression in all CEL expressions
+// and removes its schema field.
+func applyWire(w Wire, spec, status map[string]interface{}, resources []interface{}, chartIDs map[string]map[string]string) error {
+	expr := w.Expression
+
+	if w.From != nil {
+		id, ok := chartIDs[w.From.Chart][w.From.Resource]
+		if !ok {
+			return fmt.Errorf("chart %q has no resource %q", w.From.Chart, w.From.Resource)
+		}
+
+		field := w.From.Field
+		if field == "" {
+			field = "metadata.name"
+		}
+
+		expr = "${" + id + "." + field + "}"
+	}
+
+	chartSpec, _ := spec[w.Chart].(map[string]interface{})
+	if !removeField(chartSpec, strings.Split(w.Value, ".")) {
+		return fmt.Errorf("value is not a schema field of chart %q", w.Chart)
+	}
+
+	if len(chartSpec) == 0 {
+		delete(spec, w.Chart)
+	}
+
+	b := newBinder("schema.spec."+w.Chart+"."+w.Value, expr)
+
+	for i, r := range resources {
+		resources[i] = b.value(r)
+	}
+
+	for k, v := range status {
+		status[k] = b.value(v)
+	}
+
+	if b.count == 0 {
+		return fmt.Errorf("value is not referenced by any resource template")
+	}
+
+	return nil
+}
+
+// removeField deletes the leaf schema field at path and the objects it
+// leaves empty. It reports whether a leaf was removed.
+func removeField(m map[string]interface{}, path []string) bool {
+	if m == nil {
+		return false
+	}
+
+	v, ok := m[path[0]]
+	if !ok {
+		return false
+	}
+
+	if len(path) == 1 {
+		if _, isObject := v.(map[string]interface{}); isObject {
+			return false
+		}
+
+		delete(m, path[0])
+
+		return true
+	}
+
+	child, ok := v.(map[string]interface{})
+	if !ok || !removeField(child, path[1:]) {
+		return false
+	}
+
+	if len(child) == 0 {
+		delete(m, path[0])
+	}
+
+	return true
+}
+
+var (
+	// identPattern matches an identifier followed by a field selection that
+	// is not itself part of a selection chain.
+	identPattern = regexp.MustCompile(`(^|[^A-Za-z0-9_.\-])([A-Za-z_][A-Za-z0-9_\-]*)\.`)
+
+	// schemaSpecPattern matches a reference to the custom resource spec.
+	schemaSpecPattern = regexp.MustCompile(`(^|[^A-Za-z0-9_.\-])schema\.spec\.`)
+
+	// pathPattern matches CEL expressions that are a plain field path.
+	pathPattern = regexp.MustCompile(`^[A-Za-z0-9_.\-\[\]?]+$`)
+)
+
+// rewriter nests a chart's CEL references: schema.spec.x becomes
+// schema.spec.<name>.x and <id>.x becomes <prefix>-<id>.x.
+type rewriter struct {
+	name string
+	ids  map[string]string
+}
+
+func newRewriter(name string, ids map[string]string) *rewriter {
+	return &rewriter{name: name, ids: ids}
+}
+
+func (r *rewriter) value(v interface{}) interface{} {
+	return mapStrings(v, r.rewrite)
+}
+
+func (r *rewriter) rewrite(s string) string {
+	return transform.ReplaceExpressions(s, func(expr string) string {
+		inner := mapCode(expr[2:len(expr)-1], func(code string) string {
+			code = identPattern.ReplaceAllStringFunc(code, func(m string) string {
+				sub := identPattern.FindStringSubmatch(m)
+				if composed, ok := r.ids[sub[2]]; ok {
+					return sub[1] + composed + "."
+				}
+
+				return m
+			})
+
+			return schemaSpecPattern.ReplaceAllString(code, "${1}schema.spec."+r.name+".")
+		})
+
+		return "${" + inner + "}"
+	})
+}
+
+// mapCode applies fn to the parts of the CEL expression expr outside string
+// literals, leaving the literals unchanged.
+func mapCode(expr string, fn func(string) string) string {
+	var b strings.Builder
+
+	start := 0
+
+	for i := 0; i < len(expr); i++ {
+		quote := expr[i]
+		if quote != '"' && quote != '\'' {
+			continue
+		}
+
+		end := i + 1
+		for end < len(expr) && expr[end] != quote {
+			if expr[end] == '\\' {
+				end++
+			}
+
+			end++
+		}
+
+		end = min(end, len(expr)-1)
+
+		b.WriteString(fn(expr[start:i]))
+		b.WriteString(expr[i : end+1])
+
+		i = end
+		start = end + 1
+	}
+
+	b.WriteString(fn(expr[start:]))
+
+	return b.String()
+}
+
+// binder replaces a schema reference with a CEL expression.
+type binder struct {
+	pattern     *regexp.Regexp
+	exact       string
+	replacement string
+	count       int
+}
+
+func newBinder(ref, expr string) *binder {
+	inner := strings.TrimSuffix(strings.TrimPrefix(expr, "${"), "}")
+
+	replacement := inner
+	if !pathPattern.MatchString(inner) {
+		replacement = "(" + inner + ")"
+	}
+
+	return &binder{
+		pattern:     regexp.MustCompile(`(^|[^A-Za-z0-9_.\-])` + regexp.QuoteMeta(ref) + `($|[^A-Za-z0-9_.\-])`),
+		exact:       ref,
+		replacement: replacement,
+	}
+}
+
+func (b *binder) value(v interface{}) interface{} {
+	return mapStrings(v, func(s string) string {
+		return transform.ReplaceExpressions(s, func(expr string) string {
+			inner := expr[2 : len(expr)-1]
+
+			if strings.TrimSpace(inner) == b.exact {
+				b.count++
+
+				return "${" + strings.TrimSuffix(strings.TrimPrefix(b.replacement, "("), ")") + "}"
+			}
+
+			return "${" + mapCode(inner, b.bind) + "}"
+		})
+	})
+}
+
+// bind replaces the schema reference in code, which holds no string
+// literals.
+func (b *binder) bind(code string) string {
+	// Adjacent references share a boundary character, so repeat until none
+	// is left.
+	for {
+		replaced := b.pattern.ReplaceAllStringFunc(code, func(m string) string {
+			b.count++
+			sub := b.pattern.FindStringSubmatch(m)
+
+			return sub[1] + b.replacement + sub[2]
+		})
+
+		if replaced == code {
+			return code
+		}
+
+		code = replaced
+	}
+}
+
+// mapStrings applies fn to every string below v, in place for maps and
+// slices.
+func mapStrings(v interface{}, fn func(string) string) interface{} {
+	switch val := v.(type) {
+	case string:
+		return fn(val)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			val[k] = mapStrings(val[k], fn)
+		}
+
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = mapStrings(item, fn)
+		}
+
+		return val
+	default:
+		return v
+	}
+}
@@ -0,0 +1,240 @@
+package compose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func appPart() Part {
+	return Part{Name: "app", Prefix: "app", RGDMap: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"schema": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"replicaCount": "integer | default=1",
+					"redis":        map[string]interface{}{"host": `string | default="redis"`},
+				},
+				"status": map[string]interface{}{
+					"deploymentReadyReplicas": "${deployment.status.readyReplicas}",
+				},
+			},
+			"resources": []interface{}{
+				map[string]interface{}{
+					"id": "deployment",
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"replicas": "${schema.spec.replicaCount}",
+							"env": []interface{}{
+								map[string]interface{}{"name": "REDIS_URL", "value": "redis://${schema.spec.redis.host}:6379"},
+								map[string]interface{}{"name": "REDIS_HOST", "value": "${schema.spec.redis.host}"},
+							},
+						},
+					},
+					"readyWhen": []interface{}{"${self.status.readyReplicas == self.status.replicas}"},
+				},
+				map[string]interface{}{
+					"id":          "service",
+					"dependsOn":   []interface{}{"deployment"},
+					"includeWhen": []interface{}{"${schema.spec.replicaCount > 0}"},
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{"name": "${deployment.metadata.name}"},
+					},
+				},
+			},
+		},
+	}}
+}
+
+func redisPart() Part {
+	return Part{Name: "redis", Prefix: "cache", RGDMap: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"schema": map[string]interface{}{
+				"spec": map[string]interface{}{"port": "integer | default=6379"},
+			},
+			"resources": []interface{}{
+				map[string]interface{}{
+					"id": "service",
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{"port": "${schema.spec.port}"},
+					},
+				},
+			},
+		},
+	}}
+}
+
+func resourceByID(t *testing.T, rgd map[string]interface{}, id string) map[string]interface{} {
+	t.Helper()
+
+	for _, r := range rgd["spec"].(map[string]interface{})["resources"].([]interface{}) {
+		if rm := r.(map[string]interface{}); rm["id"] == id {
+			return rm
+		}
+	}
+
+	t.Fatalf("resource %q not found", id)
+
+	return nil
+}
+
+func TestMerge(t *testing.T) {
+	app := appPart()
+
+	rgd, err := Merge(MergeConfig{Name: "webstack"}, []Part{app, redisPart()})
+	require.NoError(t, err)
+
+	assert.Equal(t, "ResourceGraphDefinition", rgd["kind"])
+	assert.Equal(t, "app,redis", rgd["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})["chart2kro.dev/composition"])
+
+	schema := rgd["spec"].(map[string]interface{})["schema"].(map[string]interface{})
+	assert.Equal(t, "webstack.kro.run/v1alpha1", schema["apiVersion"])
+	assert.Equal(t, "Webstack", schema["kind"])
+	assert.Equal(t, "integer | default=1", schema["spec"].(map[string]interface{})["app"].(map[string]interface{})["replicaCount"])
+	assert.Equal(t, "integer | default=6379", schema["spec"].(map[string]interface{})["redis"].(map[string]interface{})["port"])
+	assert.Equal(t, "${app-deployment.status.readyReplicas}",
+		schema["status"].(map[string]interface{})["app"].(map[string]interface{})["deploymentReadyReplicas"])
+
+	deploy := resourceByID(t, rgd, "app-deployment")
+	assert.Equal(t, "${schema.spec.app.replicaCount}", deploy["template"].(map[string]interface{})["spec"].(map[string]interface{})["replicas"])
+	assert.Equal(t, []interface{}{"${self.status.readyReplicas == self.status.replicas}"}, deploy["readyWhen"])
+
+	svc := resourceByID(t, rgd, "app-service")
+	assert.Equal(t, []interface{}{"app-deployment"}, svc["dependsOn"])
+	assert.Equal(t, []interface{}{"${schema.spec.app.replicaCount > 0}"}, svc["includeWhen"])
+	assert.Equal(t, "${app-deployment.metadata.name}", svc["template"].(map[string]interface{})["metadata"].(map[string]interface{})["name"])
+
+	cache := resourceByID(t, rgd, "cache-service")
+	assert.Equal(t, "${schema.spec.redis.port}", cache["template"].(map[string]interface{})["spec"].(map[string]interface{})["port"])
+
+	// The input parts are not modified.
+	assert.Equal(t, "deployment", app.RGDMap["spec"].(map[string]interface{})["resources"].([]interface{})[0].(map[string]interface{})["id"])
+}
+
+func TestMerge_Wiring(t *testing.T) {
+	rgd, err := Merge(MergeConfig{
+		Name: "webstack",
+		Wiring: []Wire{{
+			Chart: "app",
+			Value: "redis.host",
+			From:  &WireSource{Chart: "redis", Resource: "service"},
+		}},
+	}, []Part{appPart(), redisPart()})
+	require.NoError(t, err)
+
+	env := resourceByID(t, rgd, "app-deployment")["template"].(map[string]interface{})["spec"].(map[string]interface{})["env"].([]interface{})
+	assert.Equal(t, "redis://${cache-service.metadata.name}:6379", env[0].(map[string]interface{})["value"])
+	assert.Equal(t, "${cache-service.metadata.name}", env[1].(map[string]interface{})["value"])
+
+	appSpec := rgd["spec"].(map[string]interface{})["schema"].(map[string]interface{})["spec"].(map[string]interface{})["app"].(map[string]interface{})
+	assert.NotContains(t, appSpec, "redis")
+	assert.Contains(t, appSpec, "replicaCount")
+}
+
+func TestMerge_WiringExpression(t *testing.T) {
+	rgd, err := Merge(MergeConfig{
+		Name:   "webstack",
+		Wiring: []Wire{{Chart: "app", Value: "replicaCount", Expression: "${schema.spec.redis.port > 0 ? 2 : 1}"}},
+	}, []Part{appPart(), redisPart()})
+	require.NoError(t, err)
+
+	deploy := resourceByID(t, rgd, "app-deployment")
+	assert.Equal(t, "${schema.spec.redis.port > 0 ? 2 : 1}", deploy["template"].(map[string]interface{})["spec"].(map[string]interface{})["replicas"])
+
+	svc := resourceByID(t, rgd, "app-service")
+	assert.Equal(t, []interface{}{"${(schema.spec.redis.port > 0 ? 2 : 1) > 0}"}, svc["includeWhen"])
+}
+
+func TestMerge_StringLiterals(t *testing.T) {
+	app := appPart()
+	svc := app.RGDMap["spec"].(map[string]interface{})["resources"].([]interface{})[1].(map[string]interface{})
+	svc["template"].(map[string]interface{})["metadata"] = map[string]interface{}{
+		"name":   `${deployment.metadata.name + "-deployment.status"}`,
+		"labels": map[string]interface{}{"tier": `${schema.spec.replicaCount > 1 ? 'schema.spec.ha' : "deployment.single \" x"}`},
+	}
+
+	rgd, err := Merge(MergeConfig{
+		Name:   "webstack",
+		Wiring: []Wire{{Chart: "app", Value: "replicaCount", Expression: "${schema.spec.redis.port}"}},
+	}, []Part{app, redisPart()})
+	require.NoError(t, err)
+
+	metadata := resourceByID(t, rgd, "app-service")["template"].(map[string]interface{})["metadata"].(map[string]interface{})
+	assert.Equal(t, `${app-deployment.metadata.name + "-deployment.status"}`, metadata["name"])
+	assert.Equal(t, `${schema.spec.redis.port > 1 ? 'schema.spec.ha' : "deployment.single \" x"}`,
+		metadata["labels"].(map[string]interface{})["tier"])
+}
+
+func TestMerge_Braces(t *testing.T) {
+	app := appPart()
+	svc := app.RGDMap["spec"].(map[string]interface{})["resources"].([]interface{})[1].(map[string]interface{})
+	svc["template"].(map[string]interface{})["metadata"] = map[string]interface{}{
+		"name":   `${deployment.metadata.name + "}"}-svc`,
+		"labels": map[string]interface{}{"tier": `${deployment.spec == {} ? schema.spec.replicaCount : 1}`},
+	}
+
+	rgd, err := Merge(MergeConfig{
+		Name:   "webstack",
+		Wiring: []Wire{{Chart: "app", Value: "replicaCount", Expression: "${schema.spec.redis.port}"}},
+	}, []Part{app, redisPart()})
+	require.NoError(t, err)
+
+	metadata := resourceByID(t, rgd, "app-service")["template"].(map[string]interface{})["metadata"].(map[string]interface{})
+	assert.Equal(t, `${app-deployment.metadata.name + "}"}-svc`, metadata["name"])
+	assert.Equal(t, `${app-deployment.spec == {} ? schema.spec.redis.port : 1}`,
+		metadata["labels"].(map[string]interface{})["tier"])
+}
+
+func TestMerge_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		wire    Wire
+		parts   []Part
+		wantErr string
+	}{
+		{
+			name:    "unknown resource",
+			wire:    Wire{Chart: "app", Value: "redis.host", From: &WireSource{Chart: "redis", Resource: "deployment"}},
+			wantErr: `chart "redis" has no resource "deployment"`,
+		},
+		{
+			name:    "unknown value",
+			wire:    Wire{Chart: "app", Value: "redis.port", Expression: "${1}"},
+			wantErr: "not a schema field",
+		},
+		{
+			name:    "object value",
+			wire:    Wire{Chart: "app", Value: "redis", Expression: "${1}"},
+			wantErr: "not a schema field",
+		},
+		{
+			name:    "unreferenced value",
+			wire:    Wire{Chart: "redis", Value: "port", Expression: "${1}"},
+			parts:   []Part{appPart(), {Name: "redis", Prefix: "cache", RGDMap: map[string]interface{}{"spec": map[string]interface{}{"schema": map[string]interface{}{"spec": map[string]interface{}{"port": "integer"}}}}}},
+			wantErr: "not referenced",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := tt.parts
+			if parts == nil {
+				parts = []Part{appPart(), redisPart()}
+			}
+
+			_, err := Merge(MergeConfig{Name: "webstack", Wiring: []Wire{tt.wire}}, parts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestMerge_IDCollision(t *testing.T) {
+	redis := redisPart()
+	redis.Prefix = "app"
+	redis.RGDMap["spec"].(map[string]interface{})["resources"].([]interface{})[0].(map[string]interface{})["id"] = "deployment"
+
+	_, err := Merge(MergeConfig{Name: "webstack"}, []Part{appPart(), redis})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "collides")
+}
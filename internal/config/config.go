@@ -13,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -26,6 +27,9 @@ const (
 	LogLevelError = "error"
 )
 
+// DefaultIndexTTL is the default time cached repository indexes are reused.
+const DefaultIndexTTL = 10 * time.Minute
+
 // Supported log formats.
 const (
 	LogFormatText = "text"
@@ -48,6 +52,16 @@ type Config struct {
 	// Quiet suppresses all log output below error level.
 	Quiet bool `mapstructure:"quiet" json:"quiet"`
 
+	// CacheDir is the chart cache directory.
+	// Empty means $XDG_CACHE_HOME/chart2kro.
+	CacheDir string `mapstructure:"cache-dir" json:"cacheDir,omitempty"`
+
+	// Offline refuses network access and serves charts from the cache.
+	Offline bool `mapstructure:"offline" json:"offline"`
+
+	// IndexTTL is how long cached repository indexes are reused.
+	IndexTTL time.Duration `mapstructure:"index-ttl" json:"indexTTL"`
+
 	// ConfigFile is the resolved path to the config file used.
 	// Set after Load() — not read from config itself.
 	ConfigFile string `mapstructure:"-" json:"-"`
@@ -60,6 +74,7 @@ func Default() *Config {
 		LogFormat: LogFormatText,
 		NoColor:   false,
 		Quiet:     false,
+		IndexTTL:  DefaultIndexTTL,
 	}
 }
 
@@ -79,6 +94,10 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid log format %q: must be one of text, json", c.LogFormat)
 	}
 
+	if c.IndexTTL < 0 {
+		return fmt.Errorf("invalid index TTL %s: must not be negative", c.IndexTTL)
+	}
+
 	return nil
 }
 
@@ -130,6 +149,9 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("log-format", LogFormatText)
 	v.SetDefault("no-color", false)
 	v.SetDefault("quiet", false)
+	v.SetDefault("cache-dir", "")
+	v.SetDefault("offline", false)
+	v.SetDefault("index-ttl", DefaultIndexTTL)
 }
 
 // configureEnv sets up environment variable support.
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
@@ -25,6 +26,9 @@ func newTestRootCmd() *cobra.Command {
 	pf.String("log-format", "text", "")
 	pf.Bool("no-color", false, "")
 	pf.BoolP("quiet", "q", false, "")
+	pf.String("cache-dir", "", "")
+	pf.Bool("offline", false, "")
+	pf.Duration("index-ttl", DefaultIndexTTL, "")
 
 	return cmd
 }
@@ -50,6 +54,8 @@ func TestDefault(t *testing.T) {
 	assert.Equal(t, LogFormatText, cfg.LogFormat)
 	assert.False(t, cfg.NoColor)
 	assert.False(t, cfg.Quiet)
+	assert.False(t, cfg.Offline)
+	assert.Equal(t, DefaultIndexTTL, cfg.IndexTTL)
 }
 
 // ---------------------------------------------------------------------------
@@ -131,6 +137,27 @@ func TestLoad_EnvBooleans(t *testing.T) {
 	assert.True(t, cfg.Quiet)
 }
 
+func TestLoad_CacheSettings(t *testing.T) {
+	t.Setenv("CHART2KRO_OFFLINE", "true")
+	t.Setenv("CHART2KRO_CACHE_DIR", "/tmp/chart2kro-cache")
+
+	p := writeTempConfig(t, "index-ttl: 1h\n")
+
+	cfg, err := Load(nil, p)
+	require.NoError(t, err)
+	assert.True(t, cfg.Offline)
+	assert.Equal(t, "/tmp/chart2kro-cache", cfg.CacheDir)
+	assert.Equal(t, time.Hour, cfg.IndexTTL)
+}
+
+func TestLoad_NegativeIndexTTL(t *testing.T) {
+	p := writeTempConfig(t, "index-ttl: -1m\n")
+
+	_, err := Load(nil, p)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid index TTL")
+}
+
 // ---------------------------------------------------------------------------
 // Load — config file
 // ---------------------------------------------------------------------------
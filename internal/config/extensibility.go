@@ -1,9 +1,13 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	sigsyaml "sigs.k8s.io/yaml"
 )
@@ -19,6 +23,15 @@ type TransformConfig struct {
 
 	// ResourceIDOverrides override assigned resource IDs.
 	ResourceIDOverrides map[string]string `json:"resourceIdOverrides,omitempty"`
+
+	// DerivedValues declares Helm values computed from other schema fields.
+	DerivedValues *DerivedValuesConfig `json:"derivedValues,omitempty"`
+
+	// Status configures top-level status fields of the generated RGD.
+	Status *StatusConfig `json:"status,omitempty"`
+
+	// Dependencies configures dependency detection.
+	Dependencies *DependenciesConfig `json:"dependencies,omitempty"`
 }
 
 // TransformerOverride defines a config-driven transformer match + overrides.
@@ -31,6 +44,51 @@ type TransformerOverride struct {
 
 	// StatusFields overrides status projections.
 	StatusFields []StatusFieldOverride `json:"statusFields,omitempty"`
+
+	// Plugin delegates the transformation of matching resources to an
+	// external plugin. It cannot be combined with ReadyWhen or StatusFields.
+	Plugin *PluginConfig `json:"plugin,omitempty"`
+}
+
+// PluginConfig references an external transformer plugin. Exactly one of
+// Exec and WASM must be set.
+type PluginConfig struct {
+	// Exec is the path or $PATH name of a plugin executable.
+	Exec string `json:"exec,omitempty"`
+
+	// WASM is the path of a WASI module (wasip1).
+	WASM string `json:"wasm,omitempty"`
+
+	// Args are passed to the plugin as command-line arguments.
+	Args []string `json:"args,omitempty"`
+
+	// Timeout limits a single plugin invocation (e.g., "10s").
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Validate checks the plugin config for correctness.
+func (p *PluginConfig) Validate() error {
+	if (p.Exec == "") == (p.WASM == "") {
+		return errors.New("exactly one of exec and wasm is required")
+	}
+
+	if p.Timeout != "" {
+		d, err := time.ParseDuration(p.Timeout)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("timeout %q is not a positive duration", p.Timeout)
+		}
+	}
+
+	return nil
+}
+
+// TimeoutDuration returns the plugin timeout, or def when none is set.
+func (p *PluginConfig) TimeoutDuration(def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(p.Timeout); err == nil && d > 0 {
+		return d
+	}
+
+	return def
 }
 
 // TransformerMatch identifies resources by Kind and optionally APIVersion.
@@ -60,14 +118,120 @@ type SchemaOverride struct {
 	Default string `json:"default,omitempty"`
 }
 
-// ParseTransformConfig parses the transformers, schemaOverrides, and
-// resourceIdOverrides sections from raw config file bytes.
+// DerivedValuesConfig declares high-level schema inputs and the Helm values
+// that are computed from them with CEL expressions.
+type DerivedValuesConfig struct {
+	// Inputs are additional top-level schema fields (e.g., "size") that
+	// derived value expressions can reference.
+	Inputs map[string]SchemaOverride `json:"inputs,omitempty"`
+
+	// Values maps dotted Helm value paths to their derivation.
+	Values map[string]DerivedValue `json:"values,omitempty"`
+}
+
+// DerivedValue defines how a single Helm value is computed.
+type DerivedValue struct {
+	// Expression is the CEL expression producing the value, e.g.
+	// schema.spec.size == "large" ? 3 : 1. The ${...} wrapper is optional.
+	Expression string `json:"expression"`
+
+	// Type is the expected result type (string, integer, number, boolean).
+	// When empty, the type is inferred from the expression.
+	Type string `json:"type,omitempty"`
+}
+
+// DependenciesConfig configures dependency detection.
+type DependenciesConfig struct {
+	// References are custom name reference paths on resource kinds,
+	// typically CRDs the built-in detectors do not know.
+	References []ReferencePathConfig `json:"references,omitempty"`
+
+	// CycleStrategy resolves dependency cycles instead of failing:
+	// "fail" (default), "drop-weakest" removes the weakest edge of each
+	// cycle, "literal" keeps the literal name of a name reference instead
+	// of a CEL reference.
+	CycleStrategy string `json:"cycleStrategy,omitempty"`
+}
+
+// ReferencePathConfig declares that a field of the matched kind names
+// another resource of the chart.
+type ReferencePathConfig struct {
+	// Match selects the referencing resources.
+	Match TransformerMatch `json:"match"`
+
+	// Path is the dot-separated path of the name field, e.g.
+	// "spec.secretRef.name". A "[]" suffix iterates a list, e.g.
+	// "spec.sources[].configMapName".
+	Path string `json:"path"`
+
+	// TargetKind is the kind of the referenced resource (e.g., "Secret").
+	TargetKind string `json:"targetKind"`
+}
+
+// Validate checks the dependencies config for correctness.
+func (c *DependenciesConfig) Validate() error {
+	for i, ref := range c.References {
+		if ref.Match.Kind == "" {
+			return fmt.Errorf("dependencies.references[%d]: match.kind is required", i)
+		}
+
+		if ref.TargetKind == "" {
+			return fmt.Errorf("dependencies.references[%d]: targetKind is required", i)
+		}
+
+		if !referencePathPattern.MatchString(ref.Path) || strings.HasSuffix(ref.Path, "[]") {
+			return fmt.Errorf("dependencies.references[%d]: path %q is invalid (must match %s)", i, ref.Path, referencePathPattern.String())
+		}
+	}
+
+	switch c.CycleStrategy {
+	case "", "fail", "drop-weakest", "literal":
+	default:
+		return fmt.Errorf("dependencies.cycleStrategy: unknown strategy %q (expected fail, drop-weakest, literal)", c.CycleStrategy)
+	}
+
+	return nil
+}
+
+// StatusConfig configures custom status fields and the aggregate readiness
+// summary of the generated RGD.
+type StatusConfig struct {
+	// Fields are status projections whose expressions may reference any
+	// resource ID, e.g. "${ingress.spec.rules[0].host}".
+	Fields []StatusFieldOverride `json:"fields,omitempty"`
+
+	// DisableDefaults drops the built-in per-resource status projections.
+	DisableDefaults bool `json:"disableDefaults,omitempty"`
+
+	// Readiness adds a boolean field that ANDs the readiness conditions of
+	// all (or the selected kinds of) resources.
+	Readiness *ReadinessConfig `json:"readiness,omitempty"`
+}
+
+// ReadinessConfig configures the aggregate readiness status field.
+type ReadinessConfig struct {
+	// Enabled turns the readiness summary on.
+	Enabled bool `json:"enabled"`
+
+	// Name is the status field name (default: "ready").
+	Name string `json:"name,omitempty"`
+
+	// Kinds restricts the summary to these resource kinds.
+	Kinds []string `json:"kinds,omitempty"`
+}
+
+// ParseTransformConfig parses the transformers, schemaOverrides,
+// resourceIdOverrides, derivedValues, status, and dependencies sections from
+// raw config file bytes.
 func ParseTransformConfig(data []byte) (*TransformConfig, error) {
 	// Parse the raw YAML to extract transform-related sections.
 	var raw struct {
 		Transformers        []TransformerOverride     `json:"transformers,omitempty"`
 		SchemaOverrides     map[string]SchemaOverride `json:"schemaOverrides,omitempty"`
 		ResourceIDOverrides map[string]string         `json:"resourceIdOverrides,omitempty"`
+		DerivedValues       *DerivedValuesConfig      `json:"derivedValues,omitempty"`
+		Status              *StatusConfig             `json:"status,omitempty"`
+		Dependencies        *DependenciesConfig       `json:"dependencies,omitempty"`
 	}
 
 	if err := sigsyaml.Unmarshal(data, &raw); err != nil {
@@ -78,6 +242,9 @@ func ParseTransformConfig(data []byte) (*TransformConfig, error) {
 		Transformers:        raw.Transformers,
 		SchemaOverrides:     raw.SchemaOverrides,
 		ResourceIDOverrides: raw.ResourceIDOverrides,
+		DerivedValues:       raw.DerivedValues,
+		Status:              raw.Status,
+		Dependencies:        raw.Dependencies,
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -91,27 +258,33 @@ func ParseTransformConfig(data []byte) (*TransformConfig, error) {
 // Must start with a letter and contain only letters, digits, and hyphens.
 var resourceIDPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]*$`)
 
+// referencePathPattern validates custom dependency reference paths.
+var referencePathPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+(\[\])?(\.[a-zA-Z0-9_-]+(\[\])?)*$`)
+
+// inputNamePattern validates derived input field names.
+var inputNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*$`)
+
 // Validate checks the transform config for correctness.
 func (c *TransformConfig) Validate() error {
 	for i, t := range c.Transformers {
 		if t.Match.Kind == "" {
 			return fmt.Errorf("transformer[%d]: match.kind is required", i)
 		}
-	}
 
-	validTypes := map[string]bool{
-		"string": true, "integer": true, "number": true, "boolean": true,
-	}
+		if t.Plugin != nil {
+			if len(t.ReadyWhen) > 0 || len(t.StatusFields) > 0 {
+				return fmt.Errorf("transformer[%d]: plugin cannot be combined with readyWhen or statusFields", i)
+			}
 
-	for field, override := range c.SchemaOverrides {
-		if override.Type != "" && !validTypes[override.Type] {
-			return fmt.Errorf("schemaOverrides[%s]: invalid type %q (must be string, integer, number, or boolean)", field, override.Type)
+			if err := t.Plugin.Validate(); err != nil {
+				return fmt.Errorf("transformer[%d]: plugin: %w", i, err)
+			}
 		}
+	}
 
-		if override.Default != "" && override.Type != "" {
-			if err := validateDefaultForType(override.Type, override.Default); err != nil {
-				return fmt.Errorf("schemaOverrides[%s]: %w", field, err)
-			}
+	for field, override := range c.SchemaOverrides {
+		if err := validateSchemaOverride(override); err != nil {
+			return fmt.Errorf("schemaOverrides[%s]: %w", field, err)
 		}
 	}
 
@@ -125,6 +298,111 @@ func (c *TransformConfig) Validate() error {
 		}
 	}
 
+	if c.DerivedValues != nil {
+		if err := c.DerivedValues.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Status != nil {
+		if err := c.Status.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Dependencies != nil {
+		if err := c.Dependencies.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// statusFieldPattern validates status field names.
+var statusFieldPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+// Validate checks the status config for correctness.
+func (c *StatusConfig) Validate() error {
+	seen := make(map[string]bool, len(c.Fields))
+
+	for i, f := range c.Fields {
+		if !statusFieldPattern.MatchString(f.Name) {
+			return fmt.Errorf("status.fields[%d]: name %q is invalid (must match %s)", i, f.Name, statusFieldPattern.String())
+		}
+
+		if seen[f.Name] {
+			return fmt.Errorf("status.fields[%d]: duplicate name %q", i, f.Name)
+		}
+
+		seen[f.Name] = true
+
+		if !strings.Contains(f.CELExpression, "${") {
+			return fmt.Errorf("status.fields[%d]: celExpression must use ${...} syntax", i)
+		}
+	}
+
+	if c.Readiness != nil && c.Readiness.Name != "" {
+		if !statusFieldPattern.MatchString(c.Readiness.Name) {
+			return fmt.Errorf("status.readiness: name %q is invalid (must match %s)", c.Readiness.Name, statusFieldPattern.String())
+		}
+
+		if seen[c.Readiness.Name] {
+			return fmt.Errorf("status.readiness: name %q conflicts with status.fields", c.Readiness.Name)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the derived values config for correctness.
+func (c *DerivedValuesConfig) Validate() error {
+	for name, input := range c.Inputs {
+		if !inputNamePattern.MatchString(name) {
+			return fmt.Errorf("derivedValues.inputs[%s]: name is invalid (must match %s)", name, inputNamePattern.String())
+		}
+
+		if input.Type == "" {
+			return fmt.Errorf("derivedValues.inputs[%s]: type is required", name)
+		}
+
+		if err := validateSchemaOverride(input); err != nil {
+			return fmt.Errorf("derivedValues.inputs[%s]: %w", name, err)
+		}
+	}
+
+	for path, v := range c.Values {
+		if strings.TrimSpace(v.Expression) == "" {
+			return fmt.Errorf("derivedValues.values[%s]: expression is required", path)
+		}
+
+		if v.Type != "" && !validSchemaTypes[v.Type] {
+			return fmt.Errorf("derivedValues.values[%s]: invalid type %q (must be string, integer, number, or boolean)", path, v.Type)
+		}
+
+		if _, ok := c.Inputs[path]; ok {
+			return fmt.Errorf("derivedValues.values[%s]: path is also declared as an input", path)
+		}
+	}
+
+	return nil
+}
+
+// validSchemaTypes are the scalar SimpleSchema types accepted in overrides.
+var validSchemaTypes = map[string]bool{
+	"string": true, "integer": true, "number": true, "boolean": true,
+}
+
+// validateSchemaOverride checks the type and default of a schema override.
+func validateSchemaOverride(override SchemaOverride) error {
+	if override.Type != "" && !validSchemaTypes[override.Type] {
+		return fmt.Errorf("invalid type %q (must be string, integer, number, or boolean)", override.Type)
+	}
+
+	if override.Default != "" && override.Type != "" {
+		return validateDefaultForType(override.Type, override.Default)
+	}
+
 	return nil
 }
 
@@ -151,9 +429,32 @@ func validateDefaultForType(typ, defaultVal string) error {
 	return nil
 }
 
+// ResolvePaths makes relative plugin paths relative to baseDir, typically
+// the directory of the config file. Bare executable names are left for a
+// $PATH lookup.
+func (c *TransformConfig) ResolvePaths(baseDir string) {
+	for i := range c.Transformers {
+		p := c.Transformers[i].Plugin
+		if p == nil {
+			continue
+		}
+
+		if p.Exec != "" && strings.ContainsRune(filepath.ToSlash(p.Exec), '/') && !filepath.IsAbs(p.Exec) {
+			p.Exec = filepath.Join(baseDir, p.Exec)
+		}
+
+		if p.WASM != "" && !filepath.IsAbs(p.WASM) {
+			p.WASM = filepath.Join(baseDir, p.WASM)
+		}
+	}
+}
+
 // IsEmpty returns true if the config has no overrides.
 func (c *TransformConfig) IsEmpty() bool {
 	return len(c.Transformers) == 0 &&
 		len(c.SchemaOverrides) == 0 &&
-		len(c.ResourceIDOverrides) == 0
+		len(c.ResourceIDOverrides) == 0 &&
+		(c.DerivedValues == nil || len(c.DerivedValues.Values) == 0 && len(c.DerivedValues.Inputs) == 0) &&
+		c.Status == nil &&
+		(c.Dependencies == nil || len(c.Dependencies.References) == 0 && c.Dependencies.CycleStrategy == "")
 }
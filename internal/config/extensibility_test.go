@@ -1,7 +1,9 @@
 package config
 
 import (
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -85,6 +87,64 @@ transformers:
 	assert.Contains(t, err.Error(), "match.kind is required")
 }
 
+func TestParseTransformConfig_Plugin(t *testing.T) {
+	data := []byte(`
+transformers:
+  - match:
+      kind: Certificate
+      apiVersion: cert-manager.io/v1
+    plugin:
+      wasm: plugins/certificate.wasm
+      args: ["--strict"]
+      timeout: 5s
+  - match:
+      kind: ScaledObject
+    plugin:
+      exec: ./plugins/keda
+  - match:
+      kind: Rollout
+    plugin:
+      exec: rollout-plugin
+`)
+
+	cfg, err := ParseTransformConfig(data)
+	require.NoError(t, err)
+	require.Len(t, cfg.Transformers, 3)
+
+	p := cfg.Transformers[0].Plugin
+	require.NotNil(t, p)
+	assert.Equal(t, []string{"--strict"}, p.Args)
+	assert.Equal(t, 5*time.Second, p.TimeoutDuration(time.Minute))
+	assert.Equal(t, time.Minute, cfg.Transformers[1].Plugin.TimeoutDuration(time.Minute))
+
+	cfg.ResolvePaths("/etc/chart2kro")
+	assert.Equal(t, filepath.Join("/etc/chart2kro", "plugins/certificate.wasm"), cfg.Transformers[0].Plugin.WASM)
+	assert.Equal(t, filepath.Join("/etc/chart2kro", "plugins/keda"), cfg.Transformers[1].Plugin.Exec)
+	assert.Equal(t, "rollout-plugin", cfg.Transformers[2].Plugin.Exec, "bare names are looked up on $PATH")
+}
+
+func TestParseTransformConfig_Plugin_ValidationErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		plugin string
+		errMsg string
+	}{
+		{"neither", "plugin: {}", "exactly one of exec and wasm"},
+		{"both", "plugin: {exec: a, wasm: b.wasm}", "exactly one of exec and wasm"},
+		{"invalid timeout", "plugin: {exec: a, timeout: soon}", "not a positive duration"},
+		{"with readyWhen", "plugin: {exec: a}\n    readyWhen: [\"${true}\"]", "cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []byte("transformers:\n  - match: {kind: Certificate}\n    " + tt.plugin + "\n")
+
+			_, err := ParseTransformConfig(data)
+			require.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
 func TestParseTransformConfig_ValidationError_InvalidType(t *testing.T) {
 	data := []byte(`
 schemaOverrides:
@@ -217,3 +277,286 @@ func TestValidate_SchemaDefault_ValidValues(t *testing.T) {
 		})
 	}
 }
+
+// ---------------------------------------------------------------------------
+// DerivedValues
+// ---------------------------------------------------------------------------
+
+func TestParseTransformConfig_DerivedValues(t *testing.T) {
+	data := []byte(`
+derivedValues:
+  inputs:
+    size:
+      type: string
+      default: "\"small\""
+  values:
+    replicaCount:
+      expression: 'schema.spec.size == "large" ? 3 : 1'
+      type: integer
+    resources.limits.memory:
+      expression: '${schema.spec.size == "large" ? "1Gi" : "256Mi"}'
+`)
+
+	cfg, err := ParseTransformConfig(data)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.DerivedValues)
+	assert.False(t, cfg.IsEmpty())
+	assert.Equal(t, "string", cfg.DerivedValues.Inputs["size"].Type)
+	require.Len(t, cfg.DerivedValues.Values, 2)
+	assert.Equal(t, "integer", cfg.DerivedValues.Values["replicaCount"].Type)
+	assert.Empty(t, cfg.DerivedValues.Values["resources.limits.memory"].Type)
+}
+
+func TestParseTransformConfig_DerivedValues_ValidationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name: "missing expression",
+			yaml: `
+derivedValues:
+  values:
+    replicaCount:
+      type: integer
+`,
+			wantErr: "expression is required",
+		},
+		{
+			name: "invalid value type",
+			yaml: `
+derivedValues:
+  values:
+    replicaCount:
+      expression: "1"
+      type: map
+`,
+			wantErr: "invalid type",
+		},
+		{
+			name: "input without type",
+			yaml: `
+derivedValues:
+  inputs:
+    size: {}
+`,
+			wantErr: "type is required",
+		},
+		{
+			name: "invalid input name",
+			yaml: `
+derivedValues:
+  inputs:
+    sizing.size:
+      type: string
+`,
+			wantErr: "name is invalid",
+		},
+		{
+			name: "input default mismatch",
+			yaml: `
+derivedValues:
+  inputs:
+    replicas:
+      type: integer
+      default: "many"
+`,
+			wantErr: "not a valid integer",
+		},
+		{
+			name: "path declared as input",
+			yaml: `
+derivedValues:
+  inputs:
+    size:
+      type: string
+  values:
+    size:
+      expression: '"small"'
+`,
+			wantErr: "also declared as an input",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTransformConfig([]byte(tt.yaml))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Status
+// ---------------------------------------------------------------------------
+
+func TestParseTransformConfig_Status(t *testing.T) {
+	data := []byte(`
+status:
+  disableDefaults: true
+  fields:
+    - name: endpoint
+      celExpression: "https://${ingress.spec.rules[0].host}"
+  readiness:
+    enabled: true
+    name: allReady
+    kinds: [Deployment, StatefulSet]
+`)
+
+	cfg, err := ParseTransformConfig(data)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Status)
+	assert.False(t, cfg.IsEmpty())
+	assert.True(t, cfg.Status.DisableDefaults)
+	require.Len(t, cfg.Status.Fields, 1)
+	assert.Equal(t, "endpoint", cfg.Status.Fields[0].Name)
+	require.NotNil(t, cfg.Status.Readiness)
+	assert.True(t, cfg.Status.Readiness.Enabled)
+	assert.Equal(t, "allReady", cfg.Status.Readiness.Name)
+	assert.Equal(t, []string{"Deployment", "StatefulSet"}, cfg.Status.Readiness.Kinds)
+}
+
+func TestParseTransformConfig_Status_ValidationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name: "invalid name",
+			yaml: `
+status:
+  fields:
+    - name: "my-field"
+      celExpression: "${a.b}"
+`,
+			wantErr: "is invalid",
+		},
+		{
+			name: "duplicate name",
+			yaml: `
+status:
+  fields:
+    - name: a
+      celExpression: "${a.b}"
+    - name: a
+      celExpression: "${a.c}"
+`,
+			wantErr: "duplicate name",
+		},
+		{
+			name: "missing expression syntax",
+			yaml: `
+status:
+  fields:
+    - name: a
+      celExpression: "a.b"
+`,
+			wantErr: "${...} syntax",
+		},
+		{
+			name: "readiness conflicts",
+			yaml: `
+status:
+  fields:
+    - name: ready
+      celExpression: "${a.b}"
+  readiness:
+    enabled: true
+    name: ready
+`,
+			wantErr: "conflicts with status.fields",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTransformConfig([]byte(tt.yaml))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestParseTransformConfig_Dependencies(t *testing.T) {
+	data := []byte(`
+dependencies:
+  references:
+    - match:
+        kind: Certificate
+        apiVersion: cert-manager.io/v1
+      path: spec.secretRef.name
+      targetKind: Secret
+    - match:
+        kind: Gateway
+      path: spec.listeners[].tls.certificateRefs[].name
+      targetKind: Secret
+`)
+
+	cfg, err := ParseTransformConfig(data)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Dependencies)
+	assert.False(t, cfg.IsEmpty())
+	require.Len(t, cfg.Dependencies.References, 2)
+	assert.Equal(t, "Certificate", cfg.Dependencies.References[0].Match.Kind)
+	assert.Equal(t, "cert-manager.io/v1", cfg.Dependencies.References[0].Match.APIVersion)
+	assert.Equal(t, "spec.secretRef.name", cfg.Dependencies.References[0].Path)
+	assert.Equal(t, "Secret", cfg.Dependencies.References[0].TargetKind)
+}
+
+func TestParseTransformConfig_CycleStrategy(t *testing.T) {
+	cfg, err := ParseTransformConfig([]byte("dependencies:\n  cycleStrategy: drop-weakest\n"))
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Dependencies)
+	assert.Equal(t, "drop-weakest", cfg.Dependencies.CycleStrategy)
+	assert.False(t, cfg.IsEmpty())
+}
+
+func TestParseTransformConfig_Dependencies_ValidationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name:    "missing kind",
+			yaml:    "dependencies:\n  references:\n    - path: spec.name\n      targetKind: Secret\n",
+			wantErr: "match.kind is required",
+		},
+		{
+			name:    "missing target kind",
+			yaml:    "dependencies:\n  references:\n    - match: {kind: Widget}\n      path: spec.name\n",
+			wantErr: "targetKind is required",
+		},
+		{
+			name:    "empty path",
+			yaml:    "dependencies:\n  references:\n    - match: {kind: Widget}\n      targetKind: Secret\n",
+			wantErr: "path \"\" is invalid",
+		},
+		{
+			name:    "list as name",
+			yaml:    "dependencies:\n  references:\n    - match: {kind: Widget}\n      path: spec.names[]\n      targetKind: Secret\n",
+			wantErr: "is invalid",
+		},
+		{
+			name:    "unknown cycle strategy",
+			yaml:    "dependencies:\n  cycleStrategy: ignore\n",
+			wantErr: "unknown strategy \"ignore\"",
+		},
+		{
+			name:    "malformed path",
+			yaml:    "dependencies:\n  references:\n    - match: {kind: Widget}\n      path: spec..name\n      targetKind: Secret\n",
+			wantErr: "is invalid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTransformConfig([]byte(tt.yaml))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
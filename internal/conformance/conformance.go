@@ -0,0 +1,346 @@
+// Package conformance extracts Helm test hooks into smoke-test artifacts
+// that are parameterised by the schema of the generated RGD: a conformance
+// ResourceGraphDefinition, or plain manifests resolved against an instance.
+package conformance
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/hupe1980/chart2kro/internal/k8s"
+	"github.com/hupe1980/chart2kro/internal/kro"
+	"github.com/hupe1980/chart2kro/internal/maputil"
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+// NameSuffix is appended to the application RGD name and schema kind to
+// name the conformance RGD (e.g. "nginx-conformance", "NginxConformance").
+const NameSuffix = "conformance"
+
+// Suite is the set of test resources extracted from Helm test hooks.
+type Suite struct {
+	// Tests are the test resources with schema references applied.
+	Tests []*k8s.Resource
+
+	// ResourceIDs maps each test resource to its ID.
+	ResourceIDs map[*k8s.Resource]string
+
+	// FieldMappings are the parameter mappings applied to Tests.
+	FieldMappings []transform.FieldMapping
+
+	// SchemaFields are the schema fields of the application RGD.
+	SchemaFields []*transform.SchemaField
+}
+
+// NewSuite builds a suite from the test hook resources. Mappings whose values
+// path is not part of schemaFields keep the rendered literal, so that the
+// tests only reference fields of the application schema. Helm hook
+// annotations are removed from the templates.
+func NewSuite(
+	tests []*k8s.Resource,
+	resourceIDs map[*k8s.Resource]string,
+	mappings []transform.FieldMapping,
+	schemaFields []*transform.SchemaField,
+) *Suite {
+	paths := make(map[string]bool)
+	collectPaths(schemaFields, paths)
+
+	var applied []transform.FieldMapping
+
+	for _, m := range mappings {
+		if paths[m.ValuesPath] && sentinelPathsIn(m, paths) {
+			applied = append(applied, m)
+		}
+	}
+
+	transform.ApplyFieldMappings(tests, resourceIDs, applied)
+
+	for _, r := range tests {
+		transform.StripHookAnnotations(r)
+	}
+
+	return &Suite{
+		Tests:         tests,
+		ResourceIDs:   resourceIDs,
+		FieldMappings: applied,
+		SchemaFields:  schemaFields,
+	}
+}
+
+// collectPaths adds the values paths of all leaf fields to paths.
+func collectPaths(fields []*transform.SchemaField, paths map[string]bool) {
+	for _, f := range fields {
+		if f.IsObject() {
+			collectPaths(f.Children, paths)
+			continue
+		}
+
+		paths[f.Path] = true
+	}
+}
+
+// sentinelPathsIn reports whether every values path interpolated into a
+// substring match is in paths.
+func sentinelPathsIn(m transform.FieldMapping, paths map[string]bool) bool {
+	if m.MatchType == transform.MatchExact {
+		return true
+	}
+
+	for _, ref := range schemaRefPattern.FindAllStringSubmatch(transform.BuildInterpolatedCELFromSentinel(m.SentinelRendered), -1) {
+		if ref[1] == "spec" && !paths[ref[2]] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RGD generates the conformance ResourceGraphDefinition. cfg carries the
+// application RGD settings: the suite uses the same schema spec under the
+// kind <SchemaKind>Conformance. Test Pods are ready once they succeed and
+// test Jobs once they complete.
+func (s *Suite) RGD(cfg kro.GeneratorConfig) (*kro.RGD, error) {
+	if len(s.Tests) == 0 {
+		return nil, fmt.Errorf("chart has no test hooks")
+	}
+
+	kind := cfg.SchemaKind
+	if kind == "" {
+		kind = transform.ToPascalCase(cfg.Name)
+	}
+
+	cfg.SchemaKind = kind + transform.ToPascalCase(NameSuffix)
+	cfg.Name = cfg.Name + "-" + NameSuffix
+	cfg.SchemaFields = s.SchemaFields
+	cfg.StatusFields = nil
+	cfg.ResourceReadyWhen = make(map[string][]string, len(s.Tests))
+
+	for _, r := range s.Tests {
+		id := s.ResourceIDs[r]
+
+		if cond := transform.HookReadyWhen(r); cond != "" {
+			cfg.ResourceReadyWhen[id] = []string{cond}
+		}
+
+		if k8s.IsPod(r.GVK) {
+			cfg.StatusFields = append(cfg.StatusFields, transform.StatusField{
+				Name:          id + "Phase",
+				CELExpression: transform.ResourceRef(id, "status", "phase"),
+			})
+		} else {
+			cfg.StatusFields = append(cfg.StatusFields, transform.DefaultStatusProjections(r.GVK, id)...)
+		}
+	}
+
+	sort.Slice(cfg.StatusFields, func(i, j int) bool { return cfg.StatusFields[i].Name < cfg.StatusFields[j].Name })
+
+	rgd, err := kro.NewGenerator(cfg).Generate(transform.BuildDependencyGraph(s.ResourceIDs))
+	if err != nil {
+		return nil, fmt.Errorf("generating conformance RGD: %w", err)
+	}
+
+	return rgd, nil
+}
+
+// schemaRefPattern matches the schema references resolved by Manifests.
+var schemaRefPattern = regexp.MustCompile(`\$\{schema\.(spec|metadata)\.([A-Za-z0-9_.-]+)\}`)
+
+// exprPattern matches any ${...} expression.
+var exprPattern = regexp.MustCompile(`\$\{[^}]*\}`)
+
+// Manifests resolves the schema references of the tests against instance,
+// an instance of the application RGD, and returns plain manifests in test
+// order. Spec values missing from the instance fall back to the schema
+// defaults. Tests are placed in the instance namespace, if any.
+func (s *Suite) Manifests(instance map[string]interface{}) ([]map[string]interface{}, error) {
+	if len(s.Tests) == 0 {
+		return nil, fmt.Errorf("chart has no test hooks")
+	}
+
+	r := &resolver{suite: s, instance: instance}
+
+	tests := make([]*k8s.Resource, len(s.Tests))
+	copy(tests, s.Tests)
+	sort.SliceStable(tests, func(i, j int) bool { return s.ResourceIDs[tests[i]] < s.ResourceIDs[tests[j]] })
+
+	namespace, _, _ := unstructured.NestedString(instance, "metadata", "namespace")
+
+	manifests := make([]map[string]interface{}, 0, len(tests))
+
+	for _, t := range tests {
+		id := s.ResourceIDs[t]
+
+		resolved, err := r.resolveValue(maputil.DeepCopyMap(t.Object.Object))
+		if err != nil {
+			return nil, fmt.Errorf("resolving test %s: %w", id, err)
+		}
+
+		obj, _ := resolved.(map[string]interface{})
+
+		if namespace != "" {
+			(&unstructured.Unstructured{Object: obj}).SetNamespace(namespace)
+		}
+
+		manifests = append(manifests, obj)
+	}
+
+	return manifests, nil
+}
+
+// resolver substitutes schema references with instance values.
+type resolver struct {
+	suite    *Suite
+	instance map[string]interface{}
+}
+
+// resolveValue resolves all expressions in v, recursively.
+func (r *resolver) resolveValue(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			resolved, err := r.resolveValue(child)
+			if err != nil {
+				return nil, err
+			}
+
+			val[k] = resolved
+		}
+
+		return val, nil
+	case []interface{}:
+		for i, child := range val {
+			resolved, err := r.resolveValue(child)
+			if err != nil {
+				return nil, err
+			}
+
+			val[i] = resolved
+		}
+
+		return val, nil
+	case string:
+		return r.resolveString(val)
+	default:
+		return v, nil
+	}
+}
+
+// resolveString resolves the expressions in s. A string that is a single
+// expression keeps the type of the resolved value; interpolated expressions
+// are formatted into the string.
+func (r *resolver) resolveString(s string) (interface{}, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var resolveErr error
+
+	if m := schemaRefPattern.FindStringSubmatch(s); m != nil && m[0] == s {
+		return r.lookup(m[1], m[2])
+	}
+
+	out := exprPattern.ReplaceAllStringFunc(s, func(expr string) string {
+		m := schemaRefPattern.FindStringSubmatch(expr)
+		if m == nil || m[0] != expr {
+			if resolveErr == nil {
+				resolveErr = fmt.Errorf("unsupported expression %q: only schema references can be resolved", expr)
+			}
+
+			return expr
+		}
+
+		v, err := r.lookup(m[1], m[2])
+		if err != nil {
+			if resolveErr == nil {
+				resolveErr = err
+			}
+
+			return expr
+		}
+
+		return fmt.Sprint(v)
+	})
+
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+
+	return out, nil
+}
+
+// lookup returns the instance value of schema.<section>.<path>, falling
+// back to the schema default for spec fields.
+func (r *resolver) lookup(section, path string) (interface{}, error) {
+	if v, ok := nestedValue(r.instance, section+"."+path); ok {
+		return v, nil
+	}
+
+	ref := fmt.Sprintf("schema.%s.%s", section, path)
+
+	if section != "spec" {
+		return nil, fmt.Errorf("instance has no value for %s", ref)
+	}
+
+	field := findField(r.suite.SchemaFields, path)
+	if field == nil {
+		return nil, fmt.Errorf("%s is not part of the schema", ref)
+	}
+
+	// Flat schemas store the field under its camelCase name.
+	if v, ok := nestedValue(r.instance, "spec."+field.Name); ok && !strings.Contains(field.Name, ".") {
+		return v, nil
+	}
+
+	if field.Default == "" {
+		return nil, fmt.Errorf("instance has no value for %s and the schema has no default", ref)
+	}
+
+	var def interface{}
+	if err := sigsyaml.Unmarshal([]byte(field.Default), &def); err != nil {
+		return nil, fmt.Errorf("parsing default of %s: %w", ref, err)
+	}
+
+	return def, nil
+}
+
+// nestedValue returns the value at the dot-separated path in m.
+func nestedValue(m map[string]interface{}, path string) (interface{}, bool) {
+	var cur interface{} = m
+
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		if cur, ok = obj[key]; !ok {
+			return nil, false
+		}
+	}
+
+	return cur, true
+}
+
+// findField returns the leaf schema field with the given values path.
+func findField(fields []*transform.SchemaField, path string) *transform.SchemaField {
+	for _, f := range fields {
+		if f.IsObject() {
+			if found := findField(f.Children, path); found != nil {
+				return found
+			}
+
+			continue
+		}
+
+		if f.Path == path {
+			return f
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package conformance_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/hupe1980/chart2kro/internal/conformance"
+	"github.com/hupe1980/chart2kro/internal/k8s"
+	"github.com/hupe1980/chart2kro/internal/kro"
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+// makeTestPod creates a Helm test Pod that connects to host:port.
+func makeTestPod(name, image, args string) *k8s.Resource {
+	obj := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"annotations": map[string]interface{}{"helm.sh/hook": "test"},
+		},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "test", "image": image, "args": []interface{}{args}},
+			},
+			"restartPolicy": "Never",
+		},
+	}
+
+	return &k8s.Resource{
+		GVK:         schema.FromAPIVersionAndKind("v1", "Pod"),
+		Name:        name,
+		Annotations: map[string]string{"helm.sh/hook": "test"},
+		Object:      &unstructured.Unstructured{Object: obj},
+	}
+}
+
+// newSuite builds a suite for a test Pod whose image and port are mapped to
+// the schema; service.port is only part of the schema when withPort is set.
+func newSuite(withPort bool) *conformance.Suite {
+	pod := makeTestPod("release-test", "busybox", "wget release-web:80")
+	ids := map[*k8s.Resource]string{pod: "testPod"}
+
+	mappings := []transform.FieldMapping{
+		{ValuesPath: "image", ResourceID: "testPod", FieldPath: "spec.containers[0].image", MatchType: transform.MatchExact},
+		{
+			ValuesPath:       "service.port",
+			ResourceID:       "testPod",
+			FieldPath:        "spec.containers[0].args[0]",
+			MatchType:        transform.MatchSubstring,
+			SentinelRendered: "wget release-web:" + transform.SentinelForString("service.port"),
+		},
+	}
+
+	fields := []*transform.SchemaField{
+		{Name: "image", Path: "image", Type: "string", Default: `"busybox"`},
+	}
+
+	if withPort {
+		fields = append(fields, &transform.SchemaField{
+			Name: "service", Path: "service", Type: "object",
+			Children: []*transform.SchemaField{
+				{Name: "port", Path: "service.port", Type: "integer", Default: "80"},
+			},
+		})
+	}
+
+	return conformance.NewSuite([]*k8s.Resource{pod}, ids, mappings, fields)
+}
+
+func containerField(t *testing.T, obj map[string]interface{}, field string) interface{} {
+	t.Helper()
+
+	containers, found, err := unstructured.NestedSlice(obj, "spec", "containers")
+	require.NoError(t, err)
+	require.True(t, found)
+
+	return containers[0].(map[string]interface{})[field]
+}
+
+func TestNewSuite(t *testing.T) {
+	suite := newSuite(true)
+
+	require.Len(t, suite.Tests, 1)
+	assert.Len(t, suite.FieldMappings, 2)
+
+	obj := suite.Tests[0].Object.Object
+	assert.Equal(t, "${schema.spec.image}", containerField(t, obj, "image"))
+	assert.Equal(t, []interface{}{"wget release-web:${schema.spec.service.port}"}, containerField(t, obj, "args"))
+	assert.Empty(t, suite.Tests[0].Object.GetAnnotations())
+}
+
+func TestNewSuite_KeepsLiteralsOutsideSchema(t *testing.T) {
+	suite := newSuite(false)
+
+	require.Len(t, suite.FieldMappings, 1)
+
+	obj := suite.Tests[0].Object.Object
+	assert.Equal(t, "${schema.spec.image}", containerField(t, obj, "image"))
+	assert.Equal(t, []interface{}{"wget release-web:80"}, containerField(t, obj, "args"))
+}
+
+func TestSuite_RGD(t *testing.T) {
+	rgd, err := newSuite(true).RGD(kro.GeneratorConfig{Name: "web", ChartName: "web", SchemaKind: "WebApp"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "web-conformance", rgd.Metadata.Name)
+	require.NotNil(t, rgd.Spec.Schema)
+	assert.Equal(t, "WebAppConformance", rgd.Spec.Schema.Kind)
+	assert.Equal(t, "web-conformance.kro.run/v1alpha1", rgd.Spec.Schema.APIVersion)
+	assert.Equal(t, `string | default="busybox"`, rgd.Spec.Schema.Spec["image"])
+	assert.Equal(t, "${testPod.status.phase}", rgd.Spec.Schema.Status["testPodPhase"])
+
+	require.Len(t, rgd.Spec.Resources, 1)
+	assert.Equal(t, "testPod", rgd.Spec.Resources[0].ID)
+	assert.Equal(t, []string{`${self.status.phase == "Succeeded"}`}, rgd.Spec.Resources[0].ReadyWhen)
+}
+
+func TestSuite_RGD_NoTests(t *testing.T) {
+	suite := conformance.NewSuite(nil, map[*k8s.Resource]string{}, nil, nil)
+
+	_, err := suite.RGD(kro.GeneratorConfig{Name: "web"})
+	require.ErrorContains(t, err, "no test hooks")
+}
+
+func TestSuite_Manifests(t *testing.T) {
+	instance := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "shop", "namespace": "prod"},
+		"spec":     map[string]interface{}{"service": map[string]interface{}{"port": int64(8080)}},
+	}
+
+	manifests, err := newSuite(true).Manifests(instance)
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+
+	m := manifests[0]
+	assert.Equal(t, "busybox", containerField(t, m, "image"), "missing values use the schema default")
+	assert.Equal(t, []interface{}{"wget release-web:8080"}, containerField(t, m, "args"))
+
+	ns, _, _ := unstructured.NestedString(m, "metadata", "namespace")
+	assert.Equal(t, "prod", ns)
+
+	// The suite templates are left untouched.
+	assert.Equal(t, "${schema.spec.image}", containerField(t, newSuite(true).Tests[0].Object.Object, "image"))
+}
+
+func TestSuite_Manifests_UnsupportedExpression(t *testing.T) {
+	pod := makeTestPod("release-test", "busybox", "wget ${web.metadata.name}")
+	suite := conformance.NewSuite([]*k8s.Resource{pod}, map[*k8s.Resource]string{pod: "testPod"}, nil, nil)
+
+	_, err := suite.Manifests(map[string]interface{}{})
+	require.ErrorContains(t, err, "unsupported expression")
+}
@@ -0,0 +1,19 @@
+// Package controller reconciles ChartConversion resources.
+//
+// A ChartConversion points to a chart in an OCI registry or Helm repository,
+// with a semver version constraint, values, and a profile. The controller
+// converts the highest matching chart version into a
+// ResourceGraphDefinition, applies it, and checks for new chart versions at
+// the interval of the ChartConversion. RGDs with breaking schema or
+// resource changes are only applied when the ChartConversion allows them;
+// otherwise the deployed RGD is kept and the changes are reported in the
+// status. ChartConversions are handled as unstructured objects, so the
+// package needs no generated code.
+package controller
+
+import _ "embed"
+
+// CRD is the CustomResourceDefinition of ChartConversion.
+//
+//go:embed crd.yaml
+var CRD []byte
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// TestController_Envtest runs the controller against a local API server.
+// It needs the envtest binaries, e.g. KUBEBUILDER_ASSETS="$(setup-envtest
+// use -p path)".
+func TestController_Envtest(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS is not set")
+	}
+
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	require.NoError(t, sigsyaml.Unmarshal(CRD, crd))
+
+	env := &envtest.Environment{
+		CRDs:                  []*apiextensionsv1.CustomResourceDefinition{crd},
+		CRDDirectoryPaths:     []string{"testdata/crds"},
+		ErrorIfCRDPathMissing: true,
+	}
+
+	cfg, err := env.Start()
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = env.Stop() })
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:  runtime.NewScheme(),
+		Metrics: metricsserver.Options{BindAddress: "0"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, (&Reconciler{AllowLocal: true}).SetupWithManager(mgr, 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	go func() { _ = mgr.Start(ctx) }()
+
+	c, err := client.New(cfg, client.Options{})
+	require.NoError(t, err)
+
+	cr := New()
+	cr.SetName("web")
+	cr.Object["spec"] = map[string]interface{}{"chart": simpleChart}
+	require.NoError(t, c.Create(ctx, cr))
+
+	var status *Status
+
+	require.Eventually(t, func() bool {
+		got := New()
+		if err := c.Get(ctx, types.NamespacedName{Name: "web"}, got); err != nil {
+			return false
+		}
+
+		_, status, err = decode(got)
+
+		return err == nil && meta.IsStatusConditionTrue(status.Conditions, ConditionReady)
+	}, 30*time.Second, 250*time.Millisecond)
+
+	assert.Equal(t, "1.0.0", status.ChartVersion)
+	assert.Equal(t, metav1.ConditionTrue, meta.FindStatusCondition(status.Conditions, ConditionReady).Status)
+
+	rgd := newObject(rgdGVK)
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "web"}, rgd))
+	assert.Equal(t, "1.0.0", rgd.GetAnnotations()[chartVersionAnnotation])
+	require.Len(t, rgd.GetOwnerReferences(), 1)
+	assert.Equal(t, cr.GetUID(), rgd.GetOwnerReferences()[0].UID)
+}
+
+func TestCRD(t *testing.T) {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	require.NoError(t, sigsyaml.UnmarshalStrict(CRD, crd))
+
+	assert.Equal(t, Group, crd.Spec.Group)
+	assert.Equal(t, Kind, crd.Spec.Names.Kind)
+	assert.Equal(t, apiextensionsv1.ClusterScoped, crd.Spec.Scope)
+	require.Len(t, crd.Spec.Versions, 1)
+	assert.Equal(t, Version, crd.Spec.Versions[0].Name)
+	assert.NotNil(t, crd.Spec.Versions[0].Subresources.Status)
+}
@@ -0,0 +1,370 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/hupe1980/chart2kro/internal/plan"
+	"github.com/hupe1980/chart2kro/pkg/chart2kro"
+)
+
+// Labels and annotations of the managed RGDs.
+const (
+	managedByLabel         = "app.kubernetes.io/managed-by"
+	managedByValue         = "chart2kro"
+	chartVersionAnnotation = "chart2kro.io/chart-version"
+)
+
+// Reconciler converts the chart of a ChartConversion into an RGD, applies
+// it, and converts again when a newer chart version matching the version
+// constraint appears.
+type Reconciler struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+
+	// Options are applied to every conversion after the spec options, e.g.
+	// the chart cache directory.
+	Options []chart2kro.Option
+
+	// AllowLocal permits chart paths and values files on the controller's
+	// file system. Otherwise only OCI references and repository charts are
+	// accepted.
+	AllowLocal bool
+
+	// AllowPlugins permits transformer plugins in spec.config. Plugins run
+	// executables and load WASM modules in the controller.
+	AllowPlugins bool
+}
+
+// SetupWithManager registers the reconciler with mgr. Only spec changes of
+// ChartConversions and their RGDs trigger reconciliation; new chart
+// versions are picked up at the interval of each ChartConversion.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, maxConcurrent int) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+
+	if r.Scheme == nil {
+		r.Scheme = mgr.GetScheme()
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("chartconversion").
+		For(New(), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(newObject(rgdGVK), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrent}).
+		Complete(r)
+}
+
+// Reconcile implements reconcile.Reconciler.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	cr := New()
+	if err := r.Client.Get(ctx, req.NamespacedName, cr); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	spec, status, err := decode(cr)
+	if err != nil {
+		status = &Status{Conditions: statusConditions(cr)}
+	} else {
+		err = r.validate(spec)
+	}
+
+	if err != nil {
+		// An invalid spec is not retried until it changes.
+		setReady(status, cr, metav1.ConditionFalse, ReasonInvalidSpec, err.Error())
+
+		return ctrl.Result{}, r.updateStatus(ctx, cr, status)
+	}
+
+	status.ObservedGeneration = cr.GetGeneration()
+	status.RGDName = cr.GetName()
+	requeue := ctrl.Result{RequeueAfter: spec.interval()}
+
+	result, err := r.convert(ctx, spec)
+	if err != nil {
+		logger.Error(err, "conversion failed")
+		setReady(status, cr, metav1.ConditionFalse, ReasonConversionFailed, err.Error())
+
+		return requeue, r.updateStatus(ctx, cr, status)
+	}
+
+	desired, err := desiredRGD(cr, result)
+	if err != nil {
+		setReady(status, cr, metav1.ConditionFalse, ReasonConversionFailed, err.Error())
+
+		return requeue, r.updateStatus(ctx, cr, status)
+	}
+
+	if err := controllerutil.SetControllerReference(cr, desired, r.Scheme); err != nil {
+		return ctrl.Result{}, fmt.Errorf("setting owner reference: %w", err)
+	}
+
+	existing := newObject(rgdGVK)
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: desired.GetName()}, existing); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("getting RGD: %w", err)
+		}
+
+		existing = nil
+	}
+
+	if existing != nil && !adoptable(existing, cr) {
+		setReady(status, cr, metav1.ConditionFalse, ReasonConflict, fmt.Sprintf(
+			"RGD %s exists and is not managed by chart2kro; delete it or label it %s=%s to let the controller take it over",
+			existing.GetName(), managedByLabel, managedByValue))
+
+		return requeue, r.updateStatus(ctx, cr, status)
+	}
+
+	evolution := &plan.EvolutionResult{}
+	if existing != nil {
+		evolution = plan.Analyze(existing.Object, desired.Object)
+	}
+
+	status.Plan = summarize(result, evolution)
+	status.Warnings = warnings(result, evolution)
+
+	if evolution.HasBreakingChanges() && !spec.AllowBreakingChanges {
+		setReady(status, cr, metav1.ConditionFalse, ReasonBreakingChanges, fmt.Sprintf(
+			"chart version %s has %d breaking change(s); keeping the deployed RGD, set allowBreakingChanges to apply",
+			result.ChartVersion, evolution.BreakingCount()))
+
+		return requeue, r.updateStatus(ctx, cr, status)
+	}
+
+	applied, err := r.apply(ctx, existing, desired)
+	if err != nil {
+		setReady(status, cr, metav1.ConditionFalse, ReasonApplyFailed, err.Error())
+
+		return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, cr, status))
+	}
+
+	if applied {
+		logger.Info("applied RGD", "rgd", desired.GetName(), "chartVersion", result.ChartVersion)
+	}
+
+	now := metav1.NewTime(time.Now())
+	status.ChartVersion = result.ChartVersion
+	status.LastConversionTime = &now
+	setReady(status, cr, metav1.ConditionTrue, ReasonApplied,
+		fmt.Sprintf("RGD %s is up to date with chart version %s", desired.GetName(), result.ChartVersion))
+
+	return requeue, r.updateStatus(ctx, cr, status)
+}
+
+// validate checks the spec and, unless allowed, that it has no plugins and
+// a remote chart.
+func (r *Reconciler) validate(spec *Spec) error {
+	if err := spec.Validate(); err != nil {
+		return fmt.Errorf("spec.%w", err)
+	}
+
+	if !r.AllowPlugins {
+		if err := spec.ValidatePlugins(); err != nil {
+			return fmt.Errorf("spec.%w", err)
+		}
+	}
+
+	if r.AllowLocal {
+		return nil
+	}
+
+	if !spec.IsRemote() {
+		return fmt.Errorf("spec.chart %q: only OCI references and repository charts are accepted", spec.Chart)
+	}
+
+	if len(spec.ValueFiles) > 0 {
+		return errors.New("spec.valueFiles: local files are not accepted; use values")
+	}
+
+	return nil
+}
+
+func (r *Reconciler) convert(ctx context.Context, spec *Spec) (*chart2kro.Result, error) {
+	opts, cleanup, err := spec.Options()
+	defer cleanup()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return chart2kro.Convert(ctx, spec.Chart, append(opts, r.Options...)...)
+}
+
+// apply creates the RGD or updates it when it differs from desired. It
+// reports whether the RGD changed.
+func (r *Reconciler) apply(ctx context.Context, existing, desired *unstructured.Unstructured) (bool, error) {
+	if existing == nil {
+		if err := r.Client.Create(ctx, desired); err != nil {
+			return false, fmt.Errorf("creating RGD: %w", err)
+		}
+
+		return true, nil
+	}
+
+	updated := existing.DeepCopy()
+	updated.Object["spec"] = desired.Object["spec"]
+	updated.SetLabels(merge(existing.GetLabels(), desired.GetLabels()))
+	updated.SetAnnotations(merge(existing.GetAnnotations(), desired.GetAnnotations()))
+	updated.SetOwnerReferences(ownerReferences(existing, desired))
+
+	if equality.Semantic.DeepEqual(existing.Object, updated.Object) {
+		return false, nil
+	}
+
+	if err := r.Client.Update(ctx, updated); err != nil {
+		return false, fmt.Errorf("updating RGD: %w", err)
+	}
+
+	return true, nil
+}
+
+// adoptable reports whether the existing RGD may be updated for cr: it is
+// controlled by cr, or it is labeled as managed by chart2kro and has no
+// other controller.
+func adoptable(existing, cr *unstructured.Unstructured) bool {
+	if metav1.IsControlledBy(existing, cr) {
+		return true
+	}
+
+	return existing.GetLabels()[managedByLabel] == managedByValue && metav1.GetControllerOf(existing) == nil
+}
+
+// ownerReferences returns the owner references of the existing RGD with
+// the controller reference of desired. Other owners are kept.
+func ownerReferences(existing, desired *unstructured.Unstructured) []metav1.OwnerReference {
+	var refs []metav1.OwnerReference
+
+	for _, ref := range existing.GetOwnerReferences() {
+		if ref.Controller == nil || !*ref.Controller {
+			refs = append(refs, ref)
+		}
+	}
+
+	return append(refs, desired.GetOwnerReferences()...)
+}
+
+func (r *Reconciler) updateStatus(ctx context.Context, cr *unstructured.Unstructured, status *Status) error {
+	if err := setStatus(cr, status); err != nil {
+		return err
+	}
+
+	if err := r.Client.Status().Update(ctx, cr); err != nil {
+		return fmt.Errorf("updating status: %w", err)
+	}
+
+	return nil
+}
+
+// desiredRGD returns the RGD of a conversion, named after the
+// ChartConversion. The RGD is decoded from its YAML so that its values have
+// the types the API server returns.
+func desiredRGD(cr *unstructured.Unstructured, result *chart2kro.Result) (*unstructured.Unstructured, error) {
+	data, err := sigsyaml.YAMLToJSON(result.YAML)
+	if err != nil {
+		return nil, fmt.Errorf("parsing generated RGD: %w", err)
+	}
+
+	rgd := &unstructured.Unstructured{}
+	if err := rgd.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("parsing generated RGD: %w", err)
+	}
+
+	rgd.SetName(cr.GetName())
+	rgd.SetLabels(merge(rgd.GetLabels(), map[string]string{managedByLabel: managedByValue}))
+	rgd.SetAnnotations(merge(rgd.GetAnnotations(), map[string]string{chartVersionAnnotation: result.ChartVersion}))
+
+	return rgd, nil
+}
+
+// summarize returns the plan summary of a conversion.
+func summarize(result *chart2kro.Result, evolution *plan.EvolutionResult) *PlanSummary {
+	return &PlanSummary{
+		ChartVersion:    result.ChartVersion,
+		Resources:       result.ResourceCount,
+		SchemaFields:    result.SchemaFieldCount,
+		DependencyEdges: result.DependencyEdges,
+		SchemaChanges:   len(evolution.SchemaChanges),
+		ResourceChanges: len(evolution.ResourceChanges),
+		BreakingChanges: evolution.BreakingCount(),
+	}
+}
+
+// warnings returns the breaking changes and hardening warnings of a
+// conversion.
+func warnings(result *chart2kro.Result, evolution *plan.EvolutionResult) []string {
+	var out []string
+
+	for _, c := range evolution.SchemaChanges {
+		if c.Breaking {
+			out = append(out, fmt.Sprintf("breaking: schema field %s %s: %s", c.Field, c.Type, c.Details))
+		}
+	}
+
+	for _, c := range evolution.ResourceChanges {
+		if c.Breaking {
+			out = append(out, fmt.Sprintf("breaking: resource %s %s: %s", c.ID, c.Type, c.Details))
+		}
+	}
+
+	if result.HardenResult != nil {
+		out = append(out, result.HardenResult.Warnings...)
+	}
+
+	return out
+}
+
+// setReady sets the Ready condition of status.
+func setReady(status *Status, cr *unstructured.Unstructured, s metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               ConditionReady,
+		Status:             s,
+		ObservedGeneration: cr.GetGeneration(),
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// statusConditions returns the conditions of a ChartConversion, ignoring a
+// malformed status.
+func statusConditions(cr *unstructured.Unstructured) []metav1.Condition {
+	status := &Status{}
+	_ = fromMap(cr.Object["status"], status)
+
+	return status.Conditions
+}
+
+func merge(base, overrides map[string]string) map[string]string {
+	out := make(map[string]string, len(base)+len(overrides))
+
+	for k, v := range base {
+		out[k] = v
+	}
+
+	for k, v := range overrides {
+		out[k] = v
+	}
+
+	return out
+}
@@ -0,0 +1,283 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const simpleChart = "../../testdata/charts/simple"
+
+func newChartConversion(name string, spec map[string]interface{}) *unstructured.Unstructured {
+	cr := New()
+	cr.SetName(name)
+	cr.SetUID(types.UID(name + "-uid"))
+	cr.SetGeneration(1)
+	cr.Object["spec"] = spec
+
+	return cr
+}
+
+func newReconciler(t *testing.T, objs ...client.Object) *Reconciler {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(New()).
+		Build()
+
+	return &Reconciler{Client: c, Scheme: scheme, AllowLocal: true}
+}
+
+func reconcile(t *testing.T, r *Reconciler, name string) (ctrl.Result, *unstructured.Unstructured, *Status) {
+	t.Helper()
+
+	res, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: name}})
+	require.NoError(t, err)
+
+	cr := New()
+	require.NoError(t, r.Client.Get(context.Background(), types.NamespacedName{Name: name}, cr))
+
+	_, status, err := decode(cr)
+	require.NoError(t, err)
+
+	return res, cr, status
+}
+
+func getRGD(t *testing.T, r *Reconciler, name string) *unstructured.Unstructured {
+	t.Helper()
+
+	rgd := newObject(rgdGVK)
+	require.NoError(t, r.Client.Get(context.Background(), types.NamespacedName{Name: name}, rgd))
+
+	return rgd
+}
+
+func TestReconcile_CreatesRGD(t *testing.T) {
+	r := newReconciler(t, newChartConversion("web", map[string]interface{}{
+		"chart":    simpleChart,
+		"interval": "1h",
+	}))
+
+	res, _, status := reconcile(t, r, "web")
+
+	assert.Equal(t, "1h0m0s", res.RequeueAfter.String())
+	assert.Equal(t, "1.0.0", status.ChartVersion)
+	assert.Equal(t, "web", status.RGDName)
+	assert.Equal(t, int64(1), status.ObservedGeneration)
+	assert.NotNil(t, status.LastConversionTime)
+	require.NotNil(t, status.Plan)
+	assert.Positive(t, status.Plan.Resources)
+	assert.Zero(t, status.Plan.BreakingChanges)
+
+	ready := meta.FindStatusCondition(status.Conditions, ConditionReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, metav1.ConditionTrue, ready.Status)
+	assert.Equal(t, ReasonApplied, ready.Reason)
+
+	rgd := getRGD(t, r, "web")
+	assert.Equal(t, managedByValue, rgd.GetLabels()[managedByLabel])
+	assert.Equal(t, "1.0.0", rgd.GetAnnotations()[chartVersionAnnotation])
+
+	owners := rgd.GetOwnerReferences()
+	require.Len(t, owners, 1)
+	assert.Equal(t, Kind, owners[0].Kind)
+	assert.Equal(t, "web", owners[0].Name)
+}
+
+func TestReconcile_Idempotent(t *testing.T) {
+	r := newReconciler(t, newChartConversion("web", map[string]interface{}{"chart": simpleChart}))
+
+	reconcile(t, r, "web")
+	before := getRGD(t, r, "web").GetResourceVersion()
+
+	res, _, status := reconcile(t, r, "web")
+
+	assert.Equal(t, DefaultInterval, res.RequeueAfter)
+	assert.Equal(t, before, getRGD(t, r, "web").GetResourceVersion())
+	assert.Zero(t, status.Plan.SchemaChanges)
+	assert.Zero(t, status.Plan.ResourceChanges)
+}
+
+func TestReconcile_BreakingChanges(t *testing.T) {
+	cr := newChartConversion("web", map[string]interface{}{"chart": simpleChart})
+	r := newReconciler(t, cr)
+
+	reconcile(t, r, "web")
+	applied := getRGD(t, r, "web")
+
+	// Dropping a resource is a breaking change.
+	updateSpec(t, r, "web", func(spec map[string]interface{}) {
+		spec["excludeKinds"] = []interface{}{"Service"}
+	})
+
+	_, _, status := reconcile(t, r, "web")
+
+	ready := meta.FindStatusCondition(status.Conditions, ConditionReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, metav1.ConditionFalse, ready.Status)
+	assert.Equal(t, ReasonBreakingChanges, ready.Reason)
+	assert.Positive(t, status.Plan.BreakingChanges)
+	require.NotEmpty(t, status.Warnings)
+	assert.Contains(t, status.Warnings[0], "breaking:")
+	assert.Equal(t, applied.GetResourceVersion(), getRGD(t, r, "web").GetResourceVersion(), "RGD must be kept")
+
+	updateSpec(t, r, "web", func(spec map[string]interface{}) {
+		spec["allowBreakingChanges"] = true
+	})
+
+	_, _, status = reconcile(t, r, "web")
+
+	ready = meta.FindStatusCondition(status.Conditions, ConditionReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, metav1.ConditionTrue, ready.Status)
+	assert.NotEqual(t, applied.GetResourceVersion(), getRGD(t, r, "web").GetResourceVersion())
+}
+
+func TestReconcile_ForeignRGD(t *testing.T) {
+	foreign := newObject(rgdGVK)
+	foreign.SetName("web")
+	foreign.Object["spec"] = map[string]interface{}{"schema": map[string]interface{}{"kind": "Handwritten"}}
+
+	r := newReconciler(t, newChartConversion("web", map[string]interface{}{"chart": simpleChart}), foreign)
+	before := getRGD(t, r, "web")
+
+	res, _, status := reconcile(t, r, "web")
+
+	assert.Equal(t, DefaultInterval, res.RequeueAfter)
+
+	ready := meta.FindStatusCondition(status.Conditions, ConditionReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, metav1.ConditionFalse, ready.Status)
+	assert.Equal(t, ReasonConflict, ready.Reason)
+	assert.Contains(t, ready.Message, "not managed by chart2kro")
+
+	after := getRGD(t, r, "web")
+	assert.Equal(t, before.GetResourceVersion(), after.GetResourceVersion(), "RGD must be kept")
+	assert.Empty(t, after.GetOwnerReferences())
+}
+
+func TestReconcile_AdoptsManagedRGD(t *testing.T) {
+	other := metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "bundle", UID: "bundle-uid"}
+
+	managed := newObject(rgdGVK)
+	managed.SetName("web")
+	managed.SetLabels(map[string]string{managedByLabel: managedByValue})
+	managed.SetOwnerReferences([]metav1.OwnerReference{other})
+	managed.Object["spec"] = map[string]interface{}{}
+
+	r := newReconciler(t, newChartConversion("web", map[string]interface{}{"chart": simpleChart}), managed)
+
+	_, _, status := reconcile(t, r, "web")
+
+	ready := meta.FindStatusCondition(status.Conditions, ConditionReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, ReasonApplied, ready.Reason)
+
+	owners := getRGD(t, r, "web").GetOwnerReferences()
+	require.Len(t, owners, 2)
+	assert.Equal(t, other.UID, owners[0].UID, "other owners are kept")
+	assert.Equal(t, Kind, owners[1].Kind)
+}
+
+func TestReconcile_InvalidSpec(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       map[string]interface{}
+		allowLocal bool
+		want       string
+	}{
+		{
+			name:       "missing chart",
+			spec:       map[string]interface{}{},
+			allowLocal: true,
+			want:       "spec.chart is required",
+		},
+		{
+			name:       "invalid interval",
+			spec:       map[string]interface{}{"chart": simpleChart, "interval": "soon"},
+			allowLocal: true,
+			want:       "spec.interval",
+		},
+		{
+			name: "plugin",
+			spec: map[string]interface{}{"chart": simpleChart, "config": map[string]interface{}{
+				"transformers": []interface{}{map[string]interface{}{
+					"match":  map[string]interface{}{"kind": "Deployment"},
+					"plugin": map[string]interface{}{"exec": "sh", "args": []interface{}{"-c", "id"}},
+				}},
+			}},
+			allowLocal: true,
+			want:       "spec.config.transformers[0].plugin",
+		},
+		{
+			name: "local chart",
+			spec: map[string]interface{}{"chart": simpleChart},
+			want: "only OCI references and repository charts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newReconciler(t, newChartConversion("web", tt.spec))
+			r.AllowLocal = tt.allowLocal
+
+			res, _, status := reconcile(t, r, "web")
+
+			assert.Zero(t, res.RequeueAfter)
+
+			ready := meta.FindStatusCondition(status.Conditions, ConditionReady)
+			require.NotNil(t, ready)
+			assert.Equal(t, metav1.ConditionFalse, ready.Status)
+			assert.Equal(t, ReasonInvalidSpec, ready.Reason)
+			assert.Contains(t, ready.Message, tt.want)
+		})
+	}
+}
+
+func TestReconcile_ConversionFailed(t *testing.T) {
+	r := newReconciler(t, newChartConversion("web", map[string]interface{}{"chart": "../../testdata/charts/missing"}))
+
+	res, _, status := reconcile(t, r, "web")
+
+	assert.Equal(t, DefaultInterval, res.RequeueAfter)
+
+	ready := meta.FindStatusCondition(status.Conditions, ConditionReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, ReasonConversionFailed, ready.Reason)
+}
+
+func TestReconcile_NotFound(t *testing.T) {
+	r := newReconciler(t)
+
+	res, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "gone"}})
+	require.NoError(t, err)
+	assert.Zero(t, res)
+}
+
+func updateSpec(t *testing.T, r *Reconciler, name string, fn func(spec map[string]interface{})) {
+	t.Helper()
+
+	cr := New()
+	require.NoError(t, r.Client.Get(context.Background(), types.NamespacedName{Name: name}, cr))
+
+	spec, _, _ := unstructured.NestedMap(cr.Object, "spec")
+	fn(spec)
+	cr.Object["spec"] = spec
+	cr.SetGeneration(cr.GetGeneration() + 1)
+
+	require.NoError(t, r.Client.Update(context.Background(), cr))
+}
@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/hupe1980/chart2kro/internal/krmfn"
+)
+
+// ChartConversion identification.
+const (
+	Group   = "chart2kro.io"
+	Version = "v1alpha1"
+	Kind    = "ChartConversion"
+)
+
+// GroupVersionKind identifies ChartConversion resources.
+var GroupVersionKind = schema.GroupVersionKind{Group: Group, Version: Version, Kind: Kind}
+
+// rgdGVK identifies the managed ResourceGraphDefinitions.
+var rgdGVK = schema.GroupVersionKind{Group: "kro.run", Version: "v1alpha1", Kind: "ResourceGraphDefinition"}
+
+// DefaultInterval is the default interval between checks for new chart
+// versions.
+const DefaultInterval = 10 * time.Minute
+
+// ConditionReady is the condition type reporting whether the RGD of the
+// current chart version is applied.
+const ConditionReady = "Ready"
+
+// Reasons of the Ready condition.
+const (
+	ReasonApplied          = "Applied"
+	ReasonInvalidSpec      = "InvalidSpec"
+	ReasonConversionFailed = "ConversionFailed"
+	ReasonBreakingChanges  = "BreakingChanges"
+	ReasonApplyFailed      = "ApplyFailed"
+	ReasonConflict         = "Conflict"
+)
+
+// Spec is the spec of a ChartConversion: the options of the krm-fn
+// ChartToRGD spec, which mirror the pkg/chart2kro options, and the update
+// policy. The version is a semver constraint; the highest matching version
+// is converted.
+type Spec struct {
+	krmfn.Spec `json:",inline"`
+
+	// Interval is the interval between checks for new chart versions, e.g.
+	// "1h" (default: 10m).
+	Interval string `json:"interval,omitempty"`
+
+	// AllowBreakingChanges applies RGDs with breaking schema or resource
+	// changes. Otherwise the deployed RGD is kept and the changes are
+	// reported in the status.
+	AllowBreakingChanges bool `json:"allowBreakingChanges,omitempty"`
+}
+
+// Validate checks the spec. Errors name the offending field relative to the
+// spec.
+func (s *Spec) Validate() error {
+	if err := s.Spec.Validate(); err != nil {
+		return err
+	}
+
+	if s.Interval != "" {
+		d, err := time.ParseDuration(s.Interval)
+		if err != nil {
+			return fmt.Errorf("interval: %w", err)
+		}
+
+		if d <= 0 {
+			return fmt.Errorf("interval: must be positive, got %s", s.Interval)
+		}
+	}
+
+	return nil
+}
+
+// interval returns the interval between checks for new chart versions.
+func (s *Spec) interval() time.Duration {
+	if d, err := time.ParseDuration(s.Interval); err == nil && d > 0 {
+		return d
+	}
+
+	return DefaultInterval
+}
+
+// Status is the status of a ChartConversion.
+type Status struct {
+	// ObservedGeneration is the generation of the last reconciled spec.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ChartVersion is the chart version of the applied RGD.
+	ChartVersion string `json:"chartVersion,omitempty"`
+
+	// RGDName is the name of the managed RGD.
+	RGDName string `json:"rgdName,omitempty"`
+
+	// LastConversionTime is the time of the last successful conversion.
+	LastConversionTime *metav1.Time `json:"lastConversionTime,omitempty"`
+
+	// Plan summarizes the last conversion and its changes to the RGD.
+	Plan *PlanSummary `json:"plan,omitempty"`
+
+	// Warnings are the breaking changes and hardening warnings of the last
+	// conversion.
+	Warnings []string `json:"warnings,omitempty"`
+
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// PlanSummary summarizes a conversion and its changes to the deployed RGD.
+type PlanSummary struct {
+	// ChartVersion is the converted chart version.
+	ChartVersion    string `json:"chartVersion"`
+	Resources       int    `json:"resources"`
+	SchemaFields    int    `json:"schemaFields"`
+	DependencyEdges int    `json:"dependencyEdges"`
+	SchemaChanges   int    `json:"schemaChanges"`
+	ResourceChanges int    `json:"resourceChanges"`
+	BreakingChanges int    `json:"breakingChanges"`
+}
+
+// decode decodes the spec and status of a ChartConversion.
+func decode(obj *unstructured.Unstructured) (*Spec, *Status, error) {
+	spec := &Spec{}
+	if err := fromMap(obj.Object["spec"], spec); err != nil {
+		return nil, nil, fmt.Errorf("spec: %w", err)
+	}
+
+	status := &Status{}
+	if err := fromMap(obj.Object["status"], status); err != nil {
+		return nil, nil, fmt.Errorf("status: %w", err)
+	}
+
+	return spec, status, nil
+}
+
+func fromMap(m, v interface{}) error {
+	if m == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// setStatus sets the status of a ChartConversion.
+func setStatus(obj *unstructured.Unstructured, status *Status) error {
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(status)
+	if err != nil {
+		return fmt.Errorf("converting status: %w", err)
+	}
+
+	obj.Object["status"] = m
+
+	return nil
+}
+
+// newObject returns an empty unstructured object of gvk.
+func newObject(gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+
+	return obj
+}
+
+// New returns an empty ChartConversion, e.g. to register it with a builder.
+func New() *unstructured.Unstructured {
+	return newObject(GroupVersionKind)
+}
@@ -0,0 +1,207 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Supported export formats.
+const (
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+	FormatJSON    = "json"
+)
+
+// Write exports the graph in the given format.
+func Write(w io.Writer, g *Graph, format string) error {
+	switch format {
+	case FormatDOT:
+		return WriteDOT(w, g)
+	case FormatMermaid:
+		return WriteMermaid(w, g)
+	case FormatJSON:
+		return WriteJSON(w, g)
+	default:
+		return fmt.Errorf("unknown graph format %q: expected dot, mermaid, json", format)
+	}
+}
+
+// WriteJSON writes the graph as indented JSON.
+func WriteJSON(w io.Writer, g *Graph) error {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling graph: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(data))
+
+	return err
+}
+
+// WriteDOT writes the graph in Graphviz DOT format. Edges point from a
+// resource to the resources it depends on and are labeled with their
+// reasons. Cycles are red, conditional resources dashed, and external
+// resources grey.
+func WriteDOT(w io.Writer, g *Graph) error {
+	var b strings.Builder
+
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+
+	for _, n := range g.Nodes {
+		attrs := []string{fmt.Sprintf("label=%s", dotQuote(strings.Join(nodeLabel(n), "\n")))}
+
+		styles := []string{"rounded"}
+		if len(n.IncludeWhen) > 0 {
+			styles = append(styles, "dashed")
+		}
+
+		if n.External {
+			styles = append(styles, "filled")
+			attrs = append(attrs, `fillcolor="lightgrey"`)
+		}
+
+		attrs = append(attrs, fmt.Sprintf("style=%q", strings.Join(styles, ",")))
+
+		if n.InCycle {
+			attrs = append(attrs, `color="red"`)
+		}
+
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(n.ID), strings.Join(attrs, ", "))
+	}
+
+	for _, e := range g.Edges {
+		var attrs []string
+		if len(e.Reasons) > 0 {
+			attrs = append(attrs, fmt.Sprintf("label=%s", dotQuote(strings.Join(e.Reasons, ", "))))
+		}
+
+		if e.InCycle {
+			attrs = append(attrs, `color="red"`, "penwidth=2")
+		}
+
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotQuote(e.From), dotQuote(e.To), strings.Join(attrs, ", "))
+		} else {
+			fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(e.From), dotQuote(e.To))
+		}
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart with the same
+// conventions as WriteDOT.
+func WriteMermaid(w io.Writer, g *Graph) error {
+	var b strings.Builder
+
+	b.WriteString("flowchart LR\n")
+
+	var cycleNodes, conditionalNodes, externalNodes []string
+
+	for _, n := range g.Nodes {
+		id := mermaidID(n.ID)
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", id, mermaidEscape(strings.Join(nodeLabel(n), "<br/>")))
+
+		if n.InCycle {
+			cycleNodes = append(cycleNodes, id)
+		}
+
+		if len(n.IncludeWhen) > 0 {
+			conditionalNodes = append(conditionalNodes, id)
+		}
+
+		if n.External {
+			externalNodes = append(externalNodes, id)
+		}
+	}
+
+	var cycleLinks []string
+
+	for i, e := range g.Edges {
+		if len(e.Reasons) > 0 {
+			fmt.Fprintf(&b, "  %s -->|%s| %s\n", mermaidID(e.From), mermaidEscape(strings.Join(e.Reasons, ", ")), mermaidID(e.To))
+		} else {
+			fmt.Fprintf(&b, "  %s --> %s\n", mermaidID(e.From), mermaidID(e.To))
+		}
+
+		if e.InCycle {
+			cycleLinks = append(cycleLinks, fmt.Sprint(i))
+		}
+	}
+
+	writeMermaidClass(&b, "cycle", "stroke:#d00,stroke-width:2px", cycleNodes)
+	writeMermaidClass(&b, "conditional", "stroke-dasharray:5 5", conditionalNodes)
+	writeMermaidClass(&b, "external", "fill:#eee,stroke:#999", externalNodes)
+
+	if len(cycleLinks) > 0 {
+		fmt.Fprintf(&b, "  linkStyle %s stroke:#d00,stroke-width:2px\n", strings.Join(cycleLinks, ","))
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// nodeLabel returns the label lines of a node: ID, kind/name, and
+// includeWhen conditions.
+func nodeLabel(n Node) []string {
+	lines := []string{n.ID}
+
+	switch {
+	case n.External:
+		lines = append(lines, fmt.Sprintf("external %s/%s", n.Kind, n.Name))
+	case n.Kind != "":
+		lines = append(lines, n.Kind)
+	}
+
+	for _, cond := range n.IncludeWhen {
+		lines = append(lines, "includeWhen: "+cond)
+	}
+
+	return lines
+}
+
+func writeMermaidClass(b *strings.Builder, name, style string, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "  classDef %s %s\n", name, style)
+	fmt.Fprintf(b, "  class %s %s\n", strings.Join(ids, ","), name)
+}
+
+// dotQuote returns s as a quoted DOT string. Newlines become DOT line breaks.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+
+	return `"` + s + `"`
+}
+
+// mermaidID returns a Mermaid-safe node identifier.
+func mermaidID(id string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+
+		return '_'
+	}, id)
+}
+
+// mermaidEscape escapes text for a quoted Mermaid label.
+func mermaidEscape(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	s = strings.ReplaceAll(s, "|", "#124;")
+
+	return s
+}
@@ -0,0 +1,146 @@
+// Package graph exports the resource dependency graph of a conversion as
+// Graphviz DOT, Mermaid, or JSON, with edge reasons, cycles, includeWhen
+// conditions, and externalized resources.
+package graph
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+// Graph is the exportable form of a dependency graph.
+type Graph struct {
+	Nodes  []Node     `json:"nodes"`
+	Edges  []Edge     `json:"edges"`
+	Cycles [][]string `json:"cycles,omitempty"`
+}
+
+// Node is a resource of the graph.
+type Node struct {
+	ID          string   `json:"id"`
+	Kind        string   `json:"kind"`
+	Name        string   `json:"name,omitempty"`
+	IncludeWhen []string `json:"includeWhen,omitempty"`
+	External    bool     `json:"external,omitempty"`
+	InCycle     bool     `json:"inCycle,omitempty"`
+}
+
+// Edge is a dependency: From depends on To.
+type Edge struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Reasons []string `json:"reasons,omitempty"`
+	InCycle bool     `json:"inCycle,omitempty"`
+}
+
+// External describes a resource promoted to an external reference. Resources
+// whose templates contain Ref depend on it.
+type External struct {
+	// ID is the node ID of the external resource.
+	ID string
+	// Kind is the kind of the external resource.
+	Kind string
+	// Name is the original name of the external resource.
+	Name string
+	// Ref is the CEL expression that replaced references to the resource,
+	// e.g. "${schema.spec.externalDatabase.host}".
+	Ref string
+}
+
+// Options controls the annotations of the exported graph.
+type Options struct {
+	// IncludeWhen holds the includeWhen conditions by resource ID.
+	IncludeWhen map[string][]string
+
+	// External adds externalized resources as nodes.
+	External []External
+}
+
+// Build converts a dependency graph into its exportable form. Cycles are
+// detected with DetectCycles and marked on their nodes and edges.
+func Build(g *transform.DependencyGraph, opts Options) *Graph {
+	out := &Graph{Cycles: g.DetectCycles()}
+
+	cycleNodes := make(map[string]bool)
+	cycleEdges := make(map[[2]string]bool)
+
+	for _, cycle := range out.Cycles {
+		for i, id := range cycle {
+			cycleNodes[id] = true
+
+			if i+1 < len(cycle) {
+				cycleEdges[[2]string{id, cycle[i+1]}] = true
+			}
+		}
+	}
+
+	for _, id := range g.Nodes() {
+		node := Node{ID: id, IncludeWhen: opts.IncludeWhen[id], InCycle: cycleNodes[id]}
+
+		if r := g.Resource(id); r != nil {
+			node.Kind = r.Kind()
+			node.Name = r.Name
+		}
+
+		out.Nodes = append(out.Nodes, node)
+
+		for _, dep := range g.DependenciesOf(id) {
+			edge := Edge{From: id, To: dep, InCycle: cycleEdges[[2]string{id, dep}]}
+			for _, reason := range g.EdgeReasons(id, dep) {
+				edge.Reasons = append(edge.Reasons, string(reason))
+			}
+
+			out.Edges = append(out.Edges, edge)
+		}
+	}
+
+	addExternals(out, g, opts.External)
+
+	return out
+}
+
+// addExternals adds the external nodes and an edge from every resource whose
+// template references an external resource.
+func addExternals(out *Graph, g *transform.DependencyGraph, externals []External) {
+	sorted := append([]External(nil), externals...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	for _, ext := range sorted {
+		out.Nodes = append(out.Nodes, Node{ID: ext.ID, Kind: ext.Kind, Name: ext.Name, External: true})
+
+		if ext.Ref == "" {
+			continue
+		}
+
+		for _, id := range g.Nodes() {
+			r := g.Resource(id)
+			if r != nil && r.Object != nil && containsString(r.Object.Object, ext.Ref) {
+				out.Edges = append(out.Edges, Edge{From: id, To: ext.ID, Reasons: []string{"external"}})
+			}
+		}
+	}
+}
+
+// containsString reports whether any string value of v contains s.
+func containsString(v interface{}, s string) bool {
+	switch val := v.(type) {
+	case string:
+		return strings.Contains(val, s)
+	case map[string]interface{}:
+		for _, item := range val {
+			if containsString(item, s) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			if containsString(item, s) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,123 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/hupe1980/chart2kro/internal/k8s"
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+func makeResource(kind, name string, obj map[string]interface{}) *k8s.Resource {
+	return &k8s.Resource{
+		GVK:    schema.GroupVersionKind{Version: "v1", Kind: kind},
+		Name:   name,
+		Object: &unstructured.Unstructured{Object: obj},
+	}
+}
+
+func testGraph() *transform.DependencyGraph {
+	g := transform.NewDependencyGraph()
+	g.AddNode("deployment", makeResource("Deployment", "web", map[string]interface{}{
+		"spec": map[string]interface{}{"host": "${schema.spec.externalDatabase.host}"},
+	}))
+	g.AddNode("service", makeResource("Service", "web", nil))
+	g.AddNode("config", makeResource("ConfigMap", "web-config", nil))
+	g.AddEdgeWithReason("service", "deployment", transform.EdgeSelector)
+	g.AddEdgeWithReason("deployment", "config", transform.EdgeVolume)
+	g.AddEdgeWithReason("deployment", "config", transform.EdgeEnv)
+
+	return g
+}
+
+func TestBuild(t *testing.T) {
+	out := Build(testGraph(), Options{
+		IncludeWhen: map[string][]string{"config": {"${schema.spec.config.enabled}"}},
+		External: []External{{
+			ID: "Service/postgres", Kind: "Service", Name: "postgres",
+			Ref: "${schema.spec.externalDatabase.host}",
+		}},
+	})
+
+	require.Len(t, out.Nodes, 4)
+	assert.Empty(t, out.Cycles)
+	assert.Equal(t, Node{ID: "config", Kind: "ConfigMap", Name: "web-config", IncludeWhen: []string{"${schema.spec.config.enabled}"}}, out.Nodes[0])
+	assert.Equal(t, Node{ID: "Service/postgres", Kind: "Service", Name: "postgres", External: true}, out.Nodes[3])
+
+	assert.Contains(t, out.Edges, Edge{From: "deployment", To: "config", Reasons: []string{"env", "volume"}})
+	assert.Contains(t, out.Edges, Edge{From: "service", To: "deployment", Reasons: []string{"selector"}})
+	assert.Contains(t, out.Edges, Edge{From: "deployment", To: "Service/postgres", Reasons: []string{"external"}})
+}
+
+func TestBuild_Cycles(t *testing.T) {
+	g := testGraph()
+	g.AddEdge("config", "deployment")
+
+	out := Build(g, Options{})
+
+	require.Len(t, out.Cycles, 1)
+
+	inCycle := make(map[string]bool)
+	for _, n := range out.Nodes {
+		inCycle[n.ID] = n.InCycle
+	}
+
+	assert.Equal(t, map[string]bool{"config": true, "deployment": true, "service": false}, inCycle)
+
+	for _, e := range out.Edges {
+		assert.Equal(t, e.From != "service", e.InCycle, "%s -> %s", e.From, e.To)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	g := testGraph()
+	g.AddEdge("config", "deployment")
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, Build(g, Options{IncludeWhen: map[string][]string{"service": {"${schema.spec.service.enabled}"}}}), FormatDOT))
+
+	out := buf.String()
+	assert.Contains(t, out, "digraph dependencies {")
+	assert.Contains(t, out, `"service" -> "deployment" [label="selector"];`)
+	assert.Contains(t, out, `"deployment" -> "config" [label="env, volume", color="red", penwidth=2];`)
+	assert.Contains(t, out, `"service" [label="service\nService\nincludeWhen: ${schema.spec.service.enabled}", style="rounded,dashed"];`)
+	assert.Contains(t, out, `"config" [label="config\nConfigMap", style="rounded", color="red"];`)
+}
+
+func TestWriteMermaid(t *testing.T) {
+	g := testGraph()
+	g.AddEdge("config", "deployment")
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, Build(g, Options{External: []External{{ID: "Secret/db", Kind: "Secret", Name: "db"}}}), FormatMermaid))
+
+	out := buf.String()
+	assert.Contains(t, out, "flowchart LR\n")
+	assert.Contains(t, out, `Secret_db["Secret/db<br/>external Secret/db"]`)
+	assert.Contains(t, out, "service -->|selector| deployment")
+	assert.Contains(t, out, "class config,deployment cycle")
+	assert.Contains(t, out, "class Secret_db external")
+	assert.Contains(t, out, "linkStyle 0,1 stroke:#d00")
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, Build(testGraph(), Options{}), FormatJSON))
+
+	var out Graph
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Len(t, out.Nodes, 3)
+	assert.Len(t, out.Edges, 2)
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	err := Write(&bytes.Buffer{}, &Graph{}, "svg")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown graph format")
+}
@@ -28,6 +28,24 @@ type ProvenanceConfig struct {
 
 	// EmbedTimestamp controls whether chart2kro.io/generated-at is added.
 	EmbedTimestamp bool
+
+	// Verification is the verified chart signature (optional).
+	Verification *SignatureVerification
+}
+
+// SignatureVerification describes how the chart's signature was verified.
+type SignatureVerification struct {
+	// Method is the verification method ("helm-provenance" or "cosign").
+	Method string
+
+	// Identity is the verified signer.
+	Identity string
+
+	// KeyFingerprint is the fingerprint of the verifying key.
+	KeyFingerprint string
+
+	// Digest is the digest covered by the signature.
+	Digest string
 }
 
 // SLSAPredicate represents a simplified SLSA v1.0 provenance predicate.
@@ -58,8 +76,9 @@ type SLSAConfigSource struct {
 
 // SLSAMaterial represents an input material (e.g., the chart archive).
 type SLSAMaterial struct {
-	URI    string            `json:"uri"`
-	Digest map[string]string `json:"digest,omitempty"`
+	URI         string            `json:"uri"`
+	Digest      map[string]string `json:"digest,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // SLSAMetadata contains build metadata.
@@ -93,6 +112,11 @@ func GenerateProvenanceAnnotations(cfg ProvenanceConfig) (map[string]string, err
 		annotations["chart2kro.io/excluded-subcharts"] = strings.Join(cfg.ExcludedSubcharts, ",")
 	}
 
+	if v := cfg.Verification; v != nil {
+		annotations["chart2kro.io/verified-by"] = v.Identity
+		annotations["chart2kro.io/verification-method"] = v.Method
+	}
+
 	var buildTime string
 
 	if cfg.EmbedTimestamp {
@@ -148,6 +172,20 @@ func buildSLSAPredicate(cfg ProvenanceConfig, info version.Info, buildTime strin
 		}
 	}
 
+	if v := cfg.Verification; v != nil {
+		if len(predicate.Materials) == 0 {
+			predicate.Materials = []SLSAMaterial{{URI: cfg.ChartRef}}
+		}
+
+		predicate.Materials[0].Annotations = map[string]string{
+			"verificationMethod": v.Method,
+			"verifiedBy":         v.Identity,
+			"keyFingerprint":     v.KeyFingerprint,
+			"signedDigest":       v.Digest,
+		}
+		predicate.Invocation.Parameters["verify"] = "true"
+	}
+
 	if cfg.Profile != "" {
 		predicate.Invocation.Parameters["profile"] = cfg.Profile
 	}
@@ -141,3 +141,50 @@ func TestSLSAPredicate_Materials(t *testing.T) {
 	assert.Equal(t, cfg.ChartRef, predicate.Materials[0].URI)
 	assert.Equal(t, "sha256hash", predicate.Materials[0].Digest["sha256"])
 }
+
+func TestGenerateProvenanceAnnotations_Verification(t *testing.T) {
+	cfg := ProvenanceConfig{
+		ChartRef:    "oci://registry/chart:1.0",
+		ChartDigest: "abc123",
+		Verification: &SignatureVerification{
+			Method:         "cosign",
+			Identity:       "sha256:f00d",
+			KeyFingerprint: "f00d",
+			Digest:         "sha256:beef",
+		},
+	}
+
+	annotations, err := GenerateProvenanceAnnotations(cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, "sha256:f00d", annotations["chart2kro.io/verified-by"])
+	assert.Equal(t, "cosign", annotations["chart2kro.io/verification-method"])
+
+	var predicate SLSAPredicate
+	require.NoError(t, json.Unmarshal([]byte(annotations["chart2kro.io/provenance"]), &predicate))
+
+	require.Len(t, predicate.Materials, 1)
+	assert.Equal(t, "abc123", predicate.Materials[0].Digest["sha256"])
+	assert.Equal(t, map[string]string{
+		"verificationMethod": "cosign",
+		"verifiedBy":         "sha256:f00d",
+		"keyFingerprint":     "f00d",
+		"signedDigest":       "sha256:beef",
+	}, predicate.Materials[0].Annotations)
+	assert.Equal(t, "true", predicate.Invocation.Parameters["verify"])
+}
+
+func TestGenerateProvenanceAnnotations_VerificationWithoutDigest(t *testing.T) {
+	annotations, err := GenerateProvenanceAnnotations(ProvenanceConfig{
+		ChartRef:     "repo/chart",
+		Verification: &SignatureVerification{Method: "helm-provenance", Identity: "Jane <jane@example.com>"},
+	})
+	require.NoError(t, err)
+
+	var predicate SLSAPredicate
+	require.NoError(t, json.Unmarshal([]byte(annotations["chart2kro.io/provenance"]), &predicate))
+
+	require.Len(t, predicate.Materials, 1)
+	assert.Equal(t, "repo/chart", predicate.Materials[0].URI)
+	assert.Equal(t, "Jane <jane@example.com>", predicate.Materials[0].Annotations["verifiedBy"])
+}
@@ -0,0 +1,426 @@
+// Package cache implements a persistent, content-addressed cache for Helm
+// chart archives and repository indexes.
+//
+// Layout below the cache directory:
+//
+//	blobs/sha256/<hex>     chart archives, addressed by their sha256 digest
+//	charts/<key>.json      entries mapping repository + chart + version to a blob
+//	indexes/<key>.yaml     repository index data
+//	indexes/<key>.json     index metadata (URL, fetch time)
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultIndexTTL is how long a cached repository index is considered fresh.
+const DefaultIndexTTL = 10 * time.Minute
+
+// Entry describes a cached chart archive.
+type Entry struct {
+	// Repository is the repository URL or OCI repository (oci://host/path).
+	Repository string `json:"repository"`
+	// Chart is the chart name.
+	Chart string `json:"chart"`
+	// Version is the resolved chart version.
+	Version string `json:"version"`
+	// Digest is the sha256 digest of the archive ("sha256:<hex>").
+	Digest string `json:"digest"`
+	// Size is the archive size in bytes.
+	Size int64 `json:"size"`
+	// CreatedAt is when the archive was first cached.
+	CreatedAt time.Time `json:"createdAt"`
+	// LastUsed is when the archive was last served from the cache.
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// IndexEntry describes a cached repository index.
+type IndexEntry struct {
+	// URL is the index URL.
+	URL string `json:"url"`
+	// FetchedAt is when the index was downloaded.
+	FetchedAt time.Time `json:"fetchedAt"`
+	// Size is the index size in bytes.
+	Size int64 `json:"size"`
+}
+
+// Cache is a persistent chart cache rooted at a directory.
+type Cache struct {
+	dir string
+	now func() time.Time
+}
+
+// New creates a Cache rooted at dir. The directory is created lazily.
+func New(dir string) *Cache {
+	return &Cache{dir: dir, now: time.Now}
+}
+
+// DefaultDir returns $XDG_CACHE_HOME/chart2kro, falling back to the
+// platform user cache directory, or "" when neither can be determined.
+func DefaultDir() string {
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+		return filepath.Join(xdg, "chart2kro")
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "chart2kro")
+}
+
+// Dir returns the cache root directory.
+func (c *Cache) Dir() string { return c.dir }
+
+// Digest returns the "sha256:<hex>" digest of data.
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// GetChart returns the cached archive for repository, chart, and version.
+// When digest is non-empty (with or without the "sha256:" prefix), the
+// cached archive must match it. Corrupted blobs are treated as misses.
+func (c *Cache) GetChart(repository, chart, version, digest string) ([]byte, *Entry, bool) {
+	entryPath := c.entryPath(repository, chart, version)
+
+	entry, err := readEntry(entryPath)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	if digest != "" && !strings.EqualFold(normalizeDigest(digest), entry.Digest) {
+		return nil, nil, false
+	}
+
+	data, err := os.ReadFile(c.blobPath(entry.Digest))
+	if err != nil || Digest(data) != entry.Digest {
+		return nil, nil, false
+	}
+
+	entry.LastUsed = c.now()
+	_ = writeJSON(entryPath, entry) // best effort
+
+	return data, entry, true
+}
+
+// PutChart stores an archive and returns its entry.
+func (c *Cache) PutChart(repository, chart, version string, data []byte) (*Entry, error) {
+	digest := Digest(data)
+
+	if err := writeFileAtomic(c.blobPath(digest), data); err != nil {
+		return nil, fmt.Errorf("writing cache blob: %w", err)
+	}
+
+	now := c.now()
+	entry := &Entry{
+		Repository: repository,
+		Chart:      chart,
+		Version:    version,
+		Digest:     digest,
+		Size:       int64(len(data)),
+		CreatedAt:  now,
+		LastUsed:   now,
+	}
+
+	if err := writeJSON(c.entryPath(repository, chart, version), entry); err != nil {
+		return nil, fmt.Errorf("writing cache entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// Versions returns the cached versions of a chart in a repository.
+func (c *Cache) Versions(repository, chart string) []string {
+	entries, err := c.List()
+	if err != nil {
+		return nil
+	}
+
+	var versions []string
+
+	for _, e := range entries {
+		if e.Repository == repository && e.Chart == chart {
+			versions = append(versions, e.Version)
+		}
+	}
+
+	return versions
+}
+
+// GetIndex returns a cached repository index and when it was fetched.
+func (c *Cache) GetIndex(url string) ([]byte, time.Time, bool) {
+	base := c.indexBase(url)
+
+	var meta IndexEntry
+
+	raw, err := os.ReadFile(base + ".json")
+	if err != nil || json.Unmarshal(raw, &meta) != nil || meta.URL != url {
+		return nil, time.Time{}, false
+	}
+
+	data, err := os.ReadFile(base + ".yaml")
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+
+	return data, meta.FetchedAt, true
+}
+
+// PutIndex stores a repository index.
+func (c *Cache) PutIndex(url string, data []byte) error {
+	base := c.indexBase(url)
+
+	if err := writeFileAtomic(base+".yaml", data); err != nil {
+		return fmt.Errorf("writing cached index: %w", err)
+	}
+
+	meta := &IndexEntry{URL: url, FetchedAt: c.now(), Size: int64(len(data))}
+	if err := writeJSON(base+".json", meta); err != nil {
+		return fmt.Errorf("writing cached index metadata: %w", err)
+	}
+
+	return nil
+}
+
+// List returns all cached chart entries sorted by repository, chart, and
+// version.
+func (c *Cache) List() ([]*Entry, error) {
+	paths, err := filepath.Glob(filepath.Join(c.dir, "charts", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry, 0, len(paths))
+
+	for _, p := range paths {
+		e, err := readEntry(p)
+		if err != nil {
+			continue
+		}
+
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+
+		if a.Chart != b.Chart {
+			return a.Chart < b.Chart
+		}
+
+		return a.Version < b.Version
+	})
+
+	return entries, nil
+}
+
+// ListIndexes returns all cached repository indexes sorted by URL.
+func (c *Cache) ListIndexes() ([]*IndexEntry, error) {
+	paths, err := filepath.Glob(filepath.Join(c.dir, "indexes", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]*IndexEntry, 0, len(paths))
+
+	for _, p := range paths {
+		var meta IndexEntry
+
+		raw, err := os.ReadFile(p) //nolint:gosec // path within cache dir
+		if err != nil || json.Unmarshal(raw, &meta) != nil {
+			continue
+		}
+
+		indexes = append(indexes, &meta)
+	}
+
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].URL < indexes[j].URL })
+
+	return indexes, nil
+}
+
+// PruneOptions selects what Prune removes.
+type PruneOptions struct {
+	// OlderThan removes charts not used and indexes not fetched within this
+	// duration.
+	OlderThan time.Duration
+	// All removes every cached chart and index.
+	All bool
+	// DryRun reports what would be removed without deleting anything.
+	DryRun bool
+}
+
+// PruneResult summarizes a Prune run.
+type PruneResult struct {
+	// Charts are the removed chart entries.
+	Charts []*Entry
+	// Indexes are the removed index entries.
+	Indexes []*IndexEntry
+	// Bytes is the number of bytes freed by removed blobs and indexes.
+	Bytes int64
+}
+
+// Prune removes stale entries and garbage-collects blobs no longer
+// referenced by any entry.
+func (c *Cache) Prune(opts PruneOptions) (*PruneResult, error) {
+	cutoff := c.now().Add(-opts.OlderThan)
+	result := &PruneResult{}
+
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[string]bool)
+
+	for _, e := range entries {
+		if !opts.All && e.LastUsed.After(cutoff) {
+			referenced[e.Digest] = true
+			continue
+		}
+
+		result.Charts = append(result.Charts, e)
+
+		if !opts.DryRun {
+			if err := os.Remove(c.entryPath(e.Repository, e.Chart, e.Version)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+		}
+	}
+
+	blobs, err := filepath.Glob(filepath.Join(c.dir, "blobs", "sha256", "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range blobs {
+		if referenced["sha256:"+filepath.Base(b)] {
+			continue
+		}
+
+		if info, err := os.Stat(b); err == nil {
+			result.Bytes += info.Size()
+		}
+
+		if !opts.DryRun {
+			if err := os.Remove(b); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+		}
+	}
+
+	indexes, err := c.ListIndexes()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, idx := range indexes {
+		if !opts.All && idx.FetchedAt.After(cutoff) {
+			continue
+		}
+
+		result.Indexes = append(result.Indexes, idx)
+		result.Bytes += idx.Size
+
+		if !opts.DryRun {
+			base := c.indexBase(idx.URL)
+			for _, p := range []string{base + ".yaml", base + ".json"} {
+				if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (c *Cache) blobPath(digest string) string {
+	return filepath.Join(c.dir, "blobs", "sha256", strings.TrimPrefix(digest, "sha256:"))
+}
+
+func (c *Cache) entryPath(repository, chart, version string) string {
+	return filepath.Join(c.dir, "charts", hashKey(repository, chart, version)+".json")
+}
+
+func (c *Cache) indexBase(url string) string {
+	return filepath.Join(c.dir, "indexes", hashKey(url))
+}
+
+// hashKey derives a file name from the given key parts.
+func hashKey(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+
+	return hex.EncodeToString(sum[:16])
+}
+
+// normalizeDigest lower-cases a digest and adds the "sha256:" prefix.
+func normalizeDigest(digest string) string {
+	return "sha256:" + strings.ToLower(strings.TrimPrefix(digest, "sha256:"))
+}
+
+func readEntry(path string) (*Entry, error) {
+	raw, err := os.ReadFile(path) //nolint:gosec // path within cache dir
+	if err != nil {
+		return nil, err
+	}
+
+	var e Entry
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes data to path via a temporary file and rename,
+// creating parent directories.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCache(t *testing.T) (*Cache, *time.Time) {
+	t.Helper()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New(t.TempDir())
+	c.now = func() time.Time { return now }
+
+	return c, &now
+}
+
+func TestCache_ChartRoundTrip(t *testing.T) {
+	c, _ := newTestCache(t)
+	data := []byte("archive-data")
+
+	entry, err := c.PutChart("https://charts.example.com", "redis", "1.0.0", data)
+	require.NoError(t, err)
+	assert.Equal(t, Digest(data), entry.Digest)
+	assert.Equal(t, int64(len(data)), entry.Size)
+
+	got, e, ok := c.GetChart("https://charts.example.com", "redis", "1.0.0", "")
+	require.True(t, ok)
+	assert.Equal(t, data, got)
+	assert.Equal(t, "1.0.0", e.Version)
+
+	_, _, ok = c.GetChart("https://charts.example.com", "redis", "1.0.1", "")
+	assert.False(t, ok, "different version")
+
+	_, _, ok = c.GetChart("https://other.example.com", "redis", "1.0.0", "")
+	assert.False(t, ok, "different repository")
+}
+
+func TestCache_GetChart_DigestMismatch(t *testing.T) {
+	c, _ := newTestCache(t)
+	data := []byte("archive-data")
+
+	_, err := c.PutChart("repo", "redis", "1.0.0", data)
+	require.NoError(t, err)
+
+	_, _, ok := c.GetChart("repo", "redis", "1.0.0", Digest(data)[len("sha256:"):])
+	assert.True(t, ok, "unprefixed digest matches")
+
+	_, _, ok = c.GetChart("repo", "redis", "1.0.0", Digest([]byte("other")))
+	assert.False(t, ok, "digest from a republished index misses")
+}
+
+func TestCache_GetChart_CorruptedBlob(t *testing.T) {
+	c, _ := newTestCache(t)
+
+	entry, err := c.PutChart("repo", "redis", "1.0.0", []byte("archive-data"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(c.blobPath(entry.Digest), []byte("tampered"), 0o600))
+
+	_, _, ok := c.GetChart("repo", "redis", "1.0.0", "")
+	assert.False(t, ok)
+}
+
+func TestCache_ContentAddressedBlobsShared(t *testing.T) {
+	c, _ := newTestCache(t)
+	data := []byte("same-archive")
+
+	_, err := c.PutChart("https://a.example.com", "redis", "1.0.0", data)
+	require.NoError(t, err)
+	_, err = c.PutChart("https://b.example.com", "redis", "1.0.0", data)
+	require.NoError(t, err)
+
+	blobs, err := filepath.Glob(filepath.Join(c.Dir(), "blobs", "sha256", "*"))
+	require.NoError(t, err)
+	assert.Len(t, blobs, 1)
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "https://a.example.com", entries[0].Repository)
+	assert.Equal(t, []string{"1.0.0"}, c.Versions("https://b.example.com", "redis"))
+}
+
+func TestCache_IndexRoundTrip(t *testing.T) {
+	c, now := newTestCache(t)
+
+	_, _, ok := c.GetIndex("https://charts.example.com/index.yaml")
+	assert.False(t, ok)
+
+	require.NoError(t, c.PutIndex("https://charts.example.com/index.yaml", []byte("apiVersion: v1")))
+
+	data, fetchedAt, ok := c.GetIndex("https://charts.example.com/index.yaml")
+	require.True(t, ok)
+	assert.Equal(t, "apiVersion: v1", string(data))
+	assert.Equal(t, *now, fetchedAt)
+
+	indexes, err := c.ListIndexes()
+	require.NoError(t, err)
+	require.Len(t, indexes, 1)
+	assert.Equal(t, int64(len("apiVersion: v1")), indexes[0].Size)
+}
+
+func TestCache_Prune(t *testing.T) {
+	c, now := newTestCache(t)
+
+	_, err := c.PutChart("repo", "old", "1.0.0", []byte("old-archive"))
+	require.NoError(t, err)
+	require.NoError(t, c.PutIndex("https://old.example.com/index.yaml", []byte("old")))
+
+	*now = now.Add(48 * time.Hour)
+
+	_, err = c.PutChart("repo", "fresh", "1.0.0", []byte("fresh-archive"))
+	require.NoError(t, err)
+
+	dry, err := c.Prune(PruneOptions{OlderThan: 24 * time.Hour, DryRun: true})
+	require.NoError(t, err)
+	assert.Len(t, dry.Charts, 1)
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "dry run keeps everything")
+
+	result, err := c.Prune(PruneOptions{OlderThan: 24 * time.Hour})
+	require.NoError(t, err)
+	require.Len(t, result.Charts, 1)
+	assert.Equal(t, "old", result.Charts[0].Chart)
+	require.Len(t, result.Indexes, 1)
+	assert.Equal(t, int64(len("old-archive")+len("old")), result.Bytes)
+
+	_, _, ok := c.GetChart("repo", "fresh", "1.0.0", "")
+	assert.True(t, ok)
+
+	result, err = c.Prune(PruneOptions{All: true})
+	require.NoError(t, err)
+	assert.Len(t, result.Charts, 1)
+
+	entries, err = c.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestDefaultDir_XDG(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/tmp/xdg")
+	assert.Equal(t, filepath.Join("/tmp/xdg", "chart2kro"), DefaultDir())
+}
@@ -12,6 +12,18 @@ type DependencyMeta struct {
 	Repository string
 	Condition  string
 	Tags       []string
+	// Alias is the name the dependency is installed under, if set.
+	Alias string
+}
+
+// Key returns the name the dependency is installed under: its alias, or its
+// chart name. Helm uses it as the subchart's values key and chart name.
+func (d DependencyMeta) Key() string {
+	if d.Alias != "" {
+		return d.Alias
+	}
+
+	return d.Name
 }
 
 // ChartMeta wraps key metadata extracted from a loaded Helm chart.
@@ -49,6 +61,7 @@ func FromChart(ch *chart.Chart) *ChartMeta {
 			Repository: dep.Repository,
 			Condition:  dep.Condition,
 			Tags:       dep.Tags,
+			Alias:      dep.Alias,
 		})
 	}
 
@@ -61,6 +61,7 @@ func TestFromChart_WithDependencies(t *testing.T) {
 					Name:       "redis",
 					Version:    "17.0.0",
 					Repository: "https://charts.bitnami.com/bitnami",
+					Alias:      "cache",
 				},
 			},
 		},
@@ -75,6 +76,8 @@ func TestFromChart_WithDependencies(t *testing.T) {
 	assert.Equal(t, "postgresql.enabled", meta.Dependencies[0].Condition)
 	assert.Equal(t, []string{"database"}, meta.Dependencies[0].Tags)
 	assert.Equal(t, "redis", meta.Dependencies[1].Name)
+	assert.Equal(t, "postgresql", meta.Dependencies[0].Key())
+	assert.Equal(t, "cache", meta.Dependencies[1].Key())
 	assert.Equal(t, []string{"postgresql", "redis"}, meta.DependencyNames())
 }
 
@@ -6,6 +6,7 @@ import (
 	"io"
 	"log/slog"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -16,6 +17,50 @@ import (
 // HookAnnotation is the Helm hook annotation key.
 const HookAnnotation = "helm.sh/hook"
 
+// HookWeightAnnotation is the Helm hook weight annotation key. Hooks of the
+// same type run in ascending weight order.
+const HookWeightAnnotation = "helm.sh/hook-weight"
+
+// Mode selects how hook resources are handled.
+type Mode string
+
+// Mode values enumerate the supported hook handling modes.
+const (
+	// ModeDrop removes hook resources from the output.
+	ModeDrop Mode = "drop"
+	// ModeInclude keeps hook resources as regular resources with the hook
+	// annotations stripped.
+	ModeInclude Mode = "include"
+	// ModeOrdered keeps install and upgrade hooks as ordered RGD resources
+	// and drops hook types that cannot be represented in an RGD.
+	ModeOrdered Mode = "ordered"
+)
+
+// ParseMode parses a hook mode name. An empty string means ModeDrop.
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case "", ModeDrop:
+		return ModeDrop, nil
+	case ModeInclude, ModeOrdered:
+		return Mode(s), nil
+	default:
+		return "", fmt.Errorf("invalid hook mode %q (must be drop, include, or ordered)", s)
+	}
+}
+
+// Phase is when an ordered hook runs relative to the regular resources.
+type Phase int
+
+// Phase values for ordered hooks.
+const (
+	// PhaseNone marks hook types that cannot be ordered in an RGD.
+	PhaseNone Phase = iota
+	// PhasePre hooks must be ready before regular resources are created.
+	PhasePre
+	// PhasePost hooks are created once all regular resources are ready.
+	PhasePost
+)
+
 // HookType represents a Helm lifecycle hook type.
 type HookType string
 
@@ -38,6 +83,62 @@ func (h HookType) IsTestHook() bool {
 	return h == HookTest || h == HookTestSuccess
 }
 
+// Phase returns the ordering phase of the hook type. Install and upgrade
+// hooks map to PhasePre or PhasePost; delete, rollback, and test hooks have
+// no RGD equivalent and return PhaseNone.
+func (h HookType) Phase() Phase {
+	switch h {
+	case HookPreInstall, HookPreUpgrade:
+		return PhasePre
+	case HookPostInstall, HookPostUpgrade:
+		return PhasePost
+	default:
+		return PhaseNone
+	}
+}
+
+// ParseHookTypes parses the comma-separated value of the helm.sh/hook annotation.
+func ParseHookTypes(value string) []HookType {
+	var types []HookType
+
+	for _, h := range strings.Split(value, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			types = append(types, HookType(h))
+		}
+	}
+
+	return types
+}
+
+// ParseWeight parses the value of the helm.sh/hook-weight annotation.
+// Missing or invalid weights are 0, as in Helm.
+func ParseWeight(value string) int {
+	w, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0
+	}
+
+	return w
+}
+
+// HookPhase returns the phase of a hook with the given types. A hook that is
+// both a pre and a post hook is ordered as a pre hook.
+func HookPhase(types []HookType) Phase {
+	phase := PhaseNone
+
+	for _, ht := range types {
+		switch ht.Phase() {
+		case PhasePre:
+			return PhasePre
+		case PhasePost:
+			phase = PhasePost
+		case PhaseNone:
+		}
+	}
+
+	return phase
+}
+
 // Resource represents a parsed Kubernetes resource with hook metadata.
 type Resource struct {
 	Name       string
@@ -46,6 +147,14 @@ type Resource struct {
 	RawYAML    string
 	HookTypes  []HookType
 	IsHook     bool
+	Weight     int
+}
+
+// UnsupportedHook records hook types of a resource that cannot be
+// represented in an RGD.
+type UnsupportedHook struct {
+	Resource Resource
+	Types    []HookType
 }
 
 // FilterResult contains the outcome of hook filtering.
@@ -53,13 +162,51 @@ type FilterResult struct {
 	Resources     []Resource
 	DroppedHooks  []Resource
 	IncludedHooks []Resource
-	HookCount     int
+	// OrderedHooks are hooks kept as ordered RGD resources (ModeOrdered).
+	OrderedHooks []Resource
+	// UnsupportedHooks lists hook types ignored in ModeOrdered. Resources
+	// with only unsupported types are also in DroppedHooks.
+	UnsupportedHooks []UnsupportedHook
+	HookCount        int
+}
+
+// TestHooks returns the Helm test hooks (test and test-success) seen during
+// filtering, whether they were dropped or included as regular resources.
+func (r *FilterResult) TestHooks() []Resource {
+	var tests []Resource
+
+	for _, group := range [][]Resource{r.DroppedHooks, r.IncludedHooks} {
+		for _, res := range group {
+			for _, ht := range res.HookTypes {
+				if ht.IsTestHook() {
+					tests = append(tests, res)
+					break
+				}
+			}
+		}
+	}
+
+	return tests
 }
 
 // Filter processes rendered YAML documents and separates hooks from regular
 // resources. When includeHooks is true, hook resources are included as regular
 // resources with the helm.sh/hook annotation stripped.
 func Filter(yamlDocs []byte, includeHooks bool, logger *slog.Logger) (*FilterResult, error) {
+	mode := ModeDrop
+	if includeHooks {
+		mode = ModeInclude
+	}
+
+	return FilterMode(yamlDocs, mode, logger)
+}
+
+// FilterMode is like Filter but selects the hook handling mode. In
+// ModeOrdered, install and upgrade hooks are kept with their hook
+// annotations so that the transformation engine can order them (see
+// transform.ApplyHookOrdering); other hook types are dropped and reported
+// in UnsupportedHooks.
+func FilterMode(yamlDocs []byte, mode Mode, logger *slog.Logger) (*FilterResult, error) {
 	docs := splitYAMLDocuments(yamlDocs)
 	result := &FilterResult{}
 
@@ -78,7 +225,12 @@ func Filter(yamlDocs []byte, includeHooks bool, logger *slog.Logger) (*FilterRes
 
 		result.HookCount++
 
-		if includeHooks {
+		if mode == ModeOrdered {
+			filterOrdered(result, res, logger)
+			continue
+		}
+
+		if mode == ModeInclude {
 			res.RawYAML = stripHookAnnotations(res.RawYAML)
 			res.IsHook = false
 			result.IncludedHooks = append(result.IncludedHooks, res)
@@ -102,11 +254,61 @@ func Filter(yamlDocs []byte, includeHooks bool, logger *slog.Logger) (*FilterRes
 	return result, nil
 }
 
+// filterOrdered classifies a hook resource in ModeOrdered.
+func filterOrdered(result *FilterResult, res Resource, logger *slog.Logger) {
+	var unsupported []HookType
+
+	for _, ht := range res.HookTypes {
+		if ht.Phase() == PhaseNone {
+			unsupported = append(unsupported, ht)
+		}
+	}
+
+	ordered := HookPhase(res.HookTypes) != PhaseNone
+
+	if len(unsupported) > 0 {
+		result.UnsupportedHooks = append(result.UnsupportedHooks, UnsupportedHook{Resource: res, Types: unsupported})
+
+		msg := "dropping Helm hook resource that cannot be represented in an RGD"
+		if ordered {
+			msg = "ignoring Helm hook types that cannot be represented in an RGD"
+		}
+
+		logger.Warn(msg,
+			slog.String("hook", joinHookTypes(unsupported)),
+			slog.String("resource", fmt.Sprintf("%s/%s", res.Kind, res.Name)),
+		)
+	}
+
+	if !ordered {
+		result.DroppedHooks = append(result.DroppedHooks, res)
+		return
+	}
+
+	result.OrderedHooks = append(result.OrderedHooks, res)
+	result.Resources = append(result.Resources, res)
+}
+
+// joinHookTypes joins hook types with ", ".
+func joinHookTypes(types []HookType) string {
+	names := make([]string, 0, len(types))
+	for _, ht := range types {
+		names = append(names, string(ht))
+	}
+
+	return strings.Join(names, ", ")
+}
+
 // CombineResources produces a multi-document YAML from a FilterResult.
 func CombineResources(result *FilterResult) []byte {
+	return Combine(result.Resources)
+}
+
+// Combine joins the raw YAML of resources into a multi-document stream.
+func Combine(resources []Resource) []byte {
 	var sb strings.Builder
 
-	for i, res := range result.Resources {
+	for i, res := range resources {
 		if i > 0 {
 			sb.WriteString("---\n")
 		}
@@ -130,18 +332,29 @@ func PrintHookSummary(w io.Writer, result *FilterResult) {
 		_, _ = fmt.Fprintf(w, "  Dropped: %d\n", len(result.DroppedHooks))
 
 		for _, h := range result.DroppedHooks {
-			types := make([]string, 0, len(h.HookTypes))
-			for _, ht := range h.HookTypes {
-				types = append(types, string(ht))
-			}
-
-			_, _ = fmt.Fprintf(w, "    - %s/%s (%s)\n", h.Kind, h.Name, strings.Join(types, ", "))
+			_, _ = fmt.Fprintf(w, "    - %s/%s (%s)\n", h.Kind, h.Name, joinHookTypes(h.HookTypes))
 		}
 	}
 
 	if len(result.IncludedHooks) > 0 {
 		_, _ = fmt.Fprintf(w, "  Included as regular resources: %d\n", len(result.IncludedHooks))
 	}
+
+	if len(result.OrderedHooks) > 0 {
+		_, _ = fmt.Fprintf(w, "  Ordered as RGD resources: %d\n", len(result.OrderedHooks))
+
+		for _, h := range result.OrderedHooks {
+			_, _ = fmt.Fprintf(w, "    - %s/%s (%s, weight %d)\n", h.Kind, h.Name, joinHookTypes(h.HookTypes), h.Weight)
+		}
+	}
+
+	if len(result.UnsupportedHooks) > 0 {
+		_, _ = fmt.Fprintf(w, "  Unsupported hook types (not representable in an RGD): %d\n", len(result.UnsupportedHooks))
+
+		for _, u := range result.UnsupportedHooks {
+			_, _ = fmt.Fprintf(w, "    - %s/%s (%s)\n", u.Resource.Kind, u.Resource.Name, joinHookTypes(u.Types))
+		}
+	}
 }
 
 func splitYAMLDocuments(data []byte) []string {
@@ -178,9 +391,8 @@ func parseResource(doc string) Resource {
 	hookValue, hasHook := meta.Metadata.Annotations[HookAnnotation]
 	if hasHook && hookValue != "" {
 		res.IsHook = true
-		for _, h := range strings.Split(hookValue, ",") {
-			res.HookTypes = append(res.HookTypes, HookType(strings.TrimSpace(h)))
-		}
+		res.HookTypes = ParseHookTypes(hookValue)
+		res.Weight = ParseWeight(meta.Metadata.Annotations[HookWeightAnnotation])
 	}
 
 	return res
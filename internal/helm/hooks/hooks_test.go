@@ -216,6 +216,87 @@ func TestFilter_MultipleHookTypes(t *testing.T) {
 	assert.Equal(t, HookPostUpgrade, result.DroppedHooks[0].HookTypes[1])
 }
 
+func TestFilterMode_Ordered(t *testing.T) {
+	docs := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n" +
+		"---\napiVersion: batch/v1\nkind: Job\nmetadata:\n  name: migrate\n  annotations:\n    helm.sh/hook: pre-install,pre-rollback\n    helm.sh/hook-weight: \"-5\"\n" +
+		"---\napiVersion: batch/v1\nkind: Job\nmetadata:\n  name: cleanup\n  annotations:\n    helm.sh/hook: pre-delete\n" +
+		"---\napiVersion: v1\nkind: Pod\nmetadata:\n  name: test-connection\n  annotations:\n    helm.sh/hook: test")
+
+	var logBuf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logBuf, nil))
+
+	result, err := FilterMode(docs, ModeOrdered, logger)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.HookCount)
+	require.Len(t, result.Resources, 2)
+	assert.Contains(t, result.Resources[1].RawYAML, "helm.sh/hook: pre-install", "ordered hooks keep their annotations")
+
+	require.Len(t, result.OrderedHooks, 1)
+	assert.Equal(t, "migrate", result.OrderedHooks[0].Name)
+	assert.Equal(t, -5, result.OrderedHooks[0].Weight)
+
+	require.Len(t, result.DroppedHooks, 2)
+	assert.Equal(t, "cleanup", result.DroppedHooks[0].Name)
+	assert.Equal(t, "test-connection", result.DroppedHooks[1].Name)
+
+	require.Len(t, result.UnsupportedHooks, 3)
+	assert.Equal(t, []HookType{HookPreRollback}, result.UnsupportedHooks[0].Types)
+	assert.Equal(t, []HookType{HookPreDelete}, result.UnsupportedHooks[1].Types)
+	assert.Equal(t, []HookType{HookTest}, result.UnsupportedHooks[2].Types)
+
+	assert.Contains(t, logBuf.String(), "ignoring Helm hook types")
+	assert.Contains(t, logBuf.String(), "cannot be represented in an RGD")
+
+	var out bytes.Buffer
+	PrintHookSummary(&out, result)
+	assert.Contains(t, out.String(), "Ordered as RGD resources: 1")
+	assert.Contains(t, out.String(), "Job/migrate (pre-install, pre-rollback, weight -5)")
+	assert.Contains(t, out.String(), "Job/cleanup (pre-delete)")
+}
+
+func TestFilterResult_TestHooks(t *testing.T) {
+	docs := []byte("apiVersion: batch/v1\nkind: Job\nmetadata:\n  name: migrate\n  annotations:\n    helm.sh/hook: pre-install\n" +
+		"---\napiVersion: v1\nkind: Pod\nmetadata:\n  name: test-connection\n  annotations:\n    helm.sh/hook: test\n" +
+		"---\napiVersion: v1\nkind: Pod\nmetadata:\n  name: test-legacy\n  annotations:\n    helm.sh/hook: test-success")
+
+	for _, mode := range []Mode{ModeDrop, ModeInclude, ModeOrdered} {
+		t.Run(string(mode), func(t *testing.T) {
+			result, err := FilterMode(docs, mode, discardLogger())
+			require.NoError(t, err)
+
+			tests := result.TestHooks()
+			require.Len(t, tests, 2)
+			assert.Equal(t, "test-connection", tests[0].Name)
+			assert.Equal(t, "test-legacy", tests[1].Name)
+		})
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for in, want := range map[string]Mode{"": ModeDrop, "drop": ModeDrop, "include": ModeInclude, "ordered": ModeOrdered} {
+		got, err := ParseMode(in)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := ParseMode("always")
+	require.Error(t, err)
+}
+
+func TestHookPhase(t *testing.T) {
+	assert.Equal(t, PhasePre, HookPhase([]HookType{HookPostInstall, HookPreUpgrade}))
+	assert.Equal(t, PhasePost, HookPhase([]HookType{HookPostUpgrade, HookPreDelete}))
+	assert.Equal(t, PhaseNone, HookPhase([]HookType{HookTest, HookPostRollback}))
+}
+
+func TestParseWeight(t *testing.T) {
+	assert.Equal(t, -5, ParseWeight(`-5`))
+	assert.Equal(t, 3, ParseWeight(" 3 "))
+	assert.Equal(t, 0, ParseWeight("heavy"))
+	assert.Equal(t, 0, ParseWeight(""))
+}
+
 func TestFilter_EmptyDocument(t *testing.T) {
 	docs := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n---\n\n---\napiVersion: v1\nkind: Service\nmetadata:\n  name: svc")
 	result, err := Filter(docs, false, discardLogger())
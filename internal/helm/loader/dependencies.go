@@ -0,0 +1,264 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// ResolvedDependency describes a dependency fetched by a DependencyResolver.
+type ResolvedDependency struct {
+	// Name is the dependency chart name.
+	Name string
+	// Version is the fetched chart version.
+	Version string
+	// Repository is the repository declared in Chart.yaml.
+	Repository string
+	// Cached reports whether the archive was served from the cache.
+	Cached bool
+}
+
+// DependencyResolver fetches the Chart.yaml dependencies of a chart that are
+// not vendored in its charts/ directory, mirroring "helm dependency build".
+// Versions are pinned by Chart.lock when present.
+type DependencyResolver struct {
+	directory  *DirectoryLoader
+	archive    *ArchiveLoader
+	oci        *OCILoader
+	repository *RepositoryLoader
+}
+
+// NewDependencyResolver creates a DependencyResolver.
+func NewDependencyResolver() *DependencyResolver {
+	return &DependencyResolver{
+		directory:  NewDirectoryLoader(),
+		archive:    NewArchiveLoader(),
+		oci:        NewOCILoader(),
+		repository: NewRepositoryLoader(),
+	}
+}
+
+// Resolve fetches the missing dependencies of ch and attaches them as
+// subcharts. chartDir is the chart's directory on disk, used to resolve
+// file:// repositories; it is empty for packaged or remote charts.
+// Credentials in opts are not forwarded to dependency repositories. When
+// opts.Verify is set, every fetched dependency must carry a valid signature.
+func (r *DependencyResolver) Resolve(
+	ctx context.Context,
+	ch *chart.Chart,
+	chartDir string,
+	opts LoadOptions,
+) ([]ResolvedDependency, error) {
+	if ch.Metadata == nil || len(ch.Metadata.Dependencies) == 0 {
+		return nil, nil
+	}
+
+	vendored := make(map[string]bool, len(ch.Dependencies()))
+	for _, sub := range ch.Dependencies() {
+		if sub.Metadata != nil {
+			vendored[sub.Metadata.Name] = true
+		}
+	}
+
+	var missing []*chart.Dependency
+
+	for _, dep := range ch.Metadata.Dependencies {
+		if !vendored[dep.Name] {
+			missing = append(missing, dep)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	locked := make(map[string]*chart.Dependency)
+
+	if ch.Lock != nil {
+		digest, err := HashRequirements(ch.Metadata.Dependencies, ch.Lock.Dependencies)
+		if err != nil {
+			return nil, err
+		}
+
+		if digest != ch.Lock.Digest {
+			return nil, errors.New("chart lock file is out of sync with Chart.yaml (run 'helm dependency update')")
+		}
+
+		for _, d := range ch.Lock.Dependencies {
+			locked[d.Name] = d
+		}
+	}
+
+	depOpts := LoadOptions{
+		CaFile:         opts.CaFile,
+		CertFile:       opts.CertFile,
+		KeyFile:        opts.KeyFile,
+		MaxArchiveSize: opts.MaxArchiveSize,
+		PlainHTTP:      opts.PlainHTTP,
+		Cache:          opts.Cache,
+		Offline:        opts.Offline,
+		IndexTTL:       opts.IndexTTL,
+		Verify:         opts.Verify,
+		Keyring:        opts.Keyring,
+		PublicKey:      opts.PublicKey,
+	}
+
+	var resolved []ResolvedDependency
+
+	for _, dep := range missing {
+		if vendored[dep.Name] {
+			continue // declared twice
+		}
+
+		version := dep.Version
+		if lock, ok := locked[dep.Name]; ok {
+			version = lock.Version
+		}
+
+		sub, info, err := r.fetch(ctx, dep, version, chartDir, depOpts)
+		if err != nil {
+			return nil, fmt.Errorf("dependency %q: %w", dep.Name, err)
+		}
+
+		if sub.Metadata == nil || sub.Metadata.Name != dep.Name {
+			return nil, fmt.Errorf("dependency %q: fetched chart is named %q", dep.Name, sub.Name())
+		}
+
+		if _, ok := locked[dep.Name]; ok && sub.Metadata.Version != version {
+			return nil, fmt.Errorf("dependency %q: fetched version %s does not match Chart.lock version %s",
+				dep.Name, sub.Metadata.Version, version)
+		}
+
+		ch.AddDependency(sub)
+
+		vendored[dep.Name] = true
+
+		resolved = append(resolved, info)
+	}
+
+	return resolved, nil
+}
+
+// fetch loads a single dependency from its declared repository.
+func (r *DependencyResolver) fetch(
+	ctx context.Context,
+	dep *chart.Dependency,
+	version, chartDir string,
+	opts LoadOptions,
+) (*chart.Chart, ResolvedDependency, error) {
+	info := ResolvedDependency{Name: dep.Name, Version: version, Repository: dep.Repository}
+	repository := dep.Repository
+
+	if repository == "" {
+		return nil, info, errors.New("not vendored in charts/ and no repository declared")
+	}
+
+	if strings.HasPrefix(repository, "file://") {
+		sub, err := r.fetchLocal(ctx, strings.TrimPrefix(repository, "file://"), chartDir, opts)
+		if err != nil {
+			return nil, info, err
+		}
+
+		info.Version = sub.Metadata.Version
+
+		return sub, info, nil
+	}
+
+	fetchOpts := opts
+	fetchOpts.Version = version
+
+	var (
+		archive *Archive
+		err     error
+	)
+
+	switch {
+	case strings.HasPrefix(repository, "oci://"):
+		archive, err = r.oci.Fetch(ctx, strings.TrimSuffix(repository, "/")+"/"+dep.Name, fetchOpts)
+	case strings.HasPrefix(repository, "http://"), strings.HasPrefix(repository, "https://"):
+		fetchOpts.RepoURL = repository
+		archive, err = r.repository.Fetch(ctx, dep.Name, fetchOpts)
+	case strings.HasPrefix(repository, "@"), strings.HasPrefix(repository, "alias:"):
+		name := strings.TrimPrefix(strings.TrimPrefix(repository, "@"), "alias:")
+		archive, err = r.repository.Fetch(ctx, name+"/"+dep.Name, fetchOpts)
+	default:
+		return nil, info, fmt.Errorf("unsupported repository %q", repository)
+	}
+
+	if err != nil {
+		return nil, info, err
+	}
+
+	sub, err := r.archive.LoadFromReader(bytes.NewReader(archive.Data), opts)
+	if err != nil {
+		return nil, info, err
+	}
+
+	info.Version = archive.Version
+	info.Cached = archive.Cached
+
+	return sub, info, nil
+}
+
+// fetchLocal loads a file:// dependency relative to chartDir and resolves its
+// own missing dependencies. When opts.Verify is set, only archives with a
+// valid provenance file next to them are accepted.
+func (r *DependencyResolver) fetchLocal(
+	ctx context.Context,
+	path, chartDir string,
+	opts LoadOptions,
+) (*chart.Chart, error) {
+	if !filepath.IsAbs(path) {
+		if chartDir == "" {
+			return nil, fmt.Errorf("relative file:// repository %q requires a chart directory", path)
+		}
+
+		path = filepath.Join(chartDir, path)
+	}
+
+	if strings.HasSuffix(path, ".tgz") || strings.HasSuffix(path, ".tar.gz") {
+		if opts.Verify {
+			if _, err := verifyProvenance(path, path+".prov", opts.Keyring); err != nil {
+				return nil, err
+			}
+		}
+
+		return r.archive.Load(ctx, path, opts)
+	}
+
+	if opts.Verify {
+		return nil, fmt.Errorf("cannot verify dependency directory %q: only packaged charts are signed", path)
+	}
+
+	sub, err := r.directory.Load(ctx, path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := r.Resolve(ctx, sub, path, opts); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// HashRequirements computes the Chart.lock digest of the Chart.yaml
+// requirements and the locked dependencies, using the same algorithm as Helm.
+func HashRequirements(req, lock []*chart.Dependency) (string, error) {
+	data, err := json.Marshal([2][]*chart.Dependency{req, lock})
+	if err != nil {
+		return "", fmt.Errorf("hashing dependencies: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,431 @@
+package loader
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/registry"
+	"sigs.k8s.io/yaml"
+
+	"github.com/hupe1980/chart2kro/internal/helm/cache"
+)
+
+// writeParentChart writes a chart declaring deps to a temp directory and
+// returns its path. When lockVersions is non-nil, a Chart.lock pinning the
+// given versions is written with a valid digest.
+func writeParentChart(t *testing.T, deps []*chart.Dependency, lockVersions map[string]string) string {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "parent")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "templates"), 0o750))
+
+	meta := &chart.Metadata{APIVersion: "v2", Name: "parent", Version: "1.0.0", Dependencies: deps}
+	data, err := yaml.Marshal(meta)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Chart.yaml"), data, 0o600))
+
+	if lockVersions != nil {
+		var locked []*chart.Dependency
+		for _, d := range deps {
+			locked = append(locked, &chart.Dependency{Name: d.Name, Repository: d.Repository, Version: lockVersions[d.Name]})
+		}
+
+		digest, err := HashRequirements(deps, locked)
+		require.NoError(t, err)
+
+		lock, err := yaml.Marshal(&chart.Lock{Dependencies: locked, Digest: digest})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "Chart.lock"), lock, 0o600))
+	}
+
+	return dir
+}
+
+// newCountingRepoServer serves a repository index with several versions of a
+// chart, including index digests, and counts archive downloads.
+func newCountingRepoServer(t *testing.T, name string, versions ...string) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var downloads int32
+
+	mux := http.NewServeMux()
+
+	var entries strings.Builder
+
+	for _, v := range versions {
+		archive := buildTestArchiveBytes(t, name, v)
+		sum := sha256.Sum256(archive)
+		file := fmt.Sprintf("%s-%s.tgz", name, v)
+
+		fmt.Fprintf(&entries, "  - name: %s\n    version: %s\n    apiVersion: v2\n    digest: %s\n    urls:\n    - %s\n",
+			name, v, hex.EncodeToString(sum[:]), file)
+
+		mux.HandleFunc("/"+file, func(w http.ResponseWriter, _ *http.Request) {
+			atomic.AddInt32(&downloads, 1)
+			_, _ = w.Write(archive)
+		})
+	}
+
+	index := fmt.Sprintf("apiVersion: v1\nentries:\n  %s:\n%s", name, entries.String())
+
+	mux.HandleFunc("/index.yaml", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(index))
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv, &downloads
+}
+
+// newTestOCIRegistry starts a minimal OCI distribution API stand-in serving
+// the given versions of a chart under <host>/charts/<name>.
+func newTestOCIRegistry(t *testing.T, name string, versions ...string) *httptest.Server {
+	t.Helper()
+
+	return newSignedTestOCIRegistry(t, name, nil, versions...)
+}
+
+// newSignedTestOCIRegistry is like newTestOCIRegistry but, when sign is
+// non-nil, also serves a cosign signature artifact for every chart manifest
+// using the payload and base64 signature returned by sign.
+func newSignedTestOCIRegistry(
+	t *testing.T, name string, sign func(manifestDigest string) ([]byte, string), versions ...string,
+) *httptest.Server {
+	t.Helper()
+
+	blobs := make(map[string][]byte)
+	manifests := make(map[string][]byte)
+
+	addBlob := func(data []byte) string {
+		sum := sha256.Sum256(data)
+		digest := "sha256:" + hex.EncodeToString(sum[:])
+		blobs[digest] = data
+
+		return digest
+	}
+
+	for _, v := range versions {
+		config := []byte(fmt.Sprintf(`{"apiVersion":"v2","name":%q,"version":%q}`, name, v))
+		archive := buildTestArchiveBytes(t, name, v)
+
+		manifest, err := json.Marshal(map[string]interface{}{
+			"schemaVersion": 2,
+			"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+			"config": map[string]interface{}{
+				"mediaType": registry.ConfigMediaType, "digest": addBlob(config), "size": len(config),
+			},
+			"layers": []map[string]interface{}{{
+				"mediaType": registry.ChartLayerMediaType, "digest": addBlob(archive), "size": len(archive),
+			}},
+		})
+		require.NoError(t, err)
+
+		manifestDigest := addBlob(manifest)
+		manifests[v] = manifest
+		manifests[manifestDigest] = manifest
+
+		if sign != nil {
+			payload, signature := sign(manifestDigest)
+			empty := []byte("{}")
+
+			sigManifest, err := json.Marshal(map[string]interface{}{
+				"schemaVersion": 2,
+				"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+				"config": map[string]interface{}{
+					"mediaType": "application/vnd.oci.image.config.v1+json", "digest": addBlob(empty), "size": len(empty),
+				},
+				"layers": []map[string]interface{}{{
+					"mediaType":   cosignPayloadMediaType,
+					"digest":      addBlob(payload),
+					"size":        len(payload),
+					"annotations": map[string]string{cosignSignatureAnnotation: signature},
+				}},
+			})
+			require.NoError(t, err)
+
+			manifests[strings.Replace(manifestDigest, ":", "-", 1)+".sig"] = sigManifest
+			manifests[addBlob(sigManifest)] = sigManifest
+		}
+	}
+
+	repoPath := "/v2/charts/" + name + "/"
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.TrimPrefix(r.URL.Path, repoPath)
+
+		switch {
+		case r.URL.Path == "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case rest == "tags/list":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": "charts/" + name, "tags": versions})
+		case strings.HasPrefix(rest, "manifests/"):
+			data, ok := manifests[strings.TrimPrefix(rest, "manifests/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+
+			sum := sha256.Sum256(data)
+			w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+			w.Header().Set("Docker-Content-Digest", "sha256:"+hex.EncodeToString(sum[:]))
+			w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+
+			if r.Method != http.MethodHead {
+				_, _ = w.Write(data)
+			}
+		case strings.HasPrefix(rest, "blobs/"):
+			data, ok := blobs[strings.TrimPrefix(rest, "blobs/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+
+			w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+			w.Header().Set("Docker-Content-Digest", strings.TrimPrefix(rest, "blobs/"))
+
+			if r.Method != http.MethodHead {
+				_, _ = w.Write(data)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(handler))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestDependencyResolver_FileRepository(t *testing.T) {
+	parent := writeParentChart(t, []*chart.Dependency{
+		{Name: "child", Version: "0.1.0", Repository: "file://../child"},
+	}, nil)
+
+	child := filepath.Join(filepath.Dir(parent), "child")
+	require.NoError(t, os.MkdirAll(child, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(child, "Chart.yaml"),
+		[]byte("apiVersion: v2\nname: child\nversion: 0.1.0\n"), 0o600))
+
+	ch, err := NewDirectoryLoader().Load(context.Background(), parent, LoadOptions{})
+	require.NoError(t, err)
+
+	resolved, err := NewDependencyResolver().Resolve(context.Background(), ch, parent, LoadOptions{})
+	require.NoError(t, err)
+	require.Len(t, resolved, 1)
+	assert.Equal(t, "0.1.0", resolved[0].Version)
+	require.Len(t, ch.Dependencies(), 1)
+	assert.Equal(t, "child", ch.Dependencies()[0].Name())
+}
+
+func TestDependencyResolver_HTTPRepositoryWithLockAndCache(t *testing.T) {
+	srv, downloads := newCountingRepoServer(t, "redis", "1.0.0", "1.1.0")
+	deps := []*chart.Dependency{{Name: "redis", Version: "^1.0.0", Repository: srv.URL}}
+	parent := writeParentChart(t, deps, map[string]string{"redis": "1.0.0"})
+	opts := LoadOptions{ResolveDependencies: true, Cache: cache.New(t.TempDir())}
+
+	for i := 0; i < 2; i++ {
+		ch, err := NewMultiLoader().Load(context.Background(), parent, opts)
+		require.NoError(t, err)
+		require.Len(t, ch.Dependencies(), 1)
+		assert.Equal(t, "1.0.0", ch.Dependencies()[0].Metadata.Version, "Chart.lock pins the version")
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(downloads), "second load is served from the cache")
+}
+
+func TestDependencyResolver_HTTPRepositoryConstraint(t *testing.T) {
+	srv, _ := newCountingRepoServer(t, "redis", "1.0.0", "1.1.0", "2.0.0")
+	parent := writeParentChart(t, []*chart.Dependency{
+		{Name: "redis", Version: "~1.0 || ^1.1.0", Repository: srv.URL},
+	}, nil)
+
+	ch, err := NewDirectoryLoader().Load(context.Background(), parent, LoadOptions{})
+	require.NoError(t, err)
+
+	resolved, err := NewDependencyResolver().Resolve(context.Background(), ch, parent, LoadOptions{})
+	require.NoError(t, err)
+	require.Len(t, resolved, 1)
+	assert.Equal(t, "1.1.0", resolved[0].Version)
+	assert.False(t, resolved[0].Cached)
+}
+
+func TestDependencyResolver_OCIRepository(t *testing.T) {
+	srv := newTestOCIRegistry(t, "redis", "18.1.0", "19.0.0")
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	parent := writeParentChart(t, []*chart.Dependency{
+		{Name: "redis", Version: "18.x", Repository: "oci://" + host + "/charts"},
+	}, nil)
+
+	ch, err := NewMultiLoader().Load(context.Background(), parent, LoadOptions{
+		ResolveDependencies: true,
+		PlainHTTP:           true,
+	})
+	require.NoError(t, err)
+	require.Len(t, ch.Dependencies(), 1)
+	assert.Equal(t, "18.1.0", ch.Dependencies()[0].Metadata.Version)
+}
+
+func TestOCILoader_CacheAndOffline(t *testing.T) {
+	srv := newTestOCIRegistry(t, "redis", "18.1.0", "19.0.0")
+	ref := "oci://" + strings.TrimPrefix(srv.URL, "http://") + "/charts/redis"
+	c := cache.New(t.TempDir())
+
+	archive, err := NewOCILoader().Fetch(context.Background(), ref, LoadOptions{
+		Version: "^18.0.0", PlainHTTP: true, Cache: c,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "18.1.0", archive.Version)
+	assert.False(t, archive.Cached)
+
+	srv.Close()
+
+	offline := LoadOptions{Version: "18.x", Cache: c, Offline: true}
+
+	archive, err = NewOCILoader().Fetch(context.Background(), ref, offline)
+	require.NoError(t, err)
+	assert.Equal(t, "18.1.0", archive.Version)
+	assert.True(t, archive.Cached)
+
+	_, err = NewOCILoader().Fetch(context.Background(), ref+":19.0.0", offline)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--offline")
+}
+
+func TestDependencyResolver_LockOutOfSync(t *testing.T) {
+	srv, downloads := newCountingRepoServer(t, "redis", "1.0.0")
+	parent := writeParentChart(t, []*chart.Dependency{
+		{Name: "redis", Version: "1.0.0", Repository: srv.URL},
+	}, map[string]string{"redis": "1.0.0"})
+
+	// Change the requirement without updating Chart.lock.
+	chartYAML := filepath.Join(parent, "Chart.yaml")
+	data, err := os.ReadFile(chartYAML) //nolint:gosec // test file
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(chartYAML, []byte(strings.Replace(string(data), "version: 1.0.0", "version: ^1.0.0", 1)), 0o600))
+
+	_, err = NewMultiLoader().Load(context.Background(), parent, LoadOptions{ResolveDependencies: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "out of sync")
+	assert.Zero(t, atomic.LoadInt32(downloads))
+}
+
+func TestDependencyResolver_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		dep     *chart.Dependency
+		wantErr string
+	}{
+		{"no repository", &chart.Dependency{Name: "x", Version: "1.0.0"}, "no repository declared"},
+		{"unsupported", &chart.Dependency{Name: "x", Version: "1.0.0", Repository: "git://example.com/x"}, "unsupported repository"},
+		{"missing file", &chart.Dependency{Name: "x", Version: "1.0.0", Repository: "file://../missing"}, "chart directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := writeParentChart(t, []*chart.Dependency{tt.dep}, nil)
+
+			_, err := NewMultiLoader().Load(context.Background(), parent, LoadOptions{ResolveDependencies: true})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestDependencyResolver_Verify(t *testing.T) {
+	entity, keyring := newTestSigner(t, "Jane")
+	opts := LoadOptions{Verify: true, Keyring: keyring}
+
+	t.Run("unsigned repository chart", func(t *testing.T) {
+		srv, _ := newCountingRepoServer(t, "redis", "1.0.0")
+		parent := writeParentChart(t, []*chart.Dependency{{Name: "redis", Version: "1.0.0", Repository: srv.URL}}, nil)
+
+		ch, err := NewDirectoryLoader().Load(context.Background(), parent, LoadOptions{})
+		require.NoError(t, err)
+
+		_, err = NewDependencyResolver().Resolve(context.Background(), ch, parent, opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "fetching provenance file")
+		assert.Empty(t, ch.Dependencies())
+	})
+
+	t.Run("chart directory", func(t *testing.T) {
+		parent := writeParentChart(t, []*chart.Dependency{{Name: "child", Version: "0.1.0", Repository: "file://../child"}}, nil)
+
+		ch, err := NewDirectoryLoader().Load(context.Background(), parent, LoadOptions{})
+		require.NoError(t, err)
+
+		_, err = NewDependencyResolver().Resolve(context.Background(), ch, parent, opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "only packaged charts are signed")
+	})
+
+	t.Run("signed archive", func(t *testing.T) {
+		parent := writeParentChart(t, []*chart.Dependency{{Name: "child", Version: "0.1.0", Repository: "file://../child-0.1.0.tgz"}}, nil)
+		writeSignedArchive(t, filepath.Dir(parent), entity, "child", "0.1.0")
+
+		ch, err := NewDirectoryLoader().Load(context.Background(), parent, LoadOptions{})
+		require.NoError(t, err)
+
+		_, err = NewDependencyResolver().Resolve(context.Background(), ch, parent, opts)
+		require.NoError(t, err)
+		require.Len(t, ch.Dependencies(), 1)
+	})
+}
+
+func TestDependencyResolver_VendoredDependenciesUntouched(t *testing.T) {
+	// The fixture's Chart.lock digest is a placeholder; it must not be checked
+	// when nothing needs fetching.
+	ch, err := NewMultiLoader().Load(context.Background(), "../../../testdata/charts/with-subchart",
+		LoadOptions{ResolveDependencies: true})
+	require.NoError(t, err)
+	assert.Len(t, ch.Dependencies(), 1)
+}
+
+func TestVerifyArchiveDigest(t *testing.T) {
+	data := []byte("archive")
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+
+	assert.NoError(t, verifyArchiveDigest(data, ""))
+	assert.NoError(t, verifyArchiveDigest(data, digest))
+	assert.NoError(t, verifyArchiveDigest(data, "sha256:"+digest))
+	assert.ErrorContains(t, verifyArchiveDigest([]byte("tampered"), digest), "digest mismatch")
+}
+
+func TestSplitOCITag(t *testing.T) {
+	tests := []struct {
+		ref, repo, tag string
+	}{
+		{"localhost:5000/charts/redis:1.0.0", "localhost:5000/charts/redis", "1.0.0"},
+		{"localhost:5000/charts/redis", "localhost:5000/charts/redis", ""},
+		{"ghcr.io/org/redis@sha256:abc", "ghcr.io/org/redis", ""},
+	}
+
+	for _, tt := range tests {
+		repo, tag := splitOCITag(tt.ref)
+		assert.Equal(t, tt.repo, repo, tt.ref)
+		assert.Equal(t, tt.tag, tag, tt.ref)
+	}
+}
+
+func TestHasOCITag(t *testing.T) {
+	assert.True(t, hasOCITag("localhost:5000/charts/redis:1.0.0"))
+	assert.True(t, hasOCITag("ghcr.io/org/redis@sha256:abc"))
+	assert.False(t, hasOCITag("localhost:5000/charts/redis"))
+}
@@ -8,8 +8,12 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"helm.sh/helm/v3/pkg/chart"
+
+	"github.com/hupe1980/chart2kro/internal/helm/cache"
 )
 
 // SourceType identifies the origin of a Helm chart reference.
@@ -65,6 +69,28 @@ type LoadOptions struct {
 	// MaxArchiveSize is the maximum allowed archive size in bytes.
 	// Zero means use the default (100 MB).
 	MaxArchiveSize int64
+	// PlainHTTP uses insecure HTTP connections for OCI registries.
+	PlainHTTP bool
+	// ResolveDependencies fetches declared dependencies that are not
+	// vendored in charts/ and attaches them to the loaded chart.
+	ResolveDependencies bool
+	// Cache stores repository indexes and chart archives across runs.
+	// Nil disables caching.
+	Cache *cache.Cache
+	// Offline refuses network access and serves charts from Cache only.
+	Offline bool
+	// IndexTTL is how long cached repository indexes are reused before
+	// being refreshed. Zero means cache.DefaultIndexTTL.
+	IndexTTL time.Duration
+	// Verify requires a valid chart signature: a Helm .prov file checked
+	// against Keyring, or for OCI charts with PublicKey set, a cosign
+	// signature. Verified charts are never served from the cache.
+	Verify bool
+	// Keyring is the PGP keyring used to verify .prov files.
+	Keyring string
+	// PublicKey is the PEM public key used to verify cosign signatures of
+	// OCI charts.
+	PublicKey string
 }
 
 // DefaultMaxArchiveSize is 100 MB.
@@ -80,6 +106,46 @@ func (o *LoadOptions) effectiveMaxArchiveSize() int64 {
 	return DefaultMaxArchiveSize
 }
 
+// effectiveIndexTTL returns the index TTL, falling back to
+// cache.DefaultIndexTTL when not configured.
+func (o *LoadOptions) effectiveIndexTTL() time.Duration {
+	if o.IndexTTL > 0 {
+		return o.IndexTTL
+	}
+
+	return cache.DefaultIndexTTL
+}
+
+// Archive is a fetched chart archive.
+type Archive struct {
+	// Data is the raw .tgz archive.
+	Data []byte
+	// Version is the resolved chart version.
+	Version string
+	// Cached reports whether the archive was served from the cache.
+	Cached bool
+	// Verification is the verified signature when LoadOptions.Verify is set.
+	Verification *Verification
+}
+
+// errOffline reports that a resource is unavailable in offline mode.
+func errOffline(what string) error {
+	return fmt.Errorf("%s is not cached and --offline forbids network access", what)
+}
+
+// errVerifyOffline reports that verification was requested in offline mode.
+func errVerifyOffline(what string) error {
+	return fmt.Errorf("%s cannot be verified with --offline: signatures are fetched from the source", what)
+}
+
+// isExactVersion reports whether v is an exact semantic version rather than
+// a constraint.
+func isExactVersion(v string) bool {
+	_, err := semver.StrictNewVersion(v)
+
+	return err == nil
+}
+
 // Loader loads a Helm chart from a given reference.
 type Loader interface {
 	// Load resolves ref according to opts and returns the in-memory chart.
@@ -1,10 +1,14 @@
 package loader
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 
 	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/provenance"
+
+	"github.com/hupe1980/chart2kro/internal/helm/cache"
 )
 
 // MultiLoader implements the Loader interface by auto-detecting the source
@@ -14,6 +18,7 @@ type MultiLoader struct {
 	archive    *ArchiveLoader
 	oci        *OCILoader
 	repository *RepositoryLoader
+	deps       *DependencyResolver
 }
 
 // NewMultiLoader creates a MultiLoader with all source-type loaders initialised.
@@ -23,11 +28,63 @@ func NewMultiLoader() *MultiLoader {
 		archive:    NewArchiveLoader(),
 		oci:        NewOCILoader(),
 		repository: NewRepositoryLoader(),
+		deps:       NewDependencyResolver(),
 	}
 }
 
+// LoadResult is a loaded chart together with what is known about its origin.
+type LoadResult struct {
+	// Chart is the loaded chart.
+	Chart *chart.Chart
+	// Source is the detected source type.
+	Source SourceType
+	// Digest is the "sha256:" digest of the chart archive. It is empty for
+	// chart directories.
+	Digest string
+	// Verification is the verified chart signature when LoadOptions.Verify
+	// is set.
+	Verification *Verification
+}
+
 // Load auto-detects the chart source type and delegates to the appropriate loader.
+// When opts.ResolveDependencies is set, missing dependencies are fetched and
+// attached to the loaded chart.
 func (m *MultiLoader) Load(ctx context.Context, ref string, opts LoadOptions) (*chart.Chart, error) {
+	res, err := m.LoadChart(ctx, ref, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Chart, nil
+}
+
+// LoadChart is like Load but also reports the archive digest and, when
+// opts.Verify is set, the verified signature. Verification failures are
+// returned as errors.
+func (m *MultiLoader) LoadChart(ctx context.Context, ref string, opts LoadOptions) (*LoadResult, error) {
+	res, err := m.load(ctx, ref, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.ResolveDependencies {
+		return res, nil
+	}
+
+	chartDir := ""
+	if res.Source == SourceDirectory {
+		chartDir = ref
+	}
+
+	if _, err := m.deps.Resolve(ctx, res.Chart, chartDir, opts); err != nil {
+		return nil, fmt.Errorf("resolving dependencies: %w", err)
+	}
+
+	return res, nil
+}
+
+// load delegates to the loader for the detected source type.
+func (m *MultiLoader) load(ctx context.Context, ref string, opts LoadOptions) (*LoadResult, error) {
 	st, err := Detect(ref)
 	if err != nil {
 		// When detection fails but a repo URL is explicitly provided,
@@ -39,18 +96,58 @@ func (m *MultiLoader) Load(ctx context.Context, ref string, opts LoadOptions) (*
 		}
 	}
 
+	res := &LoadResult{Source: st}
+
+	var archive *Archive
+
 	switch st {
 	case SourceUnknown:
 		return nil, fmt.Errorf("unsupported chart source type: %s", st)
 	case SourceDirectory:
-		return m.directory.Load(ctx, ref, opts)
+		if opts.Verify {
+			return nil, fmt.Errorf("cannot verify chart directory %q: only packaged charts are signed", ref)
+		}
+
+		res.Chart, err = m.directory.Load(ctx, ref, opts)
+
+		return res, err
 	case SourceArchive:
-		return m.archive.Load(ctx, ref, opts)
+		if opts.Verify {
+			if res.Verification, err = verifyProvenance(ref, ref+".prov", opts.Keyring); err != nil {
+				return nil, err
+			}
+		}
+
+		if res.Chart, err = m.archive.Load(ctx, ref, opts); err != nil {
+			return nil, err
+		}
+
+		sum, err := provenance.DigestFile(ref)
+		if err != nil {
+			return nil, fmt.Errorf("digesting archive %q: %w", ref, err)
+		}
+
+		res.Digest = "sha256:" + sum
+
+		return res, nil
 	case SourceOCI:
-		return m.oci.Load(ctx, ref, opts)
+		archive, err = m.oci.Fetch(ctx, ref, opts)
 	case SourceRepository:
-		return m.repository.Load(ctx, ref, opts)
+		archive, err = m.repository.Fetch(ctx, ref, opts)
 	default:
 		return nil, fmt.Errorf("unsupported chart source type: %s", st)
 	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Chart, err = m.archive.LoadFromReader(bytes.NewReader(archive.Data), opts); err != nil {
+		return nil, err
+	}
+
+	res.Digest = cache.Digest(archive.Data)
+	res.Verification = archive.Verification
+
+	return res, nil
 }
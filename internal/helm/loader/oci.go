@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/Masterminds/semver/v3"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/registry"
 )
@@ -21,15 +22,145 @@ func NewOCILoader() *OCILoader {
 }
 
 // Load pulls a chart from an OCI registry and returns the in-memory chart.
-func (l *OCILoader) Load(_ context.Context, ref string, opts LoadOptions) (*chart.Chart, error) {
+func (l *OCILoader) Load(ctx context.Context, ref string, opts LoadOptions) (*chart.Chart, error) {
+	archive, err := l.Fetch(ctx, ref, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.archive.LoadFromReader(bytes.NewReader(archive.Data), opts)
+}
+
+// Fetch pulls a chart from an OCI registry and returns the raw chart archive.
+// When ref carries no tag and opts.Version is set, the highest tag satisfying
+// the constraint is pulled. With opts.Cache set, tagged versions are served
+// from the cache; offline, constraints are resolved against cached versions.
+// With opts.Verify set, the chart is always pulled and its cosign signature
+// (when opts.PublicKey is set) or provenance layer is verified.
+func (l *OCILoader) Fetch(ctx context.Context, ref string, opts LoadOptions) (*Archive, error) {
 	if !strings.HasPrefix(ref, "oci://") {
 		return nil, fmt.Errorf("OCI reference must start with oci://, got %q", ref)
 	}
 
+	// Strip the oci:// prefix for the pull API.
+	pullRef := strings.TrimPrefix(ref, "oci://")
+
+	repository, tag := splitOCITag(pullRef)
+	chartName := repository[strings.LastIndex(repository, "/")+1:]
+	cacheRepo := "oci://" + repository
+
+	version := tag
+	if version == "" && !hasOCITag(pullRef) {
+		version = opts.Version
+	}
+
+	if opts.Verify && opts.Offline {
+		return nil, errVerifyOffline(fmt.Sprintf("chart %q", ref))
+	}
+
+	if opts.Cache != nil && !opts.Verify && isExactVersion(version) {
+		if data, entry, ok := opts.Cache.GetChart(cacheRepo, chartName, version, ""); ok {
+			return &Archive{Data: data, Version: entry.Version, Cached: true}, nil
+		}
+	}
+
+	if opts.Offline {
+		if opts.Cache != nil && !hasOCITag(pullRef) && !isExactVersion(version) {
+			constraint := version
+			if constraint == "" {
+				constraint = "*"
+			}
+
+			if cached := highestSatisfying(opts.Cache.Versions(cacheRepo, chartName), constraint); cached != "" {
+				if data, entry, ok := opts.Cache.GetChart(cacheRepo, chartName, cached, ""); ok {
+					return &Archive{Data: data, Version: entry.Version, Cached: true}, nil
+				}
+			}
+		}
+
+		return nil, errOffline(fmt.Sprintf("chart %q", ref))
+	}
+
+	client, err := newRegistryClient(ref, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasOCITag(pullRef) && opts.Version != "" {
+		tag, err := resolveOCITag(client, pullRef, opts.Version)
+		if err != nil {
+			return nil, fmt.Errorf("resolving version of %q: %w", ref, err)
+		}
+
+		pullRef += ":" + tag
+	}
+
+	pullOpts := []registry.PullOption{
+		registry.PullOptWithChart(true),
+	}
+
+	verifyProv := opts.Verify && opts.PublicKey == ""
+	if verifyProv {
+		pullOpts = append(pullOpts, registry.PullOptWithProv(true))
+	}
+
+	result, err := client.Pull(pullRef, pullOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("pulling chart from %q: %w", ref, err)
+	}
+
+	if result.Chart == nil || result.Chart.Data == nil {
+		return nil, fmt.Errorf("no chart data in OCI pull result for %q", ref)
+	}
+
+	archive := &Archive{Data: result.Chart.Data}
+	if result.Chart.Meta != nil {
+		archive.Version = result.Chart.Meta.Version
+	}
+
+	switch {
+	case verifyProv:
+		if result.Prov == nil || result.Prov.Data == nil {
+			return nil, fmt.Errorf("verifying %q: no provenance layer in OCI artifact", ref)
+		}
+
+		fileName := fmt.Sprintf("%s-%s.tgz", chartName, archive.Version)
+
+		archive.Verification, err = verifyProvenanceData(fileName, archive.Data, result.Prov.Data, opts.Keyring)
+		if err != nil {
+			return nil, err
+		}
+	case opts.Verify:
+		if result.Manifest == nil {
+			return nil, fmt.Errorf("verifying %q: no manifest in OCI pull result", ref)
+		}
+
+		archive.Verification, err = verifyCosign(ctx, ref, result.Manifest.Digest, opts.PublicKey, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Cache != nil && archive.Version != "" {
+		if _, err := opts.Cache.PutChart(cacheRepo, chartName, archive.Version, archive.Data); err != nil {
+			return nil, fmt.Errorf("caching chart archive: %w", err)
+		}
+	}
+
+	return archive, nil
+}
+
+// newRegistryClient creates a registry client for ref, configured for TLS,
+// plain HTTP, and basic authentication according to opts.
+func newRegistryClient(ref string, opts LoadOptions) (*registry.Client, error) {
 	registryOpts := []registry.ClientOption{
 		registry.ClientOptEnableCache(true),
 	}
 
+	if opts.PlainHTTP {
+		registryOpts = append(registryOpts, registry.ClientOptPlainHTTP())
+	}
+
 	if opts.CaFile != "" || opts.CertFile != "" || opts.KeyFile != "" {
 		httpClient, err := httpClientForOpts(opts)
 		if err != nil {
@@ -49,30 +180,107 @@ func (l *OCILoader) Load(_ context.Context, ref string, opts LoadOptions) (*char
 	// Authenticate if credentials are provided.
 	if opts.Username != "" && opts.Password != "" {
 		host := extractHost(ref)
-		if err := client.Login(host,
+
+		loginOpts := []registry.LoginOption{
 			registry.LoginOptBasicAuth(opts.Username, opts.Password),
-		); err != nil {
+		}
+		if opts.PlainHTTP {
+			loginOpts = append(loginOpts, registry.LoginOptPlainText(true))
+		}
+
+		if err := client.Login(host, loginOpts...); err != nil {
 			return nil, fmt.Errorf("authenticating to OCI registry %q: %w", host, err)
 		}
 	}
 
-	// Strip the oci:// prefix for the pull API.
-	pullRef := strings.TrimPrefix(ref, "oci://")
+	return client, nil
+}
 
-	pullOpts := []registry.PullOption{
-		registry.PullOptWithChart(true),
+// splitOCITag splits an OCI reference (without oci://) into repository and
+// tag. Digest references and untagged references return an empty tag.
+func splitOCITag(ref string) (repository, tag string) {
+	repository = ref
+	if idx := strings.Index(repository, "@"); idx >= 0 {
+		repository = repository[:idx]
 	}
 
-	result, err := client.Pull(pullRef, pullOpts...)
+	slash := strings.LastIndex(repository, "/")
+	if idx := strings.LastIndex(repository, ":"); idx > slash {
+		if !strings.Contains(ref, "@") {
+			tag = repository[idx+1:]
+		}
+
+		repository = repository[:idx]
+	}
+
+	return repository, tag
+}
+
+// highestSatisfying returns the highest version in versions that satisfies
+// constraint, or "" if none does.
+func highestSatisfying(versions []string, constraint string) string {
+	c, err := semver.NewConstraint(constraint)
 	if err != nil {
-		return nil, fmt.Errorf("pulling chart from %q: %w", ref, err)
+		return ""
 	}
 
-	if result.Chart == nil || result.Chart.Data == nil {
-		return nil, fmt.Errorf("no chart data in OCI pull result for %q", ref)
+	var best *semver.Version
+
+	for _, v := range versions {
+		sv, err := semver.NewVersion(v)
+		if err != nil || !c.Check(sv) {
+			continue
+		}
+
+		if best == nil || sv.GreaterThan(best) {
+			best = sv
+		}
+	}
+
+	if best == nil {
+		return ""
+	}
+
+	return best.Original()
+}
+
+// hasOCITag reports whether an OCI reference (without oci://) carries a tag
+// or digest after its final path segment.
+func hasOCITag(ref string) bool {
+	last := ref
+	if idx := strings.LastIndex(ref, "/"); idx >= 0 {
+		last = ref[idx+1:]
+	}
+
+	return strings.ContainsAny(last, ":@")
+}
+
+// resolveOCITag returns the highest semver tag of ref that satisfies the
+// version constraint. Exact versions are returned unchanged.
+func resolveOCITag(client *registry.Client, ref, constraint string) (string, error) {
+	if v, err := semver.StrictNewVersion(constraint); err == nil {
+		return v.Original(), nil
+	}
+
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+
+	tags, err := client.Tags(ref)
+	if err != nil {
+		return "", fmt.Errorf("listing tags: %w", err)
+	}
+
+	// Tags are sorted in descending semver order.
+	for _, tag := range tags {
+		v, err := semver.NewVersion(tag)
+		if err == nil && c.Check(v) {
+			return tag, nil
+		}
 	}
 
-	return l.archive.LoadFromReader(bytes.NewReader(result.Chart.Data), opts)
+	return "", fmt.Errorf("no tag satisfies version constraint %q", constraint)
 }
 
 // extractHost extracts the registry host from an oci:// reference.
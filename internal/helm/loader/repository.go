@@ -1,13 +1,17 @@
 package loader
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -72,6 +76,22 @@ func httpClientForOpts(opts LoadOptions) (*http.Client, error) {
 
 // Load resolves a repo/chart style reference and downloads the chart archive.
 func (l *RepositoryLoader) Load(ctx context.Context, ref string, opts LoadOptions) (*chart.Chart, error) {
+	archive, err := l.Fetch(ctx, ref, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.archive.LoadFromReader(bytes.NewReader(archive.Data), opts)
+}
+
+// Fetch resolves a repo/chart style reference and returns the raw chart
+// archive. When the repository index records a digest for the version, the
+// archive is verified against it. With opts.Cache set, indexes are reused
+// within opts.IndexTTL and archives are served from the cache; an exact
+// version that is already cached requires no network access at all.
+// With opts.Verify set, the chart's .prov file is downloaded alongside the
+// archive and verified against opts.Keyring.
+func (l *RepositoryLoader) Fetch(ctx context.Context, ref string, opts LoadOptions) (*Archive, error) {
 	// Extract repo name and chart name from "repo/chart" reference.
 	repoName, chartName := splitRepoRef(ref)
 
@@ -106,13 +126,122 @@ func (l *RepositoryLoader) Load(ctx context.Context, ref string, opts LoadOption
 		}
 	}
 
+	if opts.Verify && opts.Offline {
+		return nil, errVerifyOffline(fmt.Sprintf("chart %q", ref))
+	}
+
+	if opts.Cache != nil && !opts.Verify && isExactVersion(opts.Version) {
+		if data, entry, ok := opts.Cache.GetChart(opts.RepoURL, chartName, opts.Version, ""); ok {
+			return &Archive{Data: data, Version: entry.Version, Cached: true}, nil
+		}
+	}
+
 	httpClient, err := httpClientForOpts(opts)
 	if err != nil {
 		return nil, fmt.Errorf("configuring HTTP client: %w", err)
 	}
 
+	index, err := fetchIndex(ctx, httpClient, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cv, err := resolveChartVersion(index, chartName, opts.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Cache != nil && !opts.Verify {
+		if data, _, ok := opts.Cache.GetChart(opts.RepoURL, chartName, cv.Version, cv.Digest); ok {
+			return &Archive{Data: data, Version: cv.Version, Cached: true}, nil
+		}
+	}
+
+	if opts.Offline {
+		return nil, errOffline(fmt.Sprintf("chart %q version %q", chartName, cv.Version))
+	}
+
+	if len(cv.URLs) == 0 {
+		return nil, fmt.Errorf("chart %q version %q has no download URLs", chartName, cv.Version)
+	}
+
+	chartURL := cv.URLs[0]
+	if !strings.HasPrefix(chartURL, "http://") && !strings.HasPrefix(chartURL, "https://") {
+		chartURL = strings.TrimSuffix(opts.RepoURL, "/") + "/" + chartURL
+	}
+
+	data, err := downloadArchive(ctx, httpClient, chartURL, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyArchiveDigest(data, cv.Digest); err != nil {
+		return nil, fmt.Errorf("chart %q version %q: %w", chartName, cv.Version, err)
+	}
+
+	archive := &Archive{Data: data, Version: cv.Version}
+
+	if opts.Verify {
+		prov, err := downloadArchive(ctx, httpClient, chartURL+".prov", opts)
+		if err != nil {
+			return nil, fmt.Errorf("fetching provenance file: %w", err)
+		}
+
+		archive.Verification, err = verifyProvenanceData(path.Base(chartURL), data, prov, opts.Keyring)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Cache != nil {
+		if _, err := opts.Cache.PutChart(opts.RepoURL, chartName, cv.Version, data); err != nil {
+			return nil, fmt.Errorf("caching chart archive: %w", err)
+		}
+	}
+
+	return archive, nil
+}
+
+// FetchIndex returns the sorted repository index of opts.RepoURL.
+func FetchIndex(ctx context.Context, opts LoadOptions) (*repo.IndexFile, error) {
+	httpClient, err := httpClientForOpts(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return fetchIndex(ctx, httpClient, opts)
+}
+
+// LoadIndexFile reads a sorted repository index from a local file.
+func LoadIndexFile(path string) (*repo.IndexFile, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // user-specified index path
+	if err != nil {
+		return nil, fmt.Errorf("reading repository index: %w", err)
+	}
+
+	return loadIndex(data)
+}
+
+// fetchIndex returns the parsed repository index for opts.RepoURL, reusing a
+// cached copy while it is younger than the index TTL (or at any age when
+// offline).
+func fetchIndex(ctx context.Context, httpClient *http.Client, opts LoadOptions) (*repo.IndexFile, error) {
 	indexURL := strings.TrimSuffix(opts.RepoURL, "/") + "/index.yaml"
 
+	if opts.Cache != nil {
+		data, fetchedAt, ok := opts.Cache.GetIndex(indexURL)
+		if ok && (opts.Offline || time.Since(fetchedAt) < opts.effectiveIndexTTL()) {
+			index, err := loadIndex(data)
+			if err == nil {
+				return index, nil
+			}
+		}
+	}
+
+	if opts.Offline {
+		return nil, errOffline(fmt.Sprintf("repository index %q", indexURL))
+	}
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, indexURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating index request: %w", err)
@@ -145,21 +274,13 @@ func (l *RepositoryLoader) Load(ctx context.Context, ref string, opts LoadOption
 		return nil, fmt.Errorf("parsing repository index: %w", err)
 	}
 
-	cv, err := resolveChartVersion(index, chartName, opts.Version)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(cv.URLs) == 0 {
-		return nil, fmt.Errorf("chart %q version %q has no download URLs", chartName, cv.Version)
-	}
-
-	chartURL := cv.URLs[0]
-	if !strings.HasPrefix(chartURL, "http://") && !strings.HasPrefix(chartURL, "https://") {
-		chartURL = strings.TrimSuffix(opts.RepoURL, "/") + "/" + chartURL
+	if opts.Cache != nil {
+		if err := opts.Cache.PutIndex(indexURL, indexData); err != nil {
+			return nil, fmt.Errorf("caching repository index: %w", err)
+		}
 	}
 
-	return l.downloadChart(ctx, httpClient, chartURL, opts)
+	return index, nil
 }
 
 // loadIndex parses a Helm repository index from raw YAML bytes.
@@ -205,8 +326,9 @@ func resolveChartVersion(idx *repo.IndexFile, name, version string) (*repo.Chart
 	return cv, nil
 }
 
-// downloadChart fetches the chart archive from the given URL.
-func (l *RepositoryLoader) downloadChart(ctx context.Context, httpClient *http.Client, url string, opts LoadOptions) (*chart.Chart, error) {
+// downloadArchive fetches the chart archive from the given URL, enforcing the
+// configured maximum archive size.
+func downloadArchive(ctx context.Context, httpClient *http.Client, url string, opts LoadOptions) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating chart download request: %w", err)
@@ -226,8 +348,35 @@ func (l *RepositoryLoader) downloadChart(ctx context.Context, httpClient *http.C
 		return nil, fmt.Errorf("chart download from %q returned status %d", url, resp.StatusCode)
 	}
 
-	// Stream directly to the archive loader which applies its own size limit.
-	return l.archive.LoadFromReader(resp.Body, opts)
+	maxSize := opts.effectiveMaxArchiveSize()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading chart archive from %q: %w", url, err)
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("archive exceeds maximum size of %d bytes", maxSize)
+	}
+
+	return data, nil
+}
+
+// verifyArchiveDigest checks data against a hex sha256 digest (optionally
+// prefixed with "sha256:"). An empty digest is not verified.
+func verifyArchiveDigest(data []byte, digest string) error {
+	if digest == "" {
+		return nil
+	}
+
+	sum := sha256.Sum256(data)
+	actual := hex.EncodeToString(sum[:])
+
+	if want := strings.TrimPrefix(digest, "sha256:"); !strings.EqualFold(want, actual) {
+		return fmt.Errorf("archive digest mismatch: expected sha256:%s, got sha256:%s", want, actual)
+	}
+
+	return nil
 }
 
 // splitRepoRef splits a "repo/chart" reference into repo name and chart name.
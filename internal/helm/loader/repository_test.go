@@ -10,11 +10,14 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/chart2kro/internal/helm/cache"
 )
 
 // newTestRepoServer starts a test HTTP server that serves a Helm repository
@@ -631,3 +634,58 @@ func TestRepositoryLoader_Load_NoRepoURL_NoConfig(t *testing.T) {
 	assert.Contains(t, err.Error(), "repository \"myrepo\" not configured")
 	assert.Contains(t, err.Error(), "helm repo add myrepo")
 }
+
+func TestRepositoryLoader_Fetch_CacheAndOffline(t *testing.T) {
+	srv, downloads := newCountingRepoServer(t, "cached-chart", "1.0.0", "1.1.0")
+	c := cache.New(t.TempDir())
+	loader := NewRepositoryLoader()
+
+	archive, err := loader.Fetch(context.Background(), "repo/cached-chart", LoadOptions{RepoURL: srv.URL, Cache: c})
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", archive.Version)
+	assert.False(t, archive.Cached)
+
+	srv.Close()
+
+	// The index is still fresh and the archive is cached.
+	archive, err = loader.Fetch(context.Background(), "repo/cached-chart", LoadOptions{RepoURL: srv.URL, Cache: c})
+	require.NoError(t, err)
+	assert.True(t, archive.Cached)
+	assert.Equal(t, int32(1), atomic.LoadInt32(downloads))
+
+	// Offline mode ignores the index TTL.
+	archive, err = loader.Fetch(context.Background(), "repo/cached-chart", LoadOptions{
+		RepoURL: srv.URL, Cache: c, Offline: true, IndexTTL: time.Nanosecond,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", archive.Version)
+
+	_, err = loader.Fetch(context.Background(), "repo/cached-chart", LoadOptions{
+		RepoURL: srv.URL, Cache: c, Offline: true, Version: "1.0.0",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not cached")
+}
+
+func TestRepositoryLoader_Fetch_ExpiredIndexRefreshed(t *testing.T) {
+	srv, _ := newCountingRepoServer(t, "ttl-chart", "1.0.0")
+	c := cache.New(t.TempDir())
+
+	_, err := NewRepositoryLoader().Fetch(context.Background(), "repo/ttl-chart", LoadOptions{RepoURL: srv.URL, Cache: c})
+	require.NoError(t, err)
+
+	srv.Close()
+
+	_, err = NewRepositoryLoader().Fetch(context.Background(), "repo/ttl-chart", LoadOptions{
+		RepoURL: srv.URL, Cache: c, IndexTTL: time.Nanosecond,
+	})
+	require.Error(t, err, "an expired index must be refreshed from the network")
+}
+
+func TestRepositoryLoader_Fetch_OfflineWithoutCache(t *testing.T) {
+	_, err := NewRepositoryLoader().Fetch(context.Background(), "repo/chart", LoadOptions{
+		RepoURL: "http://127.0.0.1:1", Offline: true,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--offline")
+}
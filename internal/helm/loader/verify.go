@@ -0,0 +1,329 @@
+package loader
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"helm.sh/helm/v3/pkg/provenance"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// Verification methods recorded in Verification.Method.
+const (
+	// VerifyMethodProvenance is a Helm .prov file checked against a keyring.
+	VerifyMethodProvenance = "helm-provenance"
+	// VerifyMethodCosign is a cosign signature checked against a public key.
+	VerifyMethodCosign = "cosign"
+)
+
+const (
+	// cosignSignatureAnnotation holds the base64 signature on a cosign layer.
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// cosignPayloadMediaType is the media type of cosign simple-signing layers.
+	cosignPayloadMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	// maxSignatureSize bounds signature manifests and payloads.
+	maxSignatureSize = 4 << 20
+)
+
+// Verification describes a successfully verified chart signature.
+type Verification struct {
+	// Method is VerifyMethodProvenance or VerifyMethodCosign.
+	Method string `json:"method"`
+	// Identity is the signer: the PGP user ID for provenance files, or the
+	// public key fingerprint for cosign signatures.
+	Identity string `json:"identity"`
+	// KeyFingerprint is the hex fingerprint of the verifying key.
+	KeyFingerprint string `json:"keyFingerprint"`
+	// Digest is the signed digest: the archive sha256 for provenance files,
+	// or the OCI manifest digest for cosign signatures.
+	Digest string `json:"digest"`
+}
+
+// DefaultKeyring returns Helm's default public keyring path,
+// $GNUPGHOME/pubring.gpg or ~/.gnupg/pubring.gpg.
+func DefaultKeyring() string {
+	if home := os.Getenv("GNUPGHOME"); home != "" {
+		return filepath.Join(home, "pubring.gpg")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".gnupg", "pubring.gpg")
+}
+
+// verifyProvenance checks the provenance file sigPath for the archive at
+// chartPath against the keyring.
+func verifyProvenance(chartPath, sigPath, keyring string) (*Verification, error) {
+	if keyring == "" {
+		return nil, fmt.Errorf("verifying %q: no keyring configured", filepath.Base(chartPath))
+	}
+
+	if _, err := os.Stat(sigPath); err != nil {
+		return nil, fmt.Errorf("verifying %q: provenance file: %w", filepath.Base(chartPath), err)
+	}
+
+	sig, err := provenance.NewFromKeyring(keyring, "")
+	if err != nil {
+		return nil, fmt.Errorf("loading keyring %q: %w", keyring, err)
+	}
+
+	ver, err := sig.Verify(chartPath, sigPath)
+	if err != nil {
+		return nil, fmt.Errorf("verifying %q: %w", filepath.Base(chartPath), err)
+	}
+
+	v := &Verification{
+		Method: VerifyMethodProvenance,
+		Digest: ver.FileHash,
+	}
+
+	if ver.SignedBy != nil {
+		if ver.SignedBy.PrimaryKey != nil {
+			v.KeyFingerprint = hex.EncodeToString(ver.SignedBy.PrimaryKey.Fingerprint[:])
+		}
+
+		names := make([]string, 0, len(ver.SignedBy.Identities))
+		for name := range ver.SignedBy.Identities {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		if len(names) > 0 {
+			v.Identity = names[0]
+		} else {
+			v.Identity = v.KeyFingerprint
+		}
+	}
+
+	return v, nil
+}
+
+// verifyProvenanceData writes the archive and provenance file to a
+// temporary directory, naming the archive fileName so that it matches the
+// entry in the provenance file, and verifies them against the keyring.
+func verifyProvenanceData(fileName string, data, prov []byte, keyring string) (*Verification, error) {
+	tmpDir, err := os.MkdirTemp("", "chart2kro-verify-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp dir for verification: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	chartPath := filepath.Join(tmpDir, filepath.Base(fileName))
+	if err := os.WriteFile(chartPath, data, 0o600); err != nil {
+		return nil, fmt.Errorf("writing chart archive: %w", err)
+	}
+
+	sigPath := chartPath + ".prov"
+	if err := os.WriteFile(sigPath, prov, 0o600); err != nil {
+		return nil, fmt.Errorf("writing provenance file: %w", err)
+	}
+
+	return verifyProvenance(chartPath, sigPath, keyring)
+}
+
+// cosignPayload is the subset of the cosign simple-signing payload that is
+// checked during verification.
+type cosignPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// verifyCosign fetches the cosign signature stored for manifestDigest in the
+// repository of ref (oci://host/repo[:tag]) and verifies it against the PEM
+// public key at keyPath. The key is read locally; only the signature
+// artifact is fetched from the registry.
+func verifyCosign(ctx context.Context, ref, manifestDigest, keyPath string, opts LoadOptions) (*Verification, error) {
+	if keyPath == "" {
+		return nil, fmt.Errorf("verifying %q: no public key configured", ref)
+	}
+
+	pub, fingerprint, err := loadPublicKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	repository, _ := splitOCITag(strings.TrimPrefix(ref, "oci://"))
+
+	repo, err := remote.NewRepository(repository)
+	if err != nil {
+		return nil, fmt.Errorf("parsing OCI repository %q: %w", repository, err)
+	}
+
+	repo.PlainHTTP = opts.PlainHTTP
+
+	httpClient, err := httpClientForOpts(opts)
+	if err != nil {
+		return nil, fmt.Errorf("configuring OCI HTTP client: %w", err)
+	}
+
+	client := &auth.Client{Client: httpClient, Cache: auth.NewCache()}
+	if opts.Username != "" && opts.Password != "" {
+		client.Credential = auth.StaticCredential(repo.Reference.Registry, auth.Credential{
+			Username: opts.Username,
+			Password: opts.Password,
+		})
+	}
+
+	repo.Client = client
+
+	sigTag := strings.Replace(manifestDigest, ":", "-", 1) + ".sig"
+
+	desc, err := repo.Resolve(ctx, sigTag)
+	if err != nil {
+		return nil, fmt.Errorf("verifying %q: fetching signature %q: %w", ref, sigTag, err)
+	}
+
+	if desc.Size > maxSignatureSize {
+		return nil, fmt.Errorf("verifying %q: signature manifest exceeds %d bytes", ref, maxSignatureSize)
+	}
+
+	manifestData, err := content.FetchAll(ctx, repo, desc)
+	if err != nil {
+		return nil, fmt.Errorf("verifying %q: fetching signature manifest: %w", ref, err)
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, fmt.Errorf("verifying %q: parsing signature manifest: %w", ref, err)
+	}
+
+	var errs []error
+
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != cosignPayloadMediaType {
+			continue
+		}
+
+		sig, ok := layer.Annotations[cosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+
+		if layer.Size > maxSignatureSize {
+			errs = append(errs, fmt.Errorf("payload %s exceeds %d bytes", layer.Digest, maxSignatureSize))
+			continue
+		}
+
+		payload, err := content.FetchAll(ctx, repo.Blobs(), layer)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("fetching payload %s: %w", layer.Digest, err))
+			continue
+		}
+
+		if err := verifyCosignPayload(pub, payload, sig, manifestDigest); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		return &Verification{
+			Method:         VerifyMethodCosign,
+			Identity:       "sha256:" + fingerprint,
+			KeyFingerprint: fingerprint,
+			Digest:         manifestDigest,
+		}, nil
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("verifying %q: no cosign signatures found", ref)
+	}
+
+	return nil, fmt.Errorf("verifying %q: no valid signature: %w", ref, errors.Join(errs...))
+}
+
+// verifyCosignPayload checks the base64 signature over payload with pub and
+// that the payload refers to manifestDigest.
+func verifyCosignPayload(pub crypto.PublicKey, payload []byte, signature, manifestDigest string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("decoding signature: %w", err)
+	}
+
+	if err := verifySignature(pub, payload, sig); err != nil {
+		return err
+	}
+
+	var p cosignPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("parsing signature payload: %w", err)
+	}
+
+	if got := p.Critical.Image.DockerManifestDigest; got != manifestDigest {
+		return fmt.Errorf("signature is for manifest %q, not %q", got, manifestDigest)
+	}
+
+	return nil
+}
+
+// verifySignature checks sig over payload for ECDSA, RSA, and Ed25519 keys.
+func verifySignature(pub crypto.PublicKey, payload, sig []byte) error {
+	digest := sha256.Sum256(payload)
+
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], sig) {
+			return errors.New("invalid ECDSA signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			if rsa.VerifyPSS(key, crypto.SHA256, digest[:], sig, nil) != nil {
+				return fmt.Errorf("invalid RSA signature: %w", err)
+			}
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, payload, sig) {
+			return errors.New("invalid Ed25519 signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+
+	return nil
+}
+
+// loadPublicKey reads a PEM-encoded PKIX public key and returns it with the
+// hex sha256 fingerprint of its DER encoding.
+func loadPublicKey(path string) (crypto.PublicKey, string, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // user-provided key path
+	if err != nil {
+		return nil, "", fmt.Errorf("reading public key %q: %w", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, "", fmt.Errorf("public key %q is not PEM encoded", path)
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("parsing public key %q: %w", path, err)
+	}
+
+	sum := sha256.Sum256(block.Bytes)
+
+	return pub, hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,256 @@
+package loader
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp" //nolint:staticcheck // Helm provenance files use x/crypto/openpgp
+	"helm.sh/helm/v3/pkg/provenance"
+
+	"github.com/hupe1980/chart2kro/internal/helm/cache"
+)
+
+// newTestSigner creates a PGP entity and writes its public keyring to a temp
+// file, returning both.
+func newTestSigner(t *testing.T, name string) (*openpgp.Entity, string) {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity(name, "", strings.ToLower(name)+"@example.com", nil)
+	require.NoError(t, err)
+
+	keyring := filepath.Join(t.TempDir(), "pubring.gpg")
+
+	f, err := os.Create(keyring) //nolint:gosec // test path
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(f))
+	require.NoError(t, f.Close())
+
+	return entity, keyring
+}
+
+// writeSignedArchive writes a chart archive and its provenance file, signed
+// by entity, to dir and returns the archive path.
+func writeSignedArchive(t *testing.T, dir string, entity *openpgp.Entity, name, version string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.tgz", name, version))
+	require.NoError(t, os.WriteFile(path, buildTestArchiveBytes(t, name, version), 0o600))
+
+	sig, err := (&provenance.Signatory{Entity: entity}).ClearSign(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path+".prov", []byte(sig), 0o600))
+
+	return path
+}
+
+func TestMultiLoader_VerifyArchive(t *testing.T) {
+	entity, keyring := newTestSigner(t, "Jane")
+	path := writeSignedArchive(t, t.TempDir(), entity, "mychart", "1.0.0")
+
+	res, err := NewMultiLoader().LoadChart(context.Background(), path, LoadOptions{Verify: true, Keyring: keyring})
+	require.NoError(t, err)
+	require.NotNil(t, res.Verification)
+
+	assert.Equal(t, VerifyMethodProvenance, res.Verification.Method)
+	assert.Equal(t, "Jane <jane@example.com>", res.Verification.Identity)
+	assert.Equal(t, hex.EncodeToString(entity.PrimaryKey.Fingerprint[:]), res.Verification.KeyFingerprint)
+	assert.Equal(t, res.Digest, res.Verification.Digest)
+	assert.Equal(t, "mychart", res.Chart.Metadata.Name)
+}
+
+func TestMultiLoader_VerifyArchiveFailures(t *testing.T) {
+	entity, keyring := newTestSigner(t, "Jane")
+	_, otherKeyring := newTestSigner(t, "Mallory")
+	dir := t.TempDir()
+	path := writeSignedArchive(t, dir, entity, "mychart", "1.0.0")
+
+	t.Run("unknown signer", func(t *testing.T) {
+		_, err := NewMultiLoader().LoadChart(context.Background(), path, LoadOptions{Verify: true, Keyring: otherKeyring})
+		require.Error(t, err)
+	})
+
+	t.Run("tampered archive", func(t *testing.T) {
+		tampered := filepath.Join(t.TempDir(), filepath.Base(path))
+		require.NoError(t, os.WriteFile(tampered, buildTestArchiveBytes(t, "mychart", "1.0.1"), 0o600))
+
+		prov, err := os.ReadFile(path + ".prov") //nolint:gosec // test path
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(tampered+".prov", prov, 0o600))
+
+		_, err = NewMultiLoader().LoadChart(context.Background(), tampered, LoadOptions{Verify: true, Keyring: keyring})
+		require.ErrorContains(t, err, "sha256 sum does not match")
+	})
+
+	t.Run("missing provenance file", func(t *testing.T) {
+		unsigned := filepath.Join(t.TempDir(), "mychart-1.0.0.tgz")
+		require.NoError(t, os.WriteFile(unsigned, buildTestArchiveBytes(t, "mychart", "1.0.0"), 0o600))
+
+		_, err := NewMultiLoader().LoadChart(context.Background(), unsigned, LoadOptions{Verify: true, Keyring: otherKeyring})
+		require.ErrorContains(t, err, "provenance file")
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		_, err := NewMultiLoader().LoadChart(context.Background(), t.TempDir(), LoadOptions{Verify: true})
+		require.ErrorContains(t, err, "cannot verify chart directory")
+	})
+}
+
+func TestRepositoryLoader_Verify(t *testing.T) {
+	entity, keyring := newTestSigner(t, "Jane")
+	path := writeSignedArchive(t, t.TempDir(), entity, "mychart", "1.0.0")
+
+	archive, err := os.ReadFile(path) //nolint:gosec // test path
+	require.NoError(t, err)
+
+	prov, err := os.ReadFile(path + ".prov") //nolint:gosec // test path
+	require.NoError(t, err)
+
+	serveProv := true
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/index.yaml", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("apiVersion: v1\nentries:\n  mychart:\n  - name: mychart\n    version: 1.0.0\n" +
+			"    apiVersion: v2\n    urls:\n    - charts/mychart-1.0.0.tgz\n"))
+	})
+	mux.HandleFunc("/charts/mychart-1.0.0.tgz", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(archive)
+	})
+	mux.HandleFunc("/charts/mychart-1.0.0.tgz.prov", func(w http.ResponseWriter, r *http.Request) {
+		if !serveProv {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write(prov)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	chartCache := cache.New(t.TempDir())
+	opts := LoadOptions{RepoURL: srv.URL, Version: "1.0.0", Verify: true, Keyring: keyring, Cache: chartCache}
+
+	fetched, err := NewRepositoryLoader().Fetch(context.Background(), "myrepo/mychart", opts)
+	require.NoError(t, err)
+	require.NotNil(t, fetched.Verification)
+	assert.Equal(t, "Jane <jane@example.com>", fetched.Verification.Identity)
+
+	// A cached archive is not trusted: the signature is still required.
+	serveProv = false
+
+	_, err = NewRepositoryLoader().Fetch(context.Background(), "myrepo/mychart", opts)
+	require.ErrorContains(t, err, "provenance file")
+
+	opts.Offline = true
+
+	_, err = NewRepositoryLoader().Fetch(context.Background(), "myrepo/mychart", opts)
+	require.ErrorContains(t, err, "--offline")
+}
+
+// cosignSigner returns a sign function for newSignedTestOCIRegistry that
+// signs cosign payloads with key, and the path of the PEM public key.
+func cosignSigner(t *testing.T, key *ecdsa.PrivateKey) (func(string) ([]byte, string), string) {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	keyPath := filepath.Join(t.TempDir(), "cosign.pub")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	sign := func(manifestDigest string) ([]byte, string) {
+		payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"charts/redis"},`+
+			`"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, manifestDigest))
+		sum := sha256.Sum256(payload)
+
+		sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+		require.NoError(t, err)
+
+		return payload, base64.StdEncoding.EncodeToString(sig)
+	}
+
+	return sign, keyPath
+}
+
+func TestOCILoader_VerifyCosign(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	sign, keyPath := cosignSigner(t, key)
+	srv := newSignedTestOCIRegistry(t, "redis", sign, "18.1.0")
+	ref := "oci://" + strings.TrimPrefix(srv.URL, "http://") + "/charts/redis:18.1.0"
+
+	archive, err := NewOCILoader().Fetch(context.Background(), ref, LoadOptions{
+		PlainHTTP: true,
+		Verify:    true,
+		PublicKey: keyPath,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, archive.Verification)
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	fingerprint := sha256.Sum256(der)
+
+	assert.Equal(t, VerifyMethodCosign, archive.Verification.Method)
+	assert.Equal(t, hex.EncodeToString(fingerprint[:]), archive.Verification.KeyFingerprint)
+	assert.Equal(t, "sha256:"+archive.Verification.KeyFingerprint, archive.Verification.Identity)
+	assert.True(t, strings.HasPrefix(archive.Verification.Digest, "sha256:"))
+}
+
+func TestOCILoader_VerifyCosignFailures(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	sign, _ := cosignSigner(t, key)
+	_, otherKeyPath := cosignSigner(t, otherKey)
+
+	t.Run("wrong key", func(t *testing.T) {
+		srv := newSignedTestOCIRegistry(t, "redis", sign, "18.1.0")
+		ref := "oci://" + strings.TrimPrefix(srv.URL, "http://") + "/charts/redis:18.1.0"
+
+		_, err := NewOCILoader().Fetch(context.Background(), ref, LoadOptions{PlainHTTP: true, Verify: true, PublicKey: otherKeyPath})
+		require.ErrorContains(t, err, "invalid ECDSA signature")
+	})
+
+	t.Run("signature for another manifest", func(t *testing.T) {
+		replay := func(string) ([]byte, string) {
+			return sign("sha256:" + strings.Repeat("0", 64))
+		}
+
+		_, keyPath := cosignSigner(t, key)
+		srv := newSignedTestOCIRegistry(t, "redis", replay, "18.1.0")
+		ref := "oci://" + strings.TrimPrefix(srv.URL, "http://") + "/charts/redis:18.1.0"
+
+		_, err := NewOCILoader().Fetch(context.Background(), ref, LoadOptions{PlainHTTP: true, Verify: true, PublicKey: keyPath})
+		require.ErrorContains(t, err, "signature is for manifest")
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		srv := newTestOCIRegistry(t, "redis", "18.1.0")
+		ref := "oci://" + strings.TrimPrefix(srv.URL, "http://") + "/charts/redis:18.1.0"
+
+		_, err := NewOCILoader().Fetch(context.Background(), ref, LoadOptions{PlainHTTP: true, Verify: true, PublicKey: otherKeyPath})
+		require.ErrorContains(t, err, "fetching signature")
+	})
+}
@@ -0,0 +1,154 @@
+package postrender
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// RenderedFile is the name under which the rendered manifests are added to
+// the overlay's resources.
+const RenderedFile = "chart2kro-rendered.yaml"
+
+// overlayRoot is the overlay directory in the in-memory file system.
+const overlayRoot = "/overlay"
+
+// Kustomize applies a Kustomize overlay in-process. The rendered manifests
+// are prepended to the overlay's resources, so patches (patches,
+// patchesStrategicMerge, patchesJson6902) and transformers such as
+// commonLabels apply to them. Files the kustomization refers to must be
+// inside the overlay directory.
+type Kustomize struct {
+	dir string
+}
+
+// NewKustomize returns a Kustomize post-renderer for the overlay in dir.
+func NewKustomize(dir string) (*Kustomize, error) {
+	if _, err := kustomizationFile(dir); err != nil {
+		return nil, err
+	}
+
+	return &Kustomize{dir: dir}, nil
+}
+
+// Run builds the overlay with the rendered manifests as a resource.
+func (k *Kustomize) Run(ctx context.Context, manifests []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("kustomize overlay: %w", err)
+	}
+
+	memFS := filesys.MakeFsInMemory()
+
+	if err := copyDir(k.dir, memFS); err != nil {
+		return nil, fmt.Errorf("loading kustomize overlay %s: %w", k.dir, err)
+	}
+
+	if err := injectRendered(k.dir, memFS, manifests); err != nil {
+		return nil, fmt.Errorf("loading kustomize overlay %s: %w", k.dir, err)
+	}
+
+	// The sort order comes from the kustomization (see injectRendered).
+	opts := krusty.MakeDefaultOptions()
+	opts.Reorder = krusty.ReorderOptionUnspecified
+
+	resMap, err := krusty.MakeKustomizer(opts).Run(memFS, overlayRoot)
+	if err != nil {
+		return nil, fmt.Errorf("building kustomize overlay %s: %w", k.dir, err)
+	}
+
+	out, err := resMap.AsYaml()
+	if err != nil {
+		return nil, fmt.Errorf("serializing kustomize output: %w", err)
+	}
+
+	return out, nil
+}
+
+// kustomizationFile returns the kustomization file name in dir.
+func kustomizationFile(dir string) (string, error) {
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("no kustomization file found in %s", dir)
+}
+
+// copyDir copies the files below dir into memFS under overlayRoot.
+func copyDir(dir string, memFS filesys.FileSystem) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.ToSlash(filepath.Join(overlayRoot, rel))
+
+		if d.IsDir() {
+			return memFS.MkdirAll(target)
+		}
+
+		data, err := os.ReadFile(path) //nolint:gosec // overlay files are user-provided input
+		if err != nil {
+			return err
+		}
+
+		return memFS.WriteFile(target, data)
+	})
+}
+
+// injectRendered writes the rendered manifests into the overlay and adds
+// them to the kustomization's resources. Resources keep their rendered
+// order unless the kustomization sets sortOptions.
+func injectRendered(dir string, memFS filesys.FileSystem, manifests []byte) error {
+	name, err := kustomizationFile(dir)
+	if err != nil {
+		return err
+	}
+
+	path := overlayRoot + "/" + name
+
+	data, err := memFS.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var kustomization map[string]interface{}
+	if err := sigsyaml.Unmarshal(data, &kustomization); err != nil {
+		return fmt.Errorf("parsing %s: %w", name, err)
+	}
+
+	if kustomization == nil {
+		kustomization = make(map[string]interface{})
+	}
+
+	resources, _ := kustomization["resources"].([]interface{})
+	kustomization["resources"] = append([]interface{}{RenderedFile}, resources...)
+
+	if _, ok := kustomization["sortOptions"]; !ok {
+		kustomization["sortOptions"] = map[string]interface{}{"order": "fifo"}
+	}
+
+	data, err = sigsyaml.Marshal(kustomization)
+	if err != nil {
+		return err
+	}
+
+	if err := memFS.WriteFile(path, data); err != nil {
+		return err
+	}
+
+	return memFS.WriteFile(overlayRoot+"/"+RenderedFile, manifests)
+}
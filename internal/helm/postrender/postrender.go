@@ -0,0 +1,129 @@
+// Package postrender patches rendered manifests before they are parsed.
+//
+// Two post-render stages are supported: external post-renderer executables
+// following the Helm contract (rendered manifests on stdin, patched
+// manifests on stdout) and in-process Kustomize overlays. Post-renderers run
+// on every render of a chart, including the sentinel renders, so fields a
+// post-renderer copies from templated values are still parameterised.
+package postrender
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// PostRenderer transforms rendered manifests.
+type PostRenderer interface {
+	Run(ctx context.Context, manifests []byte) ([]byte, error)
+}
+
+// Options selects the post-render stages.
+type Options struct {
+	// Exec is the path or name of a post-renderer executable.
+	Exec string
+	// ExecArgs are the arguments passed to the executable.
+	ExecArgs []string
+	// KustomizeDir is a directory containing a kustomization file.
+	KustomizeDir string
+}
+
+// New returns the post-renderer for opts, or nil when no stage is
+// configured. The executable runs before the Kustomize overlay.
+func New(opts Options) (PostRenderer, error) {
+	var chain Chain
+
+	if opts.Exec != "" {
+		e, err := NewExec(opts.Exec, opts.ExecArgs...)
+		if err != nil {
+			return nil, err
+		}
+
+		chain = append(chain, e)
+	} else if len(opts.ExecArgs) > 0 {
+		return nil, errors.New("post-renderer arguments given without a post-renderer")
+	}
+
+	if opts.KustomizeDir != "" {
+		k, err := NewKustomize(opts.KustomizeDir)
+		if err != nil {
+			return nil, err
+		}
+
+		chain = append(chain, k)
+	}
+
+	switch len(chain) {
+	case 0:
+		return nil, nil //nolint:nilnil // nil post-renderer means "no post-render stage"
+	case 1:
+		return chain[0], nil
+	default:
+		return chain, nil
+	}
+}
+
+// Chain runs post-renderers in order, each on the output of the previous.
+type Chain []PostRenderer
+
+// Run applies all post-renderers of the chain.
+func (c Chain) Run(ctx context.Context, manifests []byte) ([]byte, error) {
+	out := manifests
+
+	for _, pr := range c {
+		var err error
+
+		out, err = pr.Run(ctx, out)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// Exec is a post-renderer executable. It receives the rendered manifests
+// on stdin and must write the patched manifests to stdout, like a Helm
+// --post-renderer.
+type Exec struct {
+	path string
+	args []string
+}
+
+// NewExec resolves a post-renderer executable by path or, for bare names,
+// on $PATH.
+func NewExec(name string, args ...string) (*Exec, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("post-renderer %q: %w", name, err)
+	}
+
+	return &Exec{path: path, args: args}, nil
+}
+
+// Run executes the post-renderer.
+func (e *Exec) Run(ctx context.Context, manifests []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, e.path, e.args...) //nolint:gosec // user-configured post-renderer
+	cmd.Stdin = bytes.NewReader(manifests)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("running post-renderer %s: %w: %s", e.path, err, msg)
+		}
+
+		return nil, fmt.Errorf("running post-renderer %s: %w", e.path, err)
+	}
+
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 && len(bytes.TrimSpace(manifests)) > 0 {
+		return nil, fmt.Errorf("post-renderer %s produced no output", e.path)
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,199 @@
+package postrender
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const manifests = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.25
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+    - port: 80
+`
+
+// writeScript writes an executable shell script and returns its path.
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("shell post-renderers are not supported on Windows")
+	}
+
+	path := filepath.Join(t.TempDir(), "post-render.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755)) //nolint:gosec // test script must be executable
+
+	return path
+}
+
+// writeOverlay writes the given files into a temporary overlay directory.
+func writeOverlay(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)) //nolint:gosec // test fixture
+	}
+
+	return dir
+}
+
+func TestNew_NoStages(t *testing.T) {
+	pr, err := New(Options{})
+	require.NoError(t, err)
+	assert.Nil(t, pr)
+}
+
+func TestNew_ArgsWithoutExec(t *testing.T) {
+	_, err := New(Options{ExecArgs: []string{"--flag"}})
+	require.Error(t, err)
+}
+
+func TestNewExec_NotFound(t *testing.T) {
+	_, err := NewExec(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "post-renderer")
+}
+
+func TestExec_Run(t *testing.T) {
+	script := writeScript(t, `sed "s/nginx:1.25/nginx:$1/"`)
+
+	pr, err := NewExec(script, "1.27")
+	require.NoError(t, err)
+
+	out, err := pr.Run(context.Background(), []byte(manifests))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "image: nginx:1.27")
+}
+
+func TestExec_RunFailure(t *testing.T) {
+	script := writeScript(t, `echo "patch failed" >&2; exit 3`)
+
+	pr, err := NewExec(script)
+	require.NoError(t, err)
+
+	_, err = pr.Run(context.Background(), []byte(manifests))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "patch failed")
+}
+
+func TestExec_RunNoOutput(t *testing.T) {
+	script := writeScript(t, `cat > /dev/null`)
+
+	pr, err := NewExec(script)
+	require.NoError(t, err)
+
+	_, err = pr.Run(context.Background(), []byte(manifests))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no output")
+}
+
+func TestNewKustomize_MissingKustomization(t *testing.T) {
+	_, err := NewKustomize(t.TempDir())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no kustomization file")
+}
+
+func TestKustomize_StrategicMergePatch(t *testing.T) {
+	dir := writeOverlay(t, map[string]string{
+		"kustomization.yaml": `commonLabels:
+  team: platform
+patchesStrategicMerge:
+  - sidecar.yaml
+`,
+		"sidecar.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+        - name: proxy
+          image: envoyproxy/envoy:v1.30
+`,
+	})
+
+	pr, err := NewKustomize(dir)
+	require.NoError(t, err)
+
+	out, err := pr.Run(context.Background(), []byte(manifests))
+	require.NoError(t, err)
+
+	s := string(out)
+	assert.Contains(t, s, "team: platform")
+	assert.Contains(t, s, "image: envoyproxy/envoy:v1.30")
+	assert.Contains(t, s, "image: nginx:1.25")
+	assert.Less(t, strings.Index(s, "kind: Deployment"), strings.Index(s, "kind: Service"), "rendered order must be kept")
+}
+
+func TestKustomize_JSON6902Patch(t *testing.T) {
+	dir := writeOverlay(t, map[string]string{
+		"kustomization.yaml": `patches:
+  - target:
+      kind: Service
+      name: web
+    patch: |-
+      - op: add
+        path: /metadata/annotations
+        value:
+          example.com/exposed: "true"
+`,
+	})
+
+	pr, err := NewKustomize(dir)
+	require.NoError(t, err)
+
+	out, err := pr.Run(context.Background(), []byte(manifests))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `example.com/exposed: "true"`)
+}
+
+func TestKustomize_BuildError(t *testing.T) {
+	dir := writeOverlay(t, map[string]string{
+		"kustomization.yaml": "resources:\n  - missing.yaml\n",
+	})
+
+	pr, err := NewKustomize(dir)
+	require.NoError(t, err)
+
+	_, err = pr.Run(context.Background(), []byte(manifests))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "building kustomize overlay")
+}
+
+func TestChain_ExecThenKustomize(t *testing.T) {
+	script := writeScript(t, `sed "s/replicas: 1/replicas: 2/"`)
+	dir := writeOverlay(t, map[string]string{
+		"kustomization.yaml": "commonAnnotations:\n  patched: \"yes\"\n",
+	})
+
+	pr, err := New(Options{Exec: script, KustomizeDir: dir})
+	require.NoError(t, err)
+
+	out, err := pr.Run(context.Background(), []byte(manifests))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "replicas: 2")
+	assert.Contains(t, string(out), `patched: "yes"`)
+}
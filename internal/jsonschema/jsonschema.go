@@ -0,0 +1,179 @@
+// Package jsonschema generates JSON Schemas (draft 2020-12) from Go types,
+// so that documented machine-readable formats stay in sync with the structs
+// that produce them.
+//
+// Struct fields are mapped by their json tags: fields without omitempty are
+// required, and fields tagged "-" or unexported are skipped. Two additional
+// struct tags are recognised:
+//
+//	description:"..."   sets the property description
+//	enum:"a,b,c"        restricts a property to the given values
+package jsonschema
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Draft is the JSON Schema dialect of generated schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Generate returns the JSON Schema of the type of v. Named struct types are
+// emitted once under "$defs" and referenced by name.
+func Generate(v interface{}, id, title string) map[string]interface{} {
+	g := &generator{defs: make(map[string]interface{})}
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	root := g.structSchema(t)
+	root["$schema"] = Draft
+	root["$id"] = id
+	root["title"] = title
+
+	if len(g.defs) > 0 {
+		root["$defs"] = g.defs
+	}
+
+	return root
+}
+
+// generator collects the definitions of named struct types.
+type generator struct {
+	defs map[string]interface{}
+}
+
+// schemaFor returns the schema of t.
+func (g *generator) schemaFor(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schemaFor(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+
+		name := t.Name()
+		if _, ok := g.defs[name]; !ok {
+			// Reserve the name first so that recursive types terminate.
+			g.defs[name] = nil
+			g.defs[name] = g.structSchema(t)
+		}
+
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	default:
+		// interface{} and other kinds accept any value.
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema returns the object schema of a struct type.
+func (g *generator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, omitempty, skip := parseJSONTag(f)
+		if skip {
+			continue
+		}
+
+		prop := g.schemaFor(f.Type)
+
+		if desc := f.Tag.Get("description"); desc != "" {
+			prop = withKeyword(prop, "description", desc)
+		}
+
+		if enum := f.Tag.Get("enum"); enum != "" {
+			prop = withEnum(prop, strings.Split(enum, ","))
+		}
+
+		properties[name] = prop
+
+		if !omitempty {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// parseJSONTag returns the JSON name of a field, whether it is omitempty,
+// and whether it is skipped.
+func parseJSONTag(f reflect.StructField) (string, bool, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+
+	return name, strings.Contains(","+opts+",", ",omitempty,"), false
+}
+
+// withKeyword adds a keyword to a schema. References are wrapped in allOf,
+// since sibling keywords of $ref are not applied by all validators.
+func withKeyword(schema map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	if _, ok := schema["$ref"]; ok {
+		schema = map[string]interface{}{"allOf": []interface{}{schema}}
+	}
+
+	schema[key] = value
+
+	return schema
+}
+
+// withEnum restricts a schema (or an array schema's items) to values.
+// Values of integer schemas are converted to numbers.
+func withEnum(schema map[string]interface{}, values []string) map[string]interface{} {
+	target := schema
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		target = items
+	}
+
+	enum := make([]interface{}, len(values))
+
+	for i, v := range values {
+		v = strings.TrimSpace(v)
+		enum[i] = v
+
+		if target["type"] == "integer" {
+			if n, err := strconv.Atoi(v); err == nil {
+				enum[i] = n
+			}
+		}
+	}
+
+	target["enum"] = enum
+
+	return schema
+}
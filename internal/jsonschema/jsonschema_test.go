@@ -0,0 +1,73 @@
+package jsonschema_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hupe1980/chart2kro/internal/jsonschema"
+)
+
+type child struct {
+	Name string `json:"name"`
+}
+
+type root struct {
+	Kind     string            `json:"kind" enum:"A,B"`
+	Count    int               `json:"count,omitempty" description:"How many." enum:"1,2"`
+	Ratio    float64           `json:"ratio"`
+	Enabled  bool              `json:"enabled"`
+	Tags     []string          `json:"tags" enum:"x,y"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Child    child             `json:"child" description:"The child."`
+	Children []*child          `json:"children"`
+	Any      interface{}       `json:"any,omitempty"`
+	Skipped  string            `json:"-"`
+	internal string
+}
+
+func TestGenerate(t *testing.T) {
+	_ = root{internal: ""}
+
+	s := jsonschema.Generate(&root{}, "https://example.com/root.json", "Root")
+
+	assert.Equal(t, jsonschema.Draft, s["$schema"])
+	assert.Equal(t, "https://example.com/root.json", s["$id"])
+	assert.Equal(t, "Root", s["title"])
+	assert.Equal(t, false, s["additionalProperties"])
+	assert.Equal(t, []string{"kind", "ratio", "enabled", "tags", "child", "children"}, s["required"])
+
+	props := s["properties"].(map[string]interface{})
+	assert.Len(t, props, 9)
+	assert.NotContains(t, props, "Skipped")
+
+	assert.Equal(t, map[string]interface{}{"type": "string", "enum": []interface{}{"A", "B"}}, props["kind"])
+	assert.Equal(t, map[string]interface{}{"type": "integer", "description": "How many.", "enum": []interface{}{1, 2}}, props["count"])
+	assert.Equal(t, map[string]interface{}{"type": "number"}, props["ratio"])
+	assert.Equal(t, map[string]interface{}{"type": "boolean"}, props["enabled"])
+	assert.Equal(t, map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"type": "string", "enum": []interface{}{"x", "y"}},
+	}, props["tags"])
+	assert.Equal(t, map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": map[string]interface{}{"type": "string"},
+	}, props["labels"])
+	assert.Equal(t, map[string]interface{}{
+		"allOf":       []interface{}{map[string]interface{}{"$ref": "#/$defs/child"}},
+		"description": "The child.",
+	}, props["child"])
+	assert.Equal(t, map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"$ref": "#/$defs/child"},
+	}, props["children"])
+	assert.Equal(t, map[string]interface{}{}, props["any"])
+
+	defs := s["$defs"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"type":                 "object",
+		"properties":           map[string]interface{}{"name": map[string]interface{}{"type": "string"}},
+		"required":             []string{"name"},
+		"additionalProperties": false,
+	}, defs["child"])
+}
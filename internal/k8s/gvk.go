@@ -99,11 +99,74 @@ func IsJob(gvk schema.GroupVersionKind) bool {
 	return gvk.Kind == "Job" && gvk.Group == "batch"
 }
 
+// IsPod returns true for Pod resources.
+func IsPod(gvk schema.GroupVersionKind) bool {
+	return gvk.Kind == "Pod" && (gvk.Group == "" || gvk.Group == "core")
+}
+
 // IsPVC returns true for PersistentVolumeClaim resources.
 func IsPVC(gvk schema.GroupVersionKind) bool {
 	return gvk.Kind == "PersistentVolumeClaim" && (gvk.Group == "" || gvk.Group == "core")
 }
 
+// IsCronJob returns true for CronJob resources.
+func IsCronJob(gvk schema.GroupVersionKind) bool {
+	return gvk.Kind == "CronJob" && gvk.Group == "batch"
+}
+
+// IsIngress returns true for Ingress resources.
+func IsIngress(gvk schema.GroupVersionKind) bool {
+	return gvk.Kind == "Ingress" && (gvk.Group == "networking.k8s.io" || gvk.Group == "extensions")
+}
+
+// IsHPA returns true for HorizontalPodAutoscaler resources.
+func IsHPA(gvk schema.GroupVersionKind) bool {
+	return gvk.Kind == "HorizontalPodAutoscaler" && gvk.Group == "autoscaling"
+}
+
+// IsVPA returns true for VerticalPodAutoscaler resources.
+func IsVPA(gvk schema.GroupVersionKind) bool {
+	return gvk.Kind == "VerticalPodAutoscaler" && gvk.Group == "autoscaling.k8s.io"
+}
+
+// IsPDB returns true for PodDisruptionBudget resources.
+func IsPDB(gvk schema.GroupVersionKind) bool {
+	return gvk.Kind == "PodDisruptionBudget" && gvk.Group == "policy"
+}
+
+// IsNetworkPolicy returns true for NetworkPolicy resources.
+func IsNetworkPolicy(gvk schema.GroupVersionKind) bool {
+	return gvk.Kind == "NetworkPolicy" && gvk.Group == "networking.k8s.io"
+}
+
+// IsRoleBinding returns true for RoleBinding and ClusterRoleBinding resources.
+func IsRoleBinding(gvk schema.GroupVersionKind) bool {
+	return (gvk.Kind == "RoleBinding" || gvk.Kind == "ClusterRoleBinding") && gvk.Group == "rbac.authorization.k8s.io"
+}
+
+// --- Common CRD classifiers ---
+
+// IsCertificate returns true for cert-manager Certificate resources.
+func IsCertificate(gvk schema.GroupVersionKind) bool {
+	return gvk.Kind == "Certificate" && gvk.Group == "cert-manager.io"
+}
+
+// IsRollout returns true for Argo Rollouts Rollout resources.
+func IsRollout(gvk schema.GroupVersionKind) bool {
+	return gvk.Kind == "Rollout" && gvk.Group == "argoproj.io"
+}
+
+// IsServiceMonitor returns true for Prometheus Operator ServiceMonitor
+// resources.
+func IsServiceMonitor(gvk schema.GroupVersionKind) bool {
+	return gvk.Kind == "ServiceMonitor" && gvk.Group == "monitoring.coreos.com"
+}
+
+// IsScaledObject returns true for KEDA ScaledObject resources.
+func IsScaledObject(gvk schema.GroupVersionKind) bool {
+	return gvk.Kind == "ScaledObject" && gvk.Group == "keda.sh"
+}
+
 // APIVersion converts a GVK to an apiVersion string (e.g., "apps/v1" or "v1").
 func APIVersion(gvk schema.GroupVersionKind) string {
 	if gvk.Group == "" {
@@ -111,6 +111,35 @@ func TestIsPVC(t *testing.T) {
 	assert.False(t, k8s.IsPVC(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "PersistentVolume"}))
 }
 
+func TestIsCronJobIngressHPA(t *testing.T) {
+	assert.True(t, k8s.IsCronJob(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "CronJob"}))
+	assert.False(t, k8s.IsCronJob(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}))
+	assert.True(t, k8s.IsIngress(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}))
+	assert.True(t, k8s.IsIngress(schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}))
+	assert.False(t, k8s.IsIngress(schema.GroupVersionKind{Group: "traefik.io", Version: "v1alpha1", Kind: "Ingress"}))
+	assert.True(t, k8s.IsHPA(schema.GroupVersionKind{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"}))
+	assert.False(t, k8s.IsHPA(schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "ScaledObject"}))
+}
+
+func TestIsPolicyAndBindingKinds(t *testing.T) {
+	assert.True(t, k8s.IsVPA(schema.GroupVersionKind{Group: "autoscaling.k8s.io", Version: "v1", Kind: "VerticalPodAutoscaler"}))
+	assert.True(t, k8s.IsPDB(schema.GroupVersionKind{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"}))
+	assert.True(t, k8s.IsNetworkPolicy(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"}))
+	assert.False(t, k8s.IsNetworkPolicy(schema.GroupVersionKind{Group: "cilium.io", Version: "v2", Kind: "NetworkPolicy"}))
+	assert.True(t, k8s.IsRoleBinding(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"}))
+	assert.True(t, k8s.IsRoleBinding(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"}))
+	assert.False(t, k8s.IsRoleBinding(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"}))
+}
+
+func TestIsCommonCRDs(t *testing.T) {
+	assert.True(t, k8s.IsCertificate(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}))
+	assert.False(t, k8s.IsCertificate(schema.GroupVersionKind{Group: "certificates.k8s.io", Version: "v1", Kind: "Certificate"}))
+	assert.True(t, k8s.IsRollout(schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}))
+	assert.True(t, k8s.IsServiceMonitor(schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}))
+	assert.True(t, k8s.IsScaledObject(schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "ScaledObject"}))
+	assert.False(t, k8s.IsScaledObject(schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "ScaledJob"}))
+}
+
 func TestAPIVersion(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,479 @@
+// Package krmfn runs chart2kro as a KRM function.
+//
+// The function reads a ResourceList whose functionConfig is a ChartToRGD
+// resource, converts the referenced chart with the pkg/chart2kro options
+// the spec mirrors, and emits the ResourceGraphDefinition as an output
+// item. RGD validation findings, hardening warnings, and audit findings are
+// reported as ResourceList results. This makes chart2kro usable as a
+// Kustomize generator and as a kpt pipeline function.
+package krmfn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/hupe1980/chart2kro/internal/audit"
+	"github.com/hupe1980/chart2kro/internal/harden"
+	"github.com/hupe1980/chart2kro/internal/output"
+	"github.com/hupe1980/chart2kro/pkg/chart2kro"
+)
+
+// Function config identification.
+const (
+	APIVersion = "chart2kro.io/v1alpha1"
+	Kind       = "ChartToRGD"
+)
+
+// rgdAPIVersion and rgdKind identify the emitted item.
+const (
+	rgdAPIVersion = "kro.run/v1alpha1"
+	rgdKind       = "ResourceGraphDefinition"
+)
+
+// FunctionConfig is the functionConfig of the function.
+type FunctionConfig struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name string `json:"name,omitempty"`
+	} `json:"metadata,omitempty"`
+	Spec Spec `json:"spec"`
+}
+
+// Spec mirrors the pkg/chart2kro conversion options.
+type Spec struct {
+	// Chart is a local chart directory, .tgz archive, OCI reference, or a
+	// chart name in RepoURL.
+	Chart     string `json:"chart"`
+	Version   string `json:"version,omitempty"`
+	RepoURL   string `json:"repoURL,omitempty"`
+	PlainHTTP bool   `json:"plainHTTP,omitempty"`
+	Offline   bool   `json:"offline,omitempty"`
+
+	ReleaseName string `json:"releaseName,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Strict      bool   `json:"strict,omitempty"`
+	// Timeout is the template rendering timeout, e.g. "30s".
+	Timeout string `json:"timeout,omitempty"`
+
+	// Values are inline values, merged after ValueFiles.
+	Values     map[string]interface{} `json:"values,omitempty"`
+	ValueFiles []string               `json:"valueFiles,omitempty"`
+	Set        []string               `json:"set,omitempty"`
+	SetString  []string               `json:"setString,omitempty"`
+
+	IncludeHooks bool `json:"includeHooks,omitempty"`
+
+	Kind             string `json:"kind,omitempty"`
+	APIVersion       string `json:"apiVersion,omitempty"`
+	Group            string `json:"group,omitempty"`
+	IncludeAllValues bool   `json:"includeAllValues,omitempty"`
+	FlatSchema       bool   `json:"flatSchema,omitempty"`
+	Fast             bool   `json:"fast,omitempty"`
+
+	Profile            string   `json:"profile,omitempty"`
+	ExcludeKinds       []string `json:"excludeKinds,omitempty"`
+	ExcludeResources   []string `json:"excludeResources,omitempty"`
+	ExcludeSubcharts   []string `json:"excludeSubcharts,omitempty"`
+	ExcludeLabels      string   `json:"excludeLabels,omitempty"`
+	ExternalizeSecret  []string `json:"externalizeSecret,omitempty"`
+	ExternalizeService []string `json:"externalizeService,omitempty"`
+	UseExternalPattern []string `json:"useExternalPattern,omitempty"`
+
+	Harden *HardenSpec `json:"harden,omitempty"`
+	Audit  *AuditSpec  `json:"audit,omitempty"`
+
+	// Config is an inline .chart2kro.yaml document.
+	Config map[string]interface{} `json:"config,omitempty"`
+}
+
+// HardenSpec configures security hardening.
+type HardenSpec struct {
+	Enabled         bool   `json:"enabled,omitempty"`
+	SecurityLevel   string `json:"securityLevel,omitempty"`
+	NetworkPolicies bool   `json:"networkPolicies,omitempty"`
+	RBAC            bool   `json:"rbac,omitempty"`
+	ResolveDigests  bool   `json:"resolveDigests,omitempty"`
+}
+
+// AuditSpec configures the audit of the rendered resources.
+type AuditSpec struct {
+	Enabled       bool   `json:"enabled,omitempty"`
+	SecurityLevel string `json:"securityLevel,omitempty"`
+	// FailOn reports findings at or above this severity as errors.
+	FailOn string `json:"failOn,omitempty"`
+}
+
+// Validate implements framework.Validator.
+func (c *FunctionConfig) Validate() error {
+	if c.APIVersion != APIVersion || c.Kind != Kind {
+		return fmt.Errorf("functionConfig must be %s/%s, got %s/%s", APIVersion, Kind, c.APIVersion, c.Kind)
+	}
+
+	if err := c.Spec.Validate(); err != nil {
+		return fmt.Errorf("spec.%w", err)
+	}
+
+	return nil
+}
+
+// Validate checks the spec. Errors name the offending field relative to the
+// spec, e.g. "timeout: ...".
+func (s *Spec) Validate() error {
+	if s.Chart == "" {
+		return errors.New("chart is required")
+	}
+
+	if s.Timeout != "" {
+		if _, err := time.ParseDuration(s.Timeout); err != nil {
+			return fmt.Errorf("timeout: %w", err)
+		}
+	}
+
+	if h := s.Harden; h != nil && h.SecurityLevel != "" {
+		if _, err := harden.ParseSecurityLevel(h.SecurityLevel); err != nil {
+			return fmt.Errorf("harden.securityLevel: %w", err)
+		}
+	}
+
+	if a := s.Audit; a != nil {
+		if a.SecurityLevel != "" {
+			if _, err := harden.ParseSecurityLevel(a.SecurityLevel); err != nil {
+				return fmt.Errorf("audit.securityLevel: %w", err)
+			}
+		}
+
+		if a.FailOn != "" {
+			if _, err := audit.ParseSeverity(a.FailOn); err != nil {
+				return fmt.Errorf("audit.failOn: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsRemote reports whether the chart is an OCI reference or a chart name in
+// a repository, rather than a path on the local file system.
+func (s *Spec) IsRemote() bool {
+	if strings.HasPrefix(s.Chart, "oci://") {
+		return true
+	}
+
+	return s.RepoURL != "" && !strings.ContainsAny(s.Chart, `/\`) &&
+		!strings.HasSuffix(s.Chart, ".tgz") && !strings.HasSuffix(s.Chart, ".tar.gz")
+}
+
+// ValidatePlugins rejects config transformers with a plugin. Plugins run
+// executables and load WASM modules from the host, so servers and
+// controllers converting the specs of their clients accept them only when
+// the operator allows it.
+func (s *Spec) ValidatePlugins() error {
+	transformers, _ := s.Config["transformers"].([]interface{})
+
+	for i, t := range transformers {
+		if m, _ := t.(map[string]interface{}); m != nil {
+			if _, ok := m["plugin"]; ok {
+				return fmt.Errorf("config.transformers[%d].plugin: plugins are not accepted", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Processor returns the ResourceListProcessor of the function.
+func Processor(ctx context.Context) framework.ResourceListProcessor {
+	return framework.ResourceListProcessorFunc(func(rl *framework.ResourceList) error {
+		return Process(ctx, rl)
+	})
+}
+
+// Process converts the chart of the functionConfig and adds the RGD to the
+// items, replacing an RGD of the same name from a previous run. Errors are
+// reported as results; the returned error is non-nil when any result has
+// error severity.
+func Process(ctx context.Context, rl *framework.ResourceList) error {
+	if err := process(ctx, rl); err != nil {
+		rl.Results = append(rl.Results, &framework.Result{Message: err.Error(), Severity: framework.Error})
+	}
+
+	if rl.Results.ExitCode() != 0 {
+		return rl.Results
+	}
+
+	return nil
+}
+
+func process(ctx context.Context, rl *framework.ResourceList) error {
+	if rl.FunctionConfig == nil {
+		return errors.New("functionConfig is required")
+	}
+
+	cfg := &FunctionConfig{}
+	if err := framework.LoadFunctionConfig(rl.FunctionConfig, cfg); err != nil {
+		return fmt.Errorf("invalid functionConfig: %w", err)
+	}
+
+	opts, cleanup, err := cfg.Spec.Options()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	result, err := chart2kro.Convert(ctx, cfg.Spec.Chart, opts...)
+	if err != nil {
+		return err
+	}
+
+	rgd, err := yaml.Parse(string(result.YAML))
+	if err != nil {
+		return fmt.Errorf("parsing generated RGD: %w", err)
+	}
+
+	rl.Items = replaceOrAppend(rl.Items, rgd)
+
+	ref := &yaml.ResourceIdentifier{
+		TypeMeta: yaml.TypeMeta{APIVersion: rgdAPIVersion, Kind: rgdKind},
+		NameMeta: yaml.NameMeta{Name: rgd.GetName()},
+	}
+
+	rl.Results = append(rl.Results, validationResults(result.RGDMap, ref)...)
+
+	if result.HardenResult != nil {
+		for _, w := range result.HardenResult.Warnings {
+			rl.Results = append(rl.Results, &framework.Result{
+				Message:     w,
+				Severity:    framework.Warning,
+				ResourceRef: ref,
+				Tags:        map[string]string{"source": "harden"},
+			})
+		}
+	}
+
+	if cfg.Spec.Audit != nil && cfg.Spec.Audit.Enabled {
+		rl.Results = append(rl.Results, auditResults(result.AuditFindings, cfg.Spec.Audit.FailOn)...)
+	}
+
+	return nil
+}
+
+// Options builds the conversion options. The returned cleanup removes the
+// temporary file holding inline values.
+func (s *Spec) Options() ([]chart2kro.Option, func(), error) {
+	cleanup := func() {}
+
+	opts := []chart2kro.Option{
+		chart2kro.WithVersion(s.Version),
+		chart2kro.WithRepoURL(s.RepoURL),
+		chart2kro.WithReleaseName(s.ReleaseName),
+		chart2kro.WithNamespace(s.Namespace),
+		chart2kro.WithStringValues(s.SetString),
+		chart2kro.WithValues(s.Set),
+		chart2kro.WithKind(s.Kind),
+		chart2kro.WithAPIVersion(s.APIVersion),
+		chart2kro.WithGroup(s.Group),
+		chart2kro.WithProfile(s.Profile),
+		chart2kro.WithExcludeKinds(s.ExcludeKinds),
+		chart2kro.WithExcludeResources(s.ExcludeResources),
+		chart2kro.WithExcludeSubcharts(s.ExcludeSubcharts),
+		chart2kro.WithExcludeLabels(s.ExcludeLabels),
+		chart2kro.WithExternalizeSecret(s.ExternalizeSecret),
+		chart2kro.WithExternalizeService(s.ExternalizeService),
+		chart2kro.WithUseExternalPattern(s.UseExternalPattern),
+	}
+
+	flags := []struct {
+		set bool
+		opt chart2kro.Option
+	}{
+		{s.PlainHTTP, chart2kro.WithPlainHTTP()},
+		{s.Offline, chart2kro.WithOffline()},
+		{s.Strict, chart2kro.WithStrict()},
+		{s.IncludeHooks, chart2kro.WithIncludeHooks()},
+		{s.IncludeAllValues, chart2kro.WithIncludeAllValues()},
+		{s.FlatSchema, chart2kro.WithFlatSchema()},
+		{s.Fast, chart2kro.WithFast()},
+	}
+
+	for _, f := range flags {
+		if f.set {
+			opts = append(opts, f.opt)
+		}
+	}
+
+	if s.Timeout != "" {
+		d, err := time.ParseDuration(s.Timeout)
+		if err != nil {
+			return nil, cleanup, fmt.Errorf("spec.timeout: %w", err)
+		}
+
+		opts = append(opts, chart2kro.WithTimeout(d))
+	}
+
+	if h := s.Harden; h != nil && h.Enabled {
+		level := h.SecurityLevel
+		if level == "" {
+			level = string(harden.SecurityLevelRestricted)
+		}
+
+		opts = append(opts, chart2kro.WithHarden(), chart2kro.WithSecurityLevel(level))
+
+		if h.NetworkPolicies {
+			opts = append(opts, chart2kro.WithGenerateNetworkPolicies())
+		}
+
+		if h.RBAC {
+			opts = append(opts, chart2kro.WithGenerateRBAC())
+		}
+
+		if h.ResolveDigests {
+			opts = append(opts, chart2kro.WithResolveDigests())
+		}
+	}
+
+	if a := s.Audit; a != nil && a.Enabled {
+		opts = append(opts, chart2kro.WithAudit(a.SecurityLevel))
+	}
+
+	if len(s.Config) > 0 {
+		data, err := sigsyaml.Marshal(s.Config)
+		if err != nil {
+			return nil, cleanup, fmt.Errorf("spec.config: %w", err)
+		}
+
+		opts = append(opts, chart2kro.WithTransformConfigData(data))
+	}
+
+	valueFiles, cleanup, err := s.AllValueFiles()
+	if err != nil {
+		return nil, cleanup, err
+	}
+
+	opts = append(opts, chart2kro.WithValueFiles(valueFiles))
+
+	return opts, cleanup, nil
+}
+
+// AllValueFiles returns ValueFiles followed by a temporary file holding the
+// inline Values, if any. The returned cleanup removes the temporary file.
+func (s *Spec) AllValueFiles() ([]string, func(), error) {
+	if len(s.Values) == 0 {
+		return s.ValueFiles, func() {}, nil
+	}
+
+	path, err := writeValuesFile(s.Values)
+	if err != nil {
+		return nil, func() {}, err
+	}
+
+	return append(append([]string{}, s.ValueFiles...), path), func() { _ = os.Remove(path) }, nil
+}
+
+// writeValuesFile writes inline values to a temporary values file.
+func writeValuesFile(values map[string]interface{}) (string, error) {
+	data, err := sigsyaml.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("spec.values: %w", err)
+	}
+
+	f, err := os.CreateTemp("", "chart2kro-values-*.yaml")
+	if err != nil {
+		return "", fmt.Errorf("writing inline values: %w", err)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+
+		return "", fmt.Errorf("writing inline values: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+
+		return "", fmt.Errorf("writing inline values: %w", err)
+	}
+
+	return f.Name(), nil
+}
+
+// replaceOrAppend replaces the item with the same kind and name as rgd, so
+// that re-running the function is idempotent, or appends rgd.
+func replaceOrAppend(items []*yaml.RNode, rgd *yaml.RNode) []*yaml.RNode {
+	for i, item := range items {
+		if item.GetKind() == rgdKind && item.GetName() == rgd.GetName() {
+			items[i] = rgd
+			return items
+		}
+	}
+
+	return append(items, rgd)
+}
+
+// validationResults maps RGD validation findings to results.
+func validationResults(rgdMap map[string]interface{}, ref *yaml.ResourceIdentifier) framework.Results {
+	var results framework.Results
+
+	for _, f := range output.ValidateRGD(rgdMap).Findings {
+		severity := framework.Warning
+		if f.Severity == output.SeverityError {
+			severity = framework.Error
+		}
+
+		results = append(results, &framework.Result{
+			Message:     f.Message,
+			Severity:    severity,
+			ResourceRef: ref,
+			Field:       &framework.Field{Path: f.Field},
+			Tags:        map[string]string{"source": "validate"},
+		})
+	}
+
+	return results
+}
+
+// auditResults maps audit findings to results. Findings at or above failOn
+// are errors; otherwise medium and above are warnings and the rest info.
+func auditResults(findings []chart2kro.AuditFinding, failOn string) framework.Results {
+	threshold := audit.SeverityCritical + 1
+	if failOn != "" {
+		threshold, _ = audit.ParseSeverity(failOn)
+	}
+
+	results := make(framework.Results, 0, len(findings))
+
+	for _, f := range findings {
+		sev, _ := audit.ParseSeverity(f.Severity)
+
+		severity := framework.Info
+
+		switch {
+		case sev >= threshold:
+			severity = framework.Error
+		case sev >= audit.SeverityMedium:
+			severity = framework.Warning
+		}
+
+		results = append(results, &framework.Result{
+			Message:  fmt.Sprintf("%s: %s", f.Resource, f.Message),
+			Severity: severity,
+			Tags: map[string]string{
+				"source":      "audit",
+				"rule":        f.RuleID,
+				"severity":    f.Severity,
+				"remediation": f.Remediation,
+			},
+		})
+	}
+
+	return results
+}
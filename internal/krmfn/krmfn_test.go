@@ -0,0 +1,154 @@
+package krmfn
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+
+	"github.com/hupe1980/chart2kro/pkg/chart2kro"
+)
+
+const simpleChart = "../../testdata/charts/simple"
+
+func resourceList(t *testing.T, functionConfig string, items ...string) *framework.ResourceList {
+	t.Helper()
+
+	rl := &framework.ResourceList{FunctionConfig: yaml.MustParse(functionConfig)}
+	for _, item := range items {
+		rl.Items = append(rl.Items, yaml.MustParse(item))
+	}
+
+	return rl
+}
+
+func TestProcess_EmitsRGD(t *testing.T) {
+	rl := resourceList(t, `apiVersion: chart2kro.io/v1alpha1
+kind: ChartToRGD
+metadata:
+  name: simple
+spec:
+  chart: `+simpleChart+`
+  values:
+    replicaCount: 4
+  kind: WebApp
+`, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: other\n")
+
+	require.NoError(t, Process(context.Background(), rl))
+	require.Len(t, rl.Items, 2)
+
+	rgd := rl.Items[1]
+	assert.Equal(t, "ResourceGraphDefinition", rgd.GetKind())
+	assert.Equal(t, "simple", rgd.GetName())
+
+	kind, err := rgd.Pipe(yaml.Lookup("spec", "schema", "kind"))
+	require.NoError(t, err)
+	assert.Equal(t, "WebApp", yaml.GetValue(kind))
+
+	replicas, err := rgd.Pipe(yaml.Lookup("spec", "schema", "spec", "replicaCount"))
+	require.NoError(t, err)
+	assert.Contains(t, yaml.GetValue(replicas), "default=4")
+
+	// Running again replaces the RGD instead of adding a second one.
+	require.NoError(t, Process(context.Background(), rl))
+	assert.Len(t, rl.Items, 2)
+}
+
+func TestProcess_AuditFailOn(t *testing.T) {
+	rl := resourceList(t, `apiVersion: chart2kro.io/v1alpha1
+kind: ChartToRGD
+spec:
+  chart: `+simpleChart+`
+  audit:
+    enabled: true
+    failOn: critical
+`)
+
+	err := Process(context.Background(), rl)
+	require.Error(t, err)
+	require.Len(t, rl.Items, 1, "the RGD is emitted even when findings fail the run")
+
+	var rules []string
+
+	for _, r := range rl.Results {
+		assert.Equal(t, "audit", r.Tags["source"])
+
+		if r.Severity == framework.Error {
+			assert.Equal(t, "critical", r.Tags["severity"])
+		}
+
+		rules = append(rules, r.Tags["rule"])
+	}
+
+	assert.Contains(t, rules, "SEC-001")
+}
+
+func TestProcess_InvalidFunctionConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		errMsg string
+	}{
+		{"wrong kind", "apiVersion: v1\nkind: ConfigMap\n", "functionConfig must be"},
+		{"missing chart", "apiVersion: chart2kro.io/v1alpha1\nkind: ChartToRGD\nspec: {}\n", "spec.chart is required"},
+		{"bad timeout", "apiVersion: chart2kro.io/v1alpha1\nkind: ChartToRGD\nspec:\n  chart: x\n  timeout: soon\n", "spec.timeout"},
+		{"bad failOn", "apiVersion: chart2kro.io/v1alpha1\nkind: ChartToRGD\nspec:\n  chart: x\n  audit:\n    failOn: severe\n", "spec.audit.failOn"},
+		{"missing chart directory", "apiVersion: chart2kro.io/v1alpha1\nkind: ChartToRGD\nspec:\n  chart: ./does-not-exist\n", "loading chart"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := resourceList(t, tt.config)
+
+			err := Process(context.Background(), rl)
+			require.Error(t, err)
+			require.Len(t, rl.Results, 1)
+			assert.Equal(t, framework.Error, rl.Results[0].Severity)
+			assert.Contains(t, rl.Results[0].Message, tt.errMsg)
+			assert.Empty(t, rl.Items)
+		})
+	}
+}
+
+func TestProcess_MissingFunctionConfig(t *testing.T) {
+	rl := &framework.ResourceList{}
+
+	require.Error(t, Process(context.Background(), rl))
+	assert.Contains(t, rl.Results[0].Message, "functionConfig is required")
+}
+
+func TestAuditResults_Severity(t *testing.T) {
+	findings := []chart2kro.AuditFinding{
+		{RuleID: "SEC-001", Severity: "critical", Resource: "Deployment/web"},
+		{RuleID: "SEC-003", Severity: "high", Resource: "Deployment/web"},
+		{RuleID: "SEC-010", Severity: "low", Resource: "Deployment/web"},
+	}
+
+	results := auditResults(findings, "")
+	assert.Equal(t, framework.Warning, results[0].Severity)
+	assert.Equal(t, framework.Warning, results[1].Severity)
+	assert.Equal(t, framework.Info, results[2].Severity)
+
+	results = auditResults(findings, "high")
+	assert.Equal(t, framework.Error, results[0].Severity)
+	assert.Equal(t, framework.Error, results[1].Severity)
+	assert.Equal(t, framework.Info, results[2].Severity)
+}
+
+func TestSpec_ValidatePlugins(t *testing.T) {
+	spec := &Spec{Config: map[string]interface{}{
+		"transformers": []interface{}{
+			map[string]interface{}{"match": map[string]interface{}{"kind": "Deployment"}, "readyWhen": []interface{}{"true"}},
+			map[string]interface{}{"plugin": map[string]interface{}{"exec": "sh", "args": []interface{}{"-c", "id"}}},
+		},
+	}}
+
+	require.ErrorContains(t, spec.ValidatePlugins(), "config.transformers[1].plugin")
+
+	spec.Config["transformers"] = spec.Config["transformers"].([]interface{})[:1]
+	require.NoError(t, spec.ValidatePlugins())
+	require.NoError(t, (&Spec{}).ValidatePlugins())
+}
@@ -76,6 +76,15 @@ type GeneratorConfig struct {
 	// CustomReadyConditions are user-supplied readiness conditions keyed by Kind.
 	// When set, they override the built-in defaults for matching Kinds.
 	CustomReadyConditions map[string][]string
+	// ResourceReadyWhen are readiness conditions keyed by resource ID. They
+	// take precedence over CustomReadyConditions and the defaults.
+	ResourceReadyWhen map[string][]string
+	// ResourceIncludeWhen are conditional inclusion expressions keyed by
+	// resource ID.
+	ResourceIncludeWhen map[string][]string
+	// AlwaysSchema emits the schema even without spec and status fields, so
+	// that the RGD's custom resource can be instantiated by kind.
+	AlwaysSchema bool
 }
 
 // Generator builds a KRO ResourceGraphDefinition from parsed resources.
@@ -124,6 +133,28 @@ func (g *Generator) Generate(depGraph *transform.DependencyGraph) (*RGD, error)
 	return rgd, nil
 }
 
+// Envelope returns the RGD map for an assembled schema spec, status, and
+// resource list, e.g. of a composition of generated RGDs, with the metadata
+// and schema apiVersion and kind that Generate produces. Only the name,
+// chart, and schema settings of the config apply.
+func (g *Generator) Envelope(spec, status map[string]interface{}, resources []interface{}) map[string]interface{} {
+	schema := g.schemaHeader()
+	schema.Spec = spec
+	schema.Status = status
+
+	rgd := &RGD{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Metadata:   g.buildMetadata(),
+		Spec:       Spec{Schema: schema},
+	}
+
+	m := rgd.ToMap()
+	m["spec"].(map[string]interface{})["resources"] = resources
+
+	return m
+}
+
 func (g *Generator) buildMetadata() Metadata {
 	labels := map[string]string{
 		"app.kubernetes.io/managed-by": "chart2kro",
@@ -149,10 +180,31 @@ func (g *Generator) buildMetadata() Metadata {
 }
 
 func (g *Generator) buildSchema() *Schema {
-	if len(g.config.SchemaFields) == 0 && len(g.config.StatusFields) == 0 {
+	if len(g.config.SchemaFields) == 0 && len(g.config.StatusFields) == 0 && !g.config.AlwaysSchema {
 		return nil
 	}
 
+	s := g.schemaHeader()
+
+	if len(g.config.SchemaFields) > 0 {
+		s.Spec = transform.BuildSimpleSchema(g.config.SchemaFields)
+	}
+
+	if len(g.config.StatusFields) > 0 {
+		status := make(map[string]interface{})
+		for _, sf := range g.config.StatusFields {
+			status[sf.Name] = sf.CELExpression
+		}
+
+		s.Status = status
+	}
+
+	return s
+}
+
+// schemaHeader returns a schema with the configured or default apiVersion
+// and kind.
+func (g *Generator) schemaHeader() *Schema {
 	// Determine schema kind.
 	kind := g.config.SchemaKind
 	if kind == "" {
@@ -170,27 +222,10 @@ func (g *Generator) buildSchema() *Schema {
 		version = "v1alpha1"
 	}
 
-	apiVersion := g.config.Name + "." + group + "/" + version
-
-	s := &Schema{
-		APIVersion: apiVersion,
+	return &Schema{
+		APIVersion: g.config.Name + "." + group + "/" + version,
 		Kind:       kind,
 	}
-
-	if len(g.config.SchemaFields) > 0 {
-		s.Spec = transform.BuildSimpleSchema(g.config.SchemaFields)
-	}
-
-	if len(g.config.StatusFields) > 0 {
-		status := make(map[string]interface{})
-		for _, sf := range g.config.StatusFields {
-			status[sf.Name] = sf.CELExpression
-		}
-
-		s.Status = status
-	}
-
-	return s
 }
 
 func (g *Generator) buildResource(id string, r *k8s.Resource, depGraph *transform.DependencyGraph) (Resource, error) {
@@ -210,8 +245,13 @@ func (g *Generator) buildResource(id string, r *k8s.Resource, depGraph *transfor
 	}
 
 	// Add readyWhen conditions.
-	readyWhen := transform.ResolveReadyWhen(r.GVK, g.config.CustomReadyConditions)
+	readyWhen, ok := g.config.ResourceReadyWhen[id]
+	if !ok {
+		readyWhen = transform.ResolveReadyWhen(r.GVK, g.config.CustomReadyConditions)
+	}
+
 	res.ReadyWhen = readyWhen
+	res.IncludeWhen = g.config.ResourceIncludeWhen[id]
 
 	// Add dependsOn from the graph.
 	deps := depGraph.DependenciesOf(id)
@@ -104,6 +104,41 @@ func TestGenerator_Generate_WithReadyWhen(t *testing.T) {
 	assert.Contains(t, rgd.Spec.Resources[0].ReadyWhen[0], "availableReplicas")
 }
 
+func TestGenerator_Generate_ResourceReadyWhen(t *testing.T) {
+	g := kro.NewGenerator(kro.GeneratorConfig{
+		Name:                  "app",
+		CustomReadyConditions: map[string][]string{"Job": {"${self.status.active == 0}"}},
+		ResourceReadyWhen:     map[string][]string{"migrate": {"${self.status.succeeded > 0}"}},
+	})
+
+	depGraph := transform.NewDependencyGraph()
+	depGraph.AddNode("migrate", makeResource("batch/v1", "Job", "migrate", map[string]interface{}{}))
+	depGraph.AddNode("cleanup", makeResource("batch/v1", "Job", "cleanup", map[string]interface{}{}))
+
+	rgd, err := g.Generate(depGraph)
+	require.NoError(t, err)
+	require.Len(t, rgd.Spec.Resources, 2)
+	assert.Equal(t, []string{"${self.status.active == 0}"}, rgd.Spec.Resources[0].ReadyWhen)
+	assert.Equal(t, []string{"${self.status.succeeded > 0}"}, rgd.Spec.Resources[1].ReadyWhen)
+}
+
+func TestGenerator_Generate_ResourceIncludeWhen(t *testing.T) {
+	g := kro.NewGenerator(kro.GeneratorConfig{
+		Name:                "app",
+		ResourceIncludeWhen: map[string][]string{"certificate": {"${schema.spec.tls.enabled}"}},
+	})
+
+	depGraph := transform.NewDependencyGraph()
+	depGraph.AddNode("certificate", makeResource("cert-manager.io/v1", "Certificate", "tls", map[string]interface{}{}))
+	depGraph.AddNode("config", makeResource("v1", "ConfigMap", "config", map[string]interface{}{}))
+
+	rgd, err := g.Generate(depGraph)
+	require.NoError(t, err)
+	require.Len(t, rgd.Spec.Resources, 2)
+	assert.Equal(t, []string{"${schema.spec.tls.enabled}"}, rgd.Spec.Resources[0].IncludeWhen)
+	assert.Empty(t, rgd.Spec.Resources[1].IncludeWhen)
+}
+
 func TestGenerator_Generate_WithSchema(t *testing.T) {
 	schemaFields := []*transform.SchemaField{
 		{Name: "replicas", Path: "replicas", Type: "integer", Default: "3"},
@@ -129,6 +164,21 @@ func TestGenerator_Generate_WithSchema(t *testing.T) {
 	assert.NotNil(t, rgd.Spec.Schema.Spec)
 }
 
+func TestGenerator_Generate_AlwaysSchema(t *testing.T) {
+	depGraph := transform.NewDependencyGraph()
+	depGraph.AddNode("configmap", makeResource("v1", "ConfigMap", "x", map[string]interface{}{}))
+
+	rgd, err := kro.NewGenerator(kro.GeneratorConfig{Name: "app"}).Generate(depGraph)
+	require.NoError(t, err)
+	assert.Nil(t, rgd.Spec.Schema)
+
+	rgd, err = kro.NewGenerator(kro.GeneratorConfig{Name: "app", AlwaysSchema: true}).Generate(depGraph)
+	require.NoError(t, err)
+	require.NotNil(t, rgd.Spec.Schema)
+	assert.Equal(t, "App", rgd.Spec.Schema.Kind)
+	assert.Nil(t, rgd.Spec.Schema.Spec)
+}
+
 func TestGenerator_Generate_CustomSchemaOverrides(t *testing.T) {
 	schemaFields := []*transform.SchemaField{
 		{Name: "port", Path: "port", Type: "integer", Default: "8080"},
@@ -228,6 +278,29 @@ func TestRGD_ToMap(t *testing.T) {
 	assert.Equal(t, "test", meta["name"])
 }
 
+func TestGenerator_Envelope(t *testing.T) {
+	cfg := kro.GeneratorConfig{Name: "webstack", ChartName: "webstack", SchemaGroup: "example.com"}
+
+	generated, err := kro.NewGenerator(cfg).Generate(transform.NewDependencyGraph())
+	require.NoError(t, err)
+
+	spec := map[string]interface{}{"app": map[string]interface{}{"replicas": "integer | default=1"}}
+	resources := []interface{}{map[string]interface{}{"id": "app-deployment"}}
+
+	m := kro.NewGenerator(cfg).Envelope(spec, nil, resources)
+	assert.Equal(t, kro.APIVersion, m["apiVersion"])
+	assert.Equal(t, kro.Kind, m["kind"])
+	assert.Equal(t, generated.ToMap()["metadata"], m["metadata"])
+
+	rgdSpec := m["spec"].(map[string]interface{})
+	assert.Equal(t, resources, rgdSpec["resources"])
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "webstack.example.com/v1alpha1",
+		"kind":       "Webstack",
+		"spec":       spec,
+	}, rgdSpec["schema"])
+}
+
 func TestGenerator_Generate_NilObject(t *testing.T) {
 	g := kro.NewGenerator(kro.GeneratorConfig{
 		Name: "test",
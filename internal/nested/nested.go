@@ -0,0 +1,244 @@
+// Package nested embeds subchart RGDs into their parent RGD.
+//
+// Instead of flattening a subchart's resources into the parent RGD, each
+// subchart is converted into an RGD of its own. The parent RGD instantiates
+// it as a single resource of the subchart's generated kind whose spec passes
+// the parent's values through CEL, mapping Helm's dependency structure onto
+// composable RGDs.
+package nested
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hupe1980/chart2kro/internal/maputil"
+)
+
+// Subchart is a subchart converted into its own RGD.
+type Subchart struct {
+	// Name is the subchart name. It is also the values key of the subchart
+	// in the parent chart, so the subchart's schema fields nest under
+	// spec.<name> of the parent schema.
+	Name string
+	// Condition is the Chart.yaml condition enabling the subchart, e.g.
+	// "backend.enabled". Only the first path of a comma-separated list is
+	// used.
+	Condition string
+	// Enabled is the default of the condition.
+	Enabled bool
+	// RGDMap is the subchart's RGD.
+	RGDMap map[string]interface{}
+}
+
+// ResourceID returns the ID of the subchart instance in the parent RGD.
+func (s Subchart) ResourceID() string {
+	return strings.ToLower(s.Name)
+}
+
+// ConditionPath returns the values path of the condition, or "" if the
+// subchart is unconditional.
+func (s Subchart) ConditionPath() string {
+	path, _, _ := strings.Cut(s.Condition, ",")
+
+	return strings.TrimSpace(path)
+}
+
+// Embed adds an instance of each subchart's custom resource to the parent
+// RGD. The subchart's schema fields are added to the parent schema under
+// spec.<name>, keeping fields the parent already declares, and the instance
+// spec references them. A conditional subchart is included only when its
+// condition holds.
+func Embed(parent map[string]interface{}, subcharts []Subchart) error {
+	spec, ok := parent["spec"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("parent RGD has no spec")
+	}
+
+	schema, ok := spec["schema"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("parent RGD has no schema")
+	}
+
+	schemaSpec, _ := schema["spec"].(map[string]interface{})
+	if schemaSpec == nil {
+		schemaSpec = make(map[string]interface{})
+	}
+
+	resources, _ := spec["resources"].([]interface{})
+
+	ids := make(map[string]bool, len(resources))
+
+	for _, r := range resources {
+		if rm, ok := r.(map[string]interface{}); ok {
+			id, _ := rm["id"].(string)
+			ids[id] = true
+		}
+	}
+
+	for _, sub := range subcharts {
+		resource, err := instance(sub, schemaSpec)
+		if err != nil {
+			return fmt.Errorf("subchart %q: %w", sub.Name, err)
+		}
+
+		id := sub.ResourceID()
+		if ids[id] {
+			return fmt.Errorf("subchart %q: resource ID %q is already used by the parent chart", sub.Name, id)
+		}
+
+		ids[id] = true
+		resources = append(resources, resource)
+	}
+
+	if len(schemaSpec) > 0 {
+		schema["spec"] = schemaSpec
+	}
+
+	spec["resources"] = resources
+
+	return nil
+}
+
+// instance builds the instance resource of the subchart and merges its
+// schema fields into the parent schema spec.
+func instance(sub Subchart, parentSpec map[string]interface{}) (map[string]interface{}, error) {
+	rgdSpec, _ := sub.RGDMap["spec"].(map[string]interface{})
+	schema, _ := rgdSpec["schema"].(map[string]interface{})
+
+	apiVersion, _ := schema["apiVersion"].(string)
+	kind, _ := schema["kind"].(string)
+
+	if apiVersion == "" || kind == "" {
+		return nil, fmt.Errorf("RGD has no schema apiVersion and kind")
+	}
+
+	template := map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name": "${schema.metadata.name}-" + sub.ResourceID(),
+		},
+	}
+
+	resource := map[string]interface{}{
+		"id":       sub.ResourceID(),
+		"template": template,
+	}
+
+	if fields, ok := schema["spec"].(map[string]interface{}); ok && len(fields) > 0 {
+		target, err := ensureObject(parentSpec, []string{sub.Name})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := mergeFields(target, fields, sub.Name); err != nil {
+			return nil, err
+		}
+
+		template["spec"] = passThrough(fields, "schema.spec."+sub.Name)
+	}
+
+	if path := sub.ConditionPath(); path != "" {
+		segments := strings.Split(path, ".")
+
+		obj, err := ensureObject(parentSpec, segments[:len(segments)-1])
+		if err != nil {
+			return nil, err
+		}
+
+		leaf := segments[len(segments)-1]
+		if _, exists := obj[leaf]; !exists {
+			obj[leaf] = fmt.Sprintf("boolean | default=%t", sub.Enabled)
+		}
+
+		resource["includeWhen"] = []interface{}{"${schema.spec." + path + "}"}
+	}
+
+	return resource, nil
+}
+
+// ensureObject returns the object at path below m, creating missing
+// objects on the way.
+func ensureObject(m map[string]interface{}, path []string) (map[string]interface{}, error) {
+	for i, key := range path {
+		v, exists := m[key]
+		if !exists {
+			child := make(map[string]interface{})
+			m[key] = child
+			m = child
+
+			continue
+		}
+
+		child, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("schema field %q is not an object", strings.Join(path[:i+1], "."))
+		}
+
+		m = child
+	}
+
+	return m, nil
+}
+
+// mergeFields adds the schema fields of src to dst. Fields dst already
+// declares are kept.
+func mergeFields(dst, src map[string]interface{}, path string) error {
+	for _, k := range sortedKeys(src) {
+		fieldPath := path + "." + k
+
+		srcObj, srcIsObj := src[k].(map[string]interface{})
+		existing, exists := dst[k]
+
+		switch {
+		case !exists && srcIsObj:
+			dst[k] = maputil.DeepCopyMap(srcObj)
+		case !exists:
+			dst[k] = src[k]
+		case srcIsObj:
+			dstObj, ok := existing.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("schema field %q is not an object in the parent chart", fieldPath)
+			}
+
+			if err := mergeFields(dstObj, srcObj, fieldPath); err != nil {
+				return err
+			}
+		default:
+			if _, ok := existing.(map[string]interface{}); ok {
+				return fmt.Errorf("schema field %q is an object in the parent chart", fieldPath)
+			}
+		}
+	}
+
+	return nil
+}
+
+// passThrough mirrors the schema fields as CEL references below prefix.
+func passThrough(fields map[string]interface{}, prefix string) map[string]interface{} {
+	out := make(map[string]interface{}, len(fields))
+
+	for k, v := range fields {
+		path := prefix + "." + k
+
+		if obj, ok := v.(map[string]interface{}); ok {
+			out[k] = passThrough(obj, path)
+		} else {
+			out[k] = "${" + path + "}"
+		}
+	}
+
+	return out
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,141 @@
+package nested
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parentRGD() map[string]interface{} {
+	return map[string]interface{}{
+		"spec": map[string]interface{}{
+			"schema": map[string]interface{}{
+				"apiVersion": "app.kro.run/v1alpha1",
+				"kind":       "App",
+				"spec": map[string]interface{}{
+					"replicaCount": "integer | default=1",
+					"postgresql":   map[string]interface{}{"auth": map[string]interface{}{"database": `string | default="app"`}},
+				},
+			},
+			"resources": []interface{}{
+				map[string]interface{}{"id": "deployment"},
+			},
+		},
+	}
+}
+
+func postgresqlRGD() map[string]interface{} {
+	return map[string]interface{}{
+		"spec": map[string]interface{}{
+			"schema": map[string]interface{}{
+				"apiVersion": "app-postgresql.kro.run/v1alpha1",
+				"kind":       "AppPostgresql",
+				"spec": map[string]interface{}{
+					"auth": map[string]interface{}{
+						"database": `string | default="postgres"`,
+						"username": `string | default="app"`,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	parent := parentRGD()
+
+	err := Embed(parent, []Subchart{{Name: "postgresql", RGDMap: postgresqlRGD()}})
+	require.NoError(t, err)
+
+	spec := parent["spec"].(map[string]interface{})
+	resources := spec["resources"].([]interface{})
+	require.Len(t, resources, 2)
+
+	instance := resources[1].(map[string]interface{})
+	assert.Equal(t, "postgresql", instance["id"])
+	assert.NotContains(t, instance, "includeWhen")
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "app-postgresql.kro.run/v1alpha1",
+		"kind":       "AppPostgresql",
+		"metadata":   map[string]interface{}{"name": "${schema.metadata.name}-postgresql"},
+		"spec": map[string]interface{}{
+			"auth": map[string]interface{}{
+				"database": "${schema.spec.postgresql.auth.database}",
+				"username": "${schema.spec.postgresql.auth.username}",
+			},
+		},
+	}, instance["template"])
+
+	// Fields the parent declares keep their defaults.
+	schemaSpec := spec["schema"].(map[string]interface{})["spec"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"database": `string | default="app"`,
+		"username": `string | default="app"`,
+	}, schemaSpec["postgresql"].(map[string]interface{})["auth"])
+}
+
+func TestEmbed_Condition(t *testing.T) {
+	parent := parentRGD()
+
+	err := Embed(parent, []Subchart{{
+		Name:      "postgresql",
+		Condition: "postgresql.enabled, global.postgresql.enabled",
+		RGDMap:    postgresqlRGD(),
+	}})
+	require.NoError(t, err)
+
+	spec := parent["spec"].(map[string]interface{})
+	instance := spec["resources"].([]interface{})[1].(map[string]interface{})
+	assert.Equal(t, []interface{}{"${schema.spec.postgresql.enabled}"}, instance["includeWhen"])
+
+	schemaSpec := spec["schema"].(map[string]interface{})["spec"].(map[string]interface{})
+	assert.Equal(t, "boolean | default=false", schemaSpec["postgresql"].(map[string]interface{})["enabled"])
+}
+
+func TestEmbed_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		parent  func() map[string]interface{}
+		sub     Subchart
+		wantErr string
+	}{
+		{
+			name:    "ID collision",
+			parent:  parentRGD,
+			sub:     Subchart{Name: "deployment", RGDMap: postgresqlRGD()},
+			wantErr: "already used",
+		},
+		{
+			name:    "no child schema",
+			parent:  parentRGD,
+			sub:     Subchart{Name: "postgresql", RGDMap: map[string]interface{}{}},
+			wantErr: "no schema apiVersion and kind",
+		},
+		{
+			name: "field conflict",
+			parent: func() map[string]interface{} {
+				p := parentRGD()
+				p["spec"].(map[string]interface{})["schema"].(map[string]interface{})["spec"].(map[string]interface{})["postgresql"] = "string"
+
+				return p
+			},
+			sub:     Subchart{Name: "postgresql", RGDMap: postgresqlRGD()},
+			wantErr: `schema field "postgresql" is not an object`,
+		},
+		{
+			name:    "no parent schema",
+			parent:  func() map[string]interface{} { return map[string]interface{}{"spec": map[string]interface{}{}} },
+			sub:     Subchart{Name: "postgresql", RGDMap: postgresqlRGD()},
+			wantErr: "parent RGD has no schema",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Embed(tt.parent(), []Subchart{tt.sub})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/hupe1980/chart2kro/internal/helm/chartmeta"
+	"github.com/hupe1980/chart2kro/internal/helm/postrender"
+	"github.com/hupe1980/chart2kro/internal/helm/renderer"
+	"github.com/hupe1980/chart2kro/internal/nested"
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+// NestedRGD is a subchart converted into an RGD of its own.
+type NestedRGD struct {
+	// Path is the subchart path below the root chart, e.g. "backend" or
+	// "backend/cache".
+	Path string
+	// RGDMap is the normalized RGD.
+	RGDMap map[string]interface{}
+	Result *transform.Result
+}
+
+// nest converts the Nested subcharts into RGDs named "<parent>-<subchart>"
+// and instantiates them in RGDMap. Each subchart is rendered with the
+// values Helm would pass it: its defaults coalesced with the parent's
+// values below its name and the globals. NestedRGDs lists the RGDs,
+// including those of nested subcharts, dependencies first.
+func (p *Pipeline) nest(ctx context.Context) error {
+	ch := p.Chart()
+
+	charts := make(map[string]*chart.Chart)
+	for _, dep := range ch.Dependencies() {
+		charts[dep.Name()] = dep
+	}
+
+	coalesced, err := chartutil.CoalesceValues(aliasSubcharts(ch, p.Meta), p.Values)
+	if err != nil {
+		return fmt.Errorf("coalescing subchart values: %w", err)
+	}
+
+	deps := make(map[string]chartmeta.DependencyMeta)
+	for _, dep := range p.Meta.Dependencies {
+		deps[dep.Key()] = dep
+	}
+
+	var subcharts []nested.Subchart
+
+	for _, name := range p.Nested {
+		childValues, _ := coalesced.Table(name)
+
+		chartName := p.nestedCharts[name]
+
+		opts := childOptions(p.opts)
+		opts.Chart = rootChart(aliasChart(charts[chartName], name))
+		opts.RGDName = p.RGDName() + "-" + strings.ToLower(name)
+		opts.PresetValues = childValues.AsMap()
+
+		p.logger.Info("converting nested subchart",
+			slog.String("subchart", name), slog.String("rgd", opts.RGDName))
+
+		child, err := New(p.ref+"/charts/"+chartName, opts)
+		if err != nil {
+			return fmt.Errorf("subchart %q: %w", name, err)
+		}
+
+		if err := child.RunTo(ctx, StageGenerate, nil); err != nil {
+			return fmt.Errorf("subchart %q: %w", name, err)
+		}
+
+		_, rgdMap, err := Normalize(child.RGDMap)
+		if err != nil {
+			return fmt.Errorf("subchart %q: %w", name, err)
+		}
+
+		sub := nested.Subchart{Name: name, Condition: deps[name].Condition, RGDMap: rgdMap}
+		sub.Enabled = conditionDefault(p.Values, sub.ConditionPath())
+		subcharts = append(subcharts, sub)
+
+		for _, n := range child.NestedRGDs {
+			n.Path = name + "/" + n.Path
+			p.NestedRGDs = append(p.NestedRGDs, n)
+		}
+
+		p.NestedRGDs = append(p.NestedRGDs, NestedRGD{Path: name, RGDMap: rgdMap, Result: child.Result})
+	}
+
+	if err := nested.Embed(p.RGDMap, subcharts); err != nil {
+		return fmt.Errorf("embedding subcharts: %w", err)
+	}
+
+	return nil
+}
+
+// childOptions returns the options a nested subchart is converted with.
+// Options that address the parent chart's values, resources, or rendered
+// manifests do not carry over.
+func childOptions(opts Options) Options {
+	child := opts
+
+	child.Kind = ""
+	child.Values = renderer.ValuesOptions{}
+	child.PostRender = postrender.Options{}
+	child.ExcludeSubcharts = nil
+	child.ExcludeResources = nil
+	child.ExternalizeSecret = nil
+	child.ExternalizeService = nil
+	child.UseExternalPattern = nil
+	child.ResourceIDOverrides = nil
+	child.SchemaOverrides = nil
+	child.ExtractTests = false
+	child.CaptureRendered = false
+	child.AlwaysSchema = true
+
+	return child
+}
+
+// rootChart returns a copy of the subchart ch that renders as a root
+// chart: its templates see its values as .Values and their paths start at
+// the subchart.
+func rootChart(ch *chart.Chart) *chart.Chart {
+	c := &chart.Chart{
+		Raw:       ch.Raw,
+		Metadata:  ch.Metadata,
+		Lock:      ch.Lock,
+		Templates: ch.Templates,
+		Values:    ch.Values,
+		Schema:    ch.Schema,
+		Files:     ch.Files,
+	}
+
+	deps := make([]*chart.Chart, 0, len(ch.Dependencies()))
+	for _, dep := range ch.Dependencies() {
+		deps = append(deps, rootChart(dep))
+	}
+
+	c.SetDependencies(deps...)
+
+	return c
+}
+
+// aliasChart returns a copy of ch named alias, like Helm installs an
+// aliased dependency.
+func aliasChart(ch *chart.Chart, alias string) *chart.Chart {
+	c := *ch
+
+	if ch.Metadata != nil {
+		md := *ch.Metadata
+		md.Name = alias
+		c.Metadata = &md
+	}
+
+	return &c
+}
+
+// aliasSubcharts returns a shallow copy of ch whose subcharts are installed
+// under their aliases, once per declared dependency, so that values
+// coalesce below the alias keys as in Helm.
+func aliasSubcharts(ch *chart.Chart, meta *chartmeta.ChartMeta) *chart.Chart {
+	charts := make(map[string]*chart.Chart, len(ch.Dependencies()))
+	for _, dep := range ch.Dependencies() {
+		charts[dep.Name()] = dep
+	}
+
+	declared := make(map[string]bool, len(meta.Dependencies))
+	deps := make([]*chart.Chart, 0, len(ch.Dependencies()))
+
+	for _, dep := range meta.Dependencies {
+		declared[dep.Name] = true
+
+		if sub, ok := charts[dep.Name]; ok {
+			deps = append(deps, aliasChart(sub, dep.Key()))
+		}
+	}
+
+	for _, sub := range ch.Dependencies() {
+		if !declared[sub.Name()] {
+			deps = append(deps, aliasChart(sub, sub.Name()))
+		}
+	}
+
+	c := *ch
+	c.SetDependencies(deps...)
+
+	return &c
+}
+
+// conditionDefault returns the value of a dependency condition. Helm
+// enables subcharts whose condition path is unset.
+func conditionDefault(values map[string]interface{}, path string) bool {
+	if path == "" {
+		return true
+	}
+
+	v, err := chartutil.Values(values).PathValue(path)
+	if err != nil {
+		return true
+	}
+
+	enabled, ok := v.(bool)
+
+	return !ok || enabled
+}
@@ -0,0 +1,1186 @@
+// Package pipeline runs the chart-to-RGD conversion in stages: load,
+// render, filter, transform, harden, and generate.
+//
+// Each stage records its artifacts on the Pipeline, so callers can inspect
+// or adjust them before running the next stage. The package is the shared
+// core of the CLI commands and the pkg/chart2kro API.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"helm.sh/helm/v3/pkg/chart"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/hupe1980/chart2kro/internal/config"
+	"github.com/hupe1980/chart2kro/internal/conformance"
+	"github.com/hupe1980/chart2kro/internal/filter"
+	"github.com/hupe1980/chart2kro/internal/harden"
+	"github.com/hupe1980/chart2kro/internal/helm/chartmeta"
+	"github.com/hupe1980/chart2kro/internal/helm/deps"
+	"github.com/hupe1980/chart2kro/internal/helm/hooks"
+	"github.com/hupe1980/chart2kro/internal/helm/loader"
+	"github.com/hupe1980/chart2kro/internal/helm/postrender"
+	"github.com/hupe1980/chart2kro/internal/helm/renderer"
+	"github.com/hupe1980/chart2kro/internal/k8s"
+	"github.com/hupe1980/chart2kro/internal/k8s/parser"
+	"github.com/hupe1980/chart2kro/internal/kro"
+	"github.com/hupe1980/chart2kro/internal/output"
+	"github.com/hupe1980/chart2kro/internal/transform"
+	"github.com/hupe1980/chart2kro/internal/transform/transformer"
+)
+
+// Stage names a pipeline stage.
+type Stage string
+
+// Pipeline stages, in order.
+const (
+	StageLoad      Stage = "load"
+	StageRender    Stage = "render"
+	StageFilter    Stage = "filter"
+	StageTransform Stage = "transform"
+	StageHarden    Stage = "harden"
+	StageGenerate  Stage = "generate"
+)
+
+// Stages lists the pipeline stages in order.
+var Stages = []Stage{StageLoad, StageRender, StageFilter, StageTransform, StageHarden, StageGenerate}
+
+// Options configures a pipeline.
+type Options struct {
+	// Load configures chart loading.
+	Load loader.LoadOptions
+
+	// Chart is converted instead of loading the chart reference when set.
+	Chart *chart.Chart
+
+	ReleaseName string
+	Namespace   string
+	Strict      bool
+	// Timeout bounds each template rendering (default: 30s).
+	Timeout time.Duration
+
+	// PostRender configures the post-renderer and Kustomize overlay.
+	PostRender postrender.Options
+
+	// Values are merged with the chart defaults.
+	Values renderer.ValuesOptions
+	// PresetValues are used as the merged values instead of Values when set.
+	PresetValues map[string]interface{}
+
+	HookMode hooks.Mode
+
+	// Filtering.
+	Profile            string
+	ExcludeKinds       []string
+	ExcludeResources   []string
+	ExcludeSubcharts   []string
+	ExcludeLabels      string
+	ExternalizeSecret  []string
+	ExternalizeService []string
+	UseExternalPattern []string
+
+	// NestedSubcharts excludes the resources of the vendored subcharts
+	// (except excluded and externalized ones) so that they can be
+	// converted into RGDs of their own. The subcharts are listed in
+	// Pipeline.Nested after loading, by alias or name, and converted and
+	// instantiated by the RGD in the generate stage. It cannot be combined
+	// with FlatSchema.
+	NestedSubcharts bool
+
+	// ConfigData is a .chart2kro.yaml document with transformers, schema,
+	// status, and dependency overrides, custom profiles, and hardening
+	// policies.
+	ConfigData []byte
+	// ConfigDir is the directory relative plugin paths of ConfigData are
+	// resolved against. Paths are left as is when empty.
+	ConfigDir string
+
+	// ResourceIDOverrides take precedence over those of ConfigData.
+	ResourceIDOverrides map[string]string
+	// SchemaOverrides replace those of ConfigData when set.
+	SchemaOverrides map[string]transform.SchemaOverride
+
+	// ReadyConditions is the path of a custom ready conditions file.
+	ReadyConditions  string
+	IncludeAllValues bool
+	FlatSchema       bool
+	// Fast detects parameters by template AST analysis instead of sentinel
+	// diffing.
+	Fast bool
+
+	// CaptureRendered records copies of the rendered resources in
+	// Pipeline.RenderedCopies before parameters replace their values.
+	CaptureRendered bool
+
+	// ExtractTests builds Pipeline.Conformance from the Helm test hooks.
+	ExtractTests bool
+
+	// Hardening.
+	Harden                  bool
+	SecurityLevel           string
+	GenerateNetworkPolicies bool
+	GenerateRBAC            bool
+	ResolveDigests          bool
+	// EmbedTimestamp adds the generation time to the provenance
+	// annotations, which the RGD carries with Harden.
+	EmbedTimestamp bool
+
+	// RGDName names the RGD (default: the chart name).
+	RGDName    string
+	Kind       string
+	APIVersion string
+	Group      string
+	// AlwaysSchema emits a schema even without schema fields.
+	AlwaysSchema bool
+
+	// Logger is used for structured logging (default: discard).
+	Logger *slog.Logger
+}
+
+// OptionError is an error caused by invalid options, e.g. an unknown
+// profile or security level.
+type OptionError struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e *OptionError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the wrapped error.
+func (e *OptionError) Unwrap() error { return e.Err }
+
+// Pipeline converts a chart in stages. The exported fields hold the
+// artifacts of the stages run so far.
+type Pipeline struct {
+	// Loaded is the loaded chart with its digest and verification
+	// (load).
+	Loaded *loader.LoadResult
+	// Meta is the chart metadata (load).
+	Meta *chartmeta.ChartMeta
+	// Nested are the subcharts excluded for nested conversion, keyed by
+	// alias or name like their values (load).
+	Nested []string
+
+	// Values are the merged values (render).
+	Values map[string]interface{}
+	// Manifests are the rendered, post-rendered manifests (render).
+	Manifests []byte
+	// HookResult is the outcome of the hook handling (render).
+	HookResult *hooks.FilterResult
+	// Resources are the parsed resources (render), the resources kept by
+	// the filters (filter), and the transformed and hardened resources
+	// (transform, harden).
+	Resources []*k8s.Resource
+
+	// FilterResult is the outcome of the filters, nil without filters
+	// (filter).
+	FilterResult *filter.Result
+
+	// Config is the parsed ConfigData, nil when empty (transform).
+	Config *config.TransformConfig
+	// ResourceIDs are the IDs used for parameter detection (transform).
+	ResourceIDs map[*k8s.Resource]string
+	// RenderedCopies are copies of the rendered resources by ID, with
+	// CaptureRendered (transform).
+	RenderedCopies map[string]*k8s.Resource
+	// FieldMappings are the detected parameter mappings (transform).
+	FieldMappings []transform.FieldMapping
+	// ReferencedPaths are the values paths referenced by the templates
+	// (transform).
+	ReferencedPaths map[string]bool
+	// SentinelTests are the sentinel-rendered Helm test hooks (transform).
+	SentinelTests []hooks.Resource
+	// Result is the transformation result (transform).
+	Result *transform.Result
+
+	// HardenResult is the hardening result, nil without Harden (harden).
+	HardenResult *harden.Result
+
+	// RGDMap is the generated RGD (generate). Normalize it before writing.
+	RGDMap map[string]interface{}
+	// NestedRGDs are the subchart RGDs instantiated by RGDMap, in the
+	// order they must be applied (generate).
+	NestedRGDs []NestedRGD
+	// Conformance is the suite built from the Helm test hooks, with
+	// ExtractTests (generate).
+	Conformance *conformance.Suite
+
+	ref     string
+	opts    Options
+	logger  *slog.Logger
+	done    int
+	err     error
+	render  *renderer.HelmRenderer
+	post    postrender.PostRenderer
+	parser  *parser.DefaultParser
+	sourced []renderer.SourcedManifest
+	ready   map[string][]string
+
+	// nestedCharts maps the Nested keys to the names of the charts behind
+	// them, which the rendered template paths use.
+	nestedCharts map[string]string
+}
+
+// New returns a pipeline converting the chart reference.
+func New(ref string, opts Options) (*Pipeline, error) {
+	if opts.NestedSubcharts && opts.FlatSchema {
+		return nil, &OptionError{Err: errors.New("nested subcharts cannot be combined with a flat schema")}
+	}
+
+	post, err := postrender.New(opts.PostRender)
+	if err != nil {
+		return nil, &OptionError{Err: err}
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	return &Pipeline{
+		ref:    ref,
+		opts:   opts,
+		logger: logger,
+		post:   post,
+		parser: parser.NewParser(),
+		render: renderer.New(renderer.RenderOptions{
+			ReleaseName: opts.ReleaseName,
+			Namespace:   opts.Namespace,
+			Strict:      opts.Strict,
+		}),
+	}, nil
+}
+
+// Chart returns the loaded chart.
+func (p *Pipeline) Chart() *chart.Chart {
+	if p.Loaded == nil {
+		return nil
+	}
+
+	return p.Loaded.Chart
+}
+
+// Done reports whether the stage has run.
+func (p *Pipeline) Done(stage Stage) bool {
+	for _, s := range Stages[:p.done] {
+		if s == stage {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RunTo runs the stages up to and including stage that have not run yet.
+// The after callback, if not nil, runs after each stage. Once a stage or
+// callback fails, RunTo returns its error.
+func (p *Pipeline) RunTo(ctx context.Context, stage Stage, after func(Stage) error) error {
+	if p.err != nil || p.Done(stage) {
+		return p.err
+	}
+
+	for p.done < len(Stages) {
+		next := Stages[p.done]
+
+		if err := p.run(ctx, next); err != nil {
+			p.err = err
+
+			return err
+		}
+
+		p.done++
+
+		if after != nil {
+			if err := after(next); err != nil {
+				p.err = err
+
+				return err
+			}
+		}
+
+		if next == stage {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (p *Pipeline) run(ctx context.Context, stage Stage) error {
+	switch stage {
+	case StageLoad:
+		return p.load(ctx)
+	case StageRender:
+		return p.renderStage(ctx)
+	case StageFilter:
+		return p.filter(ctx)
+	case StageTransform:
+		return p.transform(ctx)
+	case StageHarden:
+		return p.harden(ctx)
+	case StageGenerate:
+		return p.generate(ctx)
+	default:
+		return fmt.Errorf("unknown stage %q", stage)
+	}
+}
+
+// load loads the chart and analyzes its dependencies.
+func (p *Pipeline) load(ctx context.Context) error {
+	if p.opts.Chart != nil {
+		p.Loaded = &loader.LoadResult{Chart: p.opts.Chart}
+	} else {
+		p.logger.Info("loading chart", slog.String("ref", p.ref))
+
+		loaded, err := loader.NewMultiLoader().LoadChart(ctx, p.ref, p.opts.Load)
+		if err != nil {
+			return fmt.Errorf("loading chart: %w", err)
+		}
+
+		p.Loaded = loaded
+	}
+
+	if v := p.Loaded.Verification; v != nil {
+		p.logger.Info("chart signature verified",
+			slog.String("method", v.Method),
+			slog.String("identity", v.Identity),
+		)
+	}
+
+	ch := p.Loaded.Chart
+
+	p.Meta = chartmeta.FromChart(ch)
+	p.logger.Info("chart loaded",
+		slog.String("name", p.Meta.Name),
+		slog.String("version", p.Meta.Version),
+		slog.String("appVersion", p.Meta.AppVersion),
+	)
+
+	if p.Meta.IsLibrary() {
+		return fmt.Errorf("chart %q is a library chart and cannot be converted", p.Meta.Name)
+	}
+
+	if p.Meta.HasDependencies() {
+		depResult := deps.Analyze(ch, p.logger)
+		if !depResult.AllResolved {
+			missing := deps.MissingDependencies(depResult)
+			p.logger.Warn("some dependencies are not vendored", slog.Any("missing", missing),
+				slog.String("hint", "use --resolve-dependencies to fetch them"))
+		}
+	}
+
+	if p.opts.NestedSubcharts {
+		p.Nested, p.nestedCharts = nestedSubcharts(ch, p.Meta, &p.opts)
+	}
+
+	return nil
+}
+
+// nestedSubcharts returns the vendored subcharts of ch, except excluded and
+// externalized ones, keyed by alias or name as Helm installs them, sorted,
+// and a map of the keys to the names of the charts behind them. A chart
+// declared under several aliases is returned once per alias.
+func nestedSubcharts(ch *chart.Chart, meta *chartmeta.ChartMeta, opts *Options) ([]string, map[string]string) {
+	skip := make(map[string]bool)
+	for _, name := range append(append([]string{}, opts.ExcludeSubcharts...), opts.UseExternalPattern...) {
+		skip[strings.ToLower(name)] = true
+	}
+
+	vendored := make(map[string]bool, len(ch.Dependencies()))
+	for _, dep := range ch.Dependencies() {
+		vendored[dep.Name()] = true
+	}
+
+	declared := make(map[string]bool, len(meta.Dependencies))
+	charts := make(map[string]string)
+
+	for _, dep := range meta.Dependencies {
+		declared[dep.Name] = true
+
+		if key := dep.Key(); vendored[dep.Name] && !skip[strings.ToLower(key)] {
+			charts[key] = dep.Name
+		}
+	}
+
+	// Helm also installs vendored subcharts that Chart.yaml does not list.
+	for name := range vendored {
+		if !declared[name] && !skip[strings.ToLower(name)] {
+			charts[name] = name
+		}
+	}
+
+	keys := make([]string, 0, len(charts))
+	for key := range charts {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys, charts
+}
+
+// renderStage merges the values, renders and post-renders the templates,
+// handles hooks, and parses the resources.
+func (p *Pipeline) renderStage(ctx context.Context) error {
+	ch := p.Loaded.Chart
+
+	p.Values = p.opts.PresetValues
+
+	if p.Values == nil {
+		values, err := renderer.MergeValues(ch, p.opts.Values)
+		if err != nil {
+			return fmt.Errorf("merging values: %w", err)
+		}
+
+		p.Values = values
+	}
+
+	renderCtx, cancel := context.WithTimeout(ctx, p.opts.Timeout)
+	defer cancel()
+
+	needsSources := len(p.opts.ExcludeSubcharts) > 0 || p.opts.Profile != "" ||
+		len(p.opts.UseExternalPattern) > 0 || len(p.Nested) > 0
+
+	var (
+		rendered []byte
+		err      error
+	)
+
+	if needsSources {
+		p.sourced, err = p.render.RenderWithSources(renderCtx, ch, p.Values)
+		if err != nil {
+			return fmt.Errorf("rendering templates: %w", err)
+		}
+
+		rendered = renderer.CombineSourcedManifests(p.sourced)
+	} else {
+		rendered, err = p.render.Render(renderCtx, ch, p.Values)
+		if err != nil {
+			return fmt.Errorf("rendering templates: %w", err)
+		}
+	}
+
+	// Sentinel renders go through the same post-renderer, so patched
+	// fields derived from values are still parameterised.
+	if p.post != nil {
+		rendered, err = p.post.Run(renderCtx, rendered)
+		if err != nil {
+			return fmt.Errorf("post-rendering: %w", err)
+		}
+	}
+
+	p.Manifests = rendered
+
+	p.HookResult, err = hooks.FilterMode(rendered, p.opts.HookMode, p.logger)
+	if err != nil {
+		return fmt.Errorf("filtering hooks: %w", err)
+	}
+
+	resources, err := p.parser.Parse(ctx, hooks.CombineResources(p.HookResult))
+	if err != nil {
+		return fmt.Errorf("parsing resources: %w", err)
+	}
+
+	if len(resources) == 0 {
+		return errors.New("no resources found in rendered output")
+	}
+
+	p.logger.Info("parsed resources", slog.Int("count", len(resources)))
+
+	if len(p.sourced) > 0 {
+		AssignSourcePaths(resources, p.sourced)
+	}
+
+	p.Resources = resources
+
+	return nil
+}
+
+// filter applies the resource filters.
+func (p *Pipeline) filter(ctx context.Context) error {
+	chain, err := p.filterChain()
+	if err != nil {
+		return &OptionError{Err: fmt.Errorf("building filter chain: %w", err)}
+	}
+
+	if chain != nil {
+		p.FilterResult, err = chain.Apply(ctx, p.Resources)
+		if err != nil {
+			return fmt.Errorf("applying filters: %w", err)
+		}
+
+		p.Resources = p.FilterResult.Included
+	}
+
+	if len(p.Resources) == 0 && len(p.Nested) == 0 {
+		return errors.New("no resources remaining after filtering")
+	}
+
+	return nil
+}
+
+// filterChain assembles the filter chain from the options. It returns nil
+// when no filters are configured.
+func (p *Pipeline) filterChain() (*filter.Chain, error) {
+	opts := &p.opts
+
+	var filters []filter.Filter
+
+	// 1. Profile-based filters.
+	if opts.Profile != "" {
+		if filter.IsMinimalProfile(opts.Profile) {
+			filters = append(filters, filter.NewMinimalFilter())
+		} else {
+			var customProfiles map[string]filter.ProfileConfig
+			if len(opts.ConfigData) > 0 {
+				customProfiles, _ = filter.ParseCustomProfiles(opts.ConfigData)
+			}
+
+			profile, err := filter.ResolveProfile(opts.Profile, customProfiles)
+			if err != nil {
+				return nil, err
+			}
+
+			profileFilters, err := filter.BuildFiltersFromProfile(profile)
+			if err != nil {
+				return nil, fmt.Errorf("building filters from profile %q: %w", opts.Profile, err)
+			}
+
+			filters = append(filters, profileFilters...)
+		}
+	}
+
+	// 2. Smart external pattern detection.
+	for _, name := range opts.UseExternalPattern {
+		pattern, found := filter.DetectExternalPatternsForSubchart(p.Meta, p.Values, name)
+		if !found {
+			return nil, fmt.Errorf("no external pattern detected for subchart %q", name)
+		}
+
+		filters = append(filters, filter.BuildFiltersFromPattern(pattern)...)
+	}
+
+	// 3. Explicit subchart exclusion. Nested subcharts are converted into
+	// RGDs of their own.
+	subcharts := append([]string{}, opts.ExcludeSubcharts...)
+	for _, key := range p.Nested {
+		subcharts = append(subcharts, p.nestedCharts[key])
+	}
+
+	if len(subcharts) > 0 {
+		filters = append(filters, filter.NewSubchartFilter(subcharts))
+	}
+
+	// 4. Kind exclusion.
+	if len(opts.ExcludeKinds) > 0 {
+		filters = append(filters, filter.NewKindFilter(opts.ExcludeKinds))
+	}
+
+	// 5. Label exclusion.
+	if opts.ExcludeLabels != "" {
+		lf, err := filter.NewLabelFilter(opts.ExcludeLabels)
+		if err != nil {
+			return nil, fmt.Errorf("parsing label selector: %w", err)
+		}
+
+		filters = append(filters, lf)
+	}
+
+	// 6. Resource ID exclusion (needs IDs assigned first).
+	if len(opts.ExcludeResources) > 0 {
+		tempIDs, err := transform.AssignResourceIDs(p.Resources, nil)
+		if err != nil {
+			return nil, fmt.Errorf("assigning IDs for resource exclusion: %w", err)
+		}
+
+		filters = append(filters, filter.NewResourceIDFilter(opts.ExcludeResources, tempIDs))
+	}
+
+	// 7. ExternalRef promotion.
+	var mappings []filter.ExternalMapping
+
+	for _, expr := range opts.ExternalizeSecret {
+		m, err := filter.ParseExternalMapping("Secret", expr)
+		if err != nil {
+			return nil, err
+		}
+
+		mappings = append(mappings, m)
+	}
+
+	for _, expr := range opts.ExternalizeService {
+		m, err := filter.ParseExternalMapping("Service", expr)
+		if err != nil {
+			return nil, err
+		}
+
+		mappings = append(mappings, m)
+	}
+
+	if len(mappings) > 0 {
+		filters = append(filters, filter.NewExternalRefFilter(mappings))
+	}
+
+	if len(filters) == 0 {
+		return nil, nil //nolint:nilnil // intentional: no filters configured
+	}
+
+	return filter.NewChain(filters...), nil
+}
+
+// transform loads the config, detects parameter mappings, and runs the
+// transformation engine.
+func (p *Pipeline) transform(ctx context.Context) error {
+	p.loadConfig()
+
+	resourceIDOverrides := p.resourceIDOverrides()
+
+	ids, err := transform.AssignResourceIDs(p.Resources, resourceIDOverrides)
+	if err != nil {
+		return fmt.Errorf("assigning resource IDs: %w", err)
+	}
+
+	p.ResourceIDs = ids
+
+	if p.opts.CaptureRendered {
+		p.RenderedCopies = copyResources(p.Resources, ids)
+	}
+
+	p.detectMappings(ctx)
+	p.pruneOrphanedPaths()
+
+	if p.opts.ReadyConditions != "" {
+		p.ready, err = transform.LoadCustomReadyConditions(p.opts.ReadyConditions)
+		if err != nil {
+			return fmt.Errorf("loading ready conditions: %w", err)
+		}
+	}
+
+	engineCfg := transform.EngineConfig{
+		IncludeAllValues:    p.opts.IncludeAllValues,
+		FlatSchema:          p.opts.FlatSchema,
+		FieldMappings:       p.FieldMappings,
+		ReferencedPaths:     p.ReferencedPaths,
+		JSONSchemaBytes:     p.Meta.Schema,
+		ResourceIDOverrides: resourceIDOverrides,
+		OrderHooks:          p.opts.HookMode == hooks.ModeOrdered,
+		AllowEmpty:          len(p.Nested) > 0,
+		SchemaOverrides:     p.opts.SchemaOverrides,
+	}
+
+	if cfg := p.Config; cfg != nil {
+		if len(cfg.SchemaOverrides) > 0 && len(engineCfg.SchemaOverrides) == 0 {
+			engineCfg.SchemaOverrides = toSchemaOverrides(cfg.SchemaOverrides)
+		}
+
+		if cfg.DerivedValues != nil {
+			engineCfg.DerivedInputs, engineCfg.DerivedValues = toDerivedValues(cfg.DerivedValues)
+		}
+
+		if cfg.Status != nil {
+			engineCfg.Status = toStatusConfig(cfg.Status, p.ready)
+		}
+
+		if cfg.Dependencies != nil {
+			engineCfg.ReferencePaths = toReferencePaths(cfg.Dependencies)
+
+			strategy, err := transform.ParseCycleStrategy(cfg.Dependencies.CycleStrategy)
+			if err != nil {
+				return &OptionError{Err: err}
+			}
+
+			engineCfg.CycleStrategy = strategy
+		}
+
+		// Config transformers take precedence over the defaults.
+		registry := transformer.DefaultRegistry()
+		defer p.closeRegistry(ctx, registry)
+
+		for i := len(cfg.Transformers) - 1; i >= 0; i-- {
+			t, err := transformer.FromConfig(cfg.Transformers[i])
+			if err != nil {
+				return &OptionError{Err: fmt.Errorf("loading transformer config: %w", err)}
+			}
+
+			registry.Prepend(t)
+		}
+
+		engineCfg.TransformerRegistry = registry
+	}
+
+	result, err := transform.NewEngine(engineCfg).Transform(ctx, p.Resources, p.Values)
+	if err != nil {
+		return fmt.Errorf("transformation failed: %w", err)
+	}
+
+	p.Result = result
+	p.Resources = result.Resources
+
+	return nil
+}
+
+// closeRegistry releases the plugin runtimes of registry once the engine
+// finished. Failures are logged, not fatal.
+func (p *Pipeline) closeRegistry(ctx context.Context, registry *transformer.Registry) {
+	if err := registry.Close(ctx); err != nil {
+		p.logger.Warn("closing transformers failed", slog.String("error", err.Error()))
+	}
+}
+
+// loadConfig parses ConfigData. Parse errors are logged, not fatal.
+func (p *Pipeline) loadConfig() {
+	if len(p.opts.ConfigData) == 0 {
+		return
+	}
+
+	cfg, err := config.ParseTransformConfig(p.opts.ConfigData)
+	if err != nil {
+		p.logger.Warn("transform config parse failed", slog.String("error", err.Error()))
+
+		return
+	}
+
+	if cfg.IsEmpty() {
+		return
+	}
+
+	if p.opts.ConfigDir != "" {
+		cfg.ResolvePaths(p.opts.ConfigDir)
+	}
+
+	p.Config = cfg
+	p.logger.Info("loaded transform config",
+		slog.Int("transformers", len(cfg.Transformers)),
+		slog.Int("schemaOverrides", len(cfg.SchemaOverrides)),
+		slog.Int("resourceIdOverrides", len(cfg.ResourceIDOverrides)),
+	)
+}
+
+// resourceIDOverrides merges the resource ID overrides of the options and
+// the config. The options take precedence.
+func (p *Pipeline) resourceIDOverrides() map[string]string {
+	if p.Config == nil || len(p.Config.ResourceIDOverrides) == 0 {
+		return p.opts.ResourceIDOverrides
+	}
+
+	merged := make(map[string]string, len(p.Config.ResourceIDOverrides)+len(p.opts.ResourceIDOverrides))
+	for k, v := range p.Config.ResourceIDOverrides {
+		merged[k] = v
+	}
+
+	for k, v := range p.opts.ResourceIDOverrides {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// detectMappings detects the parameter mappings, by template AST analysis
+// in fast mode and by sentinel diffing otherwise.
+func (p *Pipeline) detectMappings(ctx context.Context) {
+	ch := p.Loaded.Chart
+	p.ReferencedPaths = make(map[string]bool)
+
+	if p.opts.Fast {
+		p.logger.Info("using fast mode (template AST analysis)")
+
+		templateFiles := make(map[string]string)
+		for _, t := range ch.Templates {
+			templateFiles[t.Name] = string(t.Data)
+		}
+
+		astRefs, err := transform.AnalyzeTemplates(templateFiles)
+		if err != nil {
+			p.logger.Warn("template AST analysis failed", slog.String("error", err.Error()))
+		} else {
+			p.ReferencedPaths = astRefs
+			p.FieldMappings = transform.MatchFieldsByValue(p.Resources, p.ResourceIDs, p.Values, astRefs)
+		}
+
+		p.logger.Info("field mappings detected", slog.Int("count", len(p.FieldMappings)))
+
+		return
+	}
+
+	renderCtx, cancel := context.WithTimeout(ctx, p.opts.Timeout)
+	defer cancel()
+
+	sentinelRendered, err := p.render.Render(renderCtx, ch, transform.SentinelizeAll(p.Values))
+	if err == nil && p.post != nil {
+		sentinelRendered, err = p.post.Run(renderCtx, sentinelRendered)
+	}
+
+	var sentinelResources []*k8s.Resource
+
+	if err != nil {
+		p.logger.Warn("sentinel render failed", slog.String("error", err.Error()))
+	} else {
+		hookResult, hookErr := hooks.FilterMode(sentinelRendered, p.opts.HookMode, p.logger)
+		if hookErr != nil {
+			p.logger.Warn("sentinel hook filtering failed", slog.String("error", hookErr.Error()))
+		} else {
+			p.SentinelTests = hookResult.TestHooks()
+
+			parsed, parseErr := p.parser.Parse(ctx, hooks.CombineResources(hookResult))
+			if parseErr != nil {
+				p.logger.Warn("sentinel resource parsing failed", slog.String("error", parseErr.Error()))
+			} else {
+				sentinelResources = parsed
+			}
+		}
+	}
+
+	p.FieldMappings = transform.ParallelDiffAllResources(p.Resources, sentinelResources, p.ResourceIDs, transform.ParallelDiffConfig{})
+
+	for _, m := range p.FieldMappings {
+		p.ReferencedPaths[m.ValuesPath] = true
+	}
+
+	p.logger.Info("field mappings detected", slog.Int("count", len(p.FieldMappings)))
+}
+
+// pruneOrphanedPaths drops the values paths referenced only by excluded
+// resources.
+func (p *Pipeline) pruneOrphanedPaths() {
+	if p.FilterResult == nil || len(p.FilterResult.Excluded) == 0 || len(p.FieldMappings) == 0 {
+		return
+	}
+
+	refs := make([]filter.FieldMappingRef, len(p.FieldMappings))
+	for i, m := range p.FieldMappings {
+		refs[i] = filter.FieldMappingRef{ResourceID: m.ResourceID, ValuesPath: m.ValuesPath}
+	}
+
+	for path := range filter.PruneOrphanedFields(refs, p.FilterResult.Excluded, p.ResourceIDs) {
+		delete(p.ReferencedPaths, path)
+	}
+}
+
+// harden applies security hardening when enabled.
+func (p *Pipeline) harden(ctx context.Context) error {
+	if !p.opts.Harden {
+		return nil
+	}
+
+	level, err := harden.ParseSecurityLevel(p.opts.SecurityLevel)
+	if err != nil {
+		return &OptionError{Err: err}
+	}
+
+	cfg := harden.Config{
+		SecurityLevel:           level,
+		GenerateNetworkPolicies: p.opts.GenerateNetworkPolicies,
+		GenerateRBAC:            p.opts.GenerateRBAC,
+		ResolveDigests:          p.opts.ResolveDigests,
+		ResourceIDs:             p.Result.ResourceIDs,
+	}
+
+	if len(p.opts.ConfigData) > 0 {
+		if fileCfg, parseErr := harden.ParseFileConfig(p.opts.ConfigData); parseErr == nil && fileCfg != nil {
+			cfg.ImagePolicy = fileCfg.ToImagePolicyConfig()
+			cfg.ResourceDefaults = fileCfg.ToResourceDefaultsConfig()
+		}
+	}
+
+	result, err := harden.New(cfg).Harden(ctx, p.Result.Resources)
+	if err != nil {
+		return fmt.Errorf("hardening failed: %w", err)
+	}
+
+	p.HardenResult = result
+	p.Result.Resources = result.Resources
+	p.Resources = result.Resources
+
+	p.logger.Info("hardening complete",
+		slog.Int("changes", len(result.Changes)),
+		slog.Int("warnings", len(result.Warnings)),
+	)
+
+	for _, w := range result.Warnings {
+		p.logger.Warn("hardening warning", slog.String("detail", w))
+	}
+
+	return nil
+}
+
+// generate generates the RGD, converts and instantiates the nested
+// subcharts, and adds the provenance annotations with Harden.
+func (p *Pipeline) generate(ctx context.Context) error {
+	generator := kro.NewGenerator(kro.GeneratorConfig{
+		Name:                  p.RGDName(),
+		ChartName:             p.Meta.Name,
+		ChartVersion:          p.Meta.Version,
+		SchemaKind:            p.opts.Kind,
+		SchemaAPIVersion:      p.opts.APIVersion,
+		SchemaGroup:           p.opts.Group,
+		SchemaFields:          p.Result.SchemaFields,
+		StatusFields:          p.Result.StatusFields,
+		CustomReadyConditions: p.ready,
+		ResourceReadyWhen:     p.Result.ReadyWhen,
+		ResourceIncludeWhen:   p.Result.IncludeWhen,
+		AlwaysSchema:          p.opts.AlwaysSchema,
+	})
+
+	rgd, err := generator.Generate(p.Result.DependencyGraph)
+	if err != nil {
+		return fmt.Errorf("generating RGD: %w", err)
+	}
+
+	p.RGDMap = rgd.ToMap()
+
+	if p.opts.ExtractTests {
+		if err := p.extractTests(ctx); err != nil {
+			return err
+		}
+	}
+
+	if len(p.Nested) > 0 {
+		if err := p.nest(ctx); err != nil {
+			return err
+		}
+	}
+
+	if p.opts.Harden {
+		if err := p.addProvenance(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractTests builds the conformance suite from the Helm test hooks. Their
+// parameters are detected by diffing against the sentinel-rendered test
+// hooks or, in fast mode, by matching rendered values.
+func (p *Pipeline) extractTests(ctx context.Context) error {
+	tests, err := p.parser.Parse(ctx, hooks.Combine(p.HookResult.TestHooks()))
+	if err != nil {
+		return fmt.Errorf("parsing test hooks: %w", err)
+	}
+
+	ids, err := transform.AssignResourceIDs(tests, nil)
+	if err != nil {
+		return fmt.Errorf("assigning test hook IDs: %w", err)
+	}
+
+	var mappings []transform.FieldMapping
+
+	if p.opts.Fast {
+		mappings = transform.MatchFieldsByValue(tests, ids, p.Values, p.ReferencedPaths)
+	} else if sentinel, parseErr := p.parser.Parse(ctx, hooks.Combine(p.SentinelTests)); parseErr != nil {
+		p.logger.Warn("sentinel test hook parsing failed", slog.String("error", parseErr.Error()))
+	} else {
+		mappings = transform.ParallelDiffAllResources(tests, sentinel, ids, transform.ParallelDiffConfig{})
+	}
+
+	p.Conformance = conformance.NewSuite(tests, ids, mappings, p.Result.SchemaFields)
+
+	return nil
+}
+
+// addProvenance adds the SLSA provenance annotations to the RGD, including
+// the verified chart signature.
+func (p *Pipeline) addProvenance() error {
+	level, err := harden.ParseSecurityLevel(p.opts.SecurityLevel)
+	if err != nil {
+		return &OptionError{Err: err}
+	}
+
+	cfg := harden.ProvenanceConfig{
+		ChartRef:          p.ref,
+		Profile:           p.opts.Profile,
+		HardeningLevel:    level,
+		ExcludedSubcharts: p.opts.ExcludeSubcharts,
+		EmbedTimestamp:    p.opts.EmbedTimestamp,
+		ChartDigest:       strings.TrimPrefix(p.Loaded.Digest, "sha256:"),
+	}
+
+	if v := p.Loaded.Verification; v != nil {
+		cfg.Verification = &harden.SignatureVerification{
+			Method:         v.Method,
+			Identity:       v.Identity,
+			KeyFingerprint: v.KeyFingerprint,
+			Digest:         v.Digest,
+		}
+	}
+
+	annotations, err := harden.GenerateProvenanceAnnotations(cfg)
+	if err != nil {
+		return fmt.Errorf("generating provenance annotations: %w", err)
+	}
+
+	metadata, _ := p.RGDMap["metadata"].(map[string]interface{})
+	if metadata == nil {
+		return errors.New("generating provenance annotations: RGD has no metadata")
+	}
+
+	merged, _ := metadata["annotations"].(map[string]interface{})
+	if merged == nil {
+		merged = make(map[string]interface{}, len(annotations))
+	}
+
+	for k, v := range annotations {
+		merged[k] = v
+	}
+
+	metadata["annotations"] = merged
+
+	return nil
+}
+
+// RGDName returns the name of the RGD: RGDName, or the chart name.
+func (p *Pipeline) RGDName() string {
+	if p.opts.RGDName != "" {
+		return p.opts.RGDName
+	}
+
+	if p.Meta == nil {
+		return ""
+	}
+
+	return p.Meta.Name
+}
+
+// Normalize serializes an RGD to YAML and parses it back, so that the map
+// matches what is written to disk. The map is returned as is when it cannot
+// be parsed back.
+func Normalize(rgdMap map[string]interface{}) ([]byte, map[string]interface{}, error) {
+	data, err := output.Serialize(rgdMap, output.SerializeOptions{Comments: false, Indent: 2})
+	if err != nil {
+		return nil, nil, fmt.Errorf("serializing RGD: %w", err)
+	}
+
+	var normalized map[string]interface{}
+	if err := sigsyaml.Unmarshal(data, &normalized); err != nil {
+		return data, rgdMap, nil //nolint:nilerr // fall back to the map as generated
+	}
+
+	return data, normalized, nil
+}
+
+// AssignSourcePaths sets the template path of resources from the sourced
+// manifests. A template can produce several documents, so resources are
+// matched by kind and name.
+func AssignSourcePaths(resources []*k8s.Resource, sourced []renderer.SourcedManifest) {
+	paths := make(map[string]string)
+
+	for _, sm := range sourced {
+		for _, doc := range parser.SplitDocuments([]byte(sm.Content)) {
+			var obj map[string]interface{}
+			if err := sigsyaml.Unmarshal(doc, &obj); err != nil {
+				continue
+			}
+
+			kind, _ := obj["kind"].(string)
+
+			meta, _ := obj["metadata"].(map[string]interface{})
+			name, _ := meta["name"].(string)
+
+			if kind != "" && name != "" {
+				paths[kind+"/"+name] = sm.TemplatePath
+			}
+		}
+	}
+
+	for _, res := range resources {
+		if path, ok := paths[res.Kind()+"/"+res.Name]; ok {
+			res.SourcePath = path
+		}
+	}
+}
+
+// copyResources deep-copies resources, keyed by their resource IDs.
+func copyResources(resources []*k8s.Resource, ids map[*k8s.Resource]string) map[string]*k8s.Resource {
+	out := make(map[string]*k8s.Resource, len(resources))
+
+	for _, r := range resources {
+		c := *r
+		if r.Object != nil {
+			c.Object = r.Object.DeepCopy()
+		}
+
+		out[ids[r]] = &c
+	}
+
+	return out
+}
+
+// toSchemaOverrides converts config schema overrides to transform schema overrides.
+func toSchemaOverrides(overrides map[string]config.SchemaOverride) map[string]transform.SchemaOverride {
+	result := make(map[string]transform.SchemaOverride, len(overrides))
+	for path, override := range overrides {
+		result[path] = transform.SchemaOverride{
+			Type:    override.Type,
+			Default: override.Default,
+		}
+	}
+
+	return result
+}
+
+// toDerivedValues converts the config derived values section to transform
+// inputs and derived values, sorted for deterministic output.
+func toDerivedValues(cfg *config.DerivedValuesConfig) ([]transform.DerivedInput, []transform.DerivedValue) {
+	inputs := make([]transform.DerivedInput, 0, len(cfg.Inputs))
+	for name, in := range cfg.Inputs {
+		inputs = append(inputs, transform.DerivedInput{Name: name, Type: in.Type, Default: in.Default})
+	}
+
+	sort.Slice(inputs, func(i, j int) bool { return inputs[i].Name < inputs[j].Name })
+
+	derived := make([]transform.DerivedValue, 0, len(cfg.Values))
+	for path, v := range cfg.Values {
+		derived = append(derived, transform.DerivedValue{Path: path, Expression: v.Expression, Type: v.Type})
+	}
+
+	sort.Slice(derived, func(i, j int) bool { return derived[i].Path < derived[j].Path })
+
+	return inputs, derived
+}
+
+// toStatusConfig converts the config status section to a transform status config.
+func toStatusConfig(cfg *config.StatusConfig, readyConditions map[string][]string) *transform.StatusConfig {
+	status := &transform.StatusConfig{
+		DisableDefaults: cfg.DisableDefaults,
+		ReadyConditions: readyConditions,
+	}
+
+	for _, f := range cfg.Fields {
+		status.Fields = append(status.Fields, transform.StatusField{Name: f.Name, CELExpression: f.CELExpression})
+	}
+
+	if cfg.Readiness != nil && cfg.Readiness.Enabled {
+		status.Readiness = &transform.ReadinessSummary{Name: cfg.Readiness.Name, Kinds: cfg.Readiness.Kinds}
+	}
+
+	return status
+}
+
+// toReferencePaths converts the config dependencies section to transform
+// reference paths.
+func toReferencePaths(cfg *config.DependenciesConfig) []transform.ReferencePath {
+	paths := make([]transform.ReferencePath, 0, len(cfg.References))
+
+	for _, ref := range cfg.References {
+		paths = append(paths, transform.ReferencePath{
+			Kind:       ref.Match.Kind,
+			APIVersion: ref.Match.APIVersion,
+			Path:       ref.Path,
+			TargetKind: ref.TargetKind,
+		})
+	}
+
+	return paths
+}
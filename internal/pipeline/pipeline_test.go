@@ -0,0 +1,222 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+
+	"github.com/hupe1980/chart2kro/internal/helm/chartmeta"
+	"github.com/hupe1980/chart2kro/internal/helm/loader"
+	"github.com/hupe1980/chart2kro/internal/helm/postrender"
+)
+
+const simpleChart = "../../testdata/charts/simple"
+
+func TestRunTo(t *testing.T) {
+	ctx := context.Background()
+
+	p, err := New(simpleChart, Options{})
+	require.NoError(t, err)
+
+	var ran []Stage
+
+	after := func(s Stage) error {
+		ran = append(ran, s)
+
+		return nil
+	}
+
+	require.NoError(t, p.RunTo(ctx, StageRender, after))
+	assert.Equal(t, []Stage{StageLoad, StageRender}, ran)
+	assert.True(t, p.Done(StageRender))
+	assert.False(t, p.Done(StageFilter))
+	assert.NotEmpty(t, p.Manifests)
+	assert.Len(t, p.Resources, 2)
+	assert.Nil(t, p.Result)
+
+	// Completed stages are not run again.
+	require.NoError(t, p.RunTo(ctx, StageLoad, after))
+	assert.Len(t, ran, 2)
+
+	require.NoError(t, p.RunTo(ctx, StageGenerate, after))
+	assert.Equal(t, Stages, ran)
+	assert.Equal(t, "simple", p.RGDName())
+	assert.NotNil(t, p.RGDMap)
+	assert.NotEmpty(t, p.FieldMappings)
+	assert.Nil(t, p.HardenResult)
+
+	data, normalized, err := Normalize(p.RGDMap)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "kind: ResourceGraphDefinition")
+	assert.Equal(t, "ResourceGraphDefinition", normalized["kind"])
+}
+
+func TestRunTo_StickyError(t *testing.T) {
+	ctx := context.Background()
+	errStop := errors.New("stop")
+
+	p, err := New(simpleChart, Options{})
+	require.NoError(t, err)
+
+	err = p.RunTo(ctx, StageFilter, func(s Stage) error {
+		if s == StageRender {
+			return errStop
+		}
+
+		return nil
+	})
+	require.ErrorIs(t, err, errStop)
+	require.ErrorIs(t, p.RunTo(ctx, StageGenerate, nil), errStop)
+	assert.False(t, p.Done(StageFilter))
+}
+
+func TestOptionError(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  Options
+		stage Stage
+		want  string
+	}{
+		{
+			name:  "unknown profile",
+			opts:  Options{Profile: "unknown"},
+			stage: StageFilter,
+			want:  "building filter chain",
+		},
+		{
+			name:  "invalid security level",
+			opts:  Options{Harden: true, SecurityLevel: "paranoid"},
+			stage: StageHarden,
+			want:  "invalid security level",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(simpleChart, tt.opts)
+			require.NoError(t, err)
+
+			err = p.RunTo(context.Background(), tt.stage, nil)
+
+			var optErr *OptionError
+			require.ErrorAs(t, err, &optErr)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestNew_InvalidPostRenderer(t *testing.T) {
+	_, err := New(simpleChart, Options{PostRender: postrender.Options{Exec: "/nonexistent/post-renderer"}})
+
+	var optErr *OptionError
+	require.ErrorAs(t, err, &optErr)
+}
+
+func TestNew_NestedFlatSchema(t *testing.T) {
+	_, err := New(simpleChart, Options{NestedSubcharts: true, FlatSchema: true})
+
+	var optErr *OptionError
+	require.ErrorAs(t, err, &optErr)
+}
+
+func TestGenerate_Provenance(t *testing.T) {
+	p, err := New(simpleChart, Options{Harden: true, SecurityLevel: "baseline", Profile: "app-only"})
+	require.NoError(t, err)
+
+	err = p.RunTo(context.Background(), StageGenerate, func(s Stage) error {
+		if s == StageLoad {
+			p.Loaded.Digest = "sha256:abc123"
+			p.Loaded.Verification = &loader.Verification{Method: "helm-provenance", Identity: "Jane <jane@example.com>"}
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	metadata, _ := p.RGDMap["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	require.NotNil(t, annotations)
+
+	assert.Equal(t, simpleChart, annotations["chart2kro.io/source"])
+	assert.Equal(t, "abc123", annotations["chart2kro.io/chart-digest"])
+	assert.Equal(t, "baseline", annotations["chart2kro.io/hardening-level"])
+	assert.Equal(t, "app-only", annotations["chart2kro.io/profile"])
+	assert.Equal(t, "Jane <jane@example.com>", annotations["chart2kro.io/verified-by"])
+	assert.Equal(t, "helm-provenance", annotations["chart2kro.io/verification-method"])
+	assert.Contains(t, annotations, "chart2kro.io/provenance")
+	assert.Contains(t, annotations, "chart2kro.dev/generated", "generator annotations are kept")
+}
+
+func TestGenerate_NoProvenanceWithoutHarden(t *testing.T) {
+	p, err := New(simpleChart, Options{})
+	require.NoError(t, err)
+	require.NoError(t, p.RunTo(context.Background(), StageGenerate, nil))
+
+	metadata, _ := p.RGDMap["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	assert.NotContains(t, annotations, "chart2kro.io/provenance")
+}
+
+func TestGenerate_ExtractTests(t *testing.T) {
+	p, err := New("../../testdata/charts/with-hooks", Options{ExtractTests: true})
+	require.NoError(t, err)
+	require.NoError(t, p.RunTo(context.Background(), StageGenerate, nil))
+
+	require.NotNil(t, p.Conformance)
+	require.Len(t, p.Conformance.Tests, 1)
+	assert.Equal(t, "Pod", p.Conformance.Tests[0].Kind())
+
+	for _, r := range p.Result.Resources {
+		assert.NotEqual(t, "Pod", r.Kind(), "test hooks are not part of the RGD")
+	}
+}
+
+func TestGenerate_NestedSubcharts(t *testing.T) {
+	p, err := New("../../testdata/charts/with-aliases", Options{NestedSubcharts: true, AlwaysSchema: true})
+	require.NoError(t, err)
+	require.NoError(t, p.RunTo(context.Background(), StageGenerate, nil))
+
+	assert.Equal(t, []string{"queue", "sessions"}, p.Nested)
+	require.Len(t, p.NestedRGDs, 2)
+
+	for i, path := range []string{"queue", "sessions"} {
+		n := p.NestedRGDs[i]
+		assert.Equal(t, path, n.Path)
+
+		metadata, _ := n.RGDMap["metadata"].(map[string]interface{})
+		assert.Equal(t, "with-aliases-"+path, metadata["name"])
+		assert.NotEmpty(t, n.Result.Resources)
+	}
+
+	data, _, err := Normalize(p.RGDMap)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "kind: WithAliasesQueue")
+	assert.Contains(t, string(data), "kind: WithAliasesSessions")
+}
+
+func TestNestedSubcharts_PairsKeysWithCharts(t *testing.T) {
+	ch := &chart.Chart{Metadata: &chart.Metadata{
+		Name: "parent",
+		Dependencies: []*chart.Dependency{
+			{Name: "zeta", Alias: "a"},
+			{Name: "alpha", Alias: "b"},
+			{Name: "alpha", Alias: "c"},
+			{Name: "skipped"},
+		},
+	}}
+	ch.SetDependencies(
+		&chart.Chart{Metadata: &chart.Metadata{Name: "zeta"}},
+		&chart.Chart{Metadata: &chart.Metadata{Name: "alpha"}},
+		&chart.Chart{Metadata: &chart.Metadata{Name: "skipped"}},
+		&chart.Chart{Metadata: &chart.Metadata{Name: "undeclared"}},
+	)
+
+	keys, charts := nestedSubcharts(ch, chartmeta.FromChart(ch), &Options{ExcludeSubcharts: []string{"skipped"}})
+
+	assert.Equal(t, []string{"a", "b", "c", "undeclared"}, keys)
+	assert.Equal(t, map[string]string{"a": "zeta", "b": "alpha", "c": "alpha", "undeclared": "undeclared"}, charts)
+}
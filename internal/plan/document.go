@@ -0,0 +1,315 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hupe1980/chart2kro/internal/jsonschema"
+)
+
+// Plan document identification. The apiVersion is bumped on any change
+// that is not backwards compatible (removed or renamed fields, changed
+// types); new optional fields may be added within a version.
+const (
+	DocumentAPIVersion = "chart2kro.io/v1"
+	DocumentKind       = "Plan"
+	// DocumentSchemaID is the $id of the plan document JSON Schema.
+	DocumentSchemaID = "https://chart2kro.io/schemas/plan-v1.json"
+)
+
+// Document is the versioned, machine-readable plan format. Unlike Result,
+// its shape is stable within an apiVersion and described by
+// DocumentJSONSchema.
+type Document struct {
+	APIVersion   string              `json:"apiVersion" description:"Plan format version." enum:"chart2kro.io/v1"`
+	Kind         string              `json:"kind" enum:"Plan"`
+	Chart        DocumentChart       `json:"chart" description:"The converted chart."`
+	RGD          DocumentRGD         `json:"rgd" description:"The generated ResourceGraphDefinition."`
+	SchemaFields []DocumentField     `json:"schemaFields" description:"Fields of the custom resource spec, including object groups, sorted by path."`
+	Resources    []DocumentResource  `json:"resources" description:"Managed resources in dependency order."`
+	Order        []string            `json:"order" description:"Resource IDs in dependency order: every resource comes after the resources it depends on."`
+	StatusFields []DocumentStatus    `json:"statusFields" description:"Status projections of the custom resource."`
+	Evolution    *DocumentEvolution  `json:"evolution,omitempty" description:"Changes against the existing RGD, present when one was given."`
+	Summary      DocumentPlanSummary `json:"summary"`
+}
+
+// DocumentChart identifies the converted chart.
+type DocumentChart struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	AppVersion string `json:"appVersion,omitempty"`
+}
+
+// DocumentRGD identifies the generated RGD and its custom resource.
+type DocumentRGD struct {
+	Name       string `json:"name" description:"RGD metadata.name."`
+	APIVersion string `json:"apiVersion" description:"Custom resource apiVersion (spec.schema.apiVersion)."`
+	Kind       string `json:"kind" description:"Custom resource kind (spec.schema.kind)."`
+}
+
+// DocumentField is a field of the custom resource spec.
+type DocumentField struct {
+	Path     string `json:"path" description:"Dotted path below spec (e.g. image.tag)."`
+	Type     string `json:"type" description:"SimpleSchema type (string, integer, number, boolean, object, array, ...)."`
+	Default  string `json:"default,omitempty" description:"Default value in SimpleSchema notation."`
+	Required bool   `json:"required" description:"True when a leaf field has no default; false for object groups."`
+	HelmPath string `json:"helmPath,omitempty" description:"Source Helm values path the field was extracted from."`
+}
+
+// DocumentResource is a managed resource of the RGD.
+type DocumentResource struct {
+	ID          string            `json:"id"`
+	APIVersion  string            `json:"apiVersion"`
+	Kind        string            `json:"kind"`
+	Name        string            `json:"name,omitempty" description:"Template metadata.name, which may be a CEL expression."`
+	DependsOn   []string          `json:"dependsOn" description:"IDs of the resources this resource depends on."`
+	ReadyWhen   []string          `json:"readyWhen" description:"Readiness conditions."`
+	IncludeWhen []string          `json:"includeWhen" description:"Inclusion conditions; the resource is conditional when non-empty."`
+	Bindings    []DocumentBinding `json:"bindings" description:"Template fields bound to CEL expressions, sorted by field path."`
+}
+
+// DocumentBinding is a template field bound to a CEL expression.
+type DocumentBinding struct {
+	FieldPath  string   `json:"fieldPath" description:"Path of the field in the resource template (e.g. spec.template.spec.containers[0].image)."`
+	Expression string   `json:"expression" description:"The field value, containing one or more ${...} expressions."`
+	SchemaRefs []string `json:"schemaRefs" description:"Custom resource spec paths the expression reads (e.g. image.tag)."`
+	Resources  []string `json:"resources" description:"IDs of other resources the expression reads."`
+}
+
+// DocumentStatus is a status projection of the custom resource.
+type DocumentStatus struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+}
+
+// DocumentEvolution holds the changes against an existing RGD.
+type DocumentEvolution struct {
+	SchemaChanges   []DocumentSchemaChange   `json:"schemaChanges"`
+	ResourceChanges []DocumentResourceChange `json:"resourceChanges"`
+	Breaking        int                      `json:"breaking" description:"Number of breaking changes."`
+	NonBreaking     int                      `json:"nonBreaking" description:"Number of non-breaking changes."`
+}
+
+// DocumentSchemaChange is a change of a custom resource spec field.
+type DocumentSchemaChange struct {
+	Type     string `json:"type" enum:"added,removed,modified"`
+	Field    string `json:"field"`
+	Details  string `json:"details"`
+	Impact   string `json:"impact,omitempty"`
+	Breaking bool   `json:"breaking"`
+}
+
+// DocumentResourceChange is a change of a managed resource.
+type DocumentResourceChange struct {
+	Type     string `json:"type" enum:"added,removed,modified"`
+	ID       string `json:"id"`
+	Kind     string `json:"kind,omitempty"`
+	Details  string `json:"details"`
+	Breaking bool   `json:"breaking"`
+}
+
+// DocumentPlanSummary counts the plan contents.
+type DocumentPlanSummary struct {
+	SchemaFields       int  `json:"schemaFields"`
+	Resources          int  `json:"resources"`
+	StatusFields       int  `json:"statusFields"`
+	HasBreakingChanges bool `json:"hasBreakingChanges"`
+	ExitCode           int  `json:"exitCode" description:"Exit code of the plan command: 0, or 8 when breaking changes were detected." enum:"0,8"`
+}
+
+// BuildDocument builds the plan document from a plan result, the generated
+// RGD map, and the chart version. Resources, bindings, and dependency order
+// are read from the RGD map, so they match the written RGD exactly.
+func BuildDocument(p *Result, rgdMap map[string]interface{}, chart ChartVersion) *Document {
+	doc := &Document{
+		APIVersion: DocumentAPIVersion,
+		Kind:       DocumentKind,
+		Chart:      DocumentChart(chart),
+		RGD: DocumentRGD{
+			Name:       extractName(rgdMap),
+			APIVersion: SchemaAPIVersion(rgdMap),
+		},
+		SchemaFields: []DocumentField{},
+		Resources:    []DocumentResource{},
+		Order:        []string{},
+		StatusFields: []DocumentStatus{},
+	}
+
+	if schema, ok := nestedMap(rgdMap, "spec", "schema"); ok {
+		doc.RGD.Kind, _ = schema["kind"].(string)
+	}
+
+	for _, f := range p.SchemaFields {
+		doc.SchemaFields = append(doc.SchemaFields, DocumentField{
+			Path:     f.Name,
+			Type:     f.Type,
+			Default:  f.Default,
+			Required: f.Required && f.Type != "object",
+			HelmPath: f.Path,
+		})
+	}
+
+	sort.Slice(doc.SchemaFields, func(i, j int) bool { return doc.SchemaFields[i].Path < doc.SchemaFields[j].Path })
+
+	resources := indexResources(extractResources(rgdMap))
+
+	for _, r := range extractResources(rgdMap) {
+		rm, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		id, _ := rm["id"].(string)
+		template, _ := rm["template"].(map[string]interface{})
+		apiVersion, _ := template["apiVersion"].(string)
+
+		dr := DocumentResource{
+			ID:          id,
+			APIVersion:  apiVersion,
+			Kind:        extractResourceKind(rm),
+			DependsOn:   stringList(rm["dependsOn"]),
+			ReadyWhen:   stringList(rm["readyWhen"]),
+			IncludeWhen: stringList(rm["includeWhen"]),
+			Bindings:    []DocumentBinding{},
+		}
+
+		if metadata, ok := template["metadata"].(map[string]interface{}); ok {
+			dr.Name, _ = metadata["name"].(string)
+		}
+
+		collectBindings("", template, func(path, expr string) {
+			dr.Bindings = append(dr.Bindings, newBinding(id, path, expr, resources))
+		})
+
+		sort.Slice(dr.Bindings, func(i, j int) bool { return dr.Bindings[i].FieldPath < dr.Bindings[j].FieldPath })
+
+		doc.Resources = append(doc.Resources, dr)
+		doc.Order = append(doc.Order, id)
+	}
+
+	for _, s := range p.StatusFields {
+		doc.StatusFields = append(doc.StatusFields, DocumentStatus(s))
+	}
+
+	if p.Evolution != nil {
+		doc.Evolution = documentEvolution(p.Evolution)
+	}
+
+	doc.Summary = DocumentPlanSummary{
+		SchemaFields:       len(doc.SchemaFields),
+		Resources:          len(doc.Resources),
+		StatusFields:       len(doc.StatusFields),
+		HasBreakingChanges: p.HasBreakingChanges,
+	}
+
+	if p.HasBreakingChanges {
+		doc.Summary.ExitCode = 8
+	}
+
+	return doc
+}
+
+// collectBindings calls fn for every string value below v that contains a
+// CEL expression, with its template field path.
+func collectBindings(path string, v interface{}, fn func(path, expr string)) {
+	switch val := v.(type) {
+	case string:
+		if strings.Contains(val, "${") {
+			fn(path, val)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			collectBindings(joinPath(path, k), val[k], fn)
+		}
+	case []interface{}:
+		for i, item := range val {
+			collectBindings(fmt.Sprintf("%s[%d]", path, i), item, fn)
+		}
+	}
+}
+
+// schemaRefPattern matches custom resource spec references in CEL.
+var schemaRefPattern = regexp.MustCompile(`schema\.spec\.([A-Za-z0-9_.]+)`)
+
+// newBinding builds the binding of a template field of resource id.
+func newBinding(id, path, expr string, resources map[string]map[string]interface{}) DocumentBinding {
+	b := DocumentBinding{FieldPath: path, Expression: expr, SchemaRefs: []string{}, Resources: []string{}}
+
+	seen := make(map[string]bool)
+
+	for _, m := range schemaRefPattern.FindAllStringSubmatch(expr, -1) {
+		if ref := strings.TrimSuffix(m[1], "."); !seen[ref] {
+			seen[ref] = true
+			b.SchemaRefs = append(b.SchemaRefs, ref)
+		}
+	}
+
+	for _, m := range celRefPattern.FindAllStringSubmatch(expr, -1) {
+		ref := m[1]
+		if _, ok := resources[ref]; ok && ref != id && !seen["resource:"+ref] {
+			seen["resource:"+ref] = true
+			b.Resources = append(b.Resources, ref)
+		}
+	}
+
+	sort.Strings(b.SchemaRefs)
+	sort.Strings(b.Resources)
+
+	return b
+}
+
+// documentEvolution converts an evolution result for the plan document.
+func documentEvolution(e *EvolutionResult) *DocumentEvolution {
+	de := &DocumentEvolution{
+		SchemaChanges:   []DocumentSchemaChange{},
+		ResourceChanges: []DocumentResourceChange{},
+		Breaking:        e.BreakingCount(),
+		NonBreaking:     e.NonBreakingCount(),
+	}
+
+	for _, c := range e.SchemaChanges {
+		de.SchemaChanges = append(de.SchemaChanges, DocumentSchemaChange{
+			Type: string(c.Type), Field: c.Field, Details: c.Details, Impact: c.Impact, Breaking: c.Breaking,
+		})
+	}
+
+	for _, c := range e.ResourceChanges {
+		de.ResourceChanges = append(de.ResourceChanges, DocumentResourceChange{
+			Type: string(c.Type), ID: c.ID, Kind: c.Kind, Details: c.Details, Breaking: c.Breaking,
+		})
+	}
+
+	return de
+}
+
+// FormatDocumentJSON writes the plan document as JSON.
+func FormatDocumentJSON(w io.Writer, doc *Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(doc)
+}
+
+// DocumentJSONSchema returns the JSON Schema of the plan document, generated
+// from the Document type.
+func DocumentJSONSchema() ([]byte, error) {
+	schema := jsonschema.Generate(Document{}, DocumentSchemaID, "chart2kro plan ("+DocumentAPIVersion+")")
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,169 @@
+package plan
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	jsonschemav6 "github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// documentRGD extends semanticRGD with a Service that references the
+// Deployment and a custom resource kind.
+func documentRGD() map[string]interface{} {
+	rgd := semanticRGD("${schema.spec.image}", "${schema.spec.replicas}")
+	rgd["metadata"] = map[string]interface{}{"name": "app"}
+
+	spec := rgd["spec"].(map[string]interface{})
+	spec["schema"].(map[string]interface{})["apiVersion"] = "app.kro.run/v1alpha1"
+
+	service := spec["resources"].([]interface{})[1].(map[string]interface{})
+	service["dependsOn"] = []interface{}{"deployment"}
+	service["includeWhen"] = []interface{}{"${schema.spec.service.enabled}"}
+	service["template"].(map[string]interface{})["spec"] = map[string]interface{}{
+		"selector": map[string]interface{}{"app": "${deployment.metadata.name}-${schema.spec.suffix}"},
+	}
+
+	return rgd
+}
+
+func documentPlan() *Result {
+	return &Result{
+		SchemaFields: []SchemaField{
+			{Name: "replicas", Type: "integer", Default: "1", Path: "replicaCount"},
+			{Name: "image", Type: "string", Required: true, Path: "image"},
+			{Name: "service", Type: "object", Required: true, Path: "service"},
+			{Name: "service.enabled", Type: "boolean", Default: "true", Path: "service.enabled"},
+		},
+		StatusFields: []StatusField{{Name: "ready", Expression: "${deployment.status.readyReplicas}"}},
+	}
+}
+
+func TestBuildDocument(t *testing.T) {
+	doc := BuildDocument(documentPlan(), documentRGD(), ChartVersion{Name: "app", Version: "1.2.3"})
+
+	assert.Equal(t, DocumentAPIVersion, doc.APIVersion)
+	assert.Equal(t, DocumentKind, doc.Kind)
+	assert.Equal(t, DocumentRGD{Name: "app", APIVersion: "app.kro.run/v1alpha1", Kind: "App"}, doc.RGD)
+
+	require.Len(t, doc.SchemaFields, 4)
+	assert.Equal(t, DocumentField{Path: "image", Type: "string", Required: true, HelmPath: "image"}, doc.SchemaFields[0])
+	assert.Equal(t, DocumentField{Path: "replicas", Type: "integer", Default: "1", HelmPath: "replicaCount"}, doc.SchemaFields[1])
+	assert.False(t, doc.SchemaFields[2].Required, "object groups are not required")
+
+	assert.Equal(t, []string{"deployment", "service"}, doc.Order)
+
+	deployment := doc.Resources[0]
+	assert.Equal(t, "Deployment", deployment.Kind)
+	assert.Equal(t, []string{}, deployment.DependsOn)
+	assert.Equal(t, []DocumentBinding{
+		{FieldPath: "spec.replicas", Expression: "${schema.spec.replicas}", SchemaRefs: []string{"replicas"}, Resources: []string{}},
+		{
+			FieldPath: "spec.template.spec.containers[1].image", Expression: "${schema.spec.image}",
+			SchemaRefs: []string{"image"}, Resources: []string{},
+		},
+	}, deployment.Bindings)
+
+	service := doc.Resources[1]
+	assert.Equal(t, []string{"deployment"}, service.DependsOn)
+	assert.Equal(t, []string{"${schema.spec.service.enabled}"}, service.IncludeWhen)
+	assert.Equal(t, []DocumentBinding{{
+		FieldPath:  "spec.selector.app",
+		Expression: "${deployment.metadata.name}-${schema.spec.suffix}",
+		SchemaRefs: []string{"suffix"},
+		Resources:  []string{"deployment"},
+	}}, service.Bindings)
+
+	assert.Nil(t, doc.Evolution)
+	assert.Equal(t, DocumentPlanSummary{SchemaFields: 4, Resources: 2, StatusFields: 1}, doc.Summary)
+}
+
+// withSpecField returns documentRGD with an additional schema spec field.
+func withSpecField(name, def string) map[string]interface{} {
+	rgd := documentRGD()
+	schema := rgd["spec"].(map[string]interface{})["schema"].(map[string]interface{})
+	schema["spec"].(map[string]interface{})[name] = def
+
+	return rgd
+}
+
+func TestBuildDocument_Evolution(t *testing.T) {
+	// The existing RGD has a port field that the new RGD drops.
+	p := documentPlan()
+	ApplyEvolution(p, Analyze(withSpecField("port", "integer | default=80"), documentRGD()))
+
+	require.True(t, p.HasBreakingChanges)
+
+	doc := BuildDocument(p, documentRGD(), ChartVersion{Name: "app", Version: "1.2.3"})
+
+	require.NotNil(t, doc.Evolution)
+	assert.Equal(t, 1, doc.Evolution.Breaking)
+	require.Len(t, doc.Evolution.SchemaChanges, 1)
+	assert.Equal(t, "removed", doc.Evolution.SchemaChanges[0].Type)
+	assert.Equal(t, "port", doc.Evolution.SchemaChanges[0].Field)
+	assert.True(t, doc.Evolution.SchemaChanges[0].Breaking)
+	assert.True(t, doc.Summary.HasBreakingChanges)
+	assert.Equal(t, 8, doc.Summary.ExitCode)
+}
+
+func TestBuildDocument_EvolutionNonBreaking(t *testing.T) {
+	// The new RGD adds a field with a default.
+	p := documentPlan()
+	ApplyEvolution(p, Analyze(documentRGD(), withSpecField("debug", "boolean | default=false")))
+
+	require.False(t, p.HasBreakingChanges)
+
+	doc := BuildDocument(p, withSpecField("debug", "boolean | default=false"), ChartVersion{Name: "app", Version: "1.2.3"})
+
+	require.NotNil(t, doc.Evolution)
+	assert.Equal(t, 0, doc.Evolution.Breaking)
+	assert.Equal(t, 1, doc.Evolution.NonBreaking)
+	assert.False(t, doc.Summary.HasBreakingChanges)
+	assert.Equal(t, 0, doc.Summary.ExitCode)
+}
+
+func TestDocument_ValidatesAgainstJSONSchema(t *testing.T) {
+	schemaData, err := DocumentJSONSchema()
+	require.NoError(t, err)
+
+	schemaDoc, err := jsonschemav6.UnmarshalJSON(bytes.NewReader(schemaData))
+	require.NoError(t, err)
+
+	c := jsonschemav6.NewCompiler()
+	require.NoError(t, c.AddResource(DocumentSchemaID, schemaDoc))
+
+	sch, err := c.Compile(DocumentSchemaID)
+	require.NoError(t, err)
+
+	p := documentPlan()
+	ApplyEvolution(p, Analyze(withSpecField("port", "integer | default=80"), documentRGD()))
+
+	for _, doc := range []*Document{
+		BuildDocument(documentPlan(), documentRGD(), ChartVersion{Name: "app", Version: "1.2.3"}),
+		BuildDocument(p, documentRGD(), ChartVersion{Name: "app", Version: "1.2.3", AppVersion: "2.0"}),
+	} {
+		var buf bytes.Buffer
+		require.NoError(t, FormatDocumentJSON(&buf, doc))
+
+		inst, err := jsonschemav6.UnmarshalJSON(&buf)
+		require.NoError(t, err)
+		assert.NoError(t, sch.Validate(inst))
+	}
+
+	inst, err := jsonschemav6.UnmarshalJSON(bytes.NewReader([]byte(`{"apiVersion": "chart2kro.io/v2", "kind": "Plan"}`)))
+	require.NoError(t, err)
+	assert.Error(t, sch.Validate(inst))
+}
+
+func TestDocumentJSONSchema_Published(t *testing.T) {
+	want, err := DocumentJSONSchema()
+	require.NoError(t, err)
+
+	got, err := os.ReadFile(filepath.Join("..", "..", "docs", "schemas", "plan-v1.json"))
+	require.NoError(t, err)
+
+	assert.Equal(t, string(want), string(got), "docs/schemas/plan-v1.json is stale: run `just schemas`")
+}
@@ -171,9 +171,16 @@ func FormatTable(w io.Writer, result *EvolutionResult) {
 
 // FormatJSON writes the evolution result as JSON.
 func FormatJSON(w io.Writer, result *EvolutionResult) error {
+	return FormatDiffJSON(w, result, nil)
+}
+
+// FormatDiffJSON writes the evolution result as JSON, with the structural
+// diff under the "diff" key when diff is not nil.
+func FormatDiffJSON(w io.Writer, result *EvolutionResult, diff *SemanticDiff) error {
 	output := struct {
 		SchemaChanges   []SchemaChange   `json:"schemaChanges"`
 		ResourceChanges []ResourceChange `json:"resourceChanges"`
+		Diff            *SemanticDiff    `json:"diff,omitempty"`
 		Summary         struct {
 			Breaking    int  `json:"breaking"`
 			NonBreaking int  `json:"nonBreaking"`
@@ -182,6 +189,7 @@ func FormatJSON(w io.Writer, result *EvolutionResult) error {
 	}{
 		SchemaChanges:   result.SchemaChanges,
 		ResourceChanges: result.ResourceChanges,
+		Diff:            diff,
 	}
 	output.Summary.Breaking = result.BreakingCount()
 	output.Summary.NonBreaking = result.NonBreakingCount()
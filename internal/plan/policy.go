@@ -0,0 +1,333 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EvolutionPolicy selects how a conversion reacts to breaking schema changes
+// against an existing RGD.
+type EvolutionPolicy string
+
+// Evolution policies.
+const (
+	// PolicyAllow reports breaking changes but writes the new RGD as is.
+	PolicyAllow EvolutionPolicy = "allow"
+	// PolicyStrict fails the conversion on breaking changes.
+	PolicyStrict EvolutionPolicy = "strict"
+	// PolicyBump writes the new RGD under the next schema version, side by
+	// side with the existing one, when changes are breaking.
+	PolicyBump EvolutionPolicy = "bump"
+)
+
+// Annotations set on an RGD produced by a version bump.
+const (
+	// SupersedesAnnotation holds the schema apiVersion the RGD supersedes.
+	SupersedesAnnotation = "chart2kro.io/supersedes"
+	// FieldMappingAnnotation holds the JSON field mapping from the
+	// superseded schema.
+	FieldMappingAnnotation = "chart2kro.io/field-mapping"
+)
+
+// ParseEvolutionPolicy parses a policy name. An empty string means PolicyAllow.
+func ParseEvolutionPolicy(s string) (EvolutionPolicy, error) {
+	switch EvolutionPolicy(s) {
+	case "", PolicyAllow:
+		return PolicyAllow, nil
+	case PolicyStrict, PolicyBump:
+		return EvolutionPolicy(s), nil
+	default:
+		return "", fmt.Errorf("invalid evolution policy %q (must be strict, bump, or allow)", s)
+	}
+}
+
+// versionPattern matches Kubernetes API versions such as v1, v1alpha1, v2beta3.
+var versionPattern = regexp.MustCompile(`^v(\d+)(?:(alpha|beta)(\d+))?$`)
+
+// NextVersion returns the schema version following version: the pre-release
+// number is incremented for alpha and beta versions (v1alpha1 → v1alpha2),
+// the major version otherwise (v1 → v2).
+func NextVersion(version string) (string, error) {
+	m := versionPattern.FindStringSubmatch(version)
+	if m == nil {
+		return "", fmt.Errorf("cannot bump schema version %q: not a Kubernetes API version", version)
+	}
+
+	if m[2] == "" {
+		major, _ := strconv.Atoi(m[1])
+		return fmt.Sprintf("v%d", major+1), nil
+	}
+
+	n, _ := strconv.Atoi(m[3])
+
+	return fmt.Sprintf("v%s%s%d", m[1], m[2], n+1), nil
+}
+
+// SchemaAPIVersion returns spec.schema.apiVersion of an RGD map.
+func SchemaAPIVersion(rgd map[string]interface{}) string {
+	spec, _ := rgd["spec"].(map[string]interface{})
+	schema, _ := spec["schema"].(map[string]interface{})
+	apiVersion, _ := schema["apiVersion"].(string)
+
+	return apiVersion
+}
+
+// SchemaVersion returns the version part of spec.schema.apiVersion of an
+// RGD map (e.g. "v1alpha1").
+func SchemaVersion(rgd map[string]interface{}) string {
+	apiVersion := SchemaAPIVersion(rgd)
+
+	return apiVersion[strings.LastIndex(apiVersion, "/")+1:]
+}
+
+// Field mapping change kinds.
+const (
+	MappingUnchanged = "unchanged"
+	MappingModified  = "modified"
+	MappingRetyped   = "retyped"
+	MappingRenamed   = "renamed"
+	MappingRemoved   = "removed"
+	MappingAdded     = "added"
+)
+
+// FieldMapping maps a schema field of the superseded version to the new one.
+type FieldMapping struct {
+	// From is the field path in the old schema (empty for added fields).
+	From string `json:"from,omitempty"`
+	// To is the field path in the new schema (empty for removed fields).
+	To string `json:"to,omitempty"`
+	// Change is one of the Mapping* kinds.
+	Change string `json:"change"`
+	// Details describes type or default changes.
+	Details string `json:"details,omitempty"`
+}
+
+// BuildFieldMapping maps the schema fields of oldRGD to those of newRGD. A
+// removed field and an added field with the same SimpleSchema spec, that
+// are each the only candidate for the other, are reported as a rename.
+func BuildFieldMapping(oldRGD, newRGD map[string]interface{}) []FieldMapping {
+	oldFields := make(map[string]string)
+	flattenSchema("", extractSchemaSpec(oldRGD), oldFields)
+
+	newFields := make(map[string]string)
+	flattenSchema("", extractSchemaSpec(newRGD), newFields)
+
+	var (
+		mapping          []FieldMapping
+		removed, added   []string
+		removedBySpec    = make(map[string][]string)
+		addedBySpecCount = make(map[string]int)
+	)
+
+	for _, path := range sortedFieldPaths(oldFields) {
+		oldSpec := oldFields[path]
+
+		newSpec, ok := newFields[path]
+		if !ok {
+			removed = append(removed, path)
+			removedBySpec[oldSpec] = append(removedBySpec[oldSpec], path)
+
+			continue
+		}
+
+		m := FieldMapping{From: path, To: path, Change: MappingUnchanged}
+
+		switch oldType, newType := parseSchemaType(oldSpec), parseSchemaType(newSpec); {
+		case oldType != newType:
+			m.Change = MappingRetyped
+			m.Details = fmt.Sprintf("type %s -> %s", oldType, newType)
+		case oldSpec != newSpec:
+			m.Change = MappingModified
+			m.Details = fmt.Sprintf("%s -> %s", oldSpec, newSpec)
+		}
+
+		mapping = append(mapping, m)
+	}
+
+	for _, path := range sortedFieldPaths(newFields) {
+		if _, ok := oldFields[path]; !ok {
+			added = append(added, path)
+			addedBySpecCount[newFields[path]]++
+		}
+	}
+
+	renamed := make(map[string]bool)
+
+	for _, path := range added {
+		spec := newFields[path]
+
+		if candidates := removedBySpec[spec]; len(candidates) == 1 && addedBySpecCount[spec] == 1 {
+			mapping = append(mapping, FieldMapping{From: candidates[0], To: path, Change: MappingRenamed})
+			renamed[candidates[0]] = true
+
+			continue
+		}
+
+		mapping = append(mapping, FieldMapping{To: path, Change: MappingAdded, Details: spec})
+	}
+
+	for _, path := range removed {
+		if !renamed[path] {
+			mapping = append(mapping, FieldMapping{From: path, Change: MappingRemoved})
+		}
+	}
+
+	sort.SliceStable(mapping, func(i, j int) bool { return mappingKey(mapping[i]) < mappingKey(mapping[j]) })
+
+	return mapping
+}
+
+// mappingKey orders mappings by their old path, or new path for added fields.
+func mappingKey(m FieldMapping) string {
+	if m.From != "" {
+		return m.From
+	}
+
+	return m.To
+}
+
+// flattenSchema collects the leaf fields of a SimpleSchema spec by dotted path.
+func flattenSchema(prefix string, spec map[string]interface{}, out map[string]string) {
+	for key, val := range spec {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		if group, ok := val.(map[string]interface{}); ok {
+			flattenSchema(path, group, out)
+			continue
+		}
+
+		out[path] = fmt.Sprintf("%v", val)
+	}
+}
+
+// sortedFieldPaths returns the paths of flattened schema fields in sorted order.
+func sortedFieldPaths(fields map[string]string) []string {
+	paths := make([]string, 0, len(fields))
+	for p := range fields {
+		paths = append(paths, p)
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+// BumpVersion rewrites newRGD as the next schema version of oldRGD, so
+// that both can be installed side by side: the RGD is named
+// <name>-<version>, which also gives it its own CRD group, the schema
+// apiVersion uses version, and the superseded apiVersion and the field
+// mapping are recorded as annotations.
+func BumpVersion(oldRGD, newRGD map[string]interface{}, version string, mapping []FieldMapping) error {
+	metadata, _ := newRGD["metadata"].(map[string]interface{})
+	if metadata == nil {
+		return fmt.Errorf("RGD has no metadata")
+	}
+
+	name, _ := metadata["name"].(string)
+
+	spec, _ := newRGD["spec"].(map[string]interface{})
+	schema, _ := spec["schema"].(map[string]interface{})
+
+	if schema == nil {
+		return fmt.Errorf("RGD %q has no schema", name)
+	}
+
+	group, _ := schema["apiVersion"].(string)
+	if i := strings.LastIndex(group, "/"); i >= 0 {
+		group = group[:i]
+	}
+
+	group = strings.TrimPrefix(group, name+".")
+
+	bumpedName := name + "-" + version
+	metadata["name"] = bumpedName
+	schema["apiVersion"] = bumpedName + "." + group + "/" + version
+
+	mappingJSON, err := json.Marshal(mapping)
+	if err != nil {
+		return fmt.Errorf("encoding field mapping: %w", err)
+	}
+
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	if annotations == nil {
+		annotations = make(map[string]interface{})
+	}
+
+	annotations[SupersedesAnnotation] = SchemaAPIVersion(oldRGD)
+	annotations[FieldMappingAnnotation] = string(mappingJSON)
+	metadata["annotations"] = annotations
+
+	return nil
+}
+
+// KeepVersion rewrites newRGD to continue the schema version of oldRGD: the
+// RGD name, schema apiVersion, and version bump annotations are copied, so
+// that a compatible regeneration of a bumped RGD updates it in place.
+func KeepVersion(oldRGD, newRGD map[string]interface{}) {
+	oldMetadata, _ := oldRGD["metadata"].(map[string]interface{})
+	metadata, _ := newRGD["metadata"].(map[string]interface{})
+
+	spec, _ := newRGD["spec"].(map[string]interface{})
+	schema, _ := spec["schema"].(map[string]interface{})
+
+	if oldMetadata == nil || metadata == nil || schema == nil {
+		return
+	}
+
+	if name, ok := oldMetadata["name"]; ok {
+		metadata["name"] = name
+	}
+
+	if apiVersion := SchemaAPIVersion(oldRGD); apiVersion != "" {
+		schema["apiVersion"] = apiVersion
+	}
+
+	oldAnnotations, _ := oldMetadata["annotations"].(map[string]interface{})
+
+	for _, key := range []string{SupersedesAnnotation, FieldMappingAnnotation} {
+		v, ok := oldAnnotations[key]
+		if !ok {
+			continue
+		}
+
+		annotations, _ := metadata["annotations"].(map[string]interface{})
+		if annotations == nil {
+			annotations = make(map[string]interface{})
+			metadata["annotations"] = annotations
+		}
+
+		annotations[key] = v
+	}
+}
+
+// FormatFieldMapping writes the field mapping as a human-readable table.
+func FormatFieldMapping(w io.Writer, mapping []FieldMapping) {
+	_, _ = fmt.Fprintln(w, "Field Mapping:")
+	_, _ = fmt.Fprintln(w, strings.Repeat("-", 60))
+
+	for _, m := range mapping {
+		from, to := m.From, m.To
+		if from == "" {
+			from = "-"
+		}
+
+		if to == "" {
+			to = "-"
+		}
+
+		line := fmt.Sprintf("  %-25s -> %-25s %s", from, to, m.Change)
+		if m.Details != "" {
+			line += " (" + m.Details + ")"
+		}
+
+		_, _ = fmt.Fprintln(w, line)
+	}
+}
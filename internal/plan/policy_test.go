@@ -0,0 +1,136 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// schemaRGD builds an RGD map with the given name, schema apiVersion, and
+// schema spec.
+func schemaRGD(name, apiVersion string, spec map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"name": name},
+		"spec": map[string]interface{}{
+			"schema": map[string]interface{}{
+				"apiVersion": apiVersion,
+				"kind":       "App",
+				"spec":       spec,
+			},
+		},
+	}
+}
+
+func TestParseEvolutionPolicy(t *testing.T) {
+	for in, want := range map[string]EvolutionPolicy{"": PolicyAllow, "allow": PolicyAllow, "strict": PolicyStrict, "bump": PolicyBump} {
+		got, err := ParseEvolutionPolicy(in)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := ParseEvolutionPolicy("loose")
+	require.ErrorContains(t, err, "invalid evolution policy")
+}
+
+func TestNextVersion(t *testing.T) {
+	for in, want := range map[string]string{
+		"v1alpha1": "v1alpha2",
+		"v1beta9":  "v1beta10",
+		"v2alpha3": "v2alpha4",
+		"v1":       "v2",
+	} {
+		got, err := NextVersion(in)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, in)
+	}
+
+	_, err := NextVersion("latest")
+	require.ErrorContains(t, err, "not a Kubernetes API version")
+}
+
+func TestBuildFieldMapping(t *testing.T) {
+	oldRGD := schemaRGD("app", "app.kro.run/v1alpha1", map[string]interface{}{
+		"replicaCount": "integer | default=1",
+		"image": map[string]interface{}{
+			"repository": `string | default="nginx"`,
+			"tag":        `string | default="1.21"`,
+		},
+		"port":  "integer | default=80",
+		"debug": "boolean | default=false",
+	})
+	newRGD := schemaRGD("app", "app.kro.run/v1alpha1", map[string]interface{}{
+		"replicas": "integer | default=1",
+		"image": map[string]interface{}{
+			"repository": `string | default="nginx"`,
+			"tag":        `string | default="1.22"`,
+		},
+		"port":  `string | default="80"`,
+		"token": "string",
+	})
+
+	assert.Equal(t, []FieldMapping{
+		{From: "debug", Change: MappingRemoved},
+		{From: "image.repository", To: "image.repository", Change: MappingUnchanged},
+		{From: "image.tag", To: "image.tag", Change: MappingModified,
+			Details: `string | default="1.21" -> string | default="1.22"`},
+		{From: "port", To: "port", Change: MappingRetyped, Details: "type integer -> string"},
+		{From: "replicaCount", To: "replicas", Change: MappingRenamed},
+		{To: "token", Change: MappingAdded, Details: "string"},
+	}, BuildFieldMapping(oldRGD, newRGD))
+}
+
+func TestBumpVersion(t *testing.T) {
+	oldRGD := schemaRGD("app", "app.kro.run/v1alpha1", map[string]interface{}{"a": "string"})
+	newRGD := schemaRGD("app", "app.example.com/v1alpha1", map[string]interface{}{"b": "string"})
+	mapping := BuildFieldMapping(oldRGD, newRGD)
+
+	require.NoError(t, BumpVersion(oldRGD, newRGD, "v1alpha2", mapping))
+
+	assert.Equal(t, "app-v1alpha2", newRGD["metadata"].(map[string]interface{})["name"])
+	assert.Equal(t, "app-v1alpha2.example.com/v1alpha2", SchemaAPIVersion(newRGD))
+	assert.Equal(t, "v1alpha2", SchemaVersion(newRGD))
+
+	annotations := newRGD["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+	assert.Equal(t, "app.kro.run/v1alpha1", annotations[SupersedesAnnotation])
+
+	var decoded []FieldMapping
+	require.NoError(t, json.Unmarshal([]byte(annotations[FieldMappingAnnotation].(string)), &decoded))
+	assert.Equal(t, mapping, decoded)
+
+	// The superseded RGD is untouched.
+	assert.Equal(t, "app.kro.run/v1alpha1", SchemaAPIVersion(oldRGD))
+}
+
+func TestKeepVersion(t *testing.T) {
+	oldRGD := schemaRGD("app-v1alpha2", "app-v1alpha2.kro.run/v1alpha2", map[string]interface{}{"a": "string"})
+	oldRGD["metadata"].(map[string]interface{})["annotations"] = map[string]interface{}{
+		SupersedesAnnotation: "app.kro.run/v1alpha1",
+		"other":              "dropped",
+	}
+
+	newRGD := schemaRGD("app", "app.kro.run/v1alpha1", map[string]interface{}{"a": "string", "b": `string | default="x"`})
+
+	KeepVersion(oldRGD, newRGD)
+
+	assert.Equal(t, "app-v1alpha2", newRGD["metadata"].(map[string]interface{})["name"])
+	assert.Equal(t, "app-v1alpha2.kro.run/v1alpha2", SchemaAPIVersion(newRGD))
+	assert.Equal(t, map[string]interface{}{SupersedesAnnotation: "app.kro.run/v1alpha1"},
+		newRGD["metadata"].(map[string]interface{})["annotations"])
+}
+
+func TestFormatFieldMapping(t *testing.T) {
+	var buf bytes.Buffer
+
+	FormatFieldMapping(&buf, []FieldMapping{
+		{From: "replicaCount", To: "replicas", Change: MappingRenamed},
+		{To: "token", Change: MappingAdded, Details: "string"},
+	})
+
+	out := buf.String()
+	assert.Contains(t, out, "Field Mapping:")
+	assert.Regexp(t, `replicaCount\s+-> replicas\s+renamed`, out)
+	assert.Regexp(t, `-\s+-> token\s+added \(string\)`, out)
+}
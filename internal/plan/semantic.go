@@ -0,0 +1,637 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// FieldChange is a change of a single value, keyed by its JSON path.
+type FieldChange struct {
+	// Path is the dotted JSON path of the value. List items with a "name"
+	// field are keyed by name (e.g. "containers[name=web].image"), others by
+	// index.
+	Path string     `json:"path"`
+	Type ChangeType `json:"type"`
+	Old  any        `json:"old,omitempty"`
+	New  any        `json:"new,omitempty"`
+	// CEL is true when the old or new value contains a CEL expression.
+	CEL bool `json:"cel,omitempty"`
+}
+
+// ListChange lists the entries added to and removed from a string list.
+type ListChange struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// IsEmpty returns true when nothing was added or removed.
+func (c ListChange) IsEmpty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0
+}
+
+// ResourceDiff holds the changes of one RGD resource, keyed by resource ID.
+type ResourceDiff struct {
+	ID   string     `json:"id"`
+	Kind string     `json:"kind,omitempty"`
+	Type ChangeType `json:"type"`
+	// Fields are template and other field changes of a modified resource.
+	Fields []FieldChange `json:"fields,omitempty"`
+	// ReadyWhen and IncludeWhen are condition changes.
+	ReadyWhen   ListChange `json:"readyWhen,omitempty"`
+	IncludeWhen ListChange `json:"includeWhen,omitempty"`
+	// Dependencies are dependency edge changes: explicit dependsOn entries
+	// and resources referenced from CEL expressions.
+	Dependencies ListChange `json:"dependencies,omitempty"`
+}
+
+// SemanticDiff is a structural diff of two RGDs.
+type SemanticDiff struct {
+	// Schema holds changes of spec.schema (apiVersion, kind, spec, status).
+	Schema []FieldChange `json:"schema"`
+	// Resources holds added, removed, and modified resources by ID.
+	Resources []ResourceDiff `json:"resources"`
+}
+
+// HasDifferences returns true when the RGDs differ.
+func (d *SemanticDiff) HasDifferences() bool {
+	return len(d.Schema) > 0 || len(d.Resources) > 0
+}
+
+// resourceListFields are the resource entry fields diffed as lists rather
+// than by path.
+var resourceListFields = map[string]bool{"id": true, "readyWhen": true, "includeWhen": true, "dependsOn": true}
+
+// ComputeSemanticDiff compares two RGD maps structurally. Resources are
+// matched by ID, so reordering resources or map keys produces no changes.
+func ComputeSemanticDiff(oldRGD, newRGD map[string]interface{}) *SemanticDiff {
+	d := &SemanticDiff{
+		Schema:    []FieldChange{},
+		Resources: []ResourceDiff{},
+	}
+
+	oldSchema, _ := nestedMap(oldRGD, "spec", "schema")
+	newSchema, _ := nestedMap(newRGD, "spec", "schema")
+	diffValues("", oldSchema, newSchema, &d.Schema)
+
+	oldIdx := indexResources(extractResources(oldRGD))
+	newIdx := indexResources(extractResources(newRGD))
+
+	for _, id := range sortedKeys(oldIdx) {
+		if _, ok := newIdx[id]; !ok {
+			d.Resources = append(d.Resources, ResourceDiff{ID: id, Kind: extractResourceKind(oldIdx[id]), Type: ChangeRemoved})
+		}
+	}
+
+	for _, id := range sortedKeys(newIdx) {
+		newRes := newIdx[id]
+
+		oldRes, ok := oldIdx[id]
+		if !ok {
+			d.Resources = append(d.Resources, ResourceDiff{ID: id, Kind: extractResourceKind(newRes), Type: ChangeAdded})
+			continue
+		}
+
+		rd := ResourceDiff{
+			ID:           id,
+			Kind:         extractResourceKind(newRes),
+			Type:         ChangeModified,
+			ReadyWhen:    diffStringLists(stringList(oldRes["readyWhen"]), stringList(newRes["readyWhen"])),
+			IncludeWhen:  diffStringLists(stringList(oldRes["includeWhen"]), stringList(newRes["includeWhen"])),
+			Dependencies: diffStringLists(resourceDependencies(id, oldRes, oldIdx), resourceDependencies(id, newRes, newIdx)),
+		}
+
+		for _, key := range sortedStringKeys(mergeKeys(oldRes, newRes)) {
+			if !resourceListFields[key] {
+				diffValues(key, oldRes[key], newRes[key], &rd.Fields)
+			}
+		}
+
+		if len(rd.Fields) > 0 || !rd.ReadyWhen.IsEmpty() || !rd.IncludeWhen.IsEmpty() || !rd.Dependencies.IsEmpty() {
+			d.Resources = append(d.Resources, rd)
+		}
+	}
+
+	return d
+}
+
+// diffValues appends the changes between old and new at path to changes.
+func diffValues(path string, oldVal, newVal any, changes *[]FieldChange) {
+	switch {
+	case oldVal == nil && newVal == nil:
+		return
+	case oldVal == nil:
+		*changes = append(*changes, FieldChange{Path: path, Type: ChangeAdded, New: newVal, CEL: containsCEL(newVal)})
+		return
+	case newVal == nil:
+		*changes = append(*changes, FieldChange{Path: path, Type: ChangeRemoved, Old: oldVal, CEL: containsCEL(oldVal)})
+		return
+	}
+
+	oldMap, oldIsMap := oldVal.(map[string]interface{})
+	newMap, newIsMap := newVal.(map[string]interface{})
+
+	if oldIsMap && newIsMap {
+		for _, key := range sortedStringKeys(mergeKeys(oldMap, newMap)) {
+			diffValues(joinPath(path, key), oldMap[key], newMap[key], changes)
+		}
+
+		return
+	}
+
+	oldList, oldIsList := oldVal.([]interface{})
+	newList, newIsList := newVal.([]interface{})
+
+	if oldIsList && newIsList {
+		diffLists(path, oldList, newList, changes)
+		return
+	}
+
+	if !reflect.DeepEqual(oldVal, newVal) {
+		*changes = append(*changes, FieldChange{
+			Path: path,
+			Type: ChangeModified,
+			Old:  oldVal,
+			New:  newVal,
+			CEL:  containsCEL(oldVal) || containsCEL(newVal),
+		})
+	}
+}
+
+// diffLists compares lists by item name when every item has a unique
+// "name", and by index otherwise.
+func diffLists(path string, oldList, newList []interface{}, changes *[]FieldChange) {
+	oldNamed, oldOK := namedItems(oldList)
+	newNamed, newOK := namedItems(newList)
+
+	if oldOK && newOK {
+		oldIdx := make(map[string]interface{}, len(oldList))
+		for i, name := range oldNamed {
+			oldIdx[name] = oldList[i]
+		}
+
+		newIdx := make(map[string]interface{}, len(newList))
+		for i, name := range newNamed {
+			newIdx[name] = newList[i]
+		}
+
+		for _, name := range sortedStringKeys(mergeKeys(oldIdx, newIdx)) {
+			diffValues(fmt.Sprintf("%s[name=%s]", path, name), oldIdx[name], newIdx[name], changes)
+		}
+
+		return
+	}
+
+	for i := 0; i < len(oldList) || i < len(newList); i++ {
+		var oldItem, newItem any
+
+		if i < len(oldList) {
+			oldItem = oldList[i]
+		}
+
+		if i < len(newList) {
+			newItem = newList[i]
+		}
+
+		diffValues(fmt.Sprintf("%s[%d]", path, i), oldItem, newItem, changes)
+	}
+}
+
+// namedItems returns the "name" of every list item, or false when an item
+// is not a map with a unique string name.
+func namedItems(list []interface{}) ([]string, bool) {
+	if len(list) == 0 {
+		return nil, true
+	}
+
+	names := make([]string, len(list))
+	seen := make(map[string]bool, len(list))
+
+	for i, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		name, ok := m["name"].(string)
+		if !ok || seen[name] {
+			return nil, false
+		}
+
+		seen[name] = true
+		names[i] = name
+	}
+
+	return names, true
+}
+
+// celRefPattern matches the leading identifier of CEL references.
+var celRefPattern = regexp.MustCompile(`\$\{[^}]*?\b([A-Za-z_][A-Za-z0-9_]*)\.`)
+
+// resourceDependencies returns the explicit dependsOn entries of a resource
+// and the other resources its CEL expressions reference, sorted.
+func resourceDependencies(id string, res map[string]interface{}, all map[string]map[string]interface{}) []string {
+	deps := make(map[string]bool)
+
+	for _, dep := range stringList(res["dependsOn"]) {
+		deps[dep] = true
+	}
+
+	for _, expr := range collectStrings(res["template"]) {
+		for _, m := range celRefPattern.FindAllStringSubmatch(expr, -1) {
+			if ref := m[1]; ref != id {
+				if _, ok := all[ref]; ok {
+					deps[ref] = true
+				}
+			}
+		}
+	}
+
+	out := make([]string, 0, len(deps))
+	for dep := range deps {
+		out = append(out, dep)
+	}
+
+	sort.Strings(out)
+
+	return out
+}
+
+// diffStringLists returns the entries only in newList as added and the
+// entries only in oldList as removed.
+func diffStringLists(oldList, newList []string) ListChange {
+	var c ListChange
+
+	inOld := make(map[string]bool, len(oldList))
+	for _, s := range oldList {
+		inOld[s] = true
+	}
+
+	inNew := make(map[string]bool, len(newList))
+	for _, s := range newList {
+		inNew[s] = true
+
+		if !inOld[s] {
+			c.Added = append(c.Added, s)
+		}
+	}
+
+	for _, s := range oldList {
+		if !inNew[s] {
+			c.Removed = append(c.Removed, s)
+		}
+	}
+
+	return c
+}
+
+// indexResources indexes RGD resource entries by ID.
+func indexResources(resources []interface{}) map[string]map[string]interface{} {
+	idx := make(map[string]map[string]interface{}, len(resources))
+
+	for _, r := range resources {
+		m, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if id, ok := m["id"].(string); ok {
+			idx[id] = m
+		}
+	}
+
+	return idx
+}
+
+// stringList converts a YAML list of strings.
+func stringList(v any) []string {
+	list, _ := v.([]interface{})
+	out := make([]string, 0, len(list))
+
+	for _, item := range list {
+		out = append(out, fmt.Sprintf("%v", item))
+	}
+
+	return out
+}
+
+// collectStrings returns all string values nested in v.
+func collectStrings(v any) []string {
+	switch val := v.(type) {
+	case string:
+		return []string{val}
+	case map[string]interface{}:
+		var out []string
+		for _, child := range val {
+			out = append(out, collectStrings(child)...)
+		}
+
+		return out
+	case []interface{}:
+		var out []string
+		for _, child := range val {
+			out = append(out, collectStrings(child)...)
+		}
+
+		return out
+	default:
+		return nil
+	}
+}
+
+// containsCEL reports whether v contains a ${...} expression.
+func containsCEL(v any) bool {
+	for _, s := range collectStrings(v) {
+		if strings.Contains(s, "${") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergeKeys returns the union of the keys of a and b as a set map.
+func mergeKeys[V any](a, b map[string]V) map[string]interface{} {
+	keys := make(map[string]interface{}, len(a)+len(b))
+	for k := range a {
+		keys[k] = nil
+	}
+
+	for k := range b {
+		keys[k] = nil
+	}
+
+	return keys
+}
+
+// nestedMap returns the map at the given keys.
+func nestedMap(m map[string]interface{}, keys ...string) (map[string]interface{}, bool) {
+	cur := m
+
+	for _, k := range keys {
+		next, ok := cur[k].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		cur = next
+	}
+
+	return cur, true
+}
+
+// joinPath joins a path prefix and a key.
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "." + key
+}
+
+// formatValue renders a value compactly for table and tree output.
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "<none>"
+	case string:
+		return val
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprintf("%v", val)
+		}
+
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// FormatSemanticTable writes the structural diff as a table with one row
+// per change.
+func FormatSemanticTable(w io.Writer, d *SemanticDiff) {
+	if !d.HasDifferences() {
+		_, _ = fmt.Fprintln(w, "No differences found.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "RESOURCE\tCHANGE\tPATH\tOLD\tNEW")
+
+	for _, c := range d.Schema {
+		_, _ = fmt.Fprintf(tw, "(schema)\t%s\t%s\t%s\t%s\n", changeLabel(c), c.Path, cell(c.Old), cell(c.New))
+	}
+
+	for _, r := range d.Resources {
+		if r.Type != ChangeModified {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t\t\t\n", r.ID, r.Type)
+			continue
+		}
+
+		for _, c := range r.Fields {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.ID, changeLabel(c), c.Path, cell(c.Old), cell(c.New))
+		}
+
+		for _, l := range resourceListChanges(r) {
+			for _, s := range l.change.Added {
+				_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t\t%s\n", r.ID, ChangeAdded, l.name, s)
+			}
+
+			for _, s := range l.change.Removed {
+				_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\n", r.ID, ChangeRemoved, l.name, s)
+			}
+		}
+	}
+
+	_ = tw.Flush()
+}
+
+// FormatSemanticTree writes the structural diff as a tree of resources and
+// changed paths, with optional ANSI colors.
+func FormatSemanticTree(w io.Writer, d *SemanticDiff, color bool) {
+	if !d.HasDifferences() {
+		_, _ = fmt.Fprintln(w, "No differences found.")
+		return
+	}
+
+	p := treePrinter{w: w, color: color}
+
+	if len(d.Schema) > 0 {
+		_, _ = fmt.Fprintln(w, "schema")
+
+		for i, c := range d.Schema {
+			p.field("", i == len(d.Schema)-1, c)
+		}
+	}
+
+	if len(d.Resources) > 0 {
+		_, _ = fmt.Fprintln(w, "resources")
+	}
+
+	for i, r := range d.Resources {
+		last := i == len(d.Resources)-1
+		p.line(r.Type, branch(last)+changeSymbol(r.Type)+" "+formatResourceRef(r.ID, r.Kind))
+
+		indent := "│   "
+		if last {
+			indent = "    "
+		}
+
+		type item struct {
+			typ  ChangeType
+			text string
+			fc   *FieldChange
+		}
+
+		var items []item
+
+		for j := range r.Fields {
+			items = append(items, item{fc: &r.Fields[j]})
+		}
+
+		for _, l := range resourceListChanges(r) {
+			for _, s := range l.change.Added {
+				items = append(items, item{typ: ChangeAdded, text: l.name + ": " + s})
+			}
+
+			for _, s := range l.change.Removed {
+				items = append(items, item{typ: ChangeRemoved, text: l.name + ": " + s})
+			}
+		}
+
+		for j, it := range items {
+			itemLast := j == len(items)-1
+			if it.fc != nil {
+				p.field(indent, itemLast, *it.fc)
+				continue
+			}
+
+			p.line(it.typ, indent+branch(itemLast)+changeSymbol(it.typ)+" "+it.text)
+		}
+	}
+}
+
+// namedListChange is a list change with its display name.
+type namedListChange struct {
+	name   string
+	change ListChange
+}
+
+// resourceListChanges returns the non-empty list changes of a resource.
+func resourceListChanges(r ResourceDiff) []namedListChange {
+	var out []namedListChange
+
+	for _, l := range []namedListChange{
+		{"readyWhen", r.ReadyWhen},
+		{"includeWhen", r.IncludeWhen},
+		{"dependencies", r.Dependencies},
+	} {
+		if !l.change.IsEmpty() {
+			out = append(out, l)
+		}
+	}
+
+	return out
+}
+
+// treePrinter writes colored tree lines.
+type treePrinter struct {
+	w     io.Writer
+	color bool
+}
+
+// field writes a field change line.
+func (p treePrinter) field(indent string, last bool, c FieldChange) {
+	text := c.Path
+
+	switch c.Type {
+	case ChangeAdded:
+		text += ": " + formatValue(c.New)
+	case ChangeRemoved:
+		text += ": " + formatValue(c.Old)
+	case ChangeModified:
+		text += ": " + formatValue(c.Old) + " → " + formatValue(c.New)
+	}
+
+	if c.CEL {
+		text += " [CEL]"
+	}
+
+	p.line(c.Type, indent+branch(last)+changeSymbol(c.Type)+" "+text)
+}
+
+// line writes a line colored by change type.
+func (p treePrinter) line(t ChangeType, text string) {
+	const (
+		red    = "\033[31m"
+		green  = "\033[32m"
+		yellow = "\033[33m"
+		reset  = "\033[0m"
+	)
+
+	if !p.color {
+		_, _ = fmt.Fprintln(p.w, text)
+		return
+	}
+
+	code := yellow
+
+	switch t {
+	case ChangeAdded:
+		code = green
+	case ChangeRemoved:
+		code = red
+	}
+
+	_, _ = fmt.Fprintf(p.w, "%s%s%s\n", code, text, reset)
+}
+
+// branch returns the tree connector for an item.
+func branch(last bool) string {
+	if last {
+		return "└── "
+	}
+
+	return "├── "
+}
+
+// changeSymbol returns the diff symbol of a change type.
+func changeSymbol(t ChangeType) string {
+	switch t {
+	case ChangeAdded:
+		return "+"
+	case ChangeRemoved:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+// changeLabel returns the table label of a field change.
+func changeLabel(c FieldChange) string {
+	if c.CEL {
+		return string(c.Type) + " (CEL)"
+	}
+
+	return string(c.Type)
+}
+
+// cell formats a value for a table cell, truncating long values.
+func cell(v any) string {
+	if v == nil {
+		return ""
+	}
+
+	s := strings.ReplaceAll(formatValue(v), "\n", " ")
+	if len(s) > 60 {
+		s = s[:57] + "..."
+	}
+
+	return s
+}
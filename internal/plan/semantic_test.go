@@ -0,0 +1,202 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// semanticRGD builds an RGD map with a web Deployment and a Service that
+// selects it.
+func semanticRGD(image string, replicas interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"spec": map[string]interface{}{
+			"schema": map[string]interface{}{
+				"apiVersion": "v1alpha1",
+				"kind":       "App",
+				"spec":       map[string]interface{}{"image": `string | default="nginx"`},
+			},
+			"resources": []interface{}{
+				map[string]interface{}{
+					"id": "deployment",
+					"template": map[string]interface{}{
+						"kind": "Deployment",
+						"spec": map[string]interface{}{
+							"replicas": replicas,
+							"template": map[string]interface{}{
+								"spec": map[string]interface{}{
+									"containers": []interface{}{
+										map[string]interface{}{"name": "sidecar", "image": "envoy"},
+										map[string]interface{}{"name": "web", "image": image},
+									},
+								},
+							},
+						},
+					},
+					"readyWhen": []interface{}{"${self.status.availableReplicas == self.spec.replicas}"},
+				},
+				map[string]interface{}{
+					"id": "service",
+					"template": map[string]interface{}{
+						"kind": "Service",
+						"spec": map[string]interface{}{"selector": map[string]interface{}{"app": "web"}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestComputeSemanticDiff_Identical(t *testing.T) {
+	d := ComputeSemanticDiff(semanticRGD("nginx", 1), semanticRGD("nginx", 1))
+
+	assert.False(t, d.HasDifferences())
+}
+
+func TestComputeSemanticDiff_IgnoresResourceOrder(t *testing.T) {
+	newRGD := semanticRGD("nginx", 1)
+	resources := newRGD["spec"].(map[string]interface{})["resources"].([]interface{})
+	resources[0], resources[1] = resources[1], resources[0]
+
+	assert.False(t, ComputeSemanticDiff(semanticRGD("nginx", 1), newRGD).HasDifferences())
+}
+
+func TestComputeSemanticDiff_FieldChanges(t *testing.T) {
+	d := ComputeSemanticDiff(semanticRGD("nginx:1.25", 1), semanticRGD("${schema.spec.image}", 3))
+
+	require.Len(t, d.Resources, 1)
+
+	r := d.Resources[0]
+	assert.Equal(t, "deployment", r.ID)
+	assert.Equal(t, "Deployment", r.Kind)
+	assert.Equal(t, ChangeModified, r.Type)
+	assert.Equal(t, []FieldChange{
+		{Path: "template.spec.replicas", Type: ChangeModified, Old: 1, New: 3},
+		{
+			Path: "template.spec.template.spec.containers[name=web].image", Type: ChangeModified,
+			Old: "nginx:1.25", New: "${schema.spec.image}", CEL: true,
+		},
+	}, r.Fields)
+}
+
+func TestComputeSemanticDiff_AddedRemovedResources(t *testing.T) {
+	newRGD := semanticRGD("nginx", 1)
+	spec := newRGD["spec"].(map[string]interface{})
+	spec["resources"] = append(spec["resources"].([]interface{})[:1], map[string]interface{}{
+		"id":       "configmap",
+		"template": map[string]interface{}{"kind": "ConfigMap"},
+	})
+
+	d := ComputeSemanticDiff(semanticRGD("nginx", 1), newRGD)
+
+	require.Len(t, d.Resources, 2)
+	assert.Equal(t, ResourceDiff{ID: "service", Kind: "Service", Type: ChangeRemoved}, d.Resources[0])
+	assert.Equal(t, ResourceDiff{ID: "configmap", Kind: "ConfigMap", Type: ChangeAdded}, d.Resources[1])
+}
+
+func TestComputeSemanticDiff_ConditionsAndDependencies(t *testing.T) {
+	newRGD := semanticRGD("nginx", 1)
+	resources := newRGD["spec"].(map[string]interface{})["resources"].([]interface{})
+
+	deployment := resources[0].(map[string]interface{})
+	deployment["readyWhen"] = []interface{}{"${self.status.readyReplicas > 0}"}
+	deployment["includeWhen"] = []interface{}{"${schema.spec.enabled}"}
+
+	service := resources[1].(map[string]interface{})
+	service["template"].(map[string]interface{})["spec"].(map[string]interface{})["selector"] =
+		map[string]interface{}{"app": "${deployment.metadata.name}"}
+
+	d := ComputeSemanticDiff(semanticRGD("nginx", 1), newRGD)
+
+	require.Len(t, d.Resources, 2)
+	assert.Equal(t, ListChange{
+		Added:   []string{"${self.status.readyReplicas > 0}"},
+		Removed: []string{"${self.status.availableReplicas == self.spec.replicas}"},
+	}, d.Resources[0].ReadyWhen)
+	assert.Equal(t, ListChange{Added: []string{"${schema.spec.enabled}"}}, d.Resources[0].IncludeWhen)
+
+	assert.Equal(t, "service", d.Resources[1].ID)
+	assert.Equal(t, ListChange{Added: []string{"deployment"}}, d.Resources[1].Dependencies)
+	require.Len(t, d.Resources[1].Fields, 1)
+	assert.True(t, d.Resources[1].Fields[0].CEL)
+}
+
+func TestComputeSemanticDiff_Schema(t *testing.T) {
+	newRGD := semanticRGD("nginx", 1)
+	schema := newRGD["spec"].(map[string]interface{})["schema"].(map[string]interface{})
+	schema["spec"] = map[string]interface{}{"image": `string | default="httpd"`, "replicas": "integer"}
+
+	d := ComputeSemanticDiff(semanticRGD("nginx", 1), newRGD)
+
+	assert.Equal(t, []FieldChange{
+		{Path: "spec.image", Type: ChangeModified, Old: `string | default="nginx"`, New: `string | default="httpd"`},
+		{Path: "spec.replicas", Type: ChangeAdded, New: "integer"},
+	}, d.Schema)
+}
+
+func TestFormatSemanticTree(t *testing.T) {
+	d := ComputeSemanticDiff(semanticRGD("nginx", 1), semanticRGD("${schema.spec.image}", 1))
+
+	var buf bytes.Buffer
+
+	FormatSemanticTree(&buf, d, false)
+
+	assert.Equal(t, "resources\n"+
+		"└── ~ deployment (Deployment)\n"+
+		"    └── ~ template.spec.template.spec.containers[name=web].image: nginx → ${schema.spec.image} [CEL]\n",
+		buf.String())
+
+	buf.Reset()
+	FormatSemanticTree(&buf, d, true)
+	assert.Contains(t, buf.String(), "\033[33m")
+}
+
+func TestFormatSemanticTable(t *testing.T) {
+	newRGD := semanticRGD("nginx", 2)
+	resources := newRGD["spec"].(map[string]interface{})["resources"].([]interface{})
+	resources[0].(map[string]interface{})["includeWhen"] = []interface{}{"${schema.spec.enabled}"}
+
+	var buf bytes.Buffer
+
+	FormatSemanticTable(&buf, ComputeSemanticDiff(semanticRGD("nginx", 1), newRGD))
+
+	out := buf.String()
+	assert.Regexp(t, `RESOURCE\s+CHANGE\s+PATH\s+OLD\s+NEW`, out)
+	assert.Regexp(t, `deployment\s+modified\s+template\.spec\.replicas\s+1\s+2`, out)
+	assert.Regexp(t, `deployment\s+added\s+includeWhen\s+\$\{schema\.spec\.enabled\}`, out)
+}
+
+func TestFormatSemantic_NoDifferences(t *testing.T) {
+	d := ComputeSemanticDiff(semanticRGD("nginx", 1), semanticRGD("nginx", 1))
+
+	var buf bytes.Buffer
+
+	FormatSemanticTree(&buf, d, true)
+	FormatSemanticTable(&buf, d)
+
+	assert.Equal(t, "No differences found.\nNo differences found.\n", buf.String())
+}
+
+func TestFormatDiffJSON(t *testing.T) {
+	oldRGD, newRGD := semanticRGD("nginx", 1), semanticRGD("nginx", 2)
+
+	var buf bytes.Buffer
+
+	require.NoError(t, FormatDiffJSON(&buf, Analyze(oldRGD, newRGD), ComputeSemanticDiff(oldRGD, newRGD)))
+
+	var decoded struct {
+		ResourceChanges []ResourceChange `json:"resourceChanges"`
+		Diff            SemanticDiff     `json:"diff"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+
+	require.Len(t, decoded.Diff.Resources, 1)
+	assert.Equal(t, "template.spec.replicas", decoded.Diff.Resources[0].Fields[0].Path)
+
+	buf.Reset()
+	require.NoError(t, FormatJSON(&buf, Analyze(oldRGD, newRGD)))
+	assert.NotContains(t, buf.String(), `"diff"`)
+}
@@ -0,0 +1,352 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/hupe1980/chart2kro/internal/audit"
+	"github.com/hupe1980/chart2kro/internal/harden"
+	"github.com/hupe1980/chart2kro/internal/k8s"
+)
+
+// ChartVersion identifies one side of a chart upgrade.
+type ChartVersion struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	AppVersion string `json:"appVersion,omitempty"`
+}
+
+// String returns "name@version".
+func (c ChartVersion) String() string {
+	return c.Name + "@" + c.Version
+}
+
+// UpgradeInput is the conversion result of one chart version.
+type UpgradeInput struct {
+	Chart ChartVersion
+	// RGD is the generated RGD map.
+	RGD map[string]interface{}
+	// Rendered holds the rendered resources by resource ID, before
+	// parameterisation.
+	Rendered map[string]*k8s.Resource
+	// HardenChanges are the hardening changes, nil when hardening is off.
+	HardenChanges []harden.Change
+	// Findings are the audit findings of the rendered resources.
+	Findings []audit.Finding
+}
+
+// ImageChange is a container image that was added, removed, or changed.
+type ImageChange struct {
+	ResourceID string     `json:"resourceId"`
+	Container  string     `json:"container"`
+	Type       ChangeType `json:"type"`
+	Old        string     `json:"old,omitempty"`
+	New        string     `json:"new,omitempty"`
+}
+
+// HardeningDelta lists the hardening changes only one version needed.
+type HardeningDelta struct {
+	// Added are changes needed by the new version only.
+	Added []harden.Change `json:"added"`
+	// Removed are changes needed by the old version only.
+	Removed []harden.Change `json:"removed"`
+}
+
+// AuditDelta lists the audit findings only one version has.
+type AuditDelta struct {
+	// New are findings of the new version only.
+	New []audit.Finding `json:"new"`
+	// Resolved are findings of the old version only.
+	Resolved []audit.Finding `json:"resolved"`
+}
+
+// UpgradeReport describes the impact of upgrading a chart on RGD consumers.
+type UpgradeReport struct {
+	From      ChartVersion     `json:"from"`
+	To        ChartVersion     `json:"to"`
+	Evolution *EvolutionResult `json:"evolution"`
+	// ResourcesAdded and ResourcesRemoved are the added and removed RGD
+	// resources.
+	ResourcesAdded   []ResourceChange `json:"resourcesAdded"`
+	ResourcesRemoved []ResourceChange `json:"resourcesRemoved"`
+	Images           []ImageChange    `json:"images"`
+	// Hardening is nil when hardening was not enabled.
+	Hardening *HardeningDelta `json:"hardening,omitempty"`
+	Audit     AuditDelta      `json:"audit"`
+}
+
+// BuildUpgradeReport compares the conversions of two chart versions.
+func BuildUpgradeReport(from, to *UpgradeInput) *UpgradeReport {
+	r := &UpgradeReport{
+		From:             from.Chart,
+		To:               to.Chart,
+		Evolution:        Analyze(from.RGD, to.RGD),
+		ResourcesAdded:   []ResourceChange{},
+		ResourcesRemoved: []ResourceChange{},
+		Images:           compareImages(from.Rendered, to.Rendered),
+		Audit:            compareFindings(from.Findings, to.Findings),
+	}
+
+	for _, c := range r.Evolution.ResourceChanges {
+		switch c.Type {
+		case ChangeAdded:
+			r.ResourcesAdded = append(r.ResourcesAdded, c)
+		case ChangeRemoved:
+			r.ResourcesRemoved = append(r.ResourcesRemoved, c)
+		}
+	}
+
+	if from.HardenChanges != nil || to.HardenChanges != nil {
+		r.Hardening = compareHardenChanges(from.HardenChanges, to.HardenChanges)
+	}
+
+	return r
+}
+
+// compareImages compares container images by resource ID and container name.
+func compareImages(oldRes, newRes map[string]*k8s.Resource) []ImageChange {
+	oldImages := collectImages(oldRes)
+	newImages := collectImages(newRes)
+
+	keys := make(map[string]interface{}, len(oldImages)+len(newImages))
+	for k := range oldImages {
+		keys[k] = nil
+	}
+
+	for k := range newImages {
+		keys[k] = nil
+	}
+
+	changes := []ImageChange{}
+
+	for _, key := range sortedStringKeys(keys) {
+		oldImage, inOld := oldImages[key]
+		newImage, inNew := newImages[key]
+
+		id, container, _ := strings.Cut(key, "/")
+		c := ImageChange{ResourceID: id, Container: container, Old: oldImage, New: newImage}
+
+		switch {
+		case !inOld:
+			c.Type = ChangeAdded
+		case !inNew:
+			c.Type = ChangeRemoved
+		case oldImage != newImage:
+			c.Type = ChangeModified
+		default:
+			continue
+		}
+
+		changes = append(changes, c)
+	}
+
+	return changes
+}
+
+// collectImages returns the images of all workload containers keyed by
+// "<resource ID>/<container name>".
+func collectImages(resources map[string]*k8s.Resource) map[string]string {
+	images := make(map[string]string)
+
+	for id, res := range resources {
+		podSpec := podSpecOf(res)
+		if podSpec == nil {
+			continue
+		}
+
+		for _, key := range []string{"initContainers", "containers"} {
+			containers, _ := podSpec[key].([]interface{})
+
+			for i, c := range containers {
+				cm, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				name, _ := cm["name"].(string)
+				if name == "" {
+					name = fmt.Sprintf("%s[%d]", key, i)
+				}
+
+				if image, ok := cm["image"].(string); ok {
+					images[id+"/"+name] = image
+				}
+			}
+		}
+	}
+
+	return images
+}
+
+// podSpecOf returns the pod spec of a Pod or workload resource.
+func podSpecOf(res *k8s.Resource) map[string]interface{} {
+	if res == nil || res.Object == nil {
+		return nil
+	}
+
+	spec, _ := res.Object.Object["spec"].(map[string]interface{})
+
+	switch {
+	case res.Kind() == "Pod":
+		return spec
+	case !k8s.IsWorkloadKind(res.Kind()):
+		return nil
+	case res.Kind() == "CronJob":
+		spec, _ = nestedMap(spec, "jobTemplate", "spec")
+	}
+
+	podSpec, _ := nestedMap(spec, "template", "spec")
+
+	return podSpec
+}
+
+// compareHardenChanges returns the hardening changes only one side has.
+func compareHardenChanges(oldChanges, newChanges []harden.Change) *HardeningDelta {
+	key := func(c harden.Change) string { return c.ResourceID + "\x00" + c.FieldPath + "\x00" + c.Reason }
+
+	return &HardeningDelta{
+		Added:   missingFrom(newChanges, oldChanges, key),
+		Removed: missingFrom(oldChanges, newChanges, key),
+	}
+}
+
+// compareFindings returns the audit findings only one side has.
+func compareFindings(oldFindings, newFindings []audit.Finding) AuditDelta {
+	key := func(f audit.Finding) string { return f.RuleID + "\x00" + f.ResourceID + "\x00" + f.Message }
+
+	return AuditDelta{
+		New:      missingFrom(newFindings, oldFindings, key),
+		Resolved: missingFrom(oldFindings, newFindings, key),
+	}
+}
+
+// missingFrom returns the items of a whose key is not in b.
+func missingFrom[T any](a, b []T, key func(T) string) []T {
+	inB := make(map[string]bool, len(b))
+	for _, item := range b {
+		inB[key(item)] = true
+	}
+
+	out := []T{}
+
+	for _, item := range a {
+		if !inB[key(item)] {
+			out = append(out, item)
+		}
+	}
+
+	return out
+}
+
+// FormatUpgradeReportJSON writes the upgrade report as JSON.
+func FormatUpgradeReportJSON(w io.Writer, r *UpgradeReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+// FormatUpgradeReport writes the upgrade report as human-readable text.
+func FormatUpgradeReport(w io.Writer, r *UpgradeReport) {
+	_, _ = fmt.Fprintf(w, "Chart upgrade: %s -> %s\n", r.From, r.To)
+
+	if r.From.AppVersion != r.To.AppVersion {
+		_, _ = fmt.Fprintf(w, "App version:   %s -> %s\n", r.From.AppVersion, r.To.AppVersion)
+	}
+
+	_, _ = fmt.Fprintln(w)
+
+	FormatTable(w, r.Evolution)
+
+	section := func(title string) {
+		_, _ = fmt.Fprintln(w)
+		_, _ = fmt.Fprintln(w, title+":")
+		_, _ = fmt.Fprintln(w, strings.Repeat("-", 60))
+	}
+
+	section("Resources")
+
+	if len(r.ResourcesAdded) == 0 && len(r.ResourcesRemoved) == 0 {
+		_, _ = fmt.Fprintln(w, "  No resources added or removed.")
+	}
+
+	for _, c := range r.ResourcesAdded {
+		_, _ = fmt.Fprintf(w, "  + %s\n", formatResourceRef(c.ID, c.Kind))
+	}
+
+	for _, c := range r.ResourcesRemoved {
+		_, _ = fmt.Fprintf(w, "  - %s\n", formatResourceRef(c.ID, c.Kind))
+	}
+
+	section("Images")
+
+	if len(r.Images) == 0 {
+		_, _ = fmt.Fprintln(w, "  No image changes.")
+	}
+
+	for _, c := range r.Images {
+		ref := c.ResourceID + "/" + c.Container
+
+		switch c.Type {
+		case ChangeAdded:
+			_, _ = fmt.Fprintf(w, "  + %-30s %s\n", ref, c.New)
+		case ChangeRemoved:
+			_, _ = fmt.Fprintf(w, "  - %-30s %s\n", ref, c.Old)
+		default:
+			_, _ = fmt.Fprintf(w, "  ~ %-30s %s -> %s\n", ref, c.Old, c.New)
+		}
+	}
+
+	if r.Hardening != nil {
+		section("Hardening")
+
+		if len(r.Hardening.Added) == 0 && len(r.Hardening.Removed) == 0 {
+			_, _ = fmt.Fprintln(w, "  No hardening changes.")
+		}
+
+		for _, c := range r.Hardening.Added {
+			_, _ = fmt.Fprintf(w, "  + %s %s (%s)\n", c.ResourceID, c.FieldPath, c.Reason)
+		}
+
+		for _, c := range r.Hardening.Removed {
+			_, _ = fmt.Fprintf(w, "  - %s %s (%s)\n", c.ResourceID, c.FieldPath, c.Reason)
+		}
+	}
+
+	section("Audit")
+
+	if len(r.Audit.New) == 0 && len(r.Audit.Resolved) == 0 {
+		_, _ = fmt.Fprintln(w, "  No audit changes.")
+	}
+
+	for _, f := range sortedFindings(r.Audit.New) {
+		_, _ = fmt.Fprintf(w, "  + [%s] %s %s: %s\n", f.Severity, f.RuleID, f.ResourceID, f.Message)
+	}
+
+	for _, f := range sortedFindings(r.Audit.Resolved) {
+		_, _ = fmt.Fprintf(w, "  - [%s] %s %s: %s\n", f.Severity, f.RuleID, f.ResourceID, f.Message)
+	}
+}
+
+// sortedFindings orders findings by severity, descending, then rule and
+// resource.
+func sortedFindings(findings []audit.Finding) []audit.Finding {
+	out := append([]audit.Finding(nil), findings...)
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Severity != out[j].Severity {
+			return out[i].Severity > out[j].Severity
+		}
+
+		if out[i].RuleID != out[j].RuleID {
+			return out[i].RuleID < out[j].RuleID
+		}
+
+		return out[i].ResourceID < out[j].ResourceID
+	})
+
+	return out
+}
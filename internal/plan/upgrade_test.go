@@ -0,0 +1,130 @@
+package plan
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/hupe1980/chart2kro/internal/audit"
+	"github.com/hupe1980/chart2kro/internal/harden"
+	"github.com/hupe1980/chart2kro/internal/k8s"
+)
+
+// workload builds a rendered workload resource with the given containers.
+func workload(kind string, images map[string]string) *k8s.Resource {
+	var containers []interface{}
+	for name, image := range images {
+		containers = append(containers, map[string]interface{}{"name": name, "image": image})
+	}
+
+	podSpec := map[string]interface{}{"containers": containers}
+	spec := map[string]interface{}{"template": map[string]interface{}{"spec": podSpec}}
+
+	if kind == "CronJob" {
+		spec = map[string]interface{}{"jobTemplate": map[string]interface{}{"spec": spec}}
+	}
+
+	return &k8s.Resource{
+		GVK:    schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: kind},
+		Object: &unstructured.Unstructured{Object: map[string]interface{}{"kind": kind, "spec": spec}},
+	}
+}
+
+func TestBuildUpgradeReport(t *testing.T) {
+	from := &UpgradeInput{
+		Chart: ChartVersion{Name: "redis", Version: "18.1.0", AppVersion: "7.2"},
+		RGD:   semanticRGD("redis:7.2", 1),
+		Rendered: map[string]*k8s.Resource{
+			"master":  workload("StatefulSet", map[string]string{"redis": "redis:7.2", "metrics": "exporter:1"}),
+			"cleanup": workload("CronJob", map[string]string{"job": "kubectl:1.28"}),
+		},
+		Findings: []audit.Finding{
+			{RuleID: "SEC-004", Severity: audit.SeverityHigh, ResourceID: "StatefulSet/master", Message: "latest"},
+			{RuleID: "SEC-001", Severity: audit.SeverityMedium, ResourceID: "StatefulSet/master", Message: "root"},
+		},
+	}
+
+	toRGD := semanticRGD("redis:7.4", 1)
+	spec := toRGD["spec"].(map[string]interface{})
+	spec["resources"] = spec["resources"].([]interface{})[:1]
+
+	to := &UpgradeInput{
+		Chart: ChartVersion{Name: "redis", Version: "19.0.0", AppVersion: "7.4"},
+		RGD:   toRGD,
+		Rendered: map[string]*k8s.Resource{
+			"master":  workload("StatefulSet", map[string]string{"redis": "redis:7.4"}),
+			"cleanup": workload("CronJob", map[string]string{"job": "kubectl:1.28"}),
+			"config":  {GVK: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}},
+		},
+		Findings: []audit.Finding{
+			{RuleID: "SEC-001", Severity: audit.SeverityMedium, ResourceID: "StatefulSet/master", Message: "root"},
+		},
+	}
+
+	r := BuildUpgradeReport(from, to)
+
+	assert.Equal(t, "redis@18.1.0", r.From.String())
+	assert.Empty(t, r.ResourcesAdded)
+	require.Len(t, r.ResourcesRemoved, 1)
+	assert.Equal(t, "service", r.ResourcesRemoved[0].ID)
+
+	assert.Equal(t, []ImageChange{
+		{ResourceID: "master", Container: "metrics", Type: ChangeRemoved, Old: "exporter:1"},
+		{ResourceID: "master", Container: "redis", Type: ChangeModified, Old: "redis:7.2", New: "redis:7.4"},
+	}, r.Images)
+
+	assert.Empty(t, r.Audit.New)
+	require.Len(t, r.Audit.Resolved, 1)
+	assert.Equal(t, "SEC-004", r.Audit.Resolved[0].RuleID)
+
+	assert.Nil(t, r.Hardening, "hardening is only compared when enabled")
+}
+
+func TestBuildUpgradeReport_Hardening(t *testing.T) {
+	change := harden.Change{ResourceID: "master", FieldPath: "spec.template.spec.securityContext", Reason: "restricted PSS"}
+
+	r := BuildUpgradeReport(
+		&UpgradeInput{RGD: semanticRGD("redis", 1), HardenChanges: []harden.Change{change}},
+		&UpgradeInput{RGD: semanticRGD("redis", 1), HardenChanges: []harden.Change{}},
+	)
+
+	require.NotNil(t, r.Hardening)
+	assert.Empty(t, r.Hardening.Added)
+	assert.Equal(t, []harden.Change{change}, r.Hardening.Removed)
+}
+
+func TestFormatUpgradeReport(t *testing.T) {
+	r := BuildUpgradeReport(
+		&UpgradeInput{
+			Chart:    ChartVersion{Name: "redis", Version: "18.1.0", AppVersion: "7.2"},
+			RGD:      semanticRGD("redis", 1),
+			Rendered: map[string]*k8s.Resource{"master": workload("StatefulSet", map[string]string{"redis": "redis:7.2"})},
+		},
+		&UpgradeInput{
+			Chart:    ChartVersion{Name: "redis", Version: "19.0.0", AppVersion: "7.4"},
+			RGD:      semanticRGD("redis", 1),
+			Rendered: map[string]*k8s.Resource{"master": workload("StatefulSet", map[string]string{"redis": "redis:7.4"})},
+			Findings: []audit.Finding{{RuleID: "SEC-004", Severity: audit.SeverityHigh, ResourceID: "StatefulSet/master", Message: "uses :latest"}},
+		},
+	)
+
+	var buf bytes.Buffer
+
+	FormatUpgradeReport(&buf, r)
+
+	out := buf.String()
+	assert.Contains(t, out, "Chart upgrade: redis@18.1.0 -> redis@19.0.0")
+	assert.Contains(t, out, "App version:   7.2 -> 7.4")
+	assert.Contains(t, out, "No resources added or removed.")
+	assert.Regexp(t, `~ master/redis\s+redis:7\.2 -> redis:7\.4`, out)
+	assert.Contains(t, out, "+ [high] SEC-004 StatefulSet/master: uses :latest")
+	assert.NotContains(t, out, "Hardening:")
+
+	buf.Reset()
+	require.NoError(t, FormatUpgradeReportJSON(&buf, r))
+	assert.Contains(t, buf.String(), `"severity": "high"`)
+}
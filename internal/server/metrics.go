@@ -0,0 +1,55 @@
+package server
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// metrics are the Prometheus metrics of a server.
+type metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+	rejected prometheus.Counter
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chart2kro_server_requests_total",
+			Help: "Requests by operation and HTTP status code.",
+		}, []string{"operation", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "chart2kro_server_request_duration_seconds",
+			Help:    "Request duration by operation.",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		}, []string{"operation"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "chart2kro_server_requests_in_flight",
+			Help: "Requests being processed.",
+		}),
+		rejected: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "chart2kro_server_requests_rejected_total",
+			Help: "Requests that timed out waiting for a processing slot.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		m.requests, m.duration, m.inFlight, m.rejected,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// observe records a finished request.
+func (m *metrics) observe(op Operation, status int, elapsed time.Duration) {
+	m.requests.WithLabelValues(string(op), strconv.Itoa(status)).Inc()
+	m.duration.WithLabelValues(string(op)).Observe(elapsed.Seconds())
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/hupe1980/chart2kro/internal/krmfn"
+	"github.com/hupe1980/chart2kro/internal/output"
+)
+
+// Request is a chart operation request. The chart options are those of the
+// krm-fn ChartToRGD spec, which mirror the pkg/chart2kro options.
+type Request struct {
+	krmfn.Spec
+
+	// Existing is the YAML of a deployed RGD. Plan compares the generated
+	// RGD against it to detect breaking schema changes.
+	Existing string `json:"existing,omitempty"`
+}
+
+// ValidateRequest is an RGD validation request.
+type ValidateRequest struct {
+	// RGD is the YAML of the RGD.
+	RGD string `json:"rgd"`
+	// Strict treats warnings as errors.
+	Strict bool `json:"strict,omitempty"`
+}
+
+// Finding is an RGD validation finding.
+type Finding struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validation is the validation result of an RGD.
+type Validation struct {
+	Valid    bool      `json:"valid"`
+	Errors   []Finding `json:"errors,omitempty"`
+	Warnings []Finding `json:"warnings,omitempty"`
+}
+
+// Validate validates an RGD. With strict, warnings invalidate the RGD.
+func Validate(rgdMap map[string]interface{}, strict bool) *Validation {
+	result := output.ValidateRGD(rgdMap)
+	v := &Validation{}
+
+	for _, f := range result.Errors() {
+		v.Errors = append(v.Errors, Finding{Field: f.Field, Message: f.Message})
+	}
+
+	for _, f := range result.Warnings() {
+		v.Warnings = append(v.Warnings, Finding{Field: f.Field, Message: f.Message})
+	}
+
+	v.Valid = len(v.Errors) == 0 && (!strict || len(v.Warnings) == 0)
+
+	return v
+}
+
+func (s *Server) validate(_ context.Context, r *http.Request) (interface{}, error) {
+	var req ValidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, BadRequest(fmt.Errorf("decoding request: %w", err))
+	}
+
+	var rgdMap map[string]interface{}
+	if err := sigsyaml.Unmarshal([]byte(req.RGD), &rgdMap); err != nil {
+		return nil, BadRequest(fmt.Errorf("parsing rgd: %w", err))
+	}
+
+	if rgdMap == nil {
+		return nil, BadRequest(errors.New("rgd is required"))
+	}
+
+	return Validate(rgdMap, req.Strict), nil
+}
+
+// decodeRequest decodes a chart request: a JSON request, or a
+// multipart/form-data upload with the chart archive in the "chart" part
+// and the JSON request in the "options" part. The returned cleanup removes
+// the uploaded archive.
+func decodeRequest(r *http.Request, allowLocal, allowPlugins bool) (*Request, func(), error) {
+	cleanup := func() {}
+	req := &Request{}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType == "multipart/form-data" {
+		path, err := saveUpload(r, req)
+		if err != nil {
+			return nil, cleanup, err
+		}
+
+		cleanup = func() { _ = os.RemoveAll(filepath.Dir(path)) }
+		req.Chart = path
+	} else {
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			return nil, cleanup, BadRequest(fmt.Errorf("decoding request: %w", err))
+		}
+
+		if !allowLocal && !req.IsRemote() {
+			return nil, cleanup, BadRequest(fmt.Errorf("chart %q: only OCI references and repository charts are accepted; upload local charts", req.Chart))
+		}
+	}
+
+	if !allowLocal && len(req.ValueFiles) > 0 {
+		cleanup()
+
+		return nil, func() {}, BadRequest(errors.New("valueFiles: local files are not accepted; use values"))
+	}
+
+	if !allowPlugins {
+		if err := req.ValidatePlugins(); err != nil {
+			cleanup()
+
+			return nil, func() {}, BadRequest(err)
+		}
+	}
+
+	if err := req.Validate(); err != nil {
+		cleanup()
+
+		return nil, func() {}, BadRequest(err)
+	}
+
+	return req, cleanup, nil
+}
+
+// saveUpload decodes the options of a multipart request into req and saves
+// the uploaded chart archive to a temporary directory.
+func saveUpload(r *http.Request, req *Request) (string, error) {
+	if err := r.ParseMultipartForm(8 << 20); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return "", err
+		}
+
+		return "", BadRequest(fmt.Errorf("parsing upload: %w", err))
+	}
+
+	if options := r.FormValue("options"); options != "" {
+		if err := json.Unmarshal([]byte(options), req); err != nil {
+			return "", BadRequest(fmt.Errorf("decoding options: %w", err))
+		}
+	}
+
+	file, _, err := r.FormFile("chart")
+	if err != nil {
+		return "", BadRequest(fmt.Errorf("chart archive: %w", err))
+	}
+	defer func() { _ = file.Close() }()
+
+	dir, err := os.MkdirTemp("", "chart2kro-upload-*")
+	if err != nil {
+		return "", fmt.Errorf("saving upload: %w", err)
+	}
+
+	path := filepath.Join(dir, "chart.tgz")
+
+	if err := writeFile(path, file); err != nil {
+		_ = os.RemoveAll(dir)
+
+		return "", fmt.Errorf("saving upload: %w", err)
+	}
+
+	return path, nil
+}
+
+func writeFile(path string, r io.Reader) error {
+	f, err := os.Create(path) //nolint:gosec // path is in a temporary directory
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+// errorBody is the response body of a failed request.
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(body)
+}
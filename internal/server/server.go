@@ -0,0 +1,294 @@
+// Package server serves chart2kro operations over an HTTP JSON API.
+//
+// Chart operations (convert, inspect, plan, audit) take a chart reference or
+// an uploaded chart archive together with request-scoped options mirroring
+// the pkg/chart2kro options. RGDs are validated without a chart. Requests
+// are bounded by a concurrency limit and a timeout, and the server exports
+// Prometheus metrics.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Operation names a served operation.
+type Operation string
+
+// Served operations.
+const (
+	OpConvert  Operation = "convert"
+	OpInspect  Operation = "inspect"
+	OpPlan     Operation = "plan"
+	OpAudit    Operation = "audit"
+	OpValidate Operation = "validate"
+)
+
+// Operations performs the chart operations. Each returns the JSON response
+// body of the operation. Errors wrapping an *Error carry their HTTP status.
+type Operations interface {
+	Convert(ctx context.Context, req *Request) (interface{}, error)
+	Inspect(ctx context.Context, req *Request) (interface{}, error)
+	Plan(ctx context.Context, req *Request) (interface{}, error)
+	Audit(ctx context.Context, req *Request) (interface{}, error)
+}
+
+// Options configures the server.
+type Options struct {
+	// Addr is the listen address.
+	Addr string
+
+	// MaxConcurrent is the maximum number of requests processed at once.
+	// Further requests wait for a slot until their timeout.
+	MaxConcurrent int
+
+	// Timeout bounds the processing of a request, including the wait for a
+	// slot.
+	Timeout time.Duration
+
+	// MaxUploadSize is the maximum request body size in bytes.
+	MaxUploadSize int64
+
+	// AllowLocal permits chart references and values files on the
+	// server's file system. Otherwise only OCI references, repository
+	// charts, and uploaded archives are accepted.
+	AllowLocal bool
+
+	// AllowPlugins permits transformer plugins in request configs. Plugins
+	// run executables and load WASM modules on the server.
+	AllowPlugins bool
+
+	// Logger is used for structured logging.
+	Logger *slog.Logger
+}
+
+// DefaultOptions returns sensible default server options.
+func DefaultOptions() Options {
+	return Options{
+		Addr:          ":8080",
+		MaxConcurrent: runtime.NumCPU(),
+		Timeout:       2 * time.Minute,
+		MaxUploadSize: 32 << 20,
+		Logger:        slog.Default(),
+	}
+}
+
+// Server serves the operations over HTTP.
+type Server struct {
+	opts    Options
+	ops     Operations
+	slots   chan struct{}
+	metrics *metrics
+	handler http.Handler
+}
+
+// New returns a server performing ops.
+func New(opts Options, ops Operations) *Server {
+	defaults := DefaultOptions()
+
+	if opts.MaxConcurrent <= 0 {
+		opts.MaxConcurrent = defaults.MaxConcurrent
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaults.Timeout
+	}
+
+	if opts.MaxUploadSize <= 0 {
+		opts.MaxUploadSize = defaults.MaxUploadSize
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = defaults.Logger
+	}
+
+	s := &Server{
+		opts:    opts,
+		ops:     ops,
+		slots:   make(chan struct{}, opts.MaxConcurrent),
+		metrics: newMetrics(),
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /v1/convert", s.chartHandler(OpConvert, ops.Convert))
+	mux.Handle("POST /v1/inspect", s.chartHandler(OpInspect, ops.Inspect))
+	mux.Handle("POST /v1/plan", s.chartHandler(OpPlan, ops.Plan))
+	mux.Handle("POST /v1/audit", s.chartHandler(OpAudit, ops.Audit))
+	mux.Handle("POST /v1/validate", s.handle(OpValidate, s.validate))
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.Handle("GET /metrics", promhttp.HandlerFor(s.metrics.registry, promhttp.HandlerOpts{}))
+
+	s.handler = mux
+
+	return s
+}
+
+// Handler returns the HTTP handler of the server.
+func (s *Server) Handler() http.Handler { return s.handler }
+
+// Run serves until the context is cancelled or a SIGINT/SIGTERM signal is
+// received, then shuts down gracefully, letting in-flight requests finish
+// within the request timeout.
+func (s *Server) Run(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.opts.Addr,
+		Handler:           s.handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	sigCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Requests inherit the values of ctx, such as the logger and the
+	// global configuration, but outlive the signal context so they can
+	// finish during shutdown.
+	srv.BaseContext = func(net.Listener) context.Context { return context.WithoutCancel(ctx) }
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		s.opts.Logger.Info("serving", slog.String("addr", s.opts.Addr))
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("serving: %w", err)
+	case <-sigCtx.Done():
+	}
+
+	s.opts.Logger.Info("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutting down: %w", err)
+	}
+
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serving: %w", err)
+	}
+
+	return nil
+}
+
+// handlerFunc handles a request and returns the JSON response body.
+type handlerFunc func(ctx context.Context, r *http.Request) (interface{}, error)
+
+// handle wraps fn with the request timeout, the concurrency limit, logging,
+// and metrics.
+func (s *Server) handle(op Operation, fn handlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		ctx, cancel := context.WithTimeout(r.Context(), s.opts.Timeout)
+		defer cancel()
+
+		r.Body = http.MaxBytesReader(w, r.Body, s.opts.MaxUploadSize)
+
+		status := http.StatusOK
+
+		body, err := s.withSlot(ctx, func() (interface{}, error) { return fn(ctx, r) })
+		if err != nil {
+			status = statusOf(err)
+			body = errorBody{Error: err.Error()}
+		}
+
+		writeJSON(w, status, body)
+
+		elapsed := time.Since(start)
+		s.metrics.observe(op, status, elapsed)
+
+		s.opts.Logger.Info("request",
+			slog.String("operation", string(op)),
+			slog.Int("status", status),
+			slog.Duration("duration", elapsed),
+		)
+	})
+}
+
+// withSlot runs fn once a processing slot is free.
+func (s *Server) withSlot(ctx context.Context, fn func() (interface{}, error)) (interface{}, error) {
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		s.metrics.rejected.Inc()
+
+		return nil, &Error{Status: http.StatusServiceUnavailable, Err: errors.New("server is busy")}
+	}
+
+	s.metrics.inFlight.Inc()
+
+	defer func() {
+		s.metrics.inFlight.Dec()
+		<-s.slots
+	}()
+
+	return fn()
+}
+
+// chartHandler decodes a chart request and performs op.
+func (s *Server) chartHandler(
+	op Operation,
+	fn func(context.Context, *Request) (interface{}, error),
+) http.Handler {
+	return s.handle(op, func(ctx context.Context, r *http.Request) (interface{}, error) {
+		req, cleanup, err := decodeRequest(r, s.opts.AllowLocal, s.opts.AllowPlugins)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+
+		return fn(ctx, req)
+	})
+}
+
+// Error is an error with an HTTP status.
+type Error struct {
+	Status int
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string { return e.Err.Error() }
+
+// Unwrap returns the wrapped error.
+func (e *Error) Unwrap() error { return e.Err }
+
+// BadRequest marks err as caused by an invalid request.
+func BadRequest(err error) error {
+	return &Error{Status: http.StatusBadRequest, Err: err}
+}
+
+// statusOf returns the HTTP status of an operation error. Operations that
+// fail on a valid request, e.g. because the chart does not render, are
+// unprocessable.
+func statusOf(err error) int {
+	var httpErr *Error
+
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	case errors.As(err, &httpErr):
+		return httpErr.Status
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
@@ -0,0 +1,286 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOperations records the requests and answers with the chart name.
+type fakeOperations struct {
+	requests chan *Request
+	block    chan struct{}
+	err      error
+}
+
+func (f *fakeOperations) do(_ context.Context, req *Request) (interface{}, error) {
+	if f.requests != nil {
+		f.requests <- req
+	}
+
+	if f.block != nil {
+		<-f.block
+	}
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	archive := ""
+	if data, err := os.ReadFile(req.Chart); err == nil {
+		archive = string(data)
+	}
+
+	return map[string]string{"chart": req.Chart, "releaseName": req.ReleaseName, "archive": archive}, nil
+}
+
+func (f *fakeOperations) Convert(ctx context.Context, req *Request) (interface{}, error) {
+	return f.do(ctx, req)
+}
+
+func (f *fakeOperations) Inspect(ctx context.Context, req *Request) (interface{}, error) {
+	return f.do(ctx, req)
+}
+
+func (f *fakeOperations) Plan(ctx context.Context, req *Request) (interface{}, error) {
+	return f.do(ctx, req)
+}
+
+func (f *fakeOperations) Audit(ctx context.Context, req *Request) (interface{}, error) {
+	return f.do(ctx, req)
+}
+
+// newServer returns a server that does not log.
+func newServer(opts Options, ops Operations) *Server {
+	opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	return New(opts, ops)
+}
+
+func post(t *testing.T, h http.Handler, path, body string) (int, map[string]interface{}) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	return serve(t, h, req)
+}
+
+func serve(t *testing.T, h http.Handler, req *http.Request) (int, map[string]interface{}) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body), rec.Body.String())
+
+	return rec.Code, body
+}
+
+func TestServer_ChartOperations(t *testing.T) {
+	h := newServer(Options{}, &fakeOperations{}).Handler()
+
+	for _, op := range []string{"convert", "inspect", "plan", "audit"} {
+		t.Run(op, func(t *testing.T) {
+			code, body := post(t, h, "/v1/"+op, `{"chart": "oci://ghcr.io/org/app", "releaseName": "web"}`)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, "oci://ghcr.io/org/app", body["chart"])
+			assert.Equal(t, "web", body["releaseName"])
+		})
+	}
+}
+
+func TestServer_Upload(t *testing.T) {
+	h := newServer(Options{}, &fakeOperations{}).Handler()
+
+	var buf bytes.Buffer
+
+	mw := multipart.NewWriter(&buf)
+	require.NoError(t, mw.WriteField("options", `{"releaseName": "uploaded"}`))
+
+	part, err := mw.CreateFormFile("chart", "app-1.0.0.tgz")
+	require.NoError(t, err)
+
+	_, err = io.WriteString(part, "archive-bytes")
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/convert", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	code, body := serve(t, h, req)
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Equal(t, "uploaded", body["releaseName"])
+	assert.Equal(t, "archive-bytes", body["archive"])
+
+	// The upload is removed after the request.
+	_, err = os.Stat(body["chart"].(string))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestServer_BadRequests(t *testing.T) {
+	h := newServer(Options{}, &fakeOperations{}).Handler()
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"invalid JSON", `{`, "decoding request"},
+		{"missing chart", `{"repoURL": "https://charts.example.com"}`, "chart is required"},
+		{"local chart", `{"chart": "./charts/app"}`, "only OCI references"},
+		{"local archive in repository", `{"chart": "../app.tgz", "repoURL": "https://charts.example.com"}`, "only OCI references"},
+		{"values files", `{"chart": "oci://ghcr.io/org/app", "valueFiles": ["/etc/passwd"]}`, "valueFiles"},
+		{"invalid timeout", `{"chart": "oci://ghcr.io/org/app", "timeout": "soon"}`, "timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := post(t, h, "/v1/convert", tt.body)
+			assert.Equal(t, http.StatusBadRequest, code)
+			assert.Contains(t, body["error"], tt.want)
+		})
+	}
+}
+
+func TestServer_AllowLocal(t *testing.T) {
+	h := newServer(Options{AllowLocal: true}, &fakeOperations{}).Handler()
+
+	code, body := post(t, h, "/v1/convert", `{"chart": "./charts/app"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "./charts/app", body["chart"])
+}
+
+func TestServer_RejectsPlugins(t *testing.T) {
+	body := `{"chart": "oci://ghcr.io/org/app", "config": {"transformers": [
+		{"match": {"kind": "Deployment"}, "plugin": {"exec": "sh", "args": ["-c", "touch /tmp/pwned"]}}]}}`
+
+	ops := &fakeOperations{requests: make(chan *Request, 1)}
+	h := newServer(Options{AllowLocal: true}, ops).Handler()
+
+	code, resp := post(t, h, "/v1/convert", body)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, resp["error"], "config.transformers[0].plugin")
+	assert.Empty(t, ops.requests, "the operation must not run")
+
+	h = newServer(Options{AllowPlugins: true}, ops).Handler()
+
+	code, _ = post(t, h, "/v1/convert", body)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestServer_OperationErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errors.New("rendering templates: boom"), http.StatusUnprocessableEntity},
+		{BadRequest(errors.New("bad")), http.StatusBadRequest},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout},
+	}
+
+	for _, tt := range tests {
+		h := newServer(Options{}, &fakeOperations{err: tt.err}).Handler()
+
+		code, body := post(t, h, "/v1/convert", `{"chart": "oci://ghcr.io/org/app"}`)
+		assert.Equal(t, tt.want, code)
+		assert.Equal(t, tt.err.Error(), body["error"])
+	}
+}
+
+func TestServer_UploadTooLarge(t *testing.T) {
+	h := newServer(Options{MaxUploadSize: 16}, &fakeOperations{}).Handler()
+
+	code, _ := post(t, h, "/v1/convert", `{"chart": "oci://ghcr.io/org/app", "releaseName": "a-long-release-name"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+}
+
+func TestServer_ConcurrencyLimit(t *testing.T) {
+	ops := &fakeOperations{requests: make(chan *Request, 1), block: make(chan struct{})}
+	h := newServer(Options{MaxConcurrent: 1, Timeout: 100 * time.Millisecond}, ops).Handler()
+
+	done := make(chan int)
+
+	go func() {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/convert",
+			strings.NewReader(`{"chart": "oci://ghcr.io/org/app"}`)))
+		done <- rec.Code
+	}()
+
+	// Wait until the first request holds the only slot.
+	<-ops.requests
+
+	code, body := post(t, h, "/v1/convert", `{"chart": "oci://ghcr.io/org/app"}`)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "server is busy", body["error"])
+
+	close(ops.block)
+	assert.Equal(t, http.StatusOK, <-done)
+}
+
+func TestServer_Validate(t *testing.T) {
+	h := newServer(Options{}, &fakeOperations{}).Handler()
+
+	rgd := `apiVersion: kro.run/v1alpha1
+kind: ResourceGraphDefinition
+metadata:
+  name: app
+spec:
+  schema:
+    apiVersion: v1alpha1
+    kind: App
+  resources:
+    - id: config
+      template:
+        apiVersion: v1
+        kind: ConfigMap
+        metadata:
+          name: ${schema.spec.missing}
+`
+
+	reqBody, err := json.Marshal(ValidateRequest{RGD: rgd})
+	require.NoError(t, err)
+
+	code, body := post(t, h, "/v1/validate", string(reqBody))
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, false, body["valid"])
+	assert.NotEmpty(t, body["errors"])
+
+	code, body = post(t, h, "/v1/validate", `{"rgd": ""}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body["error"], "rgd is required")
+}
+
+func TestServer_HealthAndMetrics(t *testing.T) {
+	h := newServer(Options{}, &fakeOperations{}).Handler()
+
+	code, body := serve(t, h, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body["status"])
+
+	post(t, h, "/v1/convert", `{"chart": "oci://ghcr.io/org/app"}`)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	metrics := rec.Body.String()
+	assert.Contains(t, metrics, `chart2kro_server_requests_total{code="200",operation="convert"} 1`)
+	assert.Contains(t, metrics, "chart2kro_server_request_duration_seconds_bucket")
+	assert.Contains(t, metrics, "chart2kro_server_requests_in_flight 0")
+}
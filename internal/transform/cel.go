@@ -107,7 +107,8 @@ func Interpolate(parts ...string) string {
 
 // ReadyWhenCondition represents a readiness condition for a KRO resource.
 type ReadyWhenCondition struct {
-	// Key is the status field path to check.
+	// Key is the status field path to check. Without an Operator it is a
+	// complete boolean expression.
 	Key string
 	// Operator is the comparison operator (==, !=, >, <, etc.).
 	Operator string
@@ -117,9 +118,21 @@ type ReadyWhenCondition struct {
 
 // String returns the CEL expression for a readiness condition.
 func (c ReadyWhenCondition) String() string {
+	if c.Operator == "" {
+		return fmt.Sprintf("${%s}", c.Key)
+	}
+
 	return fmt.Sprintf("${%s %s %s}", c.Key, c.Operator, c.Value)
 }
 
+// conditionTrue returns a readiness condition that holds once the resource
+// reports a status condition of the given type with status "True".
+func conditionTrue(conditionType string) ReadyWhenCondition {
+	return ReadyWhenCondition{
+		Key: fmt.Sprintf(`self.status.?conditions.orValue([]).exists(c, c.type == %q && c.status == "True")`, conditionType),
+	}
+}
+
 // DefaultReadyWhen returns default readiness conditions for a given GVK.
 func DefaultReadyWhen(gvk schema.GroupVersionKind) []ReadyWhenCondition {
 	switch {
@@ -147,7 +160,18 @@ func DefaultReadyWhen(gvk schema.GroupVersionKind) []ReadyWhenCondition {
 		return []ReadyWhenCondition{
 			{Key: "self.status.phase", Operator: "==", Value: `"Bound"`},
 		}
+	case k8s.IsHPA(gvk):
+		return []ReadyWhenCondition{conditionTrue("AbleToScale")}
+	case k8s.IsCertificate(gvk), k8s.IsScaledObject(gvk):
+		return []ReadyWhenCondition{conditionTrue("Ready")}
+	case k8s.IsRollout(gvk):
+		return []ReadyWhenCondition{
+			{Key: `self.status.?phase.orValue("")`, Operator: "==", Value: `"Healthy"`},
+		}
 	default:
+		// CronJobs, ServiceMonitors, and unknown kinds are ready once created.
+		// So are Ingresses: many controllers never publish a load balancer
+		// address, so waiting for one is opt-in via custom ready conditions.
 		return nil
 	}
 }
@@ -202,11 +226,63 @@ func DefaultStatusProjections(gvk schema.GroupVersionKind, resourceID string) []
 		return []StatusField{
 			{Name: resourceID + "Phase", CELExpression: ResourceRef(resourceID, "status", "phase")},
 		}
+	case k8s.IsCronJob(gvk):
+		return []StatusField{
+			optionalStatusField(resourceID, "LastScheduleTime", "lastScheduleTime"),
+			optionalStatusField(resourceID, "LastSuccessfulTime", "lastSuccessfulTime"),
+		}
+	case k8s.IsIngress(gvk):
+		return []StatusField{
+			{Name: resourceID + "LoadBalancerIP", CELExpression: ResourceRefWithOptional(resourceID,
+				PathSegment{Name: "status"},
+				PathSegment{Name: "loadBalancer", Optional: true},
+				PathSegment{Name: "ingress[0]", Optional: true},
+				PathSegment{Name: "ip", Optional: true},
+			)},
+			{Name: resourceID + "LoadBalancerHostname", CELExpression: ResourceRefWithOptional(resourceID,
+				PathSegment{Name: "status"},
+				PathSegment{Name: "loadBalancer", Optional: true},
+				PathSegment{Name: "ingress[0]", Optional: true},
+				PathSegment{Name: "hostname", Optional: true},
+			)},
+		}
+	case k8s.IsHPA(gvk):
+		return []StatusField{
+			optionalStatusField(resourceID, "CurrentReplicas", "currentReplicas"),
+			optionalStatusField(resourceID, "DesiredReplicas", "desiredReplicas"),
+		}
+	case k8s.IsCertificate(gvk):
+		return []StatusField{
+			optionalStatusField(resourceID, "NotAfter", "notAfter"),
+			optionalStatusField(resourceID, "RenewalTime", "renewalTime"),
+		}
+	case k8s.IsRollout(gvk):
+		return []StatusField{
+			optionalStatusField(resourceID, "Phase", "phase"),
+			optionalStatusField(resourceID, "AvailableReplicas", "availableReplicas"),
+		}
+	case k8s.IsScaledObject(gvk):
+		return []StatusField{
+			optionalStatusField(resourceID, "HPAName", "hpaName"),
+			optionalStatusField(resourceID, "LastActiveTime", "lastActiveTime"),
+		}
 	default:
 		return nil
 	}
 }
 
+// optionalStatusField projects a status field that is absent until the
+// controller first reports it.
+func optionalStatusField(resourceID, suffix, field string) StatusField {
+	return StatusField{
+		Name: resourceID + suffix,
+		CELExpression: ResourceRefWithOptional(resourceID,
+			PathSegment{Name: "status"},
+			PathSegment{Name: field, Optional: true},
+		),
+	}
+}
+
 // IncludeWhenExpression generates a conditional inclusion expression.
 // e.g., IncludeWhenExpression("spec", "monitoring", "enabled") => "${schema.spec.monitoring.enabled}".
 func IncludeWhenExpression(path ...string) string {
@@ -268,42 +344,100 @@ func conditionFragment(c IncludeCondition) string {
 }
 
 // ValidateExpression checks that a KRO CEL expression string has balanced
-// ${...} delimiters and is non-empty. It does NOT compile or type-check
-// the inner CEL — that is KRO's responsibility at apply time.
+// ${...} delimiters, see ExpressionEnd, and is non-empty. It does NOT
+// compile or type-check the inner CEL — that is KRO's responsibility at
+// apply time.
 func ValidateExpression(expr string) error {
 	if expr == "" {
 		return fmt.Errorf("empty CEL expression")
 	}
 
-	depth := 0
-	inExpr := false
-
-	for i := 0; i < len(expr); i++ {
-		if i+1 < len(expr) && expr[i] == '$' && expr[i+1] == '{' {
-			depth++
-			inExpr = true
-			i++ // skip '{'
+	for i := strings.Index(expr, "${"); i >= 0; {
+		end := ExpressionEnd(expr, i)
+		if end < 0 {
+			return fmt.Errorf("unbalanced CEL expression delimiters in %q", expr)
+		}
 
-			continue
+		next := strings.Index(expr[end:], "${")
+		if next < 0 {
+			break
 		}
 
-		if inExpr && expr[i] == '}' {
+		i = end + next
+	}
+
+	if !strings.Contains(expr, "${") {
+		return fmt.Errorf("no CEL expression found in %q (expected ${...} syntax)", expr)
+	}
+
+	return nil
+}
+
+// ExpressionEnd returns the index just past the "}" that closes the ${...}
+// expression starting at s[start], or -1 when the expression is not
+// closed. Braces of CEL map literals and braces or quotes within string
+// literals do not close the expression.
+func ExpressionEnd(s string, start int) int {
+	depth := 0
+
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
 			depth--
 			if depth == 0 {
-				inExpr = false
+				return i + 1
 			}
-
-			continue
+		case '"', '\'':
+			i = literalEnd(s, i)
 		}
 	}
 
-	if depth != 0 {
-		return fmt.Errorf("unbalanced CEL expression delimiters in %q", expr)
+	return -1
+}
+
+// ReplaceExpressions replaces each ${...} expression of s, delimiters
+// included, with the result of fn. An expression that is not closed and the
+// text after it are left unchanged.
+func ReplaceExpressions(s string, fn func(expr string) string) string {
+	var b strings.Builder
+
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			break
+		}
+
+		end := ExpressionEnd(s, start)
+		if end < 0 {
+			break
+		}
+
+		b.WriteString(s[:start])
+		b.WriteString(fn(s[start:end]))
+
+		s = s[end:]
 	}
 
-	if !strings.Contains(expr, "${") {
-		return fmt.Errorf("no CEL expression found in %q (expected ${...} syntax)", expr)
+	b.WriteString(s)
+
+	return b.String()
+}
+
+// literalEnd returns the index of the quote closing the string literal that
+// starts at s[start], or len(s) when the literal is not closed.
+func literalEnd(s string, start int) int {
+	quote := s[start]
+
+	i := start + 1
+	for i < len(s) && s[i] != quote {
+		if s[i] == '\\' {
+			i++
+		}
+
+		i++
 	}
 
-	return nil
+	return min(i, len(s))
 }
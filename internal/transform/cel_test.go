@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/hupe1980/chart2kro/internal/k8s"
@@ -126,6 +127,123 @@ func TestDefaultReadyWhen_CronJob(t *testing.T) {
 	assert.Nil(t, conditions, "CronJobs should have no readiness conditions")
 }
 
+func TestDefaultReadyWhen_Ingress(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}
+	conditions := transform.DefaultReadyWhen(gvk)
+	assert.Nil(t, conditions, "waiting for a load balancer address is opt-in")
+}
+
+func TestDefaultReadyWhen_CommonKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		gvk  schema.GroupVersionKind
+		want string
+	}{
+		{
+			name: "hpa",
+			gvk:  schema.GroupVersionKind{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"},
+			want: `${self.status.?conditions.orValue([]).exists(c, c.type == "AbleToScale" && c.status == "True")}`,
+		},
+		{
+			name: "certificate",
+			gvk:  schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"},
+			want: `${self.status.?conditions.orValue([]).exists(c, c.type == "Ready" && c.status == "True")}`,
+		},
+		{
+			name: "rollout",
+			gvk:  schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"},
+			want: `${self.status.?phase.orValue("") == "Healthy"}`,
+		},
+		{
+			name: "scaledobject",
+			gvk:  schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "ScaledObject"},
+			want: `${self.status.?conditions.orValue([]).exists(c, c.type == "Ready" && c.status == "True")}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions := transform.DefaultReadyWhen(tt.gvk)
+			require.Len(t, conditions, 1)
+			assert.Equal(t, tt.want, conditions[0].String())
+			assert.NoError(t, transform.ValidateExpression(conditions[0].String()))
+		})
+	}
+}
+
+func TestDefaultReadyWhen_ServiceMonitor(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+	assert.Nil(t, transform.DefaultReadyWhen(gvk), "ServiceMonitors are ready once created")
+}
+
+func TestDefaultStatusProjections_CommonKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		gvk  schema.GroupVersionKind
+		want map[string]string
+	}{
+		{
+			name: "cronjob",
+			gvk:  schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "CronJob"},
+			want: map[string]string{
+				"resLastScheduleTime":   "${res.status.?lastScheduleTime}",
+				"resLastSuccessfulTime": "${res.status.?lastSuccessfulTime}",
+			},
+		},
+		{
+			name: "ingress",
+			gvk:  schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+			want: map[string]string{
+				"resLoadBalancerIP":       "${res.status.?loadBalancer.?ingress[0].?ip}",
+				"resLoadBalancerHostname": "${res.status.?loadBalancer.?ingress[0].?hostname}",
+			},
+		},
+		{
+			name: "hpa",
+			gvk:  schema.GroupVersionKind{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"},
+			want: map[string]string{
+				"resCurrentReplicas": "${res.status.?currentReplicas}",
+				"resDesiredReplicas": "${res.status.?desiredReplicas}",
+			},
+		},
+		{
+			name: "certificate",
+			gvk:  schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"},
+			want: map[string]string{
+				"resNotAfter":    "${res.status.?notAfter}",
+				"resRenewalTime": "${res.status.?renewalTime}",
+			},
+		},
+		{
+			name: "rollout",
+			gvk:  schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"},
+			want: map[string]string{
+				"resPhase":             "${res.status.?phase}",
+				"resAvailableReplicas": "${res.status.?availableReplicas}",
+			},
+		},
+		{
+			name: "scaledobject",
+			gvk:  schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "ScaledObject"},
+			want: map[string]string{
+				"resHPAName":        "${res.status.?hpaName}",
+				"resLastActiveTime": "${res.status.?lastActiveTime}",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string]string)
+			for _, f := range transform.DefaultStatusProjections(tt.gvk, "res") {
+				got[f.Name] = f.CELExpression
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestDefaultStatusProjections_Deployment(t *testing.T) {
 	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
 	fields := transform.DefaultStatusProjections(gvk, "webDeployment")
@@ -313,6 +431,9 @@ func TestValidateExpression(t *testing.T) {
 		{"unbalanced close", "schema.spec.replicas}", true, "no CEL expression found"},
 		{"missing closing brace", "${foo ${bar}", true, "unbalanced CEL expression delimiters"},
 		{"valid literal around expr", "prefix-${schema.spec.name}-suffix", false, ""},
+		{"valid map literal", "${schema.spec.labels == {} ? 'none' : 'some'}", false, ""},
+		{"valid brace in string", `${schema.spec.name + "}"}`, false, ""},
+		{"unclosed string", `${schema.spec.name + "}`, true, "unbalanced CEL expression delimiters"},
 	}
 
 	for _, tt := range tests {
@@ -327,3 +448,27 @@ func TestValidateExpression(t *testing.T) {
 		})
 	}
 }
+
+func TestReplaceExpressions(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text", "no expressions", "no expressions"},
+		{"interpolation", "${a}:${b}", "[a]:[b]"},
+		{"map literal", "${x == {} ? a : b}-x", "[x == {} ? a : b]-x"},
+		{"brace in string", `${a + "}"} ${'{' + b}`, `[a + "}"] ['{' + b]`},
+		{"escaped quote", `${a + "\"}"}`, `[a + "\"}"]`},
+		{"unclosed", "${a} ${b", "[a] ${b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := transform.ReplaceExpressions(tt.in, func(expr string) string {
+				return "[" + expr[2:len(expr)-1] + "]"
+			})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
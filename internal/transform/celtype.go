@@ -0,0 +1,543 @@
+// Package transform - celtype.go implements a small static type inference for
+// the CEL subset used in derived values. It only determines the result type of
+// an expression; evaluation remains KRO's responsibility (see cel.go).
+package transform
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TypeResolver returns the SimpleSchema type of a schema.spec field path.
+type TypeResolver func(path string) (string, error)
+
+// InferExpressionType parses a CEL expression (without ${...}) and returns
+// its SimpleSchema result type: string, integer, number, boolean, array, or
+// "" when the type cannot be determined statically. References of the form
+// schema.spec.<path> are typed through resolve.
+func InferExpressionType(expr string, resolve TypeResolver) (string, error) {
+	tokens, err := tokenizeCEL(expr)
+	if err != nil {
+		return "", err
+	}
+
+	p := &celTypeParser{tokens: tokens, resolve: resolve}
+
+	typ, err := p.ternary()
+	if err != nil {
+		return "", err
+	}
+
+	if !p.done() {
+		return "", fmt.Errorf("unexpected token %q in expression", p.peek().text)
+	}
+
+	return typ, nil
+}
+
+type celTokenKind int
+
+const (
+	celIdent celTokenKind = iota
+	celString
+	celInt
+	celFloat
+	celOp
+)
+
+type celToken struct {
+	kind celTokenKind
+	text string
+}
+
+// celOperators lists multi- and single-character operators, longest first.
+var celOperators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"<", ">", "!", "+", "-", "*", "/", "%", "?", ":", "(", ")", "[", "]", ",",
+}
+
+// tokenizeCEL splits expr into tokens. Identifiers include dotted selectors
+// and optional "?" accessors (e.g., schema.spec.?size). A selector following
+// an index or call (e.g., the ".host" in rules[0].host) is emitted as an
+// identifier with a leading dot.
+func tokenizeCEL(expr string) ([]celToken, error) {
+	var tokens []celToken
+
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(expr) && expr[end] != c {
+				if expr[end] == '\\' {
+					end++
+				}
+
+				end++
+			}
+
+			if end >= len(expr) {
+				return nil, fmt.Errorf("unterminated string literal in expression")
+			}
+
+			tokens = append(tokens, celToken{kind: celString, text: expr[i+1 : end]})
+			i = end + 1
+		case isDigit(c):
+			end, kind := scanNumber(expr, i)
+			tokens = append(tokens, celToken{kind: kind, text: expr[i:end]})
+			i = end
+		case isIdentStart(c) || c == '.' && i+1 < len(expr) && isIdentStart(expr[i+1]):
+			end := i + 1
+			for end < len(expr) && (isIdentPart(expr[end]) || expr[end] == '.' ||
+				expr[end] == '?' && end > i && expr[end-1] == '.') {
+				end++
+			}
+
+			tokens = append(tokens, celToken{kind: celIdent, text: strings.TrimRight(expr[i:end], ".")})
+			i = end
+		default:
+			matched := false
+
+			for _, op := range celOperators {
+				if strings.HasPrefix(expr[i:], op) {
+					tokens = append(tokens, celToken{kind: celOp, text: op})
+					i += len(op)
+					matched = true
+
+					break
+				}
+			}
+
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q in expression", c)
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+// scanNumber returns the end offset and kind of the numeric literal at start.
+func scanNumber(expr string, start int) (int, celTokenKind) {
+	end := start
+	kind := celInt
+
+	skipDigits := func() {
+		for end < len(expr) && isDigit(expr[end]) {
+			end++
+		}
+	}
+
+	skipDigits()
+
+	if end < len(expr) && expr[end] == '.' {
+		kind = celFloat
+		end++
+		skipDigits()
+	}
+
+	if end < len(expr) && (expr[end] == 'e' || expr[end] == 'E') {
+		kind = celFloat
+		end++
+
+		if end < len(expr) && (expr[end] == '+' || expr[end] == '-') {
+			end++
+		}
+
+		skipDigits()
+	}
+
+	if kind == celInt && end < len(expr) && expr[end] == 'u' {
+		end++
+	}
+
+	return end, kind
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool { return isIdentStart(c) || isDigit(c) }
+
+// celTypeParser is a recursive-descent parser over CEL tokens that computes
+// result types following CEL operator precedence.
+type celTypeParser struct {
+	tokens  []celToken
+	pos     int
+	resolve TypeResolver
+}
+
+func (p *celTypeParser) done() bool { return p.pos >= len(p.tokens) }
+
+func (p *celTypeParser) peek() celToken {
+	if p.done() {
+		return celToken{}
+	}
+
+	return p.tokens[p.pos]
+}
+
+func (p *celTypeParser) acceptOp(ops ...string) (string, bool) {
+	t := p.peek()
+	if p.done() || (t.kind != celOp && !(t.kind == celIdent && t.text == "in")) {
+		return "", false
+	}
+
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+
+	return "", false
+}
+
+func (p *celTypeParser) expectOp(op string) error {
+	if _, ok := p.acceptOp(op); !ok {
+		if p.done() {
+			return fmt.Errorf("expected %q, got end of expression", op)
+		}
+
+		return fmt.Errorf("expected %q, got %q", op, p.peek().text)
+	}
+
+	return nil
+}
+
+func (p *celTypeParser) ternary() (string, error) {
+	cond, err := p.logical("||")
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := p.acceptOp("?"); !ok {
+		return cond, nil
+	}
+
+	if !typesCompatible("boolean", cond) {
+		return "", fmt.Errorf("ternary condition has type %s, expected boolean", cond)
+	}
+
+	whenTrue, err := p.ternary()
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.expectOp(":"); err != nil {
+		return "", err
+	}
+
+	whenFalse, err := p.ternary()
+	if err != nil {
+		return "", err
+	}
+
+	return unifyTypes(whenTrue, whenFalse)
+}
+
+func (p *celTypeParser) logical(op string) (string, error) {
+	next := p.relation
+	if op == "||" {
+		next = func() (string, error) { return p.logical("&&") }
+	}
+
+	typ, err := next()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if _, ok := p.acceptOp(op); !ok {
+			return typ, nil
+		}
+
+		if _, err := next(); err != nil {
+			return "", err
+		}
+
+		typ = "boolean"
+	}
+}
+
+func (p *celTypeParser) relation() (string, error) {
+	typ, err := p.additive()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if _, ok := p.acceptOp("==", "!=", "<=", ">=", "<", ">", "in"); !ok {
+			return typ, nil
+		}
+
+		if _, err := p.additive(); err != nil {
+			return "", err
+		}
+
+		typ = "boolean"
+	}
+}
+
+func (p *celTypeParser) additive() (string, error) {
+	typ, err := p.multiplicative()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		op, ok := p.acceptOp("+", "-")
+		if !ok {
+			return typ, nil
+		}
+
+		rhs, err := p.multiplicative()
+		if err != nil {
+			return "", err
+		}
+
+		typ = arithmeticType(op, typ, rhs)
+	}
+}
+
+func (p *celTypeParser) multiplicative() (string, error) {
+	typ, err := p.unary()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		op, ok := p.acceptOp("*", "/", "%")
+		if !ok {
+			return typ, nil
+		}
+
+		rhs, err := p.unary()
+		if err != nil {
+			return "", err
+		}
+
+		typ = arithmeticType(op, typ, rhs)
+	}
+}
+
+func (p *celTypeParser) unary() (string, error) {
+	if _, ok := p.acceptOp("!"); ok {
+		if _, err := p.unary(); err != nil {
+			return "", err
+		}
+
+		return "boolean", nil
+	}
+
+	if _, ok := p.acceptOp("-"); ok {
+		return p.unary()
+	}
+
+	return p.primary()
+}
+
+func (p *celTypeParser) primary() (string, error) {
+	if p.done() {
+		return "", fmt.Errorf("unexpected end of expression")
+	}
+
+	t := p.tokens[p.pos]
+
+	switch t.kind {
+	case celString:
+		p.pos++
+		return "string", nil
+	case celInt:
+		p.pos++
+		return "integer", nil
+	case celFloat:
+		p.pos++
+		return "number", nil
+	case celIdent:
+		p.pos++
+		return p.identifier(t.text)
+	}
+
+	switch t.text {
+	case "(":
+		p.pos++
+
+		typ, err := p.ternary()
+		if err != nil {
+			return "", err
+		}
+
+		if err := p.expectOp(")"); err != nil {
+			return "", err
+		}
+
+		return p.postfix(typ)
+	case "[":
+		p.pos++
+
+		if err := p.arguments("]"); err != nil {
+			return "", err
+		}
+
+		return "array", nil
+	}
+
+	return "", fmt.Errorf("unexpected token %q in expression", t.text)
+}
+
+// identifier types a literal keyword, a function or method call, or a
+// field reference, and consumes any trailing index expressions.
+func (p *celTypeParser) identifier(name string) (string, error) {
+	switch name {
+	case "true", "false":
+		return "boolean", nil
+	case "null":
+		return "", nil
+	}
+
+	if _, ok := p.acceptOp("("); ok {
+		if err := p.arguments(")"); err != nil {
+			return "", err
+		}
+
+		return p.postfix(callType(name))
+	}
+
+	typ := ""
+
+	if strings.HasPrefix(name, "schema.spec.") {
+		path := strings.ReplaceAll(strings.TrimPrefix(name, "schema.spec."), "?", "")
+
+		resolved, err := p.resolve(path)
+		if err != nil {
+			return "", err
+		}
+
+		typ = resolved
+	}
+
+	return p.postfix(typ)
+}
+
+// postfix consumes trailing index expressions and member selectors (with
+// optional method calls) following a primary expression.
+func (p *celTypeParser) postfix(typ string) (string, error) {
+	for {
+		if t := p.peek(); !p.done() && t.kind == celIdent && strings.HasPrefix(t.text, ".") {
+			p.pos++
+			typ = ""
+
+			if _, ok := p.acceptOp("("); ok {
+				if err := p.arguments(")"); err != nil {
+					return "", err
+				}
+
+				typ = callType(t.text)
+			}
+
+			continue
+		}
+
+		if _, ok := p.acceptOp("["); !ok {
+			return typ, nil
+		}
+
+		if _, err := p.ternary(); err != nil {
+			return "", err
+		}
+
+		if err := p.expectOp("]"); err != nil {
+			return "", err
+		}
+
+		typ = ""
+	}
+}
+
+// arguments consumes a comma-separated expression list up to closing.
+func (p *celTypeParser) arguments(closing string) error {
+	if _, ok := p.acceptOp(closing); ok {
+		return nil
+	}
+
+	for {
+		if _, err := p.ternary(); err != nil {
+			return err
+		}
+
+		if _, ok := p.acceptOp(closing); ok {
+			return nil
+		}
+
+		if err := p.expectOp(","); err != nil {
+			return err
+		}
+	}
+}
+
+// callType returns the result type of well-known CEL functions and methods.
+func callType(name string) string {
+	fn := name
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		fn = name[idx+1:]
+	}
+
+	switch fn {
+	case "string", "lowerAscii", "upperAscii", "trim", "replace", "join", "substring", "format":
+		return "string"
+	case "int", "uint", "size", "indexOf", "lastIndexOf":
+		return "integer"
+	case "double":
+		return "number"
+	case "bool", "has", "startsWith", "endsWith", "contains", "matches", "exists", "all", "exists_one":
+		return "boolean"
+	case "split", "map", "filter":
+		return "array"
+	default:
+		return ""
+	}
+}
+
+// arithmeticType returns the result type of a binary arithmetic operation.
+func arithmeticType(op, lhs, rhs string) string {
+	switch {
+	case lhs == "" || rhs == "":
+		return ""
+	case op == "+" && lhs == "string" && rhs == "string":
+		return "string"
+	case op == "+" && lhs == "array" && rhs == "array":
+		return "array"
+	case lhs == "integer" && rhs == "integer":
+		return "integer"
+	case isNumeric(lhs) && isNumeric(rhs):
+		return "number"
+	default:
+		return ""
+	}
+}
+
+func isNumeric(typ string) bool { return typ == "integer" || typ == "number" }
+
+// unifyTypes returns the common type of two ternary branches.
+func unifyTypes(a, b string) (string, error) {
+	switch {
+	case a == b:
+		return a, nil
+	case a == "":
+		return b, nil
+	case b == "":
+		return a, nil
+	case isNumeric(a) && isNumeric(b):
+		return "number", nil
+	default:
+		return "", fmt.Errorf("ternary branches have mismatched types %s and %s", a, b)
+	}
+}
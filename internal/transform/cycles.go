@@ -0,0 +1,217 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CycleStrategy selects how the engine resolves dependency cycles.
+type CycleStrategy string
+
+// Cycle resolution strategies.
+const (
+	// CycleStrategyFail reports cycles as a CycleError (default).
+	CycleStrategyFail CycleStrategy = "fail"
+
+	// CycleStrategyDropWeakest removes the weakest edge of each cycle.
+	// Inferred edges such as selectors are weaker than edges backed by a
+	// name reference (see edgeStrength).
+	CycleStrategyDropWeakest CycleStrategy = "drop-weakest"
+
+	// CycleStrategyLiteral only breaks edges backed by name references: the
+	// literal name is kept instead of a CEL reference to the target, so KRO
+	// does not order on it. Cycles of inferred edges are still reported.
+	CycleStrategyLiteral CycleStrategy = "literal"
+)
+
+// ParseCycleStrategy converts a string to a CycleStrategy. The empty string
+// selects CycleStrategyFail.
+func ParseCycleStrategy(s string) (CycleStrategy, error) {
+	switch CycleStrategy(s) {
+	case "", CycleStrategyFail:
+		return CycleStrategyFail, nil
+	case CycleStrategyDropWeakest, CycleStrategyLiteral:
+		return CycleStrategy(s), nil
+	default:
+		return "", fmt.Errorf("unknown cycle strategy %q: expected fail, drop-weakest, literal", s)
+	}
+}
+
+// BrokenEdge is a dependency edge removed to resolve a cycle.
+type BrokenEdge struct {
+	// From depends on To.
+	From string
+	To   string
+
+	// Reasons are the reasons the edge was detected.
+	Reasons []EdgeReason
+
+	// Cycle is the cycle the edge was part of, first and last entries equal.
+	Cycle []string
+
+	// Literal reports that the edge's name references were left as literal
+	// names instead of being rewritten to CEL references.
+	Literal bool
+}
+
+// String describes the broken edge, e.g.
+// "service -> deployment (selector) in cycle deployment -> service -> deployment".
+func (b BrokenEdge) String() string {
+	return fmt.Sprintf("%s -> %s (%s) in cycle %s", b.From, b.To, joinReasons(b.Reasons), strings.Join(b.Cycle, " -> "))
+}
+
+// edgeStrength ranks edge reasons from inferred (weak) to explicit (strong).
+// Selectors and hook ordering are inferred from labels and annotations;
+// the others are backed by a name in the template.
+var edgeStrength = map[EdgeReason]int{
+	EdgeSelector:        1,
+	EdgeHook:            2,
+	EdgeReference:       3,
+	EdgeScaleTarget:     4,
+	EdgeRBAC:            5,
+	EdgeBackend:         6,
+	EdgeTLS:             7,
+	EdgeImagePullSecret: 8,
+	EdgeServiceAccount:  9,
+	EdgeEnv:             10,
+	EdgeVolume:          11,
+}
+
+// edgeWeight returns the strength of the edge from source to target, the
+// strongest of its reasons. Edges without a recorded reason are weakest.
+func (g *DependencyGraph) edgeWeight(source, target string) int {
+	weight := 0
+
+	for _, r := range g.reasons[source][target] {
+		if s := edgeStrength[r]; s > weight {
+			weight = s
+		}
+	}
+
+	return weight
+}
+
+// hasNameRef reports whether the edge from source to target is backed by a
+// literal name reference.
+func (g *DependencyGraph) hasNameRef(source, target string) bool {
+	for _, ref := range g.refs[source] {
+		if ref.target == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RemoveEdge removes the dependency of source on target together with its
+// reasons and name references, which then keep their literal names.
+func (g *DependencyGraph) RemoveEdge(source, target string) {
+	delete(g.edges[source], target)
+	delete(g.reasons[source], target)
+
+	refs := g.refs[source][:0]
+
+	for _, ref := range g.refs[source] {
+		if ref.target != target {
+			refs = append(refs, ref)
+		}
+	}
+
+	g.refs[source] = refs
+}
+
+// BreakCycles removes edges until the graph is acyclic or no cycle can be
+// broken with the strategy. Each cycle loses its weakest eligible edge; ties
+// are broken by source and target ID. It returns the removed edges in
+// removal order. CycleStrategyFail removes nothing.
+func (g *DependencyGraph) BreakCycles(strategy CycleStrategy) []BrokenEdge {
+	if strategy != CycleStrategyDropWeakest && strategy != CycleStrategyLiteral {
+		return nil
+	}
+
+	var broken []BrokenEdge
+
+	for {
+		cycles := g.DetectCycles()
+		progress := false
+
+		for _, cycle := range cycles {
+			from, to, ok := g.weakestEdge(cycle, strategy)
+			if !ok {
+				continue
+			}
+
+			edge := BrokenEdge{
+				From:    from,
+				To:      to,
+				Reasons: g.EdgeReasons(from, to),
+				Cycle:   cycle,
+				Literal: g.hasNameRef(from, to),
+			}
+
+			g.RemoveEdge(from, to)
+			broken = append(broken, edge)
+			progress = true
+
+			// Removing an edge may break other cycles too; re-detect.
+			break
+		}
+
+		if !progress {
+			return broken
+		}
+	}
+}
+
+// weakestEdge returns the weakest edge of the cycle eligible for removal.
+func (g *DependencyGraph) weakestEdge(cycle []string, strategy CycleStrategy) (string, string, bool) {
+	var (
+		from, to string
+		best     = -1
+	)
+
+	for i := 0; i+1 < len(cycle); i++ {
+		s, t := cycle[i], cycle[i+1]
+		if strategy == CycleStrategyLiteral && !g.hasNameRef(s, t) {
+			continue
+		}
+
+		w := g.edgeWeight(s, t)
+		if best == -1 || w < best || w == best && s+"\x00"+t < from+"\x00"+to {
+			from, to, best = s, t, w
+		}
+	}
+
+	return from, to, best != -1
+}
+
+// ExplainCycle describes a cycle with the reasons of each edge, e.g.
+// "deployment -[volume]-> configmap -[selector]-> deployment".
+func (g *DependencyGraph) ExplainCycle(cycle []string) string {
+	if len(cycle) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+
+	b.WriteString(cycle[0])
+
+	for i := 0; i+1 < len(cycle); i++ {
+		fmt.Fprintf(&b, " -[%s]-> %s", joinReasons(g.EdgeReasons(cycle[i], cycle[i+1])), cycle[i+1])
+	}
+
+	return b.String()
+}
+
+func joinReasons(reasons []EdgeReason) string {
+	if len(reasons) == 0 {
+		return "unknown"
+	}
+
+	names := make([]string, len(reasons))
+	for i, r := range reasons {
+		names[i] = string(r)
+	}
+
+	return strings.Join(names, ",")
+}
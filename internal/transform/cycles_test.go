@@ -0,0 +1,137 @@
+package transform_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/chart2kro/internal/k8s"
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+// selectorCycle returns a Service selecting a Deployment whose spec.frontend
+// names the Service through a custom reference path, so that
+// service -[selector]-> deployment -[reference]-> service.
+func selectorCycle() ([]*k8s.Resource, []transform.ReferencePath) {
+	deploy := labeledWorkload("web", map[string]interface{}{"app": "web"})
+	deploy.Object.Object["spec"].(map[string]interface{})["frontend"] = "web-svc"
+
+	svc := makeFullResource("v1", "Service", "web-svc", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{"app": "web"},
+		},
+	})
+
+	paths := []transform.ReferencePath{{Kind: "Deployment", Path: "spec.frontend", TargetKind: "Service"}}
+
+	return []*k8s.Resource{deploy, svc}, paths
+}
+
+func buildSelectorCycle() (*transform.DependencyGraph, *k8s.Resource) {
+	resources, paths := selectorCycle()
+
+	return transform.BuildDependencyGraph(map[*k8s.Resource]string{
+		resources[0]: "deployment",
+		resources[1]: "service",
+	}, paths...), resources[0]
+}
+
+func TestParseCycleStrategy(t *testing.T) {
+	for in, want := range map[string]transform.CycleStrategy{
+		"":             transform.CycleStrategyFail,
+		"fail":         transform.CycleStrategyFail,
+		"drop-weakest": transform.CycleStrategyDropWeakest,
+		"literal":      transform.CycleStrategyLiteral,
+	} {
+		got, err := transform.ParseCycleStrategy(in)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := transform.ParseCycleStrategy("ignore")
+	assert.Error(t, err)
+}
+
+func TestBreakCycles_Fail(t *testing.T) {
+	g, _ := buildSelectorCycle()
+
+	assert.Empty(t, g.BreakCycles(transform.CycleStrategyFail))
+	assert.Len(t, g.DetectCycles(), 1)
+}
+
+func TestBreakCycles_DropWeakest(t *testing.T) {
+	g, deploy := buildSelectorCycle()
+
+	broken := g.BreakCycles(transform.CycleStrategyDropWeakest)
+	require.Len(t, broken, 1)
+	assert.Equal(t, "service", broken[0].From)
+	assert.Equal(t, "deployment", broken[0].To)
+	assert.Equal(t, []transform.EdgeReason{transform.EdgeSelector}, broken[0].Reasons)
+	assert.False(t, broken[0].Literal)
+	assert.Empty(t, g.DetectCycles())
+
+	// The name reference survives and is rewritten.
+	assert.Equal(t, 1, g.RewriteNameReferences())
+	assert.Equal(t, "${service.metadata.name}", deploy.Object.Object["spec"].(map[string]interface{})["frontend"])
+}
+
+func TestBreakCycles_Literal(t *testing.T) {
+	g, deploy := buildSelectorCycle()
+
+	broken := g.BreakCycles(transform.CycleStrategyLiteral)
+	require.Len(t, broken, 1)
+	assert.Equal(t, "deployment", broken[0].From)
+	assert.Equal(t, "service", broken[0].To)
+	assert.True(t, broken[0].Literal)
+	assert.Equal(t, "deployment -> service (reference) in cycle "+strings.Join(broken[0].Cycle, " -> "), broken[0].String())
+	assert.Empty(t, g.DetectCycles())
+	assert.Equal(t, []string{"deployment"}, g.DependenciesOf("service"))
+
+	// The literal name is kept.
+	assert.Equal(t, 0, g.RewriteNameReferences())
+	assert.Equal(t, "web-svc", deploy.Object.Object["spec"].(map[string]interface{})["frontend"])
+}
+
+func TestBreakCycles_LiteralWithoutNameReferences(t *testing.T) {
+	g := transform.NewDependencyGraph()
+	g.AddNode("a", makeFullResource("v1", "Service", "a", nil))
+	g.AddNode("b", makeFullResource("apps/v1", "Deployment", "b", nil))
+	g.AddEdgeWithReason("a", "b", transform.EdgeSelector)
+	g.AddEdgeWithReason("b", "a", transform.EdgeHook)
+
+	assert.Empty(t, g.BreakCycles(transform.CycleStrategyLiteral))
+	assert.Len(t, g.DetectCycles(), 1)
+}
+
+func TestExplainCycle(t *testing.T) {
+	g, _ := buildSelectorCycle()
+
+	assert.Equal(t, "deployment -[reference]-> service -[selector]-> deployment",
+		g.ExplainCycle([]string{"deployment", "service", "deployment"}))
+}
+
+func TestEngine_Transform_CycleStrategy(t *testing.T) {
+	resources, paths := selectorCycle()
+
+	_, err := transform.NewEngine(transform.EngineConfig{ReferencePaths: paths}).
+		Transform(context.Background(), resources, map[string]interface{}{})
+
+	var cycleErr *transform.CycleError
+	require.True(t, errors.As(err, &cycleErr))
+	assert.Contains(t, err.Error(), "-[selector]->")
+	assert.Contains(t, err.Error(), "-[reference]->")
+
+	resources, paths = selectorCycle()
+
+	result, err := transform.NewEngine(transform.EngineConfig{
+		ReferencePaths: paths,
+		CycleStrategy:  transform.CycleStrategyDropWeakest,
+	}).Transform(context.Background(), resources, map[string]interface{}{})
+	require.NoError(t, err)
+	require.Len(t, result.BrokenEdges, 1)
+	assert.Equal(t, "service", result.BrokenEdges[0].From)
+}
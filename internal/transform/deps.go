@@ -10,15 +10,37 @@ import (
 
 // DependencyGraph represents the DAG of resource dependencies.
 type DependencyGraph struct {
-	nodes map[string]*k8s.Resource
-	edges map[string]map[string]struct{} // source -> set of targets it depends on
+	nodes   map[string]*k8s.Resource
+	edges   map[string]map[string]struct{}     // source -> set of targets it depends on
+	refs    map[string][]nameRef               // source -> literal name references backing its edges
+	reasons map[string]map[string][]EdgeReason // source -> target -> why the edge exists
 }
 
+// EdgeReason describes why a dependency edge exists.
+type EdgeReason string
+
+// Dependency edge reasons.
+const (
+	EdgeSelector        EdgeReason = "selector"
+	EdgeVolume          EdgeReason = "volume"
+	EdgeEnv             EdgeReason = "env"
+	EdgeServiceAccount  EdgeReason = "serviceAccount"
+	EdgeImagePullSecret EdgeReason = "imagePullSecret"
+	EdgeBackend         EdgeReason = "backend"
+	EdgeTLS             EdgeReason = "tls"
+	EdgeScaleTarget     EdgeReason = "scaleTarget"
+	EdgeRBAC            EdgeReason = "rbac"
+	EdgeReference       EdgeReason = "reference"
+	EdgeHook            EdgeReason = "hook"
+)
+
 // NewDependencyGraph creates an empty dependency graph.
 func NewDependencyGraph() *DependencyGraph {
 	return &DependencyGraph{
-		nodes: make(map[string]*k8s.Resource),
-		edges: make(map[string]map[string]struct{}),
+		nodes:   make(map[string]*k8s.Resource),
+		edges:   make(map[string]map[string]struct{}),
+		refs:    make(map[string][]nameRef),
+		reasons: make(map[string]map[string][]EdgeReason),
 	}
 }
 
@@ -49,6 +71,37 @@ func (g *DependencyGraph) AddEdge(source, target string) {
 	g.edges[source][target] = struct{}{}
 }
 
+// AddEdgeWithReason adds a dependency like AddEdge and records why it
+// exists.
+func (g *DependencyGraph) AddEdgeWithReason(source, target string, reason EdgeReason) {
+	g.AddEdge(source, target)
+
+	if _, ok := g.edges[source][target]; !ok {
+		return
+	}
+
+	if _, ok := g.reasons[source]; !ok {
+		g.reasons[source] = make(map[string][]EdgeReason)
+	}
+
+	for _, r := range g.reasons[source][target] {
+		if r == reason {
+			return
+		}
+	}
+
+	g.reasons[source][target] = append(g.reasons[source][target], reason)
+}
+
+// EdgeReasons returns the sorted reasons recorded for the edge from source
+// to target.
+func (g *DependencyGraph) EdgeReasons(source, target string) []EdgeReason {
+	reasons := append([]EdgeReason(nil), g.reasons[source][target]...)
+	sort.Slice(reasons, func(i, j int) bool { return reasons[i] < reasons[j] })
+
+	return reasons
+}
+
 // Nodes returns all node IDs.
 func (g *DependencyGraph) Nodes() []string {
 	ids := make([]string, 0, len(g.nodes))
@@ -73,6 +126,33 @@ func (g *DependencyGraph) DependenciesOf(id string) []string {
 	return deps
 }
 
+// reaches reports whether from depends on to, directly or transitively.
+func (g *DependencyGraph) reaches(from, to string) bool {
+	visited := make(map[string]bool)
+	stack := []string{from}
+
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if id == to {
+			return true
+		}
+
+		if visited[id] {
+			continue
+		}
+
+		visited[id] = true
+
+		for dep := range g.edges[id] {
+			stack = append(stack, dep)
+		}
+	}
+
+	return false
+}
+
 // Resource returns the resource for the given ID.
 func (g *DependencyGraph) Resource(id string) *k8s.Resource {
 	return g.nodes[id]
@@ -161,7 +241,7 @@ func (g *DependencyGraph) TopologicalSort() ([]string, error) {
 // Cycles are deduplicated by normalizing: each cycle is rotated so that
 // its lexicographically smallest node comes first, then stored as a
 // canonical string key to avoid reporting the same cycle from different
-// starting points.
+// starting points. The cycles are returned in this rotated form, sorted.
 func (g *DependencyGraph) DetectCycles() [][]string {
 	visited := make(map[string]bool)
 	recStack := make(map[string]bool)
@@ -180,24 +260,20 @@ func (g *DependencyGraph) DetectCycles() [][]string {
 			if !visited[dep] {
 				dfs(dep)
 			} else if recStack[dep] {
-				// Found a cycle — extract it.
-				cycle := []string{dep}
-
-				for i := len(path) - 1; i >= 0; i-- {
-					if path[i] == dep {
-						break
-					}
-
-					cycle = append([]string{path[i]}, cycle...)
+				// Found a cycle — extract it from the path, starting at dep.
+				start := len(path) - 1
+				for path[start] != dep {
+					start--
 				}
 
+				cycle := append([]string(nil), path[start:]...)
 				cycle = append(cycle, dep) // close the cycle
 
 				// Deduplicate: normalize by rotating to lexicographic min.
 				key := normalizeCycle(cycle)
 				if !seen[key] {
 					seen[key] = true
-					cycles = append(cycles, cycle)
+					cycles = append(cycles, rotateCycle(cycle))
 				}
 			}
 		}
@@ -212,9 +288,37 @@ func (g *DependencyGraph) DetectCycles() [][]string {
 		}
 	}
 
+	sort.Slice(cycles, func(i, j int) bool {
+		return strings.Join(cycles[i], "→") < strings.Join(cycles[j], "→")
+	})
+
 	return cycles
 }
 
+// rotateCycle returns the cycle rotated so that its lexicographically
+// smallest node comes first and last.
+func rotateCycle(cycle []string) []string {
+	if len(cycle) <= 1 {
+		return cycle
+	}
+
+	nodes := cycle[:len(cycle)-1]
+
+	minIdx := 0
+	for i := 1; i < len(nodes); i++ {
+		if nodes[i] < nodes[minIdx] {
+			minIdx = i
+		}
+	}
+
+	rotated := make([]string, 0, len(cycle))
+	for i := 0; i <= len(nodes); i++ {
+		rotated = append(rotated, nodes[(minIdx+i)%len(nodes)])
+	}
+
+	return rotated
+}
+
 // normalizeCycle produces a canonical string key for a cycle by rotating it
 // so that the lexicographically smallest node appears first.
 // The input is expected to have the form [A, B, C, A] (first == last).
@@ -249,8 +353,9 @@ func normalizeCycle(cycle []string) string {
 }
 
 // BuildDependencyGraph analyzes resources and constructs a dependency graph.
-// It detects label selector matches, name references, SA references, and volume refs.
-func BuildDependencyGraph(resources map[*k8s.Resource]string) *DependencyGraph {
+// It detects label selector matches, name references, SA references, volume
+// refs, the references of common CRDs, and the given custom reference paths.
+func BuildDependencyGraph(resources map[*k8s.Resource]string, custom ...ReferencePath) *DependencyGraph {
 	g := NewDependencyGraph()
 
 	// Index resources by ID and by kind+name for lookups.
@@ -263,59 +368,90 @@ func BuildDependencyGraph(resources map[*k8s.Resource]string) *DependencyGraph {
 		nameIndex[r.QualifiedName()] = id
 	}
 
+	indexGeneratedSecrets(resources, nameIndex)
+
 	for r, sourceID := range resources {
 		if r.Object == nil {
 			continue
 		}
 
 		obj := r.Object.Object
+		podSpec := podSpecPath(r)
 
 		// Detect label selector matches (Service -> Deployment).
 		detectSelectorDeps(g, sourceID, r, resources)
 
 		// Detect volume references.
-		detectVolumeDeps(g, sourceID, obj, nameIndex)
+		detectVolumeDeps(g, sourceID, obj, podSpec, nameIndex)
+
+		// Detect imagePullSecrets references.
+		detectImagePullSecretDeps(g, sourceID, r, podSpec, nameIndex)
 
 		// Detect serviceAccountName references.
-		detectServiceAccountDeps(g, sourceID, obj, nameIndex)
+		detectServiceAccountDeps(g, sourceID, obj, podSpec, nameIndex)
 
 		// Detect env/envFrom secret/configmap references.
-		detectEnvDeps(g, sourceID, obj, nameIndex)
+		detectEnvDeps(g, sourceID, obj, podSpec, nameIndex)
+
+		// Detect Ingress, autoscaler, RBAC, and policy references.
+		detectKindDeps(g, sourceID, r, resources, nameIndex)
+
+		// Detect references of common CRDs.
+		detectCRDDeps(g, sourceID, r, resources, nameIndex)
+
+		// Detect custom reference paths from the config.
+		detectCustomDeps(g, sourceID, r, custom, nameIndex)
 	}
 
 	return g
 }
 
+// podSpecPath returns the path of the pod spec in a resource: the pod
+// template of workloads, the job template of CronJobs, and the spec of Pods.
+func podSpecPath(r *k8s.Resource) []string {
+	switch {
+	case k8s.IsCronJob(r.GVK):
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	case k8s.IsPod(r.GVK):
+		return []string{"spec"}
+	default:
+		return []string{"spec", "template", "spec"}
+	}
+}
+
 // detectSelectorDeps checks if a Service's selector matches any workload's labels.
 func detectSelectorDeps(g *DependencyGraph, sourceID string, r *k8s.Resource, resources map[*k8s.Resource]string) {
 	if !k8s.IsService(r.GVK) {
 		return
 	}
 
-	selector := nestedStringMap(r.Object.Object, "spec", "selector")
+	addPodSelectorEdges(g, sourceID, nestedStringMap(r.Object.Object, "spec", "selector"), resources)
+}
+
+// addPodSelectorEdges adds edges to the workloads whose pod template labels
+// match selector.
+func addPodSelectorEdges(g *DependencyGraph, sourceID string, selector map[string]string, resources map[*k8s.Resource]string) {
 	if len(selector) == 0 {
 		return
 	}
 
 	for other, otherID := range resources {
-		if other == r || !k8s.IsWorkload(other.GVK) {
+		if !k8s.IsWorkload(other.GVK) || other.Object == nil {
 			continue
 		}
 
-		if other.Object == nil {
-			continue
-		}
+		podSpec := podSpecPath(other)
+		labelsPath := append(append([]string{}, podSpec[:len(podSpec)-1]...), "metadata", "labels")
 
-		podLabels := nestedStringMap(other.Object.Object, "spec", "template", "metadata", "labels")
-		if matchesSelector(selector, podLabels) {
-			g.AddEdge(sourceID, otherID)
+		if matchesSelector(selector, nestedStringMap(other.Object.Object, labelsPath...)) {
+			g.AddEdgeWithReason(sourceID, otherID, EdgeSelector)
 		}
 	}
 }
 
 // detectVolumeDeps checks for volume references to PVCs, ConfigMaps, and Secrets.
-func detectVolumeDeps(g *DependencyGraph, sourceID string, obj map[string]interface{}, nameIndex map[string]string) {
-	volumes := nestedSlice(obj, "spec", "template", "spec", "volumes")
+func detectVolumeDeps(g *DependencyGraph, sourceID string, obj map[string]interface{}, podSpec []string, nameIndex map[string]string) {
+	volumes := nestedSlice(obj, appendPath(podSpec, "volumes")...)
 
 	for _, vol := range volumes {
 		vm, ok := vol.(map[string]interface{})
@@ -325,59 +461,71 @@ func detectVolumeDeps(g *DependencyGraph, sourceID string, obj map[string]interf
 
 		// PVC volume.
 		if pvc, ok := vm["persistentVolumeClaim"].(map[string]interface{}); ok {
-			if claimName, ok := pvc["claimName"].(string); ok {
-				if targetID, ok := nameIndex["PersistentVolumeClaim/"+claimName]; ok {
-					g.AddEdge(sourceID, targetID)
-				}
-			}
+			addNameRef(g, sourceID, nameIndex, EdgeVolume, "PersistentVolumeClaim", pvc, "claimName")
 		}
 
 		// ConfigMap volume.
 		if cm, ok := vm["configMap"].(map[string]interface{}); ok {
-			if name, ok := cm["name"].(string); ok {
-				if targetID, ok := nameIndex["ConfigMap/"+name]; ok {
-					g.AddEdge(sourceID, targetID)
-				}
-			}
+			addNameRef(g, sourceID, nameIndex, EdgeVolume, "ConfigMap", cm, "name")
 		}
 
 		// Secret volume.
 		if sec, ok := vm["secret"].(map[string]interface{}); ok {
-			if name, ok := sec["secretName"].(string); ok {
-				if targetID, ok := nameIndex["Secret/"+name]; ok {
-					g.AddEdge(sourceID, targetID)
-				}
+			addNameRef(g, sourceID, nameIndex, EdgeVolume, "Secret", sec, "secretName")
+		}
+
+		// Projected volume sources.
+		for _, src := range nestedSlice(vm, "projected", "sources") {
+			sm, ok := src.(map[string]interface{})
+			if !ok {
+				continue
 			}
+
+			addNameRef(g, sourceID, nameIndex, EdgeVolume, "ConfigMap", nestedMap(sm, "configMap"), "name")
+			addNameRef(g, sourceID, nameIndex, EdgeVolume, "Secret", nestedMap(sm, "secret"), "name")
 		}
 	}
 }
 
-// detectServiceAccountDeps checks for serviceAccountName references.
-func detectServiceAccountDeps(g *DependencyGraph, sourceID string, obj map[string]interface{}, nameIndex map[string]string) {
-	saName := nestedString(obj, "spec", "template", "spec", "serviceAccountName")
-	if saName == "" {
-		saName = nestedString(obj, "spec", "serviceAccountName")
+// detectImagePullSecretDeps checks for imagePullSecrets references in pod
+// specs and ServiceAccounts.
+func detectImagePullSecretDeps(g *DependencyGraph, sourceID string, r *k8s.Resource, podSpec []string, nameIndex map[string]string) {
+	path := appendPath(podSpec, "imagePullSecrets")
+	if k8s.IsServiceAccount(r.GVK) {
+		path = []string{"imagePullSecrets"}
 	}
 
-	if saName != "" {
-		if targetID, ok := nameIndex["ServiceAccount/"+saName]; ok {
-			g.AddEdge(sourceID, targetID)
+	for _, s := range nestedSlice(r.Object.Object, path...) {
+		sm, ok := s.(map[string]interface{})
+		if !ok {
+			continue
 		}
+
+		addNameRef(g, sourceID, nameIndex, EdgeImagePullSecret, "Secret", sm, "name")
+	}
+}
+
+// detectServiceAccountDeps checks for serviceAccountName references.
+func detectServiceAccountDeps(g *DependencyGraph, sourceID string, obj map[string]interface{}, podSpec []string, nameIndex map[string]string) {
+	parent := nestedMap(obj, podSpec...)
+	if _, ok := parent["serviceAccountName"].(string); !ok {
+		parent = nestedMap(obj, "spec")
 	}
+
+	addNameRef(g, sourceID, nameIndex, EdgeServiceAccount, "ServiceAccount", parent, "serviceAccountName")
 }
 
 // detectEnvDeps checks for env valueFrom and envFrom secretRef/configMapRef.
-func detectEnvDeps(g *DependencyGraph, sourceID string, obj map[string]interface{}, nameIndex map[string]string) {
+func detectEnvDeps(g *DependencyGraph, sourceID string, obj map[string]interface{}, podSpec []string, nameIndex map[string]string) {
 	// Scan both containers and initContainers.
 	for _, containerKey := range []string{"containers", "initContainers"} {
-		containers := nestedSlice(obj, "spec", "template", "spec", containerKey)
+		containers := nestedSlice(obj, appendPath(podSpec, containerKey)...)
 		scanContainerEnvRefs(g, sourceID, containers, nameIndex)
 	}
 }
 
 // scanContainerEnvRefs scans a list of container specs for env/envFrom references.
 func scanContainerEnvRefs(g *DependencyGraph, sourceID string, containers []interface{}, nameIndex map[string]string) {
-
 	for _, c := range containers {
 		cm, ok := c.(map[string]interface{})
 		if !ok {
@@ -393,19 +541,11 @@ func scanContainerEnvRefs(g *DependencyGraph, sourceID string, containers []inte
 				}
 
 				if ref, ok := efm["configMapRef"].(map[string]interface{}); ok {
-					if name, ok := ref["name"].(string); ok {
-						if targetID, ok := nameIndex["ConfigMap/"+name]; ok {
-							g.AddEdge(sourceID, targetID)
-						}
-					}
+					addNameRef(g, sourceID, nameIndex, EdgeEnv, "ConfigMap", ref, "name")
 				}
 
 				if ref, ok := efm["secretRef"].(map[string]interface{}); ok {
-					if name, ok := ref["name"].(string); ok {
-						if targetID, ok := nameIndex["Secret/"+name]; ok {
-							g.AddEdge(sourceID, targetID)
-						}
-					}
+					addNameRef(g, sourceID, nameIndex, EdgeEnv, "Secret", ref, "name")
 				}
 			}
 		}
@@ -424,19 +564,11 @@ func scanContainerEnvRefs(g *DependencyGraph, sourceID string, containers []inte
 				}
 
 				if ref, ok := vf["secretKeyRef"].(map[string]interface{}); ok {
-					if name, ok := ref["name"].(string); ok {
-						if targetID, ok := nameIndex["Secret/"+name]; ok {
-							g.AddEdge(sourceID, targetID)
-						}
-					}
+					addNameRef(g, sourceID, nameIndex, EdgeEnv, "Secret", ref, "name")
 				}
 
 				if ref, ok := vf["configMapKeyRef"].(map[string]interface{}); ok {
-					if name, ok := ref["name"].(string); ok {
-						if targetID, ok := nameIndex["ConfigMap/"+name]; ok {
-							g.AddEdge(sourceID, targetID)
-						}
-					}
+					addNameRef(g, sourceID, nameIndex, EdgeEnv, "ConfigMap", ref, "name")
 				}
 			}
 		}
@@ -445,6 +577,11 @@ func scanContainerEnvRefs(g *DependencyGraph, sourceID string, containers []inte
 
 // Helper functions for nested map access.
 
+// appendPath returns a copy of path with key appended.
+func appendPath(path []string, key string) []string {
+	return append(append(make([]string, 0, len(path)+1), path...), key)
+}
+
 func nestedStringMap(obj map[string]interface{}, keys ...string) map[string]string {
 	current := obj
 
@@ -475,6 +612,21 @@ func nestedStringMap(obj map[string]interface{}, keys ...string) map[string]stri
 	return result
 }
 
+func nestedMap(obj map[string]interface{}, keys ...string) map[string]interface{} {
+	current := obj
+
+	for _, key := range keys {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		current = next
+	}
+
+	return current
+}
+
 func nestedSlice(obj map[string]interface{}, keys ...string) []interface{} {
 	current := obj
 
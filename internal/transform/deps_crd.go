@@ -0,0 +1,79 @@
+package transform
+
+import (
+	"github.com/hupe1980/chart2kro/internal/k8s"
+)
+
+// indexGeneratedSecrets adds the Secrets that cert-manager Certificates
+// write (spec.secretName) to nameIndex, so that workloads mounting the TLS
+// Secret depend on the Certificate. Secrets rendered by the chart itself
+// take precedence.
+func indexGeneratedSecrets(resources map[*k8s.Resource]string, nameIndex map[string]string) {
+	for r, id := range resources {
+		if r.Object == nil || !k8s.IsCertificate(r.GVK) {
+			continue
+		}
+
+		secretName := nestedString(r.Object.Object, "spec", "secretName")
+		if secretName == "" {
+			continue
+		}
+
+		if _, ok := nameIndex["Secret/"+secretName]; !ok {
+			nameIndex["Secret/"+secretName] = id
+		}
+	}
+}
+
+// detectCRDDeps checks the references of common CRDs: cert-manager issuers,
+// Argo Rollouts services and workload references, Prometheus Operator
+// ServiceMonitor selectors, and KEDA scale targets and authentications.
+func detectCRDDeps(g *DependencyGraph, sourceID string, r *k8s.Resource, resources map[*k8s.Resource]string, nameIndex map[string]string) {
+	obj := r.Object.Object
+
+	switch {
+	case k8s.IsCertificate(r.GVK):
+		addRefEdge(g, sourceID, nameIndex, EdgeReference, "Issuer", nestedMap(obj, "spec", "issuerRef"))
+	case k8s.IsRollout(r.GVK):
+		addRefEdge(g, sourceID, nameIndex, EdgeReference, "", nestedMap(obj, "spec", "workloadRef"))
+
+		canary := nestedMap(obj, "spec", "strategy", "canary")
+		addNameRef(g, sourceID, nameIndex, EdgeReference, "Service", canary, "stableService")
+		addNameRef(g, sourceID, nameIndex, EdgeReference, "Service", canary, "canaryService")
+		addNameRef(g, sourceID, nameIndex, EdgeReference, "Ingress", nestedMap(canary, "trafficRouting", "nginx"), "stableIngress")
+
+		blueGreen := nestedMap(obj, "spec", "strategy", "blueGreen")
+		addNameRef(g, sourceID, nameIndex, EdgeReference, "Service", blueGreen, "activeService")
+		addNameRef(g, sourceID, nameIndex, EdgeReference, "Service", blueGreen, "previewService")
+	case k8s.IsServiceMonitor(r.GVK):
+		selector := nestedStringMap(obj, "spec", "selector", "matchLabels")
+
+		for other, otherID := range resources {
+			if k8s.IsService(other.GVK) && matchesSelector(selector, other.Labels) {
+				g.AddEdgeWithReason(sourceID, otherID, EdgeSelector)
+			}
+		}
+	case k8s.IsScaledObject(r.GVK):
+		addRefEdge(g, sourceID, nameIndex, EdgeScaleTarget, "Deployment", nestedMap(obj, "spec", "scaleTargetRef"))
+
+		for _, trigger := range nestedSlice(obj, "spec", "triggers") {
+			tm, ok := trigger.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			addRefEdge(g, sourceID, nameIndex, EdgeReference, "TriggerAuthentication", nestedMap(tm, "authenticationRef"))
+		}
+	}
+}
+
+// addRefEdge adds an edge to the object named by a {kind, name} reference.
+// defaultKind is used when the reference omits the kind.
+func addRefEdge(g *DependencyGraph, sourceID string, nameIndex map[string]string, reason EdgeReason, defaultKind string, ref map[string]interface{}) {
+	kind, _ := ref["kind"].(string)
+	if kind == "" {
+		kind = defaultKind
+	}
+
+	addNameRef(g, sourceID, nameIndex, reason, kind, ref, "name")
+}
@@ -0,0 +1,156 @@
+package transform_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hupe1980/chart2kro/internal/k8s"
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+func TestBuildDependencyGraph_CertificateDeps(t *testing.T) {
+	issuer := makeFullResource("cert-manager.io/v1", "Issuer", "letsencrypt", map[string]interface{}{})
+	cert := makeFullResource("cert-manager.io/v1", "Certificate", "web-tls", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"secretName": "web-tls-secret",
+			"issuerRef":  map[string]interface{}{"name": "letsencrypt"},
+		},
+	})
+	deploy := makeFullResource("apps/v1", "Deployment", "web", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"volumes": []interface{}{
+						map[string]interface{}{
+							"name":   "tls",
+							"secret": map[string]interface{}{"secretName": "web-tls-secret"},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	g := transform.BuildDependencyGraph(map[*k8s.Resource]string{
+		issuer: "issuer",
+		cert:   "certificate",
+		deploy: "deployment",
+	})
+
+	assert.Equal(t, []string{"issuer"}, g.DependenciesOf("certificate"))
+	assert.Equal(t, []string{"certificate"}, g.DependenciesOf("deployment"),
+		"mounting the generated TLS Secret should depend on the Certificate")
+}
+
+func TestBuildDependencyGraph_RolloutDeps(t *testing.T) {
+	stable := makeFullResource("v1", "Service", "web-stable", map[string]interface{}{})
+	canary := makeFullResource("v1", "Service", "web-canary", map[string]interface{}{})
+	ingress := makeFullResource("networking.k8s.io/v1", "Ingress", "web", map[string]interface{}{})
+	rollout := makeFullResource("argoproj.io/v1alpha1", "Rollout", "web", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"strategy": map[string]interface{}{
+				"canary": map[string]interface{}{
+					"stableService": "web-stable",
+					"canaryService": "web-canary",
+					"trafficRouting": map[string]interface{}{
+						"nginx": map[string]interface{}{"stableIngress": "web"},
+					},
+				},
+			},
+		},
+	})
+
+	g := transform.BuildDependencyGraph(map[*k8s.Resource]string{
+		stable:  "stableService",
+		canary:  "canaryService",
+		ingress: "ingress",
+		rollout: "rollout",
+	})
+
+	assert.ElementsMatch(t, []string{"stableService", "canaryService", "ingress"}, g.DependenciesOf("rollout"))
+}
+
+func TestBuildDependencyGraph_ServiceMonitorDeps(t *testing.T) {
+	svc := makeFullResource("v1", "Service", "web", map[string]interface{}{})
+	svc.Labels = map[string]string{"app": "web"}
+
+	other := makeFullResource("v1", "Service", "db", map[string]interface{}{})
+	other.Labels = map[string]string{"app": "db"}
+
+	monitor := makeFullResource("monitoring.coreos.com/v1", "ServiceMonitor", "web", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": "web"},
+			},
+		},
+	})
+
+	g := transform.BuildDependencyGraph(map[*k8s.Resource]string{
+		svc:     "webService",
+		other:   "dbService",
+		monitor: "serviceMonitor",
+	})
+
+	assert.Equal(t, []string{"webService"}, g.DependenciesOf("serviceMonitor"))
+}
+
+func TestBuildDependencyGraph_ScaledObjectDeps(t *testing.T) {
+	deploy := makeFullResource("apps/v1", "Deployment", "worker", map[string]interface{}{})
+	auth := makeFullResource("keda.sh/v1alpha1", "TriggerAuthentication", "queue-auth", map[string]interface{}{})
+	scaled := makeFullResource("keda.sh/v1alpha1", "ScaledObject", "worker", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{"name": "worker"},
+			"triggers": []interface{}{
+				map[string]interface{}{
+					"type":              "rabbitmq",
+					"authenticationRef": map[string]interface{}{"name": "queue-auth"},
+				},
+			},
+		},
+	})
+
+	g := transform.BuildDependencyGraph(map[*k8s.Resource]string{
+		deploy: "deployment",
+		auth:   "triggerAuth",
+		scaled: "scaledObject",
+	})
+
+	assert.ElementsMatch(t, []string{"deployment", "triggerAuth"}, g.DependenciesOf("scaledObject"))
+}
+
+func TestBuildDependencyGraph_CronJobPodSpec(t *testing.T) {
+	cm := makeFullResource("v1", "ConfigMap", "backup-config", map[string]interface{}{})
+	sa := makeFullResource("v1", "ServiceAccount", "backup", map[string]interface{}{})
+	cron := makeFullResource("batch/v1", "CronJob", "backup", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"jobTemplate": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"serviceAccountName": "backup",
+							"containers": []interface{}{
+								map[string]interface{}{
+									"name": "backup",
+									"envFrom": []interface{}{
+										map[string]interface{}{
+											"configMapRef": map[string]interface{}{"name": "backup-config"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	g := transform.BuildDependencyGraph(map[*k8s.Resource]string{
+		cm:   "configmap",
+		sa:   "serviceAccount",
+		cron: "cronjob",
+	})
+
+	assert.ElementsMatch(t, []string{"configmap", "serviceAccount"}, g.DependenciesOf("cronjob"))
+}
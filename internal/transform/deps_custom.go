@@ -0,0 +1,78 @@
+package transform
+
+import (
+	"strings"
+
+	"github.com/hupe1980/chart2kro/internal/k8s"
+)
+
+// ReferencePath registers a custom name reference on a resource kind, e.g.
+// spec.secretRef.name on cert-manager Certificates pointing to a Secret.
+type ReferencePath struct {
+	// Kind is the kind of the referencing resource.
+	Kind string
+
+	// APIVersion optionally restricts the referencing resource's apiVersion.
+	APIVersion string
+
+	// Path is the dot-separated path of the name field. A "[]" suffix on a
+	// segment iterates the list, e.g. "spec.backends[].secretName".
+	Path string
+
+	// TargetKind is the kind of the referenced resource.
+	TargetKind string
+}
+
+// matches reports whether the reference path applies to r.
+func (p ReferencePath) matches(r *k8s.Resource) bool {
+	if p.Kind != r.GVK.Kind {
+		return false
+	}
+
+	return p.APIVersion == "" || p.APIVersion == k8s.APIVersion(r.GVK)
+}
+
+// detectCustomDeps checks the custom reference paths that apply to r.
+func detectCustomDeps(g *DependencyGraph, sourceID string, r *k8s.Resource, custom []ReferencePath, nameIndex map[string]string) {
+	for _, p := range custom {
+		if !p.matches(r) {
+			continue
+		}
+
+		segments := strings.Split(p.Path, ".")
+		for _, parent := range referenceParents(r.Object.Object, segments[:len(segments)-1]) {
+			addNameRef(g, sourceID, nameIndex, EdgeReference, p.TargetKind, parent, segments[len(segments)-1])
+		}
+	}
+}
+
+// referenceParents returns the maps reached by following segments from obj,
+// expanding "[]" segments to every element of the list.
+func referenceParents(obj map[string]interface{}, segments []string) []map[string]interface{} {
+	current := []map[string]interface{}{obj}
+
+	for _, seg := range segments {
+		var next []map[string]interface{}
+
+		for _, m := range current {
+			if key, ok := strings.CutSuffix(seg, "[]"); ok {
+				items, _ := m[key].([]interface{})
+				for _, item := range items {
+					if im, ok := item.(map[string]interface{}); ok {
+						next = append(next, im)
+					}
+				}
+
+				continue
+			}
+
+			if child, ok := m[seg].(map[string]interface{}); ok {
+				next = append(next, child)
+			}
+		}
+
+		current = next
+	}
+
+	return current
+}
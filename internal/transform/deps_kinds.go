@@ -0,0 +1,76 @@
+package transform
+
+import (
+	"github.com/hupe1980/chart2kro/internal/k8s"
+)
+
+// detectKindDeps checks the references of built-in kinds: Ingress backends
+// and TLS Secrets, autoscaler targets, RBAC bindings, and the pod selectors
+// of PodDisruptionBudgets and NetworkPolicies.
+func detectKindDeps(g *DependencyGraph, sourceID string, r *k8s.Resource, resources map[*k8s.Resource]string, nameIndex map[string]string) {
+	obj := r.Object.Object
+
+	switch {
+	case k8s.IsIngress(r.GVK):
+		detectIngressDeps(g, sourceID, obj, nameIndex)
+	case k8s.IsHPA(r.GVK):
+		addRefEdge(g, sourceID, nameIndex, EdgeScaleTarget, "", nestedMap(obj, "spec", "scaleTargetRef"))
+	case k8s.IsVPA(r.GVK):
+		addRefEdge(g, sourceID, nameIndex, EdgeScaleTarget, "", nestedMap(obj, "spec", "targetRef"))
+	case k8s.IsRoleBinding(r.GVK):
+		addRefEdge(g, sourceID, nameIndex, EdgeRBAC, "", nestedMap(obj, "roleRef"))
+
+		for _, subject := range nestedSlice(obj, "subjects") {
+			sm, ok := subject.(map[string]interface{})
+			if !ok || sm["kind"] != "ServiceAccount" {
+				continue
+			}
+
+			addNameRef(g, sourceID, nameIndex, EdgeRBAC, "ServiceAccount", sm, "name")
+		}
+	case k8s.IsPDB(r.GVK):
+		addPodSelectorEdges(g, sourceID, nestedStringMap(obj, "spec", "selector", "matchLabels"), resources)
+	case k8s.IsNetworkPolicy(r.GVK):
+		addPodSelectorEdges(g, sourceID, nestedStringMap(obj, "spec", "podSelector", "matchLabels"), resources)
+	}
+}
+
+// detectIngressDeps checks Ingress backends (networking.k8s.io/v1 and the
+// legacy extensions/v1beta1 form) and TLS Secrets.
+func detectIngressDeps(g *DependencyGraph, sourceID string, obj map[string]interface{}, nameIndex map[string]string) {
+	backends := []map[string]interface{}{
+		nestedMap(obj, "spec", "defaultBackend"),
+		nestedMap(obj, "spec", "backend"),
+	}
+
+	for _, rule := range nestedSlice(obj, "spec", "rules") {
+		rm, ok := rule.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, path := range nestedSlice(rm, "http", "paths") {
+			pm, ok := path.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			backends = append(backends, nestedMap(pm, "backend"))
+		}
+	}
+
+	for _, backend := range backends {
+		addNameRef(g, sourceID, nameIndex, EdgeBackend, "Service", nestedMap(backend, "service"), "name")
+		addNameRef(g, sourceID, nameIndex, EdgeBackend, "Service", backend, "serviceName")
+		addRefEdge(g, sourceID, nameIndex, EdgeBackend, "", nestedMap(backend, "resource"))
+	}
+
+	for _, tls := range nestedSlice(obj, "spec", "tls") {
+		tm, ok := tls.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		addNameRef(g, sourceID, nameIndex, EdgeTLS, "Secret", tm, "secretName")
+	}
+}
@@ -0,0 +1,254 @@
+package transform_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/hupe1980/chart2kro/internal/k8s"
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+func labeledWorkload(name string, labels map[string]interface{}) *k8s.Resource {
+	return makeFullResource("apps/v1", "Deployment", name, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": labels},
+			},
+		},
+	})
+}
+
+func TestBuildDependencyGraph_IngressDeps(t *testing.T) {
+	web := makeFullResource("v1", "Service", "web", map[string]interface{}{})
+	api := makeFullResource("v1", "Service", "api", map[string]interface{}{})
+	tls := makeFullResource("v1", "Secret", "web-tls", map[string]interface{}{})
+	ingress := makeFullResource("networking.k8s.io/v1", "Ingress", "web", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"defaultBackend": map[string]interface{}{
+				"service": map[string]interface{}{"name": "web"},
+			},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"http": map[string]interface{}{
+						"paths": []interface{}{
+							map[string]interface{}{
+								"path": "/api",
+								"backend": map[string]interface{}{
+									"service": map[string]interface{}{"name": "api"},
+								},
+							},
+						},
+					},
+				},
+			},
+			"tls": []interface{}{
+				map[string]interface{}{"secretName": "web-tls"},
+			},
+		},
+	})
+
+	g := transform.BuildDependencyGraph(map[*k8s.Resource]string{
+		web:     "webService",
+		api:     "apiService",
+		tls:     "tlsSecret",
+		ingress: "ingress",
+	})
+
+	assert.Equal(t, []string{"apiService", "tlsSecret", "webService"}, g.DependenciesOf("ingress"))
+}
+
+func TestBuildDependencyGraph_LegacyIngressBackend(t *testing.T) {
+	svc := makeFullResource("v1", "Service", "web", map[string]interface{}{})
+	ingress := makeFullResource("extensions/v1beta1", "Ingress", "web", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"backend": map[string]interface{}{"serviceName": "web", "servicePort": int64(80)},
+		},
+	})
+
+	g := transform.BuildDependencyGraph(map[*k8s.Resource]string{svc: "service", ingress: "ingress"})
+
+	assert.Equal(t, []string{"service"}, g.DependenciesOf("ingress"))
+}
+
+func TestBuildDependencyGraph_AutoscalerDeps(t *testing.T) {
+	deploy := makeFullResource("apps/v1", "Deployment", "web", map[string]interface{}{})
+	sts := makeFullResource("apps/v1", "StatefulSet", "db", map[string]interface{}{})
+	hpa := makeFullResource("autoscaling/v2", "HorizontalPodAutoscaler", "web", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "web"},
+		},
+	})
+	vpa := makeFullResource("autoscaling.k8s.io/v1", "VerticalPodAutoscaler", "db", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"targetRef": map[string]interface{}{"apiVersion": "apps/v1", "kind": "StatefulSet", "name": "db"},
+		},
+	})
+
+	g := transform.BuildDependencyGraph(map[*k8s.Resource]string{
+		deploy: "deployment",
+		sts:    "statefulset",
+		hpa:    "hpa",
+		vpa:    "vpa",
+	})
+
+	assert.Equal(t, []string{"deployment"}, g.DependenciesOf("hpa"))
+	assert.Equal(t, []string{"statefulset"}, g.DependenciesOf("vpa"))
+}
+
+func TestBuildDependencyGraph_RoleBindingDeps(t *testing.T) {
+	role := makeFullResource("rbac.authorization.k8s.io/v1", "Role", "reader", map[string]interface{}{})
+	sa := makeFullResource("v1", "ServiceAccount", "app", map[string]interface{}{})
+	binding := makeFullResource("rbac.authorization.k8s.io/v1", "RoleBinding", "app-reader", map[string]interface{}{
+		"roleRef": map[string]interface{}{
+			"apiGroup": "rbac.authorization.k8s.io",
+			"kind":     "Role",
+			"name":     "reader",
+		},
+		"subjects": []interface{}{
+			map[string]interface{}{"kind": "ServiceAccount", "name": "app"},
+			map[string]interface{}{"kind": "User", "name": "app"},
+		},
+	})
+
+	g := transform.BuildDependencyGraph(map[*k8s.Resource]string{
+		role:    "role",
+		sa:      "serviceAccount",
+		binding: "roleBinding",
+	})
+
+	assert.Equal(t, []string{"role", "serviceAccount"}, g.DependenciesOf("roleBinding"))
+}
+
+func TestBuildDependencyGraph_PolicySelectorDeps(t *testing.T) {
+	web := labeledWorkload("web", map[string]interface{}{"app": "web", "tier": "frontend"})
+	db := labeledWorkload("db", map[string]interface{}{"app": "db"})
+	pdb := makeFullResource("policy/v1", "PodDisruptionBudget", "web", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": "web"},
+			},
+		},
+	})
+	netpol := makeFullResource("networking.k8s.io/v1", "NetworkPolicy", "db", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"podSelector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": "db"},
+			},
+		},
+	})
+	allPods := makeFullResource("networking.k8s.io/v1", "NetworkPolicy", "default-deny", map[string]interface{}{
+		"spec": map[string]interface{}{"podSelector": map[string]interface{}{}},
+	})
+
+	g := transform.BuildDependencyGraph(map[*k8s.Resource]string{
+		web:     "web",
+		db:      "db",
+		pdb:     "pdb",
+		netpol:  "networkPolicy",
+		allPods: "defaultDeny",
+	})
+
+	assert.Equal(t, []string{"web"}, g.DependenciesOf("pdb"))
+	assert.Equal(t, []string{"db"}, g.DependenciesOf("networkPolicy"))
+	assert.Empty(t, g.DependenciesOf("defaultDeny"), "an empty selector adds no edges")
+}
+
+func TestBuildDependencyGraph_ImagePullSecretsAndProjectedVolumes(t *testing.T) {
+	pull := makeFullResource("v1", "Secret", "registry", map[string]interface{}{})
+	cm := makeFullResource("v1", "ConfigMap", "app-config", map[string]interface{}{})
+	creds := makeFullResource("v1", "Secret", "app-creds", map[string]interface{}{})
+	sa := makeFullResource("v1", "ServiceAccount", "app", map[string]interface{}{
+		"imagePullSecrets": []interface{}{
+			map[string]interface{}{"name": "registry"},
+		},
+	})
+	deploy := makeFullResource("apps/v1", "Deployment", "app", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"imagePullSecrets": []interface{}{
+						map[string]interface{}{"name": "registry"},
+					},
+					"volumes": []interface{}{
+						map[string]interface{}{
+							"name": "bundle",
+							"projected": map[string]interface{}{
+								"sources": []interface{}{
+									map[string]interface{}{
+										"configMap": map[string]interface{}{"name": "app-config"},
+									},
+									map[string]interface{}{
+										"secret": map[string]interface{}{"name": "app-creds"},
+									},
+									map[string]interface{}{
+										"serviceAccountToken": map[string]interface{}{"path": "token"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	g := transform.BuildDependencyGraph(map[*k8s.Resource]string{
+		pull:   "registrySecret",
+		cm:     "configmap",
+		creds:  "credsSecret",
+		sa:     "serviceAccount",
+		deploy: "deployment",
+	})
+
+	assert.Equal(t, []string{"configmap", "credsSecret", "registrySecret"}, g.DependenciesOf("deployment"))
+	assert.Equal(t, []string{"registrySecret"}, g.DependenciesOf("serviceAccount"))
+
+	g.RewriteNameReferences()
+
+	sources, _, _ := unstructured.NestedSlice(deploy.Object.Object,
+		"spec", "template", "spec", "volumes")
+	projected := sources[0].(map[string]interface{})["projected"].(map[string]interface{})["sources"].([]interface{})
+	assert.Equal(t, "${configmap.metadata.name}",
+		projected[0].(map[string]interface{})["configMap"].(map[string]interface{})["name"])
+}
+
+func TestBuildDependencyGraph_CustomReferencePaths(t *testing.T) {
+	secret := makeFullResource("v1", "Secret", "dns-creds", map[string]interface{}{})
+	cm := makeFullResource("v1", "ConfigMap", "zone", map[string]interface{}{})
+	widget := makeFullResource("example.com/v1", "Widget", "w", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"secretRef": map[string]interface{}{"name": "dns-creds"},
+			"sources": []interface{}{
+				map[string]interface{}{"configMapName": "zone"},
+				map[string]interface{}{"configMapName": "missing"},
+			},
+		},
+	})
+	other := makeFullResource("example.com/v2", "Widget", "w2", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"secretRef": map[string]interface{}{"name": "dns-creds"},
+		},
+	})
+
+	custom := []transform.ReferencePath{
+		{Kind: "Widget", APIVersion: "example.com/v1", Path: "spec.secretRef.name", TargetKind: "Secret"},
+		{Kind: "Widget", Path: "spec.sources[].configMapName", TargetKind: "ConfigMap"},
+	}
+
+	g := transform.BuildDependencyGraph(map[*k8s.Resource]string{
+		secret: "secret",
+		cm:     "configmap",
+		widget: "widget",
+		other:  "widgetV2",
+	}, custom...)
+
+	assert.Equal(t, []string{"configmap", "secret"}, g.DependenciesOf("widget"))
+	assert.Empty(t, g.DependenciesOf("widgetV2"), "apiVersion filter should not match v2")
+
+	assert.Equal(t, 2, g.RewriteNameReferences())
+
+	name, _, _ := unstructured.NestedString(widget.Object.Object, "spec", "secretRef", "name")
+	assert.Equal(t, "${secret.metadata.name}", name)
+}
@@ -168,6 +168,14 @@ func TestDetectCycles_WithCycle(t *testing.T) {
 	assert.NotEmpty(t, cycles)
 	// With deduplication, there should be exactly 1 unique cycle.
 	assert.Len(t, cycles, 1, "duplicate cycles should be deduplicated")
+
+	// Every consecutive pair of the cycle path is an edge.
+	cycle := cycles[0]
+	assert.Equal(t, []string{"a", "b", "c", "a"}, cycle)
+
+	for i := 0; i+1 < len(cycle); i++ {
+		assert.Contains(t, g.DependenciesOf(cycle[i]), cycle[i+1])
+	}
 }
 
 func TestBuildDependencyGraph_SelectorDeps(t *testing.T) {
@@ -215,6 +223,20 @@ func TestBuildDependencyGraph_SelectorDeps(t *testing.T) {
 	assert.Empty(t, deps)
 }
 
+func TestDependencyGraph_EdgeReasons(t *testing.T) {
+	g := transform.NewDependencyGraph()
+	g.AddNode("a", makeFullResource("apps/v1", "Deployment", "a", nil))
+	g.AddNode("b", makeFullResource("v1", "ConfigMap", "b", nil))
+
+	g.AddEdgeWithReason("a", "b", transform.EdgeVolume)
+	g.AddEdgeWithReason("a", "b", transform.EdgeEnv)
+	g.AddEdgeWithReason("a", "b", transform.EdgeVolume)
+
+	assert.Equal(t, []string{"b"}, g.DependenciesOf("a"))
+	assert.Equal(t, []transform.EdgeReason{transform.EdgeEnv, transform.EdgeVolume}, g.EdgeReasons("a", "b"))
+	assert.Empty(t, g.EdgeReasons("b", "a"))
+}
+
 func TestBuildDependencyGraph_VolumeDeps(t *testing.T) {
 	cm := makeFullResource("v1", "ConfigMap", "app-config", map[string]interface{}{
 		"data": map[string]interface{}{"key": "value"},
@@ -0,0 +1,223 @@
+// Package transform - derived.go implements derived (computed) values: Helm
+// value paths whose template occurrences are driven by a CEL expression over
+// other schema fields instead of being exposed as schema fields themselves.
+package transform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hupe1980/chart2kro/internal/k8s"
+)
+
+// DerivedInput is an additional top-level schema field that derived value
+// expressions can reference (e.g., a "size" knob).
+type DerivedInput struct {
+	// Name is the schema field name.
+	Name string
+
+	// Type is the SimpleSchema type (string, integer, number, boolean).
+	Type string
+
+	// Default is the default value as a SimpleSchema literal (e.g., "\"small\"").
+	Default string
+}
+
+// DerivedValue declares a Helm values path whose value is computed from a
+// CEL expression.
+type DerivedValue struct {
+	// Path is the dotted Helm values path (e.g., "resources.limits.memory").
+	Path string
+
+	// Expression is the CEL expression, with or without the ${...} wrapper.
+	Expression string
+
+	// Type is the declared result type. When empty, it is inferred.
+	Type string
+}
+
+// ApplyDerivedValues substitutes each derived value's expression into the
+// resource templates wherever its Helm path was detected, removes the derived
+// paths from the schema and appends the declared inputs. The expression's
+// type is validated against the declared type and the type of the mapped
+// schema field. It returns the updated schema fields.
+func ApplyDerivedValues(
+	resources []*k8s.Resource,
+	resourceIDs map[*k8s.Resource]string,
+	mappings []FieldMapping,
+	fields []*SchemaField,
+	inputs []DerivedInput,
+	derived []DerivedValue,
+) ([]*SchemaField, error) {
+	if len(derived) == 0 && len(inputs) == 0 {
+		return fields, nil
+	}
+
+	fieldTypes := make(map[string]string)
+	collectFieldTypes(fields, fieldTypes)
+
+	topLevel := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		topLevel[f.Name] = true
+	}
+
+	derivedPaths := make(map[string]bool, len(derived))
+	for _, d := range derived {
+		derivedPaths[d.Path] = true
+	}
+
+	for _, in := range inputs {
+		if topLevel[in.Name] {
+			return nil, fmt.Errorf("derived input %q collides with an existing schema field", in.Name)
+		}
+
+		fieldTypes[in.Name] = in.Type
+	}
+
+	byPath := make(map[string][]FieldMapping)
+	for _, m := range mappings {
+		if derivedPaths[m.ValuesPath] {
+			byPath[m.ValuesPath] = append(byPath[m.ValuesPath], m)
+		}
+	}
+
+	byID := make(map[string]*k8s.Resource, len(resourceIDs))
+	for r, id := range resourceIDs {
+		byID[id] = r
+	}
+
+	sorted := make([]DerivedValue, len(derived))
+	copy(sorted, derived)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	for _, d := range sorted {
+		expr := unwrapExpression(d.Expression)
+
+		pathMappings := byPath[d.Path]
+		if len(pathMappings) == 0 {
+			return nil, fmt.Errorf("derived value %q: path is not referenced by any resource template", d.Path)
+		}
+
+		resolve := func(ref string) (string, error) {
+			if derivedPaths[ref] {
+				return "", fmt.Errorf("references derived value %q", ref)
+			}
+
+			typ, ok := fieldTypes[ref]
+			if !ok {
+				return "", fmt.Errorf("references unknown schema field %q", ref)
+			}
+
+			return typ, nil
+		}
+
+		inferred, err := InferExpressionType(expr, resolve)
+		if err != nil {
+			return nil, fmt.Errorf("derived value %q: %w", d.Path, err)
+		}
+
+		effective := d.Type
+		if effective == "" {
+			effective = inferred
+		} else if !typesCompatible(d.Type, inferred) {
+			return nil, fmt.Errorf("derived value %q: expression has type %s, declared %s", d.Path, inferred, d.Type)
+		}
+
+		if mapped := fieldTypes[d.Path]; !typesCompatible(mapped, effective) {
+			return nil, fmt.Errorf("derived value %q: expression has type %s, mapped field has type %s", d.Path, effective, mapped)
+		}
+
+		ref := SchemaRef("spec", d.Path)
+		replacement := "${" + expr + "}"
+
+		for _, m := range pathMappings {
+			if m.MatchType == MatchSubstring && !typesCompatible("string", effective) {
+				return nil, fmt.Errorf("derived value %q: interpolated into %s.%s, requires type string, got %s",
+					d.Path, m.ResourceID, m.FieldPath, effective)
+			}
+
+			r := byID[m.ResourceID]
+			if r == nil || r.Object == nil {
+				continue
+			}
+
+			current, ok := getNestedField(r.Object.Object, m.FieldPath)
+			if !ok {
+				continue
+			}
+
+			if s, isString := current.(string); isString && strings.Contains(s, ref) {
+				setNestedField(r.Object.Object, m.FieldPath, strings.ReplaceAll(s, ref, replacement))
+			}
+		}
+	}
+
+	fields = removeSchemaPaths(fields, derivedPaths)
+
+	for _, in := range inputs {
+		fields = append(fields, &SchemaField{
+			Name:    in.Name,
+			Path:    in.Name,
+			Type:    in.Type,
+			Default: in.Default,
+		})
+	}
+
+	return fields, nil
+}
+
+// collectFieldTypes indexes leaf schema field types by Helm values path.
+func collectFieldTypes(fields []*SchemaField, out map[string]string) {
+	for _, f := range fields {
+		out[f.Path] = f.Type
+
+		if f.IsObject() {
+			collectFieldTypes(f.Children, out)
+		}
+	}
+}
+
+// removeSchemaPaths drops fields whose path is in paths, along with object
+// fields left without children.
+func removeSchemaPaths(fields []*SchemaField, paths map[string]bool) []*SchemaField {
+	kept := fields[:0]
+
+	for _, f := range fields {
+		if paths[f.Path] {
+			continue
+		}
+
+		if f.IsObject() {
+			f.Children = removeSchemaPaths(f.Children, paths)
+			if len(f.Children) == 0 {
+				continue
+			}
+		}
+
+		kept = append(kept, f)
+	}
+
+	return kept
+}
+
+// unwrapExpression strips a single enclosing ${...} from expr.
+func unwrapExpression(expr string) string {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "${") && strings.HasSuffix(expr, "}") {
+		return strings.TrimSpace(expr[2 : len(expr)-1])
+	}
+
+	return expr
+}
+
+// typesCompatible reports whether a value of type got can be used where want
+// is expected. Unknown (empty) types are always compatible, and integers are
+// accepted for number fields.
+func typesCompatible(want, got string) bool {
+	if want == "" || got == "" || want == got {
+		return true
+	}
+
+	return want == "number" && got == "integer"
+}
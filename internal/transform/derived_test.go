@@ -0,0 +1,223 @@
+package transform_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/chart2kro/internal/k8s"
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+func derivedFixture() ([]*k8s.Resource, map[*k8s.Resource]string, []transform.FieldMapping, []*transform.SchemaField) {
+	deploy := makeFullResource("apps/v1", "Deployment", "app", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": "${schema.spec.replicaCount}",
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"image": "${schema.spec.image.repository}:${schema.spec.image.tag}",
+							"resources": map[string]interface{}{
+								"limits": map[string]interface{}{"memory": "${schema.spec.resources.memory}"},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	ids := map[*k8s.Resource]string{deploy: "deployment"}
+
+	mappings := []transform.FieldMapping{
+		{ValuesPath: "replicaCount", ResourceID: "deployment", FieldPath: "spec.replicas", MatchType: transform.MatchExact},
+		{ValuesPath: "image.repository", ResourceID: "deployment", FieldPath: "spec.template.spec.containers[0].image", MatchType: transform.MatchSubstring},
+		{ValuesPath: "image.tag", ResourceID: "deployment", FieldPath: "spec.template.spec.containers[0].image", MatchType: transform.MatchSubstring},
+		{ValuesPath: "resources.memory", ResourceID: "deployment", FieldPath: "spec.template.spec.containers[0].resources.limits.memory", MatchType: transform.MatchExact},
+	}
+
+	fields := []*transform.SchemaField{
+		{Name: "image", Path: "image", Type: "object", Children: []*transform.SchemaField{
+			{Name: "repository", Path: "image.repository", Type: "string", Default: `"nginx"`},
+			{Name: "tag", Path: "image.tag", Type: "string", Default: `"1.25"`},
+		}},
+		{Name: "replicaCount", Path: "replicaCount", Type: "integer", Default: "1"},
+		{Name: "resources", Path: "resources", Type: "object", Children: []*transform.SchemaField{
+			{Name: "memory", Path: "resources.memory", Type: "string", Default: `"256Mi"`},
+		}},
+	}
+
+	return []*k8s.Resource{deploy}, ids, mappings, fields
+}
+
+func TestApplyDerivedValues_SubstitutesExpressions(t *testing.T) {
+	resources, ids, mappings, fields := derivedFixture()
+
+	inputs := []transform.DerivedInput{{Name: "size", Type: "string", Default: `"small"`}}
+	derived := []transform.DerivedValue{
+		{Path: "replicaCount", Expression: `${schema.spec.size == "large" ? 3 : 1}`},
+		{Path: "resources.memory", Expression: `schema.spec.size == "large" ? "1Gi" : "256Mi"`, Type: "string"},
+		{Path: "image.tag", Expression: `schema.spec.size + "-build"`},
+	}
+
+	out, err := transform.ApplyDerivedValues(resources, ids, mappings, fields, inputs, derived)
+	require.NoError(t, err)
+
+	obj := resources[0].Object.Object
+	spec := obj["spec"].(map[string]interface{})
+	assert.Equal(t, `${schema.spec.size == "large" ? 3 : 1}`, spec["replicas"])
+
+	container := spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, `${schema.spec.image.repository}:${schema.spec.size + "-build"}`, container["image"])
+
+	limits := container["resources"].(map[string]interface{})["limits"].(map[string]interface{})
+	assert.Equal(t, `${schema.spec.size == "large" ? "1Gi" : "256Mi"}`, limits["memory"])
+
+	// Derived paths are removed, empty objects pruned, and the input added.
+	schema := transform.BuildSimpleSchema(out)
+	assert.NotContains(t, schema, "replicaCount")
+	assert.NotContains(t, schema, "resources")
+	assert.Equal(t, map[string]interface{}{"repository": `string | default="nginx"`}, schema["image"])
+	assert.Equal(t, `string | default="small"`, schema["size"])
+}
+
+func TestApplyDerivedValues_TypeMismatchWithMappedField(t *testing.T) {
+	resources, ids, mappings, fields := derivedFixture()
+
+	_, err := transform.ApplyDerivedValues(resources, ids, mappings, fields,
+		[]transform.DerivedInput{{Name: "size", Type: "string"}},
+		[]transform.DerivedValue{{Path: "replicaCount", Expression: `schema.spec.size == "large" ? "three" : "one"`}},
+	)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mapped field has type integer")
+}
+
+func TestApplyDerivedValues_DeclaredTypeMismatch(t *testing.T) {
+	resources, ids, mappings, fields := derivedFixture()
+
+	_, err := transform.ApplyDerivedValues(resources, ids, mappings, fields, nil,
+		[]transform.DerivedValue{{Path: "replicaCount", Expression: `2 * 3`, Type: "string"}},
+	)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "declared string")
+}
+
+func TestApplyDerivedValues_InterpolationRequiresString(t *testing.T) {
+	resources, ids, mappings, fields := derivedFixture()
+
+	// A numeric tag is valid for the schema field but cannot be interpolated.
+	fields[0].Children[1].Type = "integer"
+
+	_, err := transform.ApplyDerivedValues(resources, ids, mappings, fields, nil,
+		[]transform.DerivedValue{{Path: "image.tag", Expression: `schema.spec.replicaCount + 1`}},
+	)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires type string")
+}
+
+func TestApplyDerivedValues_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  []transform.DerivedInput
+		derived []transform.DerivedValue
+		wantErr string
+	}{
+		{
+			name:    "unknown reference",
+			derived: []transform.DerivedValue{{Path: "replicaCount", Expression: "schema.spec.missing"}},
+			wantErr: `unknown schema field "missing"`,
+		},
+		{
+			name: "reference to derived value",
+			derived: []transform.DerivedValue{
+				{Path: "replicaCount", Expression: "schema.spec.resources.memory == \"1Gi\" ? 2 : 1"},
+				{Path: "resources.memory", Expression: `"1Gi"`},
+			},
+			wantErr: `references derived value "resources.memory"`,
+		},
+		{
+			name:    "path not detected",
+			derived: []transform.DerivedValue{{Path: "service.port", Expression: "80"}},
+			wantErr: "not referenced by any resource template",
+		},
+		{
+			name:    "input collides",
+			inputs:  []transform.DerivedInput{{Name: "image", Type: "string"}},
+			wantErr: "collides",
+		},
+		{
+			name:    "syntax error",
+			derived: []transform.DerivedValue{{Path: "replicaCount", Expression: "(1 + 2"}},
+			wantErr: `expected ")"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources, ids, mappings, fields := derivedFixture()
+
+			_, err := transform.ApplyDerivedValues(resources, ids, mappings, fields, tt.inputs, tt.derived)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestInferExpressionType(t *testing.T) {
+	types := map[string]string{"size": "string", "replicas": "integer", "ratio": "number", "enabled": "boolean"}
+	resolve := func(path string) (string, error) { return types[path], nil }
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`"abc"`, "string"},
+		{`42`, "integer"},
+		{`1.5`, "number"},
+		{`2e3`, "number"},
+		{`true`, "boolean"},
+		{`schema.spec.size`, "string"},
+		{`schema.spec.?size`, "string"},
+		{`schema.spec.replicas * 2`, "integer"},
+		{`schema.spec.replicas * schema.spec.ratio`, "number"},
+		{`schema.spec.size == "large"`, "boolean"},
+		{`!schema.spec.enabled || schema.spec.replicas > 2`, "boolean"},
+		{`schema.spec.size in ["small", "large"]`, "boolean"},
+		{`schema.spec.size == "large" ? 3 : 1`, "integer"},
+		{`schema.spec.enabled ? 1 : 2.5`, "number"},
+		{`schema.spec.size == "s" ? "1" : schema.spec.size == "m" ? "2" : "3"`, "string"},
+		{`string(schema.spec.replicas) + "x"`, "string"},
+		{`schema.spec.size.startsWith("l")`, "boolean"},
+		{`size(schema.spec.size)`, "integer"},
+		{`(schema.spec.replicas + 1) * 2`, "integer"},
+		{`deployment.status.readyReplicas`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := transform.InferExpressionType(tt.expr, resolve)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestInferExpressionType_Errors(t *testing.T) {
+	resolve := func(string) (string, error) { return "string", nil }
+
+	for _, expr := range []string{
+		`"unterminated`,
+		`1 +`,
+		`true ? "a" : 1`,
+		`"a" ? 1 : 2`,
+		`1 2`,
+		`a # b`,
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := transform.InferExpressionType(expr, resolve)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -6,6 +6,7 @@ package transform
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/hupe1980/chart2kro/internal/k8s"
 )
@@ -29,6 +30,18 @@ type Result struct {
 
 	// FieldMappings are the detected parameter mappings.
 	FieldMappings []FieldMapping
+
+	// ReadyWhen holds per-resource readiness conditions keyed by resource
+	// ID that take precedence over the per-Kind defaults (e.g. completion
+	// conditions for ordered hook Jobs).
+	ReadyWhen map[string][]string
+
+	// IncludeWhen holds conditional inclusion expressions keyed by
+	// resource ID.
+	IncludeWhen map[string][]string
+
+	// BrokenEdges are the dependency edges removed by the cycle strategy.
+	BrokenEdges []BrokenEdge
 }
 
 // EngineConfig configures the transformation engine.
@@ -60,11 +73,41 @@ type EngineConfig struct {
 	// Keys are dotted Helm value paths (e.g., "replicaCount", "image.tag").
 	SchemaOverrides map[string]SchemaOverride
 
+	// DerivedInputs are additional top-level schema fields referenced by
+	// derived value expressions.
+	DerivedInputs []DerivedInput
+
+	// DerivedValues replace detected Helm value paths with CEL expressions
+	// over other schema fields. Derived paths are removed from the schema.
+	DerivedValues []DerivedValue
+
+	// Status configures custom status fields and the aggregate readiness
+	// summary. When nil, only per-resource projections are generated.
+	Status *StatusConfig
+
+	// OrderHooks orders Helm install/upgrade hooks kept by
+	// hooks.FilterMode(ModeOrdered) via dependency edges (see
+	// ApplyHookOrdering).
+	OrderHooks bool
+
+	// ReferencePaths are custom name references on resource kinds that
+	// add dependency edges in addition to the built-in detectors.
+	ReferencePaths []ReferencePath
+
+	// CycleStrategy selects how dependency cycles are resolved. The zero
+	// value reports them as a CycleError.
+	CycleStrategy CycleStrategy
+
 	// TransformerRegistry is an optional pluggable transformer registry.
 	// When non-nil, the engine dispatches per-resource transformation
 	// through the registry to produce readiness conditions and status
-	// projections. When nil, DefaultStatusProjections is used.
+	// projections. Transformers receive a copy of the rendered resource.
+	// When nil, DefaultStatusProjections is used.
 	TransformerRegistry TransformerRegistry
+
+	// AllowEmpty accepts an empty resource list, e.g. for umbrella charts
+	// whose resources all come from nested subcharts.
+	AllowEmpty bool
 }
 
 // Engine orchestrates the full transformation pipeline.
@@ -80,15 +123,17 @@ func NewEngine(config EngineConfig) *Engine {
 // Transform runs the full transformation pipeline:
 // 1. Assign resource IDs
 // 2. Apply field mappings to resource templates (CEL expression injection)
-// 3. Extract schema from values (with optional pruning)
-// 4. Build dependency graph
-// 5. Generate status projections via transformer registry
+// 3. Extract schema from values (with optional pruning and derived values)
+// 4. Build dependency graph (ordering Helm hooks when configured)
+// 5. Resolve or report cycles and rewrite referenced names as CEL references
+// 6. Generate status projections via transformer registry
+// 7. Apply custom status fields and the aggregate readiness summary
 func (e *Engine) Transform(
 	ctx context.Context,
 	resources []*k8s.Resource,
 	values map[string]interface{},
 ) (*Result, error) {
-	if len(resources) == 0 {
+	if len(resources) == 0 && !e.config.AllowEmpty {
 		return nil, fmt.Errorf("no resources to transform")
 	}
 
@@ -98,6 +143,13 @@ func (e *Engine) Transform(
 		return nil, fmt.Errorf("assigning resource IDs: %w", err)
 	}
 
+	// 1b. Transformers see the rendered resources, before field mappings,
+	// derived values, and name references are applied.
+	var rendered map[*k8s.Resource]*k8s.Resource
+	if e.config.TransformerRegistry != nil {
+		rendered = copyRendered(resources)
+	}
+
 	// 2. Apply field mappings to resource templates.
 	if len(e.config.FieldMappings) > 0 {
 		ApplyFieldMappings(resources, resourceIDs, e.config.FieldMappings)
@@ -123,34 +175,79 @@ func (e *Engine) Transform(
 		ApplySchemaOverrides(schemaFields, e.config.SchemaOverrides)
 	}
 
+	// 3c. Substitute derived value expressions and add their inputs.
+	if len(e.config.DerivedValues) > 0 || len(e.config.DerivedInputs) > 0 {
+		schemaFields, err = ApplyDerivedValues(resources, resourceIDs, e.config.FieldMappings,
+			schemaFields, e.config.DerivedInputs, e.config.DerivedValues)
+		if err != nil {
+			return nil, fmt.Errorf("applying derived values: %w", err)
+		}
+	}
+
 	// 4. Build dependency graph.
-	depGraph := BuildDependencyGraph(resourceIDs)
+	depGraph := BuildDependencyGraph(resourceIDs, e.config.ReferencePaths...)
+
+	var readyWhen map[string][]string
+	if e.config.OrderHooks {
+		readyWhen = ApplyHookOrdering(depGraph, resourceIDs)
+	}
+
+	// 5. Validate: break cycles with the configured strategy, then report
+	// the remaining ones.
+	brokenEdges := depGraph.BreakCycles(e.config.CycleStrategy)
 
-	// 5. Validate: check for cycles.
 	cycles := depGraph.DetectCycles()
 	if len(cycles) > 0 {
-		return nil, &CycleError{Cycles: cycles}
+		return nil, &CycleError{Cycles: cycles, Graph: depGraph}
 	}
 
+	// 5b. Replace literal names behind dependency edges with
+	// ${id.metadata.name} so KRO derives the same ordering.
+	depGraph.RewriteNameReferences()
+
 	// 6. Generate status projections via transformer registry.
 	var statusFields []StatusField
 
+	var includeWhen map[string][]string
+
 	for _, r := range resources {
 		id := resourceIDs[r]
 
 		if e.config.TransformerRegistry != nil {
-			output, transformErr := e.config.TransformerRegistry.TransformResource(ctx, r, id, e.config.FieldMappings, values)
+			output, transformErr := e.config.TransformerRegistry.TransformResource(ctx, rendered[r], id, e.config.FieldMappings, values)
 			if transformErr != nil {
 				return nil, fmt.Errorf("transformer for %s/%s: %w", r.GVK.Kind, id, transformErr)
 			}
 
 			statusFields = append(statusFields, output.StatusFields...)
+
+			if output.OverrideReadyWhen {
+				if readyWhen == nil {
+					readyWhen = make(map[string][]string)
+				}
+
+				readyWhen[id] = output.ReadyWhen
+			}
+
+			if output.IncludeWhen != "" {
+				if includeWhen == nil {
+					includeWhen = make(map[string][]string)
+				}
+
+				includeWhen[id] = []string{output.IncludeWhen}
+			}
 		} else {
 			projections := DefaultStatusProjections(r.GVK, id)
 			statusFields = append(statusFields, projections...)
 		}
 	}
 
+	// 7. Apply custom status fields and the readiness summary.
+	statusFields, err = BuildStatusFields(statusFields, resources, resourceIDs, e.config.Status)
+	if err != nil {
+		return nil, fmt.Errorf("building status fields: %w", err)
+	}
+
 	return &Result{
 		Resources:       resources,
 		ResourceIDs:     resourceIDs,
@@ -158,14 +255,47 @@ func (e *Engine) Transform(
 		StatusFields:    statusFields,
 		DependencyGraph: depGraph,
 		FieldMappings:   e.config.FieldMappings,
+		ReadyWhen:       readyWhen,
+		IncludeWhen:     includeWhen,
+		BrokenEdges:     brokenEdges,
 	}, nil
 }
 
+// copyRendered returns deep copies of resources, keyed by the original.
+func copyRendered(resources []*k8s.Resource) map[*k8s.Resource]*k8s.Resource {
+	out := make(map[*k8s.Resource]*k8s.Resource, len(resources))
+
+	for _, r := range resources {
+		c := *r
+		if r.Object != nil {
+			c.Object = r.Object.DeepCopy()
+		}
+
+		out[r] = &c
+	}
+
+	return out
+}
+
 // CycleError is returned when the dependency graph contains cycles.
 type CycleError struct {
 	Cycles [][]string
+
+	// Graph is the dependency graph that contains the cycles.
+	Graph *DependencyGraph
 }
 
+// Error lists the cycles. With a graph, each edge is annotated with the
+// reasons it was detected.
 func (e *CycleError) Error() string {
-	return fmt.Sprintf("dependency graph contains %d cycle(s): %v", len(e.Cycles), e.Cycles)
+	if e.Graph == nil {
+		return fmt.Sprintf("dependency graph contains %d cycle(s): %v", len(e.Cycles), e.Cycles)
+	}
+
+	explained := make([]string, len(e.Cycles))
+	for i, cycle := range e.Cycles {
+		explained[i] = e.Graph.ExplainCycle(cycle)
+	}
+
+	return fmt.Sprintf("dependency graph contains %d cycle(s): %s", len(e.Cycles), strings.Join(explained, "; "))
 }
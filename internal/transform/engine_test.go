@@ -64,6 +64,14 @@ func TestEngine_Transform_EmptyResources(t *testing.T) {
 	assert.Contains(t, err.Error(), "no resources")
 }
 
+func TestEngine_Transform_AllowEmpty(t *testing.T) {
+	engine := transform.NewEngine(transform.EngineConfig{AllowEmpty: true})
+
+	result, err := engine.Transform(context.Background(), nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.Resources)
+}
+
 func TestEngine_Transform_WithOverrides(t *testing.T) {
 	resources := []*k8s.Resource{
 		makeFullResource("v1", "Service", "web-svc", map[string]interface{}{
@@ -232,3 +240,46 @@ func TestEngine_Transform_IDCollision(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "collision")
 }
+
+// stubRegistry returns a fixed transformer output for every resource.
+type stubRegistry struct {
+	output transform.TransformerOutput
+}
+
+func (s *stubRegistry) TransformResource(
+	_ context.Context, _ *k8s.Resource, _ string, _ []transform.FieldMapping, _ map[string]interface{},
+) (*transform.TransformerOutput, error) {
+	out := s.output
+	return &out, nil
+}
+
+func TestEngine_Transform_TransformerConditions(t *testing.T) {
+	resources := []*k8s.Resource{
+		makeFullResource("v1", "ConfigMap", "app-config", map[string]interface{}{}),
+	}
+
+	engine := transform.NewEngine(transform.EngineConfig{
+		TransformerRegistry: &stubRegistry{output: transform.TransformerOutput{
+			ReadyWhen:         []string{"${self.data != null}"},
+			OverrideReadyWhen: true,
+			IncludeWhen:       "${schema.spec.config.enabled}",
+		}},
+	})
+
+	result, err := engine.Transform(context.Background(), resources, map[string]interface{}{})
+	require.NoError(t, err)
+
+	id := result.ResourceIDs[resources[0]]
+	assert.Equal(t, []string{"${self.data != null}"}, result.ReadyWhen[id])
+	assert.Equal(t, []string{"${schema.spec.config.enabled}"}, result.IncludeWhen[id])
+
+	// Without OverrideReadyWhen, readiness is left to the generator defaults.
+	engine = transform.NewEngine(transform.EngineConfig{
+		TransformerRegistry: &stubRegistry{output: transform.TransformerOutput{ReadyWhen: []string{"${true}"}}},
+	})
+
+	result, err = engine.Transform(context.Background(), resources, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Empty(t, result.ReadyWhen)
+	assert.Empty(t, result.IncludeWhen)
+}
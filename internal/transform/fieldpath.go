@@ -131,3 +131,40 @@ func joinFieldPath(prefix, key string) string {
 
 	return prefix + "." + key
 }
+
+// getNestedField returns the value at a dot-separated path in a nested map.
+// Supports the same array index notation as setNestedField.
+func getNestedField(obj map[string]interface{}, path string) (interface{}, bool) {
+	parts := parseFieldPath(path)
+	if len(parts) == 0 {
+		return nil, false
+	}
+
+	current := interface{}(obj)
+
+	for _, p := range parts {
+		switch c := current.(type) {
+		case map[string]interface{}:
+			if p.Index >= 0 {
+				return nil, false
+			}
+
+			next, ok := c[p.Key]
+			if !ok {
+				return nil, false
+			}
+
+			current = next
+		case []interface{}:
+			if p.Index < 0 || p.Index >= len(c) {
+				return nil, false
+			}
+
+			current = c[p.Index]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
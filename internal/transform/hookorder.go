@@ -0,0 +1,183 @@
+package transform
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hupe1980/chart2kro/internal/helm/hooks"
+	"github.com/hupe1980/chart2kro/internal/k8s"
+)
+
+// hookNode is an ordered Helm hook in the dependency graph.
+type hookNode struct {
+	id     string
+	phase  hooks.Phase
+	weight int
+}
+
+// ApplyHookOrdering turns Helm install/upgrade hooks kept by
+// hooks.FilterMode(ModeOrdered) into ordered RGD resources:
+//
+//   - pre hooks run in ascending helm.sh/hook-weight groups, each group
+//     depending on the previous one, and every regular resource depends on
+//     the last pre group;
+//   - post hooks depend on every regular resource and then run in ascending
+//     weight groups.
+//
+// Edges that would introduce a cycle (e.g. a pre-install Job that mounts a
+// regular ConfigMap) are skipped. The helm.sh/hook* annotations are removed
+// from the templates. The returned map holds completion readiness
+// conditions for hook Jobs and Pods, keyed by resource ID.
+func ApplyHookOrdering(g *DependencyGraph, resourceIDs map[*k8s.Resource]string) map[string][]string {
+	var (
+		hookNodes []hookNode
+		regular   []string
+	)
+
+	readyWhen := make(map[string][]string)
+
+	for r, id := range resourceIDs {
+		value := r.Annotations[hooks.HookAnnotation]
+		if value == "" {
+			regular = append(regular, id)
+			continue
+		}
+
+		phase := hooks.HookPhase(hooks.ParseHookTypes(value))
+		if phase == hooks.PhaseNone {
+			regular = append(regular, id)
+			continue
+		}
+
+		hookNodes = append(hookNodes, hookNode{
+			id:     id,
+			phase:  phase,
+			weight: hooks.ParseWeight(r.Annotations[hooks.HookWeightAnnotation]),
+		})
+
+		if cond := HookReadyWhen(r); cond != "" {
+			readyWhen[id] = []string{cond}
+		}
+
+		StripHookAnnotations(r)
+	}
+
+	if len(hookNodes) == 0 {
+		return nil
+	}
+
+	sort.Strings(regular)
+	sort.Slice(hookNodes, func(i, j int) bool {
+		a, b := hookNodes[i], hookNodes[j]
+		if a.phase != b.phase {
+			return a.phase < b.phase
+		}
+
+		if a.weight != b.weight {
+			return a.weight < b.weight
+		}
+
+		return a.id < b.id
+	})
+
+	pre, post := hookGroups(hookNodes, hooks.PhasePre), hookGroups(hookNodes, hooks.PhasePost)
+
+	chainGroups(g, pre)
+	chainGroups(g, post)
+
+	if len(pre) > 0 {
+		for _, id := range regular {
+			addAcyclicEdges(g, []string{id}, pre[len(pre)-1])
+		}
+	}
+
+	if len(post) > 0 {
+		addAcyclicEdges(g, post[0], regular)
+	}
+
+	return readyWhen
+}
+
+// hookGroups returns the IDs of hooks in phase, grouped by weight in
+// ascending order. hookNodes must be sorted.
+func hookGroups(hookNodes []hookNode, phase hooks.Phase) [][]string {
+	var (
+		groups [][]string
+		last   *hookNode
+	)
+
+	for i := range hookNodes {
+		n := &hookNodes[i]
+		if n.phase != phase {
+			continue
+		}
+
+		if last == nil || last.weight != n.weight {
+			groups = append(groups, nil)
+		}
+
+		groups[len(groups)-1] = append(groups[len(groups)-1], n.id)
+		last = n
+	}
+
+	return groups
+}
+
+// chainGroups makes every hook of a weight group depend on all hooks of the
+// previous group.
+func chainGroups(g *DependencyGraph, groups [][]string) {
+	for i := 1; i < len(groups); i++ {
+		addAcyclicEdges(g, groups[i], groups[i-1])
+	}
+}
+
+// addAcyclicEdges makes each source depend on each target unless the target
+// already depends on the source, directly or transitively.
+func addAcyclicEdges(g *DependencyGraph, sources, targets []string) {
+	for _, source := range sources {
+		for _, target := range targets {
+			if !g.reaches(target, source) {
+				g.AddEdgeWithReason(source, target, EdgeHook)
+			}
+		}
+	}
+}
+
+// HookReadyWhen returns the completion condition for hook Jobs and Pods, or
+// an empty string for other kinds.
+func HookReadyWhen(r *k8s.Resource) string {
+	switch {
+	case k8s.IsJob(r.GVK):
+		return ReadyWhenCondition{Key: "self.status.succeeded", Operator: ">", Value: "0"}.String()
+	case k8s.IsPod(r.GVK):
+		return ReadyWhenCondition{Key: "self.status.phase", Operator: "==", Value: `"Succeeded"`}.String()
+	default:
+		return ""
+	}
+}
+
+// StripHookAnnotations removes helm.sh/hook* annotations from a resource.
+func StripHookAnnotations(r *k8s.Resource) {
+	for k := range r.Annotations {
+		if strings.HasPrefix(k, hooks.HookAnnotation) {
+			delete(r.Annotations, k)
+		}
+	}
+
+	if r.Object == nil {
+		return
+	}
+
+	annotations := r.Object.GetAnnotations()
+	for k := range annotations {
+		if strings.HasPrefix(k, hooks.HookAnnotation) {
+			delete(annotations, k)
+		}
+	}
+
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+
+	r.Object.SetAnnotations(annotations)
+}
@@ -0,0 +1,111 @@
+package transform_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/chart2kro/internal/k8s"
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+// makeHook creates a resource carrying Helm hook annotations.
+func makeHook(apiVersion, kind, name, hook, weight string) *k8s.Resource {
+	r := makeFullResource(apiVersion, kind, name, map[string]interface{}{})
+
+	annotations := map[string]string{"helm.sh/hook": hook, "note": "kept"}
+	if weight != "" {
+		annotations["helm.sh/hook-weight"] = weight
+	}
+
+	r.Annotations = annotations
+	r.Object.SetAnnotations(annotations)
+
+	return r
+}
+
+func TestApplyHookOrdering(t *testing.T) {
+	secret := makeHook("v1", "Secret", "gen-secret", "pre-install", "-10")
+	migrate := makeHook("batch/v1", "Job", "migrate", "pre-install,pre-upgrade", "5")
+	seed := makeHook("batch/v1", "Job", "seed", "pre-upgrade", "5")
+	deploy := makeFullResource("apps/v1", "Deployment", "app", map[string]interface{}{})
+	svc := makeFullResource("v1", "Service", "app", map[string]interface{}{})
+	notify := makeHook("v1", "Pod", "notify", "post-install", "")
+
+	ids := map[*k8s.Resource]string{
+		secret: "secret", migrate: "migrate", seed: "seed",
+		deploy: "deployment", svc: "service", notify: "notify",
+	}
+
+	g := transform.BuildDependencyGraph(ids)
+	readyWhen := transform.ApplyHookOrdering(g, ids)
+
+	// Pre hooks run in weight order.
+	assert.Equal(t, []string{"secret"}, g.DependenciesOf("migrate"))
+	assert.Equal(t, []string{"secret"}, g.DependenciesOf("seed"))
+	assert.Empty(t, g.DependenciesOf("secret"))
+
+	// Regular resources wait for the last pre group.
+	assert.Equal(t, []string{"migrate", "seed"}, g.DependenciesOf("deployment"))
+	assert.Equal(t, []string{"migrate", "seed"}, g.DependenciesOf("service"))
+
+	// Post hooks wait for all regular resources.
+	assert.Equal(t, []string{"deployment", "service"}, g.DependenciesOf("notify"))
+
+	assert.Equal(t, map[string][]string{
+		"migrate": {"${self.status.succeeded > 0}"},
+		"seed":    {"${self.status.succeeded > 0}"},
+		"notify":  {`${self.status.phase == "Succeeded"}`},
+	}, readyWhen)
+
+	// Hook annotations are stripped, others kept.
+	assert.Equal(t, map[string]string{"note": "kept"}, migrate.Object.GetAnnotations())
+	assert.Equal(t, map[string]string{"note": "kept"}, migrate.Annotations)
+
+	order, err := g.TopologicalSort()
+	require.NoError(t, err)
+	assert.Less(t, indexOf(order, "secret"), indexOf(order, "migrate"))
+	assert.Less(t, indexOf(order, "migrate"), indexOf(order, "deployment"))
+	assert.Less(t, indexOf(order, "deployment"), indexOf(order, "notify"))
+}
+
+func TestApplyHookOrdering_SkipsCyclicEdges(t *testing.T) {
+	// The pre-install Job mounts a regular ConfigMap, so the ConfigMap cannot
+	// also wait for the Job.
+	cm := makeFullResource("v1", "ConfigMap", "settings", map[string]interface{}{})
+	job := makeHook("batch/v1", "Job", "migrate", "pre-install", "")
+	job.Object.Object["spec"] = map[string]interface{}{
+		"template": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"volumes": []interface{}{
+					map[string]interface{}{"name": "cfg", "configMap": map[string]interface{}{"name": "settings"}},
+				},
+			},
+		},
+	}
+	deploy := makeFullResource("apps/v1", "Deployment", "app", map[string]interface{}{})
+
+	ids := map[*k8s.Resource]string{cm: "configmap", job: "job", deploy: "deployment"}
+
+	g := transform.BuildDependencyGraph(ids)
+	require.Equal(t, []string{"configmap"}, g.DependenciesOf("job"))
+
+	transform.ApplyHookOrdering(g, ids)
+
+	assert.Empty(t, g.DependenciesOf("configmap"))
+	assert.Equal(t, []string{"job"}, g.DependenciesOf("deployment"))
+	assert.Empty(t, g.DetectCycles())
+}
+
+func TestApplyHookOrdering_UnsupportedHooksAreRegular(t *testing.T) {
+	test := makeHook("v1", "Pod", "test", "test", "")
+	cm := makeFullResource("v1", "ConfigMap", "settings", map[string]interface{}{})
+
+	ids := map[*k8s.Resource]string{test: "pod", cm: "configmap"}
+
+	g := transform.BuildDependencyGraph(ids)
+
+	assert.Nil(t, transform.ApplyHookOrdering(g, ids))
+	assert.Equal(t, 0, g.EdgeCount())
+}
@@ -0,0 +1,74 @@
+package transform
+
+import (
+	"strings"
+
+	"github.com/hupe1980/chart2kro/internal/k8s"
+)
+
+// nameRef is a literal resource name in a template that backs a dependency
+// edge, e.g. the name of a Deployment's envFrom configMapRef.
+type nameRef struct {
+	parent map[string]interface{}
+	key    string
+	kind   string
+	target string
+}
+
+// addNameRef resolves the literal name at parent[key] to the chart resource
+// of the given kind, adds the dependency edge, and records the reference so
+// that RewriteNameReferences can replace it with a CEL expression.
+func addNameRef(g *DependencyGraph, sourceID string, nameIndex map[string]string, reason EdgeReason, kind string, parent map[string]interface{}, key string) {
+	name, _ := parent[key].(string)
+	if kind == "" || name == "" {
+		return
+	}
+
+	targetID, ok := nameIndex[kind+"/"+name]
+	if !ok || targetID == sourceID {
+		return
+	}
+
+	g.AddEdgeWithReason(sourceID, targetID, reason)
+	g.refs[sourceID] = append(g.refs[sourceID], nameRef{
+		parent: parent,
+		key:    key,
+		kind:   kind,
+		target: targetID,
+	})
+}
+
+// RewriteNameReferences replaces the literal names behind detected
+// dependency edges with CEL references to the target resource. For example,
+// configMapRef.name: myapp-config becomes ${configmap.metadata.name}, so KRO
+// derives the ordering from the templates and renames propagate. Edges that
+// are not backed by a name, such as Service selectors, are left to dependsOn.
+// It returns the number of rewritten references.
+func (g *DependencyGraph) RewriteNameReferences() int {
+	count := 0
+
+	for _, refs := range g.refs {
+		for _, ref := range refs {
+			name, ok := ref.parent[ref.key].(string)
+			if !ok || strings.Contains(name, "${") {
+				continue
+			}
+
+			ref.parent[ref.key] = nameExpression(ref.target, g.nodes[ref.target], ref.kind)
+			count++
+		}
+	}
+
+	return count
+}
+
+// nameExpression returns the CEL expression for the name of target when it
+// is referenced as a resource of the given kind. Secrets written by a
+// cert-manager Certificate are referenced through its spec.secretName.
+func nameExpression(targetID string, target *k8s.Resource, kind string) string {
+	if kind == "Secret" && target != nil && k8s.IsCertificate(target.GVK) {
+		return ResourceRef(targetID, "spec", "secretName")
+	}
+
+	return ResourceRef(targetID, "metadata", "name")
+}
@@ -0,0 +1,163 @@
+package transform_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/hupe1980/chart2kro/internal/k8s"
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+func nameRefWorkload() *k8s.Resource {
+	return makeFullResource("apps/v1", "Deployment", "myapp", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"serviceAccountName": "myapp",
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name": "app",
+							"envFrom": []interface{}{
+								map[string]interface{}{
+									"configMapRef": map[string]interface{}{"name": "myapp-config"},
+								},
+							},
+							"env": []interface{}{
+								map[string]interface{}{
+									"name": "PASSWORD",
+									"valueFrom": map[string]interface{}{
+										"secretKeyRef": map[string]interface{}{"name": "myapp-secret", "key": "password"},
+									},
+								},
+								map[string]interface{}{
+									"name": "EXTERNAL",
+									"valueFrom": map[string]interface{}{
+										"secretKeyRef": map[string]interface{}{"name": "not-in-chart", "key": "token"},
+									},
+								},
+							},
+						},
+					},
+					"volumes": []interface{}{
+						map[string]interface{}{
+							"name":                  "data",
+							"persistentVolumeClaim": map[string]interface{}{"claimName": "myapp-data"},
+						},
+						map[string]interface{}{
+							"name":   "tls",
+							"secret": map[string]interface{}{"secretName": "myapp-tls"},
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestRewriteNameReferences(t *testing.T) {
+	deploy := nameRefWorkload()
+	resources := map[*k8s.Resource]string{
+		deploy: "deployment",
+		makeFullResource("v1", "ConfigMap", "myapp-config", map[string]interface{}{}):           "configmap",
+		makeFullResource("v1", "Secret", "myapp-secret", map[string]interface{}{}):              "secret",
+		makeFullResource("v1", "ServiceAccount", "myapp", map[string]interface{}{}):             "serviceAccount",
+		makeFullResource("v1", "PersistentVolumeClaim", "myapp-data", map[string]interface{}{}): "pvc",
+		makeFullResource("cert-manager.io/v1", "Certificate", "myapp", map[string]interface{}{
+			"spec": map[string]interface{}{"secretName": "myapp-tls"},
+		}): "certificate",
+	}
+
+	g := transform.BuildDependencyGraph(resources)
+	assert.Equal(t, 5, g.RewriteNameReferences())
+
+	podSpec := deploy.Object.Object["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+
+	assert.Equal(t, "${serviceAccount.metadata.name}", podSpec["serviceAccountName"])
+
+	container := podSpec["containers"].([]interface{})[0].(map[string]interface{})
+
+	envFrom := container["envFrom"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "${configmap.metadata.name}", envFrom["configMapRef"].(map[string]interface{})["name"])
+
+	env := container["env"].([]interface{})
+	assert.Equal(t, "${secret.metadata.name}",
+		env[0].(map[string]interface{})["valueFrom"].(map[string]interface{})["secretKeyRef"].(map[string]interface{})["name"])
+	assert.Equal(t, "not-in-chart",
+		env[1].(map[string]interface{})["valueFrom"].(map[string]interface{})["secretKeyRef"].(map[string]interface{})["name"],
+		"names of resources outside the chart stay literal")
+
+	volumes := podSpec["volumes"].([]interface{})
+	assert.Equal(t, "${pvc.metadata.name}",
+		volumes[0].(map[string]interface{})["persistentVolumeClaim"].(map[string]interface{})["claimName"])
+	assert.Equal(t, "${certificate.spec.secretName}",
+		volumes[1].(map[string]interface{})["secret"].(map[string]interface{})["secretName"],
+		"Secrets written by a Certificate are referenced through spec.secretName")
+
+	// Rewriting is idempotent.
+	assert.Equal(t, 0, g.RewriteNameReferences())
+}
+
+func TestRewriteNameReferences_CRDRefs(t *testing.T) {
+	scaled := makeFullResource("keda.sh/v1alpha1", "ScaledObject", "worker", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{"name": "worker"},
+		},
+	})
+	rollout := makeFullResource("argoproj.io/v1alpha1", "Rollout", "web", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"strategy": map[string]interface{}{
+				"blueGreen": map[string]interface{}{"activeService": "web-active"},
+			},
+		},
+	})
+
+	g := transform.BuildDependencyGraph(map[*k8s.Resource]string{
+		scaled:  "scaledObject",
+		rollout: "rollout",
+		makeFullResource("apps/v1", "Deployment", "worker", map[string]interface{}{}): "deployment",
+		makeFullResource("v1", "Service", "web-active", map[string]interface{}{}):     "activeService",
+	})
+	assert.Equal(t, 2, g.RewriteNameReferences())
+
+	name, _, _ := unstructured.NestedString(scaled.Object.Object, "spec", "scaleTargetRef", "name")
+	assert.Equal(t, "${deployment.metadata.name}", name)
+
+	name, _, _ = unstructured.NestedString(rollout.Object.Object, "spec", "strategy", "blueGreen", "activeService")
+	assert.Equal(t, "${activeService.metadata.name}", name)
+}
+
+func TestEngine_Transform_RewritesNameReferences(t *testing.T) {
+	deploy := makeFullResource("apps/v1", "Deployment", "app", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"volumes": []interface{}{
+						map[string]interface{}{
+							"name":      "config",
+							"configMap": map[string]interface{}{"name": "app-config"},
+						},
+					},
+				},
+			},
+		},
+	})
+	resources := []*k8s.Resource{
+		makeFullResource("v1", "ConfigMap", "app-config", map[string]interface{}{}),
+		deploy,
+	}
+
+	result, err := transform.NewEngine(transform.EngineConfig{}).Transform(context.Background(), resources, nil)
+	require.NoError(t, err)
+
+	cmID := result.ResourceIDs[resources[0]]
+	deployID := result.ResourceIDs[deploy]
+
+	volumes, _, _ := unstructured.NestedSlice(deploy.Object.Object, "spec", "template", "spec", "volumes")
+	require.Len(t, volumes, 1)
+	assert.Equal(t, "${"+cmID+".metadata.name}", volumes[0].(map[string]interface{})["configMap"].(map[string]interface{})["name"])
+	assert.Equal(t, []string{cmID}, result.DependencyGraph.DependenciesOf(deployID))
+}
@@ -0,0 +1,232 @@
+// Package transform - status.go implements config-driven status fields:
+// custom projections spanning multiple resources and an aggregate readiness
+// summary built from each resource's readiness conditions.
+package transform
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hupe1980/chart2kro/internal/k8s"
+)
+
+// DefaultReadinessFieldName is the status field name used for the aggregate
+// readiness summary when none is configured.
+const DefaultReadinessFieldName = "ready"
+
+// StatusConfig configures the top-level status of the generated RGD.
+type StatusConfig struct {
+	// Fields are custom status projections. Expressions may reference any
+	// resource ID (e.g., "${ingress.spec.rules[0].host}").
+	Fields []StatusField
+
+	// DisableDefaults drops the per-resource default status projections.
+	DisableDefaults bool
+
+	// Readiness enables the aggregate readiness summary when non-nil.
+	Readiness *ReadinessSummary
+
+	// ReadyConditions are custom readiness conditions keyed by Kind, as
+	// loaded by LoadCustomReadyConditions. They take precedence over the
+	// built-in defaults when building the readiness summary.
+	ReadyConditions map[string][]string
+}
+
+// ReadinessSummary configures the aggregate readiness status field.
+type ReadinessSummary struct {
+	// Name is the status field name (default: "ready").
+	Name string
+
+	// Kinds restricts the summary to resources of these kinds. When empty,
+	// all resources with readiness conditions are included.
+	Kinds []string
+}
+
+// celReservedIdentifiers are root identifiers that do not refer to resources.
+var celReservedIdentifiers = map[string]bool{
+	"schema": true, "true": true, "false": true, "null": true, "in": true,
+}
+
+// exprPattern matches a single ${...} expression.
+var exprPattern = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// selfRefPattern matches "self." at an identifier boundary.
+var selfRefPattern = regexp.MustCompile(`\bself\.`)
+
+// ExpressionResourceRefs returns the sorted, de-duplicated root identifiers
+// referenced by the ${...} segments of expr, excluding "schema", literals,
+// and function names.
+func ExpressionResourceRefs(expr string) ([]string, error) {
+	if err := ValidateExpression(expr); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+
+	for _, m := range exprPattern.FindAllStringSubmatch(expr, -1) {
+		tokens, err := tokenizeCEL(m[1])
+		if err != nil {
+			return nil, err
+		}
+
+		for i, t := range tokens {
+			if t.kind != celIdent || strings.HasPrefix(t.text, ".") {
+				continue
+			}
+
+			if i+1 < len(tokens) && tokens[i+1].kind == celOp && tokens[i+1].text == "(" && !strings.Contains(t.text, ".") {
+				continue // global function call
+			}
+
+			root := strings.SplitN(t.text, ".", 2)[0]
+			if !celReservedIdentifiers[root] {
+				seen[root] = true
+			}
+		}
+	}
+
+	refs := make([]string, 0, len(seen))
+	for ref := range seen {
+		refs = append(refs, ref)
+	}
+
+	sort.Strings(refs)
+
+	return refs, nil
+}
+
+// BuildStatusFields combines the per-resource projections with the custom
+// fields and readiness summary from cfg. Custom fields replace projections
+// with the same name. Expressions referencing unknown resource IDs are
+// rejected.
+func BuildStatusFields(
+	projections []StatusField,
+	resources []*k8s.Resource,
+	resourceIDs map[*k8s.Resource]string,
+	cfg *StatusConfig,
+) ([]StatusField, error) {
+	if cfg == nil {
+		return projections, nil
+	}
+
+	known := make(map[string]bool, len(resourceIDs))
+	for _, id := range resourceIDs {
+		known[id] = true
+	}
+
+	var fields []StatusField
+	if !cfg.DisableDefaults {
+		fields = append(fields, projections...)
+	}
+
+	index := make(map[string]int, len(fields))
+	for i, f := range fields {
+		index[f.Name] = i
+	}
+
+	custom := make(map[string]bool, len(cfg.Fields))
+
+	for _, f := range cfg.Fields {
+		if custom[f.Name] {
+			return nil, fmt.Errorf("status field %q: declared more than once", f.Name)
+		}
+
+		custom[f.Name] = true
+
+		refs, err := ExpressionResourceRefs(f.CELExpression)
+		if err != nil {
+			return nil, fmt.Errorf("status field %q: %w", f.Name, err)
+		}
+
+		for _, ref := range refs {
+			if !known[ref] {
+				return nil, fmt.Errorf("status field %q: references unknown resource %q", f.Name, ref)
+			}
+		}
+
+		if i, ok := index[f.Name]; ok {
+			fields[i] = f
+		} else {
+			index[f.Name] = len(fields)
+			fields = append(fields, f)
+		}
+	}
+
+	if cfg.Readiness != nil {
+		name := cfg.Readiness.Name
+		if name == "" {
+			name = DefaultReadinessFieldName
+		}
+
+		if custom[name] {
+			return nil, fmt.Errorf("status field %q: conflicts with the readiness summary", name)
+		}
+
+		if expr := AggregateReadiness(resources, resourceIDs, cfg.Readiness.Kinds, cfg.ReadyConditions); expr != "" {
+			ready := StatusField{Name: name, CELExpression: expr}
+
+			if i, ok := index[name]; ok {
+				fields[i] = ready
+			} else {
+				fields = append(fields, ready)
+			}
+		}
+	}
+
+	return fields, nil
+}
+
+// AggregateReadiness builds a single CEL expression that is true when every
+// selected resource satisfies its readiness conditions. Conditions written
+// against "self" are rewritten to reference the resource ID. Returns "" when
+// no selected resource has readiness conditions.
+func AggregateReadiness(
+	resources []*k8s.Resource,
+	resourceIDs map[*k8s.Resource]string,
+	kinds []string,
+	custom map[string][]string,
+) string {
+	kindSet := make(map[string]bool, len(kinds))
+	for _, k := range kinds {
+		kindSet[k] = true
+	}
+
+	ordered := make([]*k8s.Resource, 0, len(resources))
+	for _, r := range resources {
+		if len(kindSet) == 0 || kindSet[r.Kind()] {
+			ordered = append(ordered, r)
+		}
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return resourceIDs[ordered[i]] < resourceIDs[ordered[j]]
+	})
+
+	var parts []string
+
+	for _, r := range ordered {
+		id := resourceIDs[r]
+
+		for _, cond := range ResolveReadyWhen(r.GVK, custom) {
+			inner := unwrapExpression(cond)
+			if inner == "" {
+				continue
+			}
+
+			inner = selfRefPattern.ReplaceAllString(inner, id+".")
+			if strings.ContainsAny(inner, "|?") {
+				inner = "(" + inner + ")"
+			}
+
+			parts = append(parts, inner)
+		}
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return "${" + strings.Join(parts, " && ") + "}"
+}
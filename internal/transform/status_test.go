@@ -0,0 +1,185 @@
+package transform_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/chart2kro/internal/k8s"
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+func statusFixture() ([]*k8s.Resource, map[*k8s.Resource]string) {
+	deploy := makeFullResource("apps/v1", "Deployment", "web", map[string]interface{}{})
+	sts := makeFullResource("apps/v1", "StatefulSet", "db", map[string]interface{}{})
+	ing := makeFullResource("networking.k8s.io/v1", "Ingress", "web", map[string]interface{}{})
+	cm := makeFullResource("v1", "ConfigMap", "cfg", map[string]interface{}{})
+
+	resources := []*k8s.Resource{deploy, sts, ing, cm}
+	ids := map[*k8s.Resource]string{deploy: "deployment", sts: "statefulset", ing: "ingress", cm: "configmap"}
+
+	return resources, ids
+}
+
+func TestExpressionResourceRefs(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"${deployment.status.readyReplicas}", []string{"deployment"}},
+		{`https://${ingress.spec.rules[0].host}${schema.spec.path}`, []string{"ingress"}},
+		{`${has(ingress.spec.tls) ? "https" : "http"}`, []string{"ingress"}},
+		{`${deployment.status.readyReplicas > 0 && statefulset.status.readyReplicas > 0}`, []string{"deployment", "statefulset"}},
+		{`${string(service.spec.ports[0].port)}`, []string{"service"}},
+		{`${schema.spec.name}`, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := transform.ExpressionResourceRefs(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExpressionResourceRefs_Invalid(t *testing.T) {
+	_, err := transform.ExpressionResourceRefs("${deployment.status")
+	assert.Error(t, err)
+}
+
+func TestAggregateReadiness(t *testing.T) {
+	resources, ids := statusFixture()
+
+	expr := transform.AggregateReadiness(resources, ids, nil, nil)
+	assert.Equal(t,
+		"${deployment.status.availableReplicas == deployment.status.replicas && "+
+			"statefulset.status.readyReplicas == statefulset.status.replicas}",
+		expr)
+}
+
+func TestAggregateReadiness_KindsAndCustomConditions(t *testing.T) {
+	resources, ids := statusFixture()
+
+	custom := map[string][]string{
+		"StatefulSet": {"${self.status.readyReplicas == self.spec.replicas}"},
+		"Ingress":     {"${self.status.?loadBalancer.?ingress.hasValue()}"},
+	}
+
+	expr := transform.AggregateReadiness(resources, ids, []string{"StatefulSet", "Ingress"}, custom)
+	assert.Equal(t,
+		"${(ingress.status.?loadBalancer.?ingress.hasValue()) && "+
+			"statefulset.status.readyReplicas == statefulset.spec.replicas}",
+		expr)
+}
+
+func TestAggregateReadiness_NoConditions(t *testing.T) {
+	resources, ids := statusFixture()
+
+	assert.Empty(t, transform.AggregateReadiness(resources, ids, []string{"ConfigMap"}, nil))
+}
+
+func TestBuildStatusFields(t *testing.T) {
+	resources, ids := statusFixture()
+
+	projections := []transform.StatusField{
+		{Name: "deploymentAvailableReplicas", CELExpression: "${deployment.status.availableReplicas}"},
+		{Name: "endpoint", CELExpression: "${deployment.metadata.name}"},
+	}
+
+	cfg := &transform.StatusConfig{
+		Fields: []transform.StatusField{
+			{Name: "endpoint", CELExpression: `${has(ingress.spec.tls) ? "https" : "http"}://${ingress.spec.rules[0].host}`},
+			{Name: "dbReady", CELExpression: "${statefulset.status.readyReplicas > 0}"},
+		},
+		Readiness: &transform.ReadinessSummary{Kinds: []string{"Deployment"}},
+	}
+
+	fields, err := transform.BuildStatusFields(projections, resources, ids, cfg)
+	require.NoError(t, err)
+
+	byName := make(map[string]string)
+	for _, f := range fields {
+		byName[f.Name] = f.CELExpression
+	}
+
+	assert.Len(t, fields, 4)
+	assert.Contains(t, byName, "deploymentAvailableReplicas")
+	assert.Contains(t, byName["endpoint"], "ingress.spec.rules[0].host")
+	assert.Equal(t, "${statefulset.status.readyReplicas > 0}", byName["dbReady"])
+	assert.Equal(t, "${deployment.status.availableReplicas == deployment.status.replicas}", byName["ready"])
+}
+
+func TestBuildStatusFields_DisableDefaults(t *testing.T) {
+	resources, ids := statusFixture()
+
+	fields, err := transform.BuildStatusFields(
+		[]transform.StatusField{{Name: "deploymentAvailableReplicas", CELExpression: "${deployment.status.availableReplicas}"}},
+		resources, ids,
+		&transform.StatusConfig{
+			DisableDefaults: true,
+			Readiness:       &transform.ReadinessSummary{Name: "allReady"},
+		},
+	)
+	require.NoError(t, err)
+	require.Len(t, fields, 1)
+	assert.Equal(t, "allReady", fields[0].Name)
+}
+
+func TestBuildStatusFields_Errors(t *testing.T) {
+	resources, ids := statusFixture()
+
+	tests := []struct {
+		name    string
+		cfg     *transform.StatusConfig
+		wantErr string
+	}{
+		{
+			name: "unknown resource",
+			cfg: &transform.StatusConfig{Fields: []transform.StatusField{
+				{Name: "host", CELExpression: "${route.spec.host}"},
+			}},
+			wantErr: `references unknown resource "route"`,
+		},
+		{
+			name: "duplicate",
+			cfg: &transform.StatusConfig{Fields: []transform.StatusField{
+				{Name: "a", CELExpression: "${deployment.status.replicas}"},
+				{Name: "a", CELExpression: "${deployment.status.replicas}"},
+			}},
+			wantErr: "declared more than once",
+		},
+		{
+			name: "readiness conflict",
+			cfg: &transform.StatusConfig{
+				Fields:    []transform.StatusField{{Name: "ready", CELExpression: "${deployment.status.replicas}"}},
+				Readiness: &transform.ReadinessSummary{},
+			},
+			wantErr: "conflicts with the readiness summary",
+		},
+		{
+			name: "unbalanced",
+			cfg: &transform.StatusConfig{Fields: []transform.StatusField{
+				{Name: "a", CELExpression: "${deployment.status.replicas"},
+			}},
+			wantErr: "unbalanced",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := transform.BuildStatusFields(nil, resources, ids, tt.cfg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestBuildStatusFields_NilConfig(t *testing.T) {
+	projections := []transform.StatusField{{Name: "x", CELExpression: "${a.b}"}}
+
+	fields, err := transform.BuildStatusFields(projections, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, projections, fields)
+}
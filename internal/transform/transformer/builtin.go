@@ -13,8 +13,9 @@ import (
 // DeploymentTransformer
 // ---------------------------------------------------------------------------
 
-// DeploymentTransformer handles Deployment and StatefulSet resources with
-// replica CEL, container image CEL, readyWhen, and status projections.
+// DeploymentTransformer handles Deployment, StatefulSet, and DaemonSet
+// resources with replica CEL, container image CEL, readyWhen, and status
+// projections.
 type DeploymentTransformer struct{}
 
 // Name returns the transformer name.
@@ -27,15 +28,7 @@ func (t *DeploymentTransformer) Matches(gvk schema.GroupVersionKind) bool {
 
 // Transform applies the transformation.
 func (t *DeploymentTransformer) Transform(_ context.Context, input TransformInput) (*TransformOutput, error) {
-	gvk := input.Resource.GVK
-
-	readyWhen := transform.DefaultReadyWhen(gvk)
-	statusFields := transform.DefaultStatusProjections(gvk, input.ResourceID)
-
-	return &TransformOutput{
-		ReadyWhen:    readyWhenToStrings(readyWhen),
-		StatusFields: statusFields,
-	}, nil
+	return defaultOutput(input), nil
 }
 
 // ---------------------------------------------------------------------------
@@ -56,15 +49,7 @@ func (t *ServiceTransformer) Matches(gvk schema.GroupVersionKind) bool {
 
 // Transform applies the transformation.
 func (t *ServiceTransformer) Transform(_ context.Context, input TransformInput) (*TransformOutput, error) {
-	gvk := input.Resource.GVK
-
-	readyWhen := transform.DefaultReadyWhen(gvk)
-	statusFields := transform.DefaultStatusProjections(gvk, input.ResourceID)
-
-	return &TransformOutput{
-		ReadyWhen:    readyWhenToStrings(readyWhen),
-		StatusFields: statusFields,
-	}, nil
+	return defaultOutput(input), nil
 }
 
 // ---------------------------------------------------------------------------
@@ -94,7 +79,8 @@ func (t *ConfigMapTransformer) Transform(_ context.Context, input TransformInput
 
 // DefaultTransformer is the fallback for resource kinds that have no
 // specific transformer. It applies default readyWhen and status projections
-// based on GVK.
+// based on GVK, which cover Jobs, CronJobs, Ingresses, HPAs, PVCs, and the
+// common CRDs (see transform.DefaultReadyWhen).
 type DefaultTransformer struct{}
 
 // Name returns the transformer name.
@@ -107,21 +93,24 @@ func (t *DefaultTransformer) Matches(_ schema.GroupVersionKind) bool {
 
 // Transform applies the transformation.
 func (t *DefaultTransformer) Transform(_ context.Context, input TransformInput) (*TransformOutput, error) {
-	gvk := input.Resource.GVK
-
-	readyWhen := transform.DefaultReadyWhen(gvk)
-	statusFields := transform.DefaultStatusProjections(gvk, input.ResourceID)
-
-	return &TransformOutput{
-		ReadyWhen:    readyWhenToStrings(readyWhen),
-		StatusFields: statusFields,
-	}, nil
+	return defaultOutput(input), nil
 }
 
 // ---------------------------------------------------------------------------
 // helpers
 // ---------------------------------------------------------------------------
 
+// defaultOutput returns the default readiness conditions and status
+// projections for the resource's GVK.
+func defaultOutput(input TransformInput) *TransformOutput {
+	gvk := input.Resource.GVK
+
+	return &TransformOutput{
+		ReadyWhen:    readyWhenToStrings(transform.DefaultReadyWhen(gvk)),
+		StatusFields: transform.DefaultStatusProjections(gvk, input.ResourceID),
+	}
+}
+
 func isDeploymentLike(gvk schema.GroupVersionKind) bool {
 	switch gvk.Kind {
 	case "Deployment", "StatefulSet", "DaemonSet":
@@ -20,18 +20,34 @@ func FromConfigOverride(override config.TransformerOverride) Transformer {
 	return &configOverrideTransformer{override: override}
 }
 
+// FromConfig creates a Transformer from a config entry: a plugin
+// transformer when the entry references a plugin, otherwise a config
+// override.
+func FromConfig(override config.TransformerOverride) (Transformer, error) {
+	if override.Plugin != nil {
+		return NewPlugin(override.Match, *override.Plugin)
+	}
+
+	return FromConfigOverride(override), nil
+}
+
 func (t *configOverrideTransformer) Name() string {
 	return "config:" + t.override.Match.Kind
 }
 
 func (t *configOverrideTransformer) Matches(gvk schema.GroupVersionKind) bool {
-	if gvk.Kind != t.override.Match.Kind {
+	return matchesConfig(t.override.Match, gvk)
+}
+
+// matchesConfig reports whether gvk is selected by a config match.
+func matchesConfig(match config.TransformerMatch, gvk schema.GroupVersionKind) bool {
+	if gvk.Kind != match.Kind {
 		return false
 	}
 
-	if t.override.Match.APIVersion != "" {
+	if match.APIVersion != "" {
 		apiVersion := gvk.GroupVersion().String()
-		return apiVersion == t.override.Match.APIVersion
+		return apiVersion == match.APIVersion
 	}
 
 	return true
@@ -43,6 +59,7 @@ func (t *configOverrideTransformer) Transform(_ context.Context, input Transform
 	// Use config-specified readyWhen, or fall back to defaults.
 	if len(t.override.ReadyWhen) > 0 {
 		out.ReadyWhen = t.override.ReadyWhen
+		out.OverrideReadyWhen = true
 	} else {
 		readyWhen := transform.DefaultReadyWhen(input.Resource.GVK)
 		out.ReadyWhen = readyWhenToStrings(readyWhen)
@@ -0,0 +1,331 @@
+package transformer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/hupe1980/chart2kro/internal/config"
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+// Plugin protocol identification. A plugin reads one PluginRequest as JSON
+// from stdin and writes one PluginResponse as JSON to stdout, once per
+// matching resource.
+const (
+	PluginAPIVersion   = "chart2kro.io/v1"
+	PluginRequestKind  = "TransformRequest"
+	PluginResponseKind = "TransformResponse"
+)
+
+// DefaultPluginTimeout limits a plugin invocation without a configured
+// timeout.
+const DefaultPluginTimeout = 30 * time.Second
+
+// PluginRequest is the JSON document a plugin receives on stdin.
+type PluginRequest struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// ResourceID is the assigned resource ID.
+	ResourceID string `json:"resourceId"`
+	// Resource is the rendered resource, before field mappings, derived
+	// values, and name references are applied, so FieldMappings point at
+	// rendered literals.
+	Resource map[string]interface{} `json:"resource"`
+	// Values is the merged Helm values.
+	Values map[string]interface{} `json:"values"`
+	// FieldMappings are the parameter mappings of this resource.
+	FieldMappings []PluginFieldMapping `json:"fieldMappings"`
+}
+
+// PluginFieldMapping is a template field that is bound to a Helm value.
+type PluginFieldMapping struct {
+	ValuesPath string `json:"valuesPath"`
+	FieldPath  string `json:"fieldPath"`
+	// MatchType is "exact" or "substring".
+	MatchType string `json:"matchType"`
+}
+
+// PluginResponse is the JSON document a plugin writes to stdout. Omitted
+// readyWhen and statusFields fall back to the defaults for the resource
+// kind; empty lists mean none.
+type PluginResponse struct {
+	APIVersion   string              `json:"apiVersion"`
+	Kind         string              `json:"kind"`
+	ReadyWhen    []string            `json:"readyWhen,omitempty"`
+	StatusFields []PluginStatusField `json:"statusFields,omitempty"`
+	IncludeWhen  string              `json:"includeWhen,omitempty"`
+	// Error fails the conversion with the given message.
+	Error string `json:"error,omitempty"`
+}
+
+// PluginStatusField is a status projection returned by a plugin.
+type PluginStatusField struct {
+	Name          string `json:"name"`
+	CELExpression string `json:"celExpression"`
+}
+
+// pluginRunner executes a plugin with the given stdin and returns its
+// stdout.
+type pluginRunner interface {
+	run(ctx context.Context, stdin []byte) ([]byte, error)
+	close(ctx context.Context) error
+}
+
+// errPluginClosed is returned when a closed plugin is run.
+var errPluginClosed = errors.New("plugin is closed")
+
+// pluginTransformer delegates transformation to an external plugin.
+type pluginTransformer struct {
+	match   config.TransformerMatch
+	name    string
+	runner  pluginRunner
+	timeout time.Duration
+}
+
+// NewPlugin creates a Transformer that runs the configured plugin for
+// resources selected by match.
+func NewPlugin(match config.TransformerMatch, cfg config.PluginConfig) (Transformer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("plugin for %s: %w", match.Kind, err)
+	}
+
+	t := &pluginTransformer{match: match, timeout: cfg.TimeoutDuration(DefaultPluginTimeout)}
+
+	if cfg.Exec != "" {
+		path, err := exec.LookPath(cfg.Exec)
+		if err != nil {
+			return nil, fmt.Errorf("plugin for %s: %w", match.Kind, err)
+		}
+
+		t.name = "plugin:" + filepath.Base(path)
+		t.runner = &execRunner{path: path, args: cfg.Args}
+
+		return t, nil
+	}
+
+	if _, err := os.Stat(cfg.WASM); err != nil {
+		return nil, fmt.Errorf("plugin for %s: %w", match.Kind, err)
+	}
+
+	t.name = "plugin:" + filepath.Base(cfg.WASM)
+	t.runner = &wasmRunner{path: cfg.WASM, args: cfg.Args}
+
+	return t, nil
+}
+
+func (t *pluginTransformer) Name() string { return t.name }
+
+func (t *pluginTransformer) Matches(gvk schema.GroupVersionKind) bool {
+	return matchesConfig(t.match, gvk)
+}
+
+// Close releases the plugin runtime.
+func (t *pluginTransformer) Close(ctx context.Context) error {
+	return t.runner.close(ctx)
+}
+
+func (t *pluginTransformer) Transform(ctx context.Context, input TransformInput) (*TransformOutput, error) {
+	req := PluginRequest{
+		APIVersion:    PluginAPIVersion,
+		Kind:          PluginRequestKind,
+		ResourceID:    input.ResourceID,
+		Values:        input.Values,
+		FieldMappings: []PluginFieldMapping{},
+	}
+
+	if input.Resource.Object != nil {
+		req.Resource = input.Resource.Object.Object
+	}
+
+	for _, fm := range input.FieldMappings {
+		if fm.ResourceID != input.ResourceID {
+			continue
+		}
+
+		req.FieldMappings = append(req.FieldMappings, PluginFieldMapping{
+			ValuesPath: fm.ValuesPath,
+			FieldPath:  fm.FieldPath,
+			MatchType:  fm.MatchType.String(),
+		})
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: encoding request: %w", t.name, err)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	out, err := t.runner.run(runCtx, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", t.name, err)
+	}
+
+	var resp PluginResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, fmt.Errorf("%s: decoding response: %w", t.name, err)
+	}
+
+	if resp.Kind != PluginResponseKind {
+		return nil, fmt.Errorf("%s: unexpected response kind %q (want %s)", t.name, resp.Kind, PluginResponseKind)
+	}
+
+	if resp.Error != "" {
+		return nil, fmt.Errorf("%s: %s", t.name, resp.Error)
+	}
+
+	return t.output(input, &resp), nil
+}
+
+// output converts a plugin response, filling omitted fields with defaults.
+func (t *pluginTransformer) output(input TransformInput, resp *PluginResponse) *TransformOutput {
+	gvk := input.Resource.GVK
+	out := &TransformOutput{ReadyWhen: resp.ReadyWhen, OverrideReadyWhen: resp.ReadyWhen != nil, IncludeWhen: resp.IncludeWhen}
+
+	if resp.ReadyWhen == nil {
+		out.ReadyWhen = readyWhenToStrings(transform.DefaultReadyWhen(gvk))
+	}
+
+	if resp.StatusFields == nil {
+		out.StatusFields = transform.DefaultStatusProjections(gvk, input.ResourceID)
+	}
+
+	for _, sf := range resp.StatusFields {
+		out.StatusFields = append(out.StatusFields, transform.StatusField{
+			Name:          sf.Name,
+			CELExpression: sf.CELExpression,
+		})
+	}
+
+	return out
+}
+
+// execRunner runs a plugin executable.
+type execRunner struct {
+	path string
+	args []string
+}
+
+func (r *execRunner) run(ctx context.Context, stdin []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, r.path, r.args...) //nolint:gosec // user-configured plugin
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Do not wait for children that keep the output pipes open after the
+	// plugin was killed on timeout.
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		return nil, withStderr(err, &stderr)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+func (r *execRunner) close(context.Context) error { return nil }
+
+// wasmRunner runs a WASI module. The module is compiled on first use and
+// instantiated once per invocation, with the request on stdin. The runtime
+// is released by close.
+type wasmRunner struct {
+	path string
+	args []string
+
+	once     sync.Once
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	err      error
+}
+
+func (r *wasmRunner) compile() {
+	code, err := os.ReadFile(r.path)
+	if err != nil {
+		r.err = err
+		return
+	}
+
+	ctx := context.Background()
+
+	r.runtime = wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+	wasi_snapshot_preview1.MustInstantiate(ctx, r.runtime)
+
+	r.compiled, err = r.runtime.CompileModule(ctx, code)
+	if err != nil {
+		r.err = fmt.Errorf("compiling %s: %w", r.path, err)
+	}
+}
+
+func (r *wasmRunner) run(ctx context.Context, stdin []byte) ([]byte, error) {
+	r.once.Do(r.compile)
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cfg := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(append([]string{filepath.Base(r.path)}, r.args...)...).
+		WithStdin(bytes.NewReader(stdin)).
+		WithStdout(&stdout).
+		WithStderr(&stderr)
+
+	mod, err := r.runtime.InstantiateModule(ctx, r.compiled, cfg)
+	if mod != nil {
+		_ = mod.Close(ctx)
+	}
+
+	var exitErr *sys.ExitError
+	if err != nil && (!errors.As(err, &exitErr) || exitErr.ExitCode() != 0) {
+		return nil, withStderr(err, &stderr)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+func (r *wasmRunner) close(ctx context.Context) error {
+	// A runner closed before its first use never compiles the module.
+	r.once.Do(func() { r.err = errPluginClosed })
+
+	if r.runtime == nil {
+		return nil
+	}
+
+	err := r.runtime.Close(ctx)
+	r.err = errPluginClosed
+
+	return err
+}
+
+// withStderr appends the plugin's stderr output to err.
+func withStderr(err error, stderr *bytes.Buffer) error {
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+
+	return err
+}
+
+// Compile-time interface check.
+var (
+	_ Transformer = (*pluginTransformer)(nil)
+	_ Closer      = (*pluginTransformer)(nil)
+)
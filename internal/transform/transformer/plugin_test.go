@@ -0,0 +1,228 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/hupe1980/chart2kro/internal/config"
+	"github.com/hupe1980/chart2kro/internal/k8s"
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+var certificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+// writePlugin writes an executable shell plugin and returns its path.
+func writePlugin(t *testing.T, body string) string {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("shell plugins are not supported on Windows")
+	}
+
+	path := filepath.Join(t.TempDir(), "plugin.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755)) //nolint:gosec // test plugin must be executable
+
+	return path
+}
+
+func pluginInput() TransformInput {
+	return TransformInput{
+		Resource:   newResource(certificateGVK, "tls"),
+		ResourceID: "certificate",
+		Values:     map[string]interface{}{"domain": "example.com"},
+		FieldMappings: []transform.FieldMapping{
+			{ValuesPath: "domain", ResourceID: "certificate", FieldPath: "spec.dnsNames[0]"},
+			{ValuesPath: "replicaCount", ResourceID: "deployment", FieldPath: "spec.replicas"},
+		},
+	}
+}
+
+func TestNewPlugin_Validation(t *testing.T) {
+	match := config.TransformerMatch{Kind: "Certificate"}
+
+	_, err := NewPlugin(match, config.PluginConfig{})
+	require.ErrorContains(t, err, "exactly one of exec and wasm")
+
+	_, err = NewPlugin(match, config.PluginConfig{Exec: filepath.Join(t.TempDir(), "missing")})
+	require.Error(t, err)
+
+	_, err = NewPlugin(match, config.PluginConfig{WASM: filepath.Join(t.TempDir(), "missing.wasm")})
+	require.Error(t, err)
+}
+
+func TestExecPlugin_Transform(t *testing.T) {
+	dir := t.TempDir()
+	requestFile := filepath.Join(dir, "request.json")
+
+	plugin := writePlugin(t, `cat > "$1"
+cat <<'JSON'
+{"apiVersion":"chart2kro.io/v1","kind":"TransformResponse",
+ "readyWhen":["${self.status.conditions.exists(c, c.type == 'Ready' && c.status == 'True')}"],
+ "statusFields":[{"name":"certificateNotAfter","celExpression":"${certificate.status.?notAfter}"}],
+ "includeWhen":"${schema.spec.tls.enabled}"}
+JSON`)
+
+	tr, err := FromConfig(config.TransformerOverride{
+		Match:  config.TransformerMatch{Kind: "Certificate", APIVersion: "cert-manager.io/v1"},
+		Plugin: &config.PluginConfig{Exec: plugin, Args: []string{requestFile}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "plugin:plugin.sh", tr.Name())
+	assert.True(t, tr.Matches(certificateGVK))
+	assert.False(t, tr.Matches(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Issuer"}))
+
+	out, err := tr.Transform(context.Background(), pluginInput())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"${self.status.conditions.exists(c, c.type == 'Ready' && c.status == 'True')}"}, out.ReadyWhen)
+	assert.Equal(t, []transform.StatusField{{Name: "certificateNotAfter", CELExpression: "${certificate.status.?notAfter}"}}, out.StatusFields)
+	assert.Equal(t, "${schema.spec.tls.enabled}", out.IncludeWhen)
+
+	data, err := os.ReadFile(requestFile) //nolint:gosec // test file
+	require.NoError(t, err)
+
+	var req PluginRequest
+	require.NoError(t, json.Unmarshal(data, &req))
+	assert.Equal(t, PluginRequestKind, req.Kind)
+	assert.Equal(t, "certificate", req.ResourceID)
+	assert.Equal(t, "Certificate", req.Resource["kind"])
+	assert.Equal(t, "example.com", req.Values["domain"])
+	assert.Equal(t, []PluginFieldMapping{{ValuesPath: "domain", FieldPath: "spec.dnsNames[0]", MatchType: "exact"}}, req.FieldMappings)
+}
+
+func TestExecPlugin_ReceivesRenderedResource(t *testing.T) {
+	// The plugin fails unless the mapped field still holds the rendered
+	// literal rather than the schema reference.
+	plugin := writePlugin(t, `case "$(cat)" in
+*'"dnsNames":["example.com"]'*)
+  echo '{"kind":"TransformResponse","readyWhen":["${self.status.ready}"]}' ;;
+*)
+  echo '{"kind":"TransformResponse","error":"spec.dnsNames[0] is not the rendered literal"}' ;;
+esac`)
+
+	tr, err := NewPlugin(config.TransformerMatch{Kind: "Certificate"}, config.PluginConfig{Exec: plugin})
+	require.NoError(t, err)
+
+	registry := DefaultRegistry()
+	registry.Prepend(tr)
+
+	res := newResource(certificateGVK, "tls")
+	require.NoError(t, unstructured.SetNestedStringSlice(res.Object.Object, []string{"example.com"}, "spec", "dnsNames"))
+
+	result, err := transform.NewEngine(transform.EngineConfig{
+		FieldMappings: []transform.FieldMapping{
+			{ValuesPath: "domain", ResourceID: "certificate", FieldPath: "spec.dnsNames[0]"},
+		},
+		TransformerRegistry: registry,
+	}).Transform(context.Background(), []*k8s.Resource{res}, map[string]interface{}{"domain": "example.com"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"${self.status.ready}"}, result.ReadyWhen["certificate"])
+
+	dnsNames, _, _ := unstructured.NestedStringSlice(result.Resources[0].Object.Object, "spec", "dnsNames")
+	assert.Equal(t, []string{"${schema.spec.domain}"}, dnsNames, "the RGD template is still parameterised")
+}
+
+func TestExecPlugin_Defaults(t *testing.T) {
+	plugin := writePlugin(t, `cat > /dev/null
+echo '{"apiVersion":"chart2kro.io/v1","kind":"TransformResponse","statusFields":[]}'`)
+
+	tr, err := NewPlugin(config.TransformerMatch{Kind: "Certificate"}, config.PluginConfig{Exec: plugin})
+	require.NoError(t, err)
+
+	out, err := tr.Transform(context.Background(), pluginInput())
+	require.NoError(t, err)
+	assert.Equal(t, readyWhenToStrings(transform.DefaultReadyWhen(certificateGVK)), out.ReadyWhen)
+	assert.Empty(t, out.StatusFields)
+}
+
+func TestExecPlugin_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		errMsg string
+	}{
+		{"exit status", `echo "boom" >&2; exit 2`, "boom"},
+		{"invalid JSON", `echo "not json"`, "decoding response"},
+		{"wrong kind", `echo '{"kind":"ResourceList"}'`, "unexpected response kind"},
+		{"reported error", `echo '{"kind":"TransformResponse","error":"unsupported issuer"}'`, "unsupported issuer"},
+		{"timeout", `sleep 5`, "killed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := writePlugin(t, tt.script)
+
+			tr, err := NewPlugin(config.TransformerMatch{Kind: "Certificate"}, config.PluginConfig{Exec: plugin, Timeout: "500ms"})
+			require.NoError(t, err)
+
+			_, err = tr.Transform(context.Background(), pluginInput())
+			require.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestWASMPlugin_Transform(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a WASI module")
+	}
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not available")
+	}
+
+	module := filepath.Join(t.TempDir(), "plugin.wasm")
+
+	build := exec.Command(goBin, "build", "-o", module, "./testdata/plugin") //nolint:gosec // test build
+	build.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm", "CGO_ENABLED=0")
+
+	out, err := build.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	tr, err := FromConfig(config.TransformerOverride{
+		Match:  config.TransformerMatch{Kind: "Certificate"},
+		Plugin: &config.PluginConfig{WASM: module, Args: []string{"Issued"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "plugin:plugin.wasm", tr.Name())
+
+	// Run twice to exercise the compiled module cache.
+	for range 2 {
+		result, err := tr.Transform(context.Background(), pluginInput())
+		require.NoError(t, err)
+		assert.Equal(t, []string{`${self.status.phase == "Issued"}`}, result.ReadyWhen)
+		assert.Equal(t, []transform.StatusField{{Name: "certificatePhase", CELExpression: "${certificate.status.phase}"}}, result.StatusFields)
+	}
+
+	registry := NewRegistry()
+	registry.Register(tr)
+	require.NoError(t, registry.Close(context.Background()))
+
+	_, err = tr.Transform(context.Background(), pluginInput())
+	require.ErrorIs(t, err, errPluginClosed)
+}
+
+func TestPlugin_CloseBeforeUse(t *testing.T) {
+	module := filepath.Join(t.TempDir(), "plugin.wasm")
+	require.NoError(t, os.WriteFile(module, []byte("not wasm"), 0o600))
+
+	tr, err := NewPlugin(config.TransformerMatch{Kind: "Certificate"}, config.PluginConfig{WASM: module})
+	require.NoError(t, err)
+
+	closer, ok := tr.(Closer)
+	require.True(t, ok)
+	require.NoError(t, closer.Close(context.Background()))
+
+	_, err = tr.Transform(context.Background(), pluginInput())
+	require.ErrorIs(t, err, errPluginClosed)
+}
@@ -2,6 +2,8 @@ package transformer
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -69,6 +71,25 @@ func (r *Registry) All() []Transformer {
 	return out
 }
 
+// Close releases the resources of all registered transformers that
+// implement Closer. The registry must not be used afterwards.
+func (r *Registry) Close(ctx context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var errs []error
+
+	for _, t := range r.transformers {
+		if c, ok := t.(Closer); ok {
+			if err := c.Close(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("closing %s: %w", t.Name(), err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 // DefaultRegistry returns a registry pre-populated with the built-in
 // transformers in priority order.
 func DefaultRegistry() *Registry {
@@ -110,9 +131,10 @@ func (r *Registry) TransformResource(
 	}
 
 	return &transform.TransformerOutput{
-		ReadyWhen:    output.ReadyWhen,
-		StatusFields: output.StatusFields,
-		IncludeWhen:  output.IncludeWhen,
+		ReadyWhen:         output.ReadyWhen,
+		OverrideReadyWhen: output.OverrideReadyWhen,
+		StatusFields:      output.StatusFields,
+		IncludeWhen:       output.IncludeWhen,
 	}, nil
 }
 
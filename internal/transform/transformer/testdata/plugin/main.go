@@ -0,0 +1,35 @@
+// Command plugin is a test transformer plugin. It marks resources ready when
+// status.phase equals its first argument and projects the phase to status.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+func main() {
+	var req struct {
+		Kind       string `json:"kind"`
+		ResourceID string `json:"resourceId"`
+	}
+
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	phase := "Ready"
+	if len(os.Args) > 1 {
+		phase = os.Args[1]
+	}
+
+	_ = json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+		"apiVersion": "chart2kro.io/v1",
+		"kind":       "TransformResponse",
+		"readyWhen":  []string{fmt.Sprintf("${self.status.phase == %q}", phase)},
+		"statusFields": []map[string]string{
+			{"name": req.ResourceID + "Phase", "celExpression": "${" + req.ResourceID + ".status.phase}"},
+		},
+	})
+}
@@ -33,6 +33,11 @@ type TransformOutput struct {
 	// ReadyWhen are the readiness conditions (CEL expressions).
 	ReadyWhen []string
 
+	// OverrideReadyWhen reports whether ReadyWhen replaces the per-Kind
+	// defaults and custom ready conditions. Built-in transformers leave it
+	// unset so that --ready-conditions still applies.
+	OverrideReadyWhen bool
+
 	// StatusFields are the status projections.
 	StatusFields []transform.StatusField
 
@@ -53,3 +58,9 @@ type Transformer interface {
 	// Transform applies resource-specific transformation logic.
 	Transform(ctx context.Context, input TransformInput) (*TransformOutput, error)
 }
+
+// Closer is implemented by transformers that hold resources, such as a
+// compiled WASM runtime, which must be released once the engine finishes.
+type Closer interface {
+	Close(ctx context.Context) error
+}
@@ -190,6 +190,116 @@ func TestConfigMapTransformer_Transform(t *testing.T) {
 	assert.Empty(t, out.StatusFields, "ConfigMap has no status fields")
 }
 
+func TestDefaultRegistry_WorkloadsAndCRDs(t *testing.T) {
+	tests := []struct {
+		name        string
+		gvk         schema.GroupVersionKind
+		readyWhen   string
+		statusField string
+	}{
+		{
+			name:        "job",
+			gvk:         schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"},
+			readyWhen:   "self.status.succeeded > 0",
+			statusField: "resSucceeded",
+		},
+		{
+			name:        "cronjob",
+			gvk:         schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "CronJob"},
+			statusField: "resLastScheduleTime",
+		},
+		{
+			name:        "ingress",
+			gvk:         schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+			statusField: "resLoadBalancerIP",
+		},
+		{
+			name:        "hpa",
+			gvk:         schema.GroupVersionKind{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"},
+			readyWhen:   `c.type == "AbleToScale"`,
+			statusField: "resDesiredReplicas",
+		},
+		{
+			name:        "pvc",
+			gvk:         schema.GroupVersionKind{Group: "", Version: "v1", Kind: "PersistentVolumeClaim"},
+			readyWhen:   "Bound",
+			statusField: "resPhase",
+		},
+		{
+			name:        "certificate",
+			gvk:         schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"},
+			readyWhen:   `c.type == "Ready"`,
+			statusField: "resNotAfter",
+		},
+		{
+			name:        "rollout",
+			gvk:         schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"},
+			readyWhen:   `"Healthy"`,
+			statusField: "resPhase",
+		},
+		{
+			name: "servicemonitor",
+			gvk:  schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"},
+		},
+		{
+			name:        "scaledobject",
+			gvk:         schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "ScaledObject"},
+			readyWhen:   `c.type == "Ready"`,
+			statusField: "resHPAName",
+		},
+	}
+
+	registry := DefaultRegistry()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := registry.TransformResource(context.Background(), newResource(tt.gvk, "x"), "res", nil, map[string]interface{}{})
+			require.NoError(t, err)
+
+			if tt.readyWhen == "" {
+				assert.Empty(t, out.ReadyWhen)
+			} else {
+				require.Len(t, out.ReadyWhen, 1)
+				assert.Contains(t, out.ReadyWhen[0], tt.readyWhen)
+			}
+
+			if tt.statusField == "" {
+				assert.Empty(t, out.StatusFields)
+			} else {
+				names := make([]string, 0, len(out.StatusFields))
+				for _, f := range out.StatusFields {
+					names = append(names, f.Name)
+				}
+
+				assert.Contains(t, names, tt.statusField)
+			}
+		})
+	}
+}
+
+func TestDefaultRegistry_BuiltInSelection(t *testing.T) {
+	r := DefaultRegistry()
+
+	tests := []struct {
+		gvk  schema.GroupVersionKind
+		want string
+	}{
+		{schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"}, "deployment"},
+		{schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "DaemonSet"}, "deployment"},
+		{schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "CronJob"}, "default"},
+		{schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "ScaledObject"}, "default"},
+		{schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}, "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.gvk.Kind, func(t *testing.T) {
+			got := r.TransformerFor(tt.gvk)
+			require.NotNil(t, got)
+			assert.Equal(t, tt.want, got.Name())
+		})
+	}
+}
+
 func TestDefaultTransformer_MatchesEverything(t *testing.T) {
 	dt := &DefaultTransformer{}
 
@@ -285,6 +395,7 @@ func TestConfigOverride_Transform_CustomReadyWhenAndStatus(t *testing.T) {
 	})
 	require.NoError(t, err)
 	assert.Equal(t, []string{"${self.status.ready}"}, out.ReadyWhen)
+	assert.True(t, out.OverrideReadyWhen)
 	require.Len(t, out.StatusFields, 1)
 	assert.Equal(t, "ready", out.StatusFields[0].Name)
 	assert.Equal(t, "${deployment.status.readyReplicas}", out.StatusFields[0].CELExpression)
@@ -13,6 +13,10 @@ type TransformerOutput struct {
 	// ReadyWhen are the readiness conditions (raw CEL-style strings).
 	ReadyWhen []string
 
+	// OverrideReadyWhen reports whether ReadyWhen replaces the per-Kind
+	// defaults and custom ready conditions for this resource.
+	OverrideReadyWhen bool
+
 	// StatusFields are the status projections for this resource.
 	StatusFields []StatusField
 
@@ -0,0 +1,145 @@
+package chart2kro
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// BatchChart is a chart of a batch conversion.
+type BatchChart struct {
+	// Ref is the chart reference, as for Convert.
+	Ref string
+	// Options configure the conversion of this chart. They are applied
+	// after the options shared by the batch.
+	Options []Option
+}
+
+// BatchResult is the outcome of converting a chart of a batch. Exactly one
+// of Result and Err is set.
+type BatchResult struct {
+	// Ref is the chart reference.
+	Ref string
+	// Result is the conversion result.
+	Result *Result
+	// Err is the conversion error.
+	Err error
+	// Duration is the time the conversion took.
+	Duration time.Duration
+}
+
+// BatchProgress reports a finished conversion of a batch.
+type BatchProgress struct {
+	// Done is the number of finished conversions, including this one.
+	Done int
+	// Total is the number of charts in the batch.
+	Total int
+	// Result is the outcome of the finished conversion.
+	Result BatchResult
+}
+
+// BatchOption configures ConvertBatch.
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	concurrency int
+	options     []Option
+	progress    func(BatchProgress)
+}
+
+// WithConcurrency sets the maximum number of concurrent conversions
+// (default: the number of CPUs).
+func WithConcurrency(n int) BatchOption { return func(o *batchOptions) { o.concurrency = n } }
+
+// WithSharedOptions sets options applied to the conversion of every chart.
+func WithSharedOptions(opts ...Option) BatchOption {
+	return func(o *batchOptions) { o.options = append(o.options, opts...) }
+}
+
+// WithProgress sets a callback invoked as each conversion finishes. Calls
+// are serialized, in completion order.
+func WithProgress(fn func(BatchProgress)) BatchOption {
+	return func(o *batchOptions) { o.progress = fn }
+}
+
+// ConvertBatch converts the charts concurrently with a bounded worker pool.
+// A failing chart does not stop the others; its error is reported in its
+// result. The results are in the order of charts. When ctx is canceled,
+// charts not yet started fail with the context error.
+//
+//	results := chart2kro.ConvertBatch(ctx, charts,
+//	    chart2kro.WithConcurrency(4),
+//	    chart2kro.WithProgress(func(p chart2kro.BatchProgress) {
+//	        log.Printf("[%d/%d] %s", p.Done, p.Total, p.Result.Ref)
+//	    }),
+//	)
+func ConvertBatch(ctx context.Context, charts []BatchChart, opts ...BatchOption) []BatchResult {
+	o := &batchOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	workers := o.concurrency
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	workers = min(workers, len(charts))
+
+	results := make([]BatchResult, len(charts))
+	jobs := make(chan int)
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+
+	for range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				results[i] = convertBatchChart(ctx, charts[i], o.options)
+
+				mu.Lock()
+				done++
+
+				if o.progress != nil {
+					o.progress(BatchProgress{Done: done, Total: len(charts), Result: results[i]})
+				}
+
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range charts {
+		jobs <- i
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func convertBatchChart(ctx context.Context, c BatchChart, shared []Option) BatchResult {
+	start := time.Now()
+	res := BatchResult{Ref: c.Ref}
+
+	if err := ctx.Err(); err != nil {
+		res.Err = err
+
+		return res
+	}
+
+	opts := append(append([]Option{}, shared...), c.Options...)
+	res.Result, res.Err = Convert(ctx, c.Ref, opts...)
+	res.Duration = time.Since(start)
+
+	return res
+}
@@ -0,0 +1,68 @@
+package chart2kro_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/chart2kro/pkg/chart2kro"
+)
+
+func TestConvertBatch(t *testing.T) {
+	charts := []chart2kro.BatchChart{
+		{Ref: "../../testdata/charts/simple"},
+		{Ref: "../../testdata/charts/nonexistent"},
+		{Ref: "../../testdata/charts/with-database", Options: []chart2kro.Option{chart2kro.WithKind("Database")}},
+	}
+
+	var (
+		mu       sync.Mutex
+		progress []chart2kro.BatchProgress
+	)
+
+	results := chart2kro.ConvertBatch(context.Background(), charts,
+		chart2kro.WithConcurrency(2),
+		chart2kro.WithSharedOptions(chart2kro.WithReleaseName("batch")),
+		chart2kro.WithProgress(func(p chart2kro.BatchProgress) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			progress = append(progress, p)
+		}),
+	)
+
+	require.Len(t, results, 3)
+
+	assert.Equal(t, "../../testdata/charts/simple", results[0].Ref)
+	require.NoError(t, results[0].Err)
+	assert.Contains(t, string(results[0].Result.YAML), "batch-simple")
+
+	require.Error(t, results[1].Err)
+	assert.Nil(t, results[1].Result)
+
+	require.NoError(t, results[2].Err)
+	assert.Contains(t, string(results[2].Result.YAML), "kind: Database")
+
+	require.Len(t, progress, 3)
+
+	for i, p := range progress {
+		assert.Equal(t, i+1, p.Done)
+		assert.Equal(t, 3, p.Total)
+	}
+}
+
+func TestConvertBatch_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := chart2kro.ConvertBatch(ctx, []chart2kro.BatchChart{{Ref: "../../testdata/charts/simple"}})
+	require.Len(t, results, 1)
+	require.ErrorIs(t, results[0].Err, context.Canceled)
+}
+
+func TestConvertBatch_Empty(t *testing.T) {
+	assert.Empty(t, chart2kro.ConvertBatch(context.Background(), nil))
+}
@@ -19,39 +19,36 @@
 //	    chart2kro.WithNamespace("production"),
 //	    chart2kro.WithIncludeAllValues(),
 //	)
+//
+// NewPipeline runs the conversion stage by stage, giving access to the
+// intermediate artifacts and running hooks between stages (see WithHook):
+//
+//	p, err := chart2kro.NewPipeline("path/to/chart")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err := p.Transform(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, m := range p.FieldMappings() {
+//	    fmt.Println(m.ValuesPath, "->", m.ResourceID, m.FieldPath)
+//	}
+//	result, err := p.Run(ctx)
 package chart2kro
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"io"
-	"log/slog"
 	"time"
 
-	sigsyaml "sigs.k8s.io/yaml"
-
-	"github.com/hupe1980/chart2kro/internal/config"
-	"github.com/hupe1980/chart2kro/internal/filter"
+	"github.com/hupe1980/chart2kro/internal/audit"
 	"github.com/hupe1980/chart2kro/internal/harden"
-	"github.com/hupe1980/chart2kro/internal/helm/chartmeta"
-	"github.com/hupe1980/chart2kro/internal/helm/deps"
 	"github.com/hupe1980/chart2kro/internal/helm/hooks"
 	"github.com/hupe1980/chart2kro/internal/helm/loader"
-	"github.com/hupe1980/chart2kro/internal/helm/renderer"
 	"github.com/hupe1980/chart2kro/internal/k8s"
-	"github.com/hupe1980/chart2kro/internal/k8s/parser"
-	"github.com/hupe1980/chart2kro/internal/kro"
-	"github.com/hupe1980/chart2kro/internal/output"
 	"github.com/hupe1980/chart2kro/internal/transform"
-	"github.com/hupe1980/chart2kro/internal/transform/transformer"
 )
 
-// discardLogger returns a logger that discards all output.
-func discardLogger() *slog.Logger {
-	return slog.New(slog.NewTextHandler(io.Discard, nil))
-}
-
 // Option configures the chart-to-RGD conversion pipeline.
 // Use the With* functions to create Options.
 type Option func(*options)
@@ -67,12 +64,25 @@ type options struct {
 	certFile string
 	keyFile  string
 
+	plainHTTP           bool
+	resolveDependencies bool
+	cacheDir            string
+	offline             bool
+	verify              bool
+	keyring             string
+	publicKey           string
+
 	// Template rendering.
 	releaseName string
 	namespace   string
 	strict      bool
 	timeout     time.Duration
 
+	// Post-rendering.
+	postRenderer     string
+	postRendererArgs []string
+	kustomizeDir     string
+
 	// Values merging.
 	valueFiles   []string
 	values       []string
@@ -81,6 +91,8 @@ type options struct {
 
 	// Hook handling.
 	includeHooks bool
+	orderedHooks bool
+	conformance  bool
 
 	// Schema generation.
 	kind             string
@@ -93,6 +105,7 @@ type options struct {
 	excludeKinds       []string
 	excludeResources   []string
 	excludeSubcharts   []string
+	nestedSubcharts    bool
 	excludeLabels      string
 	externalizeSecret  []string
 	externalizeService []string
@@ -106,6 +119,10 @@ type options struct {
 	generateRBAC            bool
 	resolveDigests          bool
 
+	// Auditing.
+	audit      bool
+	auditLevel string
+
 	// Ready conditions.
 	readyConditions string
 
@@ -113,6 +130,8 @@ type options struct {
 	schemaOverrides     map[string]SchemaOverride
 	transformConfigData []byte
 	fast                bool
+
+	hooks map[Stage][]Hook
 }
 
 // --- Chart loading ---
@@ -138,6 +157,40 @@ func WithCertFile(f string) Option { return func(o *options) { o.certFile = f }
 // WithKeyFile sets the TLS client key file.
 func WithKeyFile(f string) Option { return func(o *options) { o.keyFile = f } }
 
+// WithPlainHTTP uses insecure HTTP connections for OCI registries.
+func WithPlainHTTP() Option { return func(o *options) { o.plainHTTP = true } }
+
+// WithResolveDependencies fetches Chart.yaml dependencies that are not
+// vendored in charts/ from their file://, repository, or OCI sources.
+// Versions are pinned by Chart.lock when present.
+func WithResolveDependencies() Option { return func(o *options) { o.resolveDependencies = true } }
+
+// WithCacheDir caches repository indexes and chart archives in dir across
+// conversions (see "chart2kro cache"). By default, nothing is cached.
+func WithCacheDir(dir string) Option { return func(o *options) { o.cacheDir = dir } }
+
+// WithOffline refuses network access; remote charts are served from the
+// cache configured with WithCacheDir.
+func WithOffline() Option { return func(o *options) { o.offline = true } }
+
+// WithVerify requires a valid Helm provenance (.prov) signature for the chart,
+// checked against the PGP keyring file. Chart directories cannot be verified.
+func WithVerify(keyring string) Option {
+	return func(o *options) {
+		o.verify = true
+		o.keyring = keyring
+	}
+}
+
+// WithVerifyPublicKey requires a valid cosign signature for an OCI chart,
+// checked offline against the PEM public key file.
+func WithVerifyPublicKey(path string) Option {
+	return func(o *options) {
+		o.verify = true
+		o.publicKey = path
+	}
+}
+
 // --- Template rendering ---
 
 // WithReleaseName sets the Helm release name (default: "release").
@@ -152,6 +205,22 @@ func WithStrict() Option { return func(o *options) { o.strict = true } }
 // WithTimeout sets the template rendering timeout (default: 30s).
 func WithTimeout(d time.Duration) Option { return func(o *options) { o.timeout = d } }
 
+// --- Post-rendering ---
+
+// WithPostRenderer patches rendered manifests with an executable that follows
+// the Helm post-renderer contract: manifests on stdin, patched manifests on
+// stdout. It runs before any Kustomize overlay.
+func WithPostRenderer(path string, args ...string) Option {
+	return func(o *options) {
+		o.postRenderer = path
+		o.postRendererArgs = args
+	}
+}
+
+// WithKustomizeOverlay applies the Kustomize overlay in dir to rendered
+// manifests. The manifests are added to the overlay's resources.
+func WithKustomizeOverlay(dir string) Option { return func(o *options) { o.kustomizeDir = dir } }
+
 // --- Values merging ---
 
 // WithValueFiles sets paths to additional values files.
@@ -171,6 +240,17 @@ func WithFileValues(vals []string) Option { return func(o *options) { o.fileValu
 // WithIncludeHooks includes Helm hook resources in output.
 func WithIncludeHooks() Option { return func(o *options) { o.includeHooks = true } }
 
+// WithOrderedHooks keeps pre/post-install and pre/post-upgrade hooks as RGD
+// resources ordered by helm.sh/hook-weight via dependsOn, with completion
+// readiness for hook Jobs and Pods. Delete, rollback, and test hooks cannot
+// be represented and are dropped. Takes precedence over WithIncludeHooks.
+func WithOrderedHooks() Option { return func(o *options) { o.orderedHooks = true } }
+
+// WithConformance extracts the Helm test hooks into a conformance RGD,
+// parameterised by the schema of the generated RGD, and returns it in
+// Result.ConformanceYAML. Test hooks are never part of the generated RGD.
+func WithConformance() Option { return func(o *options) { o.conformance = true } }
+
 // --- Schema generation ---
 
 // WithKind overrides the generated CRD kind.
@@ -203,6 +283,12 @@ func WithExcludeSubcharts(subs []string) Option {
 	return func(o *options) { o.excludeSubcharts = subs }
 }
 
+// WithNestedSubcharts converts each vendored subchart, except excluded
+// and externalized ones, into an RGD of its own named "<rgd>-<subchart>",
+// which the generated RGD instantiates. The subchart RGDs are returned in
+// Result.Nested. It cannot be combined with WithFlatSchema.
+func WithNestedSubcharts() Option { return func(o *options) { o.nestedSubcharts = true } }
+
 // WithExcludeLabels excludes resources by label selector.
 func WithExcludeLabels(selector string) Option {
 	return func(o *options) { o.excludeLabels = selector }
@@ -228,7 +314,9 @@ func WithProfile(p string) Option { return func(o *options) { o.profile = p } }
 
 // --- Hardening ---
 
-// WithHarden enables security hardening.
+// WithHarden enables security hardening. The RGD carries SLSA provenance
+// annotations, including the signer verified with WithVerify or
+// WithVerifyPublicKey.
 func WithHarden() Option { return func(o *options) { o.harden = true } }
 
 // WithSecurityLevel sets the hardening security level ("restricted", "baseline", or "none").
@@ -245,6 +333,18 @@ func WithGenerateRBAC() Option { return func(o *options) { o.generateRBAC = true
 // WithResolveDigests resolves image tags to digests.
 func WithResolveDigests() Option { return func(o *options) { o.resolveDigests = true } }
 
+// --- Auditing ---
+
+// WithAudit runs the security and best-practice audit on the rendered
+// resources, as the audit command does, and reports the findings in
+// Result.AuditFindings. An empty level audits at "restricted".
+func WithAudit(securityLevel string) Option {
+	return func(o *options) {
+		o.audit = true
+		o.auditLevel = securityLevel
+	}
+}
+
 // --- Ready conditions ---
 
 // WithReadyConditions sets the path to custom ready conditions file.
@@ -306,6 +406,73 @@ type Result struct {
 
 	// HardenResult holds hardening details when hardening was enabled.
 	HardenResult *HardenSummary
+
+	// Verification describes the verified chart signature when WithVerify
+	// or WithVerifyPublicKey was set.
+	Verification *Verification
+
+	// AuditFindings holds the audit findings when WithAudit was set.
+	AuditFindings []AuditFinding
+
+	// BrokenEdges are the dependency edges removed to resolve cycles when
+	// the config sets dependencies.cycleStrategy.
+	BrokenEdges []BrokenEdge
+
+	// Nested are the subchart RGDs instantiated by the RGD when
+	// WithNestedSubcharts was set, in the order they must be applied,
+	// before the RGD.
+	Nested []NestedRGD
+
+	// ConformanceYAML is the conformance RGD built from the Helm test hooks
+	// when WithConformance was set and the chart has test hooks.
+	ConformanceYAML []byte
+}
+
+// NestedRGD is a subchart converted into an RGD of its own.
+type NestedRGD struct {
+	// Path is the subchart path below the chart, e.g. "backend" or
+	// "backend/cache".
+	Path   string
+	YAML   []byte
+	RGDMap map[string]interface{}
+}
+
+// BrokenEdge is a dependency edge removed to resolve a cycle.
+type BrokenEdge struct {
+	// From depends on To.
+	From string
+	To   string
+	// Reasons are the reasons the edge was detected, e.g. "selector".
+	Reasons []string
+	// Cycle is the cycle the edge was part of, first and last entries equal.
+	Cycle []string
+}
+
+// AuditFinding is a security or best-practice finding for a rendered
+// resource.
+type AuditFinding struct {
+	// RuleID is the audit rule, e.g. "SEC-001".
+	RuleID string
+	// Severity is "critical", "high", "medium", "low", or "info".
+	Severity string
+	// ResourceKind is the kind of the affected resource.
+	ResourceKind string
+	// Resource is the qualified name of the affected resource.
+	Resource    string
+	Message     string
+	Remediation string
+}
+
+// Verification describes a verified chart signature.
+type Verification struct {
+	// Method is "helm-provenance" or "cosign".
+	Method string
+	// Identity is the signer: the PGP user ID, or the public key fingerprint.
+	Identity string
+	// KeyFingerprint is the hex fingerprint of the verifying key.
+	KeyFingerprint string
+	// Digest is the digest covered by the signature.
+	Digest string
 }
 
 // HardenSummary holds a summary of hardening changes applied.
@@ -323,289 +490,58 @@ type HardenSummary struct {
 //
 //	result, err := chart2kro.Convert(ctx, "path/to/chart")
 func Convert(ctx context.Context, chartRef string, opts ...Option) (*Result, error) {
-	if chartRef == "" {
-		return nil, errors.New("chart reference must not be empty")
-	}
-
-	o := &options{}
-	for _, opt := range opts {
-		opt(o)
-	}
-
-	o.applyDefaults()
-
-	logger := discardLogger()
-
-	// 1. Load the chart.
-	multiLoader := loader.NewMultiLoader()
-
-	ch, err := multiLoader.Load(ctx, chartRef, loader.LoadOptions{
-		Version:  o.version,
-		RepoURL:  o.repoURL,
-		Username: o.username,
-		Password: o.password,
-		CaFile:   o.caFile,
-		CertFile: o.certFile,
-		KeyFile:  o.keyFile,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("loading chart: %w", err)
-	}
-
-	// 2. Extract chart metadata.
-	meta := chartmeta.FromChart(ch)
-	if meta.IsLibrary() {
-		return nil, fmt.Errorf("chart %q is a library chart and cannot be converted", meta.Name)
-	}
-
-	// 3. Analyze dependencies.
-	if meta.HasDependencies() {
-		deps.Analyze(ch, logger)
-	}
-
-	// 4. Merge values.
-	mergedVals, err := renderer.MergeValues(ch, renderer.ValuesOptions{
-		ValueFiles:   o.valueFiles,
-		Values:       o.values,
-		StringValues: o.stringValues,
-		FileValues:   o.fileValues,
-	})
+	p, err := NewPipeline(chartRef, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("merging values: %w", err)
-	}
-
-	// 5. Render templates.
-	renderCtx, cancel := context.WithTimeout(ctx, o.timeout)
-	defer cancel()
-
-	helmRenderer := renderer.New(renderer.RenderOptions{
-		ReleaseName: o.releaseName,
-		Namespace:   o.namespace,
-		Strict:      o.strict,
-	})
-
-	needsSources := len(o.excludeSubcharts) > 0 || o.profile != "" || len(o.useExternalPattern) > 0
-
-	var rendered []byte
-
-	var sourcedManifests []renderer.SourcedManifest
-
-	if needsSources {
-		sourcedManifests, err = helmRenderer.RenderWithSources(renderCtx, ch, mergedVals)
-		if err != nil {
-			return nil, fmt.Errorf("rendering templates: %w", err)
-		}
-
-		rendered = renderer.CombineSourcedManifests(sourcedManifests)
-	} else {
-		rendered, err = helmRenderer.Render(renderCtx, ch, mergedVals)
-		if err != nil {
-			return nil, fmt.Errorf("rendering templates: %w", err)
-		}
-	}
-
-	// 6. Filter hooks.
-	hookResult, err := hooks.Filter(rendered, o.includeHooks, logger)
-	if err != nil {
-		return nil, fmt.Errorf("filtering hooks: %w", err)
-	}
-
-	// 7. Parse rendered YAML into K8s resources.
-	combined := hooks.CombineResources(hookResult)
-	k8sParser := parser.NewParser()
-
-	resources, err := k8sParser.Parse(ctx, combined)
-	if err != nil {
-		return nil, fmt.Errorf("parsing resources: %w", err)
-	}
-
-	if len(resources) == 0 {
-		return nil, fmt.Errorf("no resources found in rendered output")
-	}
-
-	if len(sourcedManifests) > 0 {
-		assignResourceSourcePaths(resources, sourcedManifests)
-	}
-
-	// 7b. Apply resource filters.
-	filterChain, err := buildFilterChain(o, meta, mergedVals, resources)
-	if err != nil {
-		return nil, fmt.Errorf("building filter chain: %w", err)
-	}
-
-	if filterChain != nil {
-		filterResult, filterErr := filterChain.Apply(ctx, resources)
-		if filterErr != nil {
-			return nil, fmt.Errorf("applying filters: %w", filterErr)
-		}
-
-		resources = filterResult.Included
-	}
-
-	if len(resources) == 0 {
-		return nil, fmt.Errorf("no resources remaining after filtering")
-	}
-
-	// 7c. Load extensibility config.
-	var transformCfg *config.TransformConfig
-
-	if len(o.transformConfigData) > 0 {
-		parsed, parseErr := config.ParseTransformConfig(o.transformConfigData)
-		if parseErr == nil && !parsed.IsEmpty() {
-			transformCfg = parsed
-		}
-	}
-
-	// Merge resource ID overrides from options and config.
-	resourceIDOverrides := mergeResourceIDOverrides(o.resourceIDOverrides, transformCfg)
-
-	tempIDs, err := transform.AssignResourceIDs(resources, resourceIDOverrides)
-	if err != nil {
-		return nil, fmt.Errorf("assigning resource IDs: %w", err)
-	}
-
-	// 8. Detect parameter mappings.
-	var fieldMappings []transform.FieldMapping
-
-	referencedPaths := make(map[string]bool)
-
-	if o.fast {
-		templateFiles := make(map[string]string)
-		for _, t := range ch.Templates {
-			templateFiles[t.Name] = string(t.Data)
-		}
-
-		astRefs, astErr := transform.AnalyzeTemplates(templateFiles)
-		if astErr == nil {
-			referencedPaths = astRefs
-			fieldMappings = transform.MatchFieldsByValue(resources, tempIDs, mergedVals, referencedPaths)
-		}
-	} else {
-		fullSentinelValues := transform.SentinelizeAll(mergedVals)
-
-		fullSentinelRendered, fullSentErr := helmRenderer.Render(renderCtx, ch, fullSentinelValues)
-
-		var fullSentinelResources []*k8s.Resource
-		if fullSentErr == nil {
-			fullHookResult, hookErr := hooks.Filter(fullSentinelRendered, o.includeHooks, logger)
-			if hookErr == nil {
-				fullSentinelCombined := hooks.CombineResources(fullHookResult)
-
-				parsed, parseErr := k8sParser.Parse(ctx, fullSentinelCombined)
-				if parseErr == nil {
-					fullSentinelResources = parsed
-				}
-			}
-		}
-
-		fieldMappings = transform.ParallelDiffAllResources(
-			resources, fullSentinelResources, tempIDs, transform.ParallelDiffConfig{},
-		)
-
-		for _, m := range fieldMappings {
-			referencedPaths[m.ValuesPath] = true
-		}
+		return nil, err
 	}
 
-	// 9. Run transformation pipeline.
-	engineCfg := transform.EngineConfig{
-		IncludeAllValues:    o.includeAllValues,
-		FlatSchema:          o.flatSchema,
-		FieldMappings:       fieldMappings,
-		ReferencedPaths:     referencedPaths,
-		JSONSchemaBytes:     meta.Schema,
-		ResourceIDOverrides: resourceIDOverrides,
-	}
+	return p.Run(ctx)
+}
 
-	// Apply schema overrides from options.
-	if len(o.schemaOverrides) > 0 {
-		engineCfg.SchemaOverrides = toTransformSchemaOverrides(o.schemaOverrides)
+// brokenEdges converts the broken cycle edges to the public type.
+func brokenEdges(edges []transform.BrokenEdge) []BrokenEdge {
+	if len(edges) == 0 {
+		return nil
 	}
 
-	// Apply config-based overrides.
-	if transformCfg != nil {
-		if len(transformCfg.SchemaOverrides) > 0 && len(engineCfg.SchemaOverrides) == 0 {
-			engineCfg.SchemaOverrides = configToSchemaOverrides(transformCfg.SchemaOverrides)
-		}
+	out := make([]BrokenEdge, len(edges))
 
-		registry := transformer.DefaultRegistry()
-		for i := len(transformCfg.Transformers) - 1; i >= 0; i-- {
-			registry.Prepend(transformer.FromConfigOverride(transformCfg.Transformers[i]))
+	for i, e := range edges {
+		reasons := make([]string, len(e.Reasons))
+		for j, r := range e.Reasons {
+			reasons[j] = string(r)
 		}
 
-		engineCfg.TransformerRegistry = registry
-	}
-
-	engine := transform.NewEngine(engineCfg)
-
-	result, err := engine.Transform(ctx, resources, mergedVals)
-	if err != nil {
-		return nil, fmt.Errorf("transformation failed: %w", err)
+		out[i] = BrokenEdge{From: e.From, To: e.To, Reasons: reasons, Cycle: e.Cycle}
 	}
 
-	// 9b. Security hardening (optional).
-	var hardenSummary *HardenSummary
-
-	if o.harden {
-		hardenSummary, err = applyHardening(ctx, o, result)
-		if err != nil {
-			return nil, fmt.Errorf("hardening failed: %w", err)
-		}
-	}
-
-	// 10. Generate RGD.
-	var customReadyConditions map[string][]string
-	if o.readyConditions != "" {
-		customReadyConditions, err = transform.LoadCustomReadyConditions(o.readyConditions)
-		if err != nil {
-			return nil, fmt.Errorf("loading ready conditions: %w", err)
-		}
-	}
+	return out
+}
 
-	generator := kro.NewGenerator(kro.GeneratorConfig{
-		Name:                  meta.Name,
-		ChartName:             meta.Name,
-		ChartVersion:          meta.Version,
-		SchemaKind:            o.kind,
-		SchemaAPIVersion:      o.apiVersion,
-		SchemaGroup:           o.group,
-		SchemaFields:          result.SchemaFields,
-		StatusFields:          result.StatusFields,
-		CustomReadyConditions: customReadyConditions,
-	})
-
-	rgd, err := generator.Generate(result.DependencyGraph)
-	if err != nil {
-		return nil, fmt.Errorf("generating RGD: %w", err)
+// verificationResult converts a loader verification to the public type.
+func verificationResult(v *loader.Verification) *Verification {
+	if v == nil {
+		return nil
 	}
 
-	rgdMap := rgd.ToMap()
-
-	// Serialize to YAML.
-	yamlBytes, err := output.Serialize(rgdMap, output.SerializeOptions{
-		Comments: false,
-		Indent:   2,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("serializing RGD: %w", err)
+	return &Verification{
+		Method:         v.Method,
+		Identity:       v.Identity,
+		KeyFingerprint: v.KeyFingerprint,
+		Digest:         v.Digest,
 	}
+}
 
-	// Normalize via round-trip.
-	var normalized map[string]interface{}
-	if unmarshalErr := sigsyaml.Unmarshal(yamlBytes, &normalized); unmarshalErr == nil {
-		rgdMap = normalized
+// hookMode returns the hook handling mode selected by the options.
+func (o *options) hookMode() hooks.Mode {
+	switch {
+	case o.orderedHooks:
+		return hooks.ModeOrdered
+	case o.includeHooks:
+		return hooks.ModeInclude
+	default:
+		return hooks.ModeDrop
 	}
-
-	return &Result{
-		YAML:             yamlBytes,
-		RGDMap:           rgdMap,
-		ChartName:        meta.Name,
-		ChartVersion:     meta.Version,
-		ResourceCount:    len(result.Resources),
-		SchemaFieldCount: len(result.SchemaFields),
-		DependencyEdges:  result.DependencyGraph.EdgeCount(),
-		HardenResult:     hardenSummary,
-	}, nil
 }
 
 // applyDefaults sets zero-value fields to sensible defaults.
@@ -623,25 +559,6 @@ func (o *options) applyDefaults() {
 	}
 }
 
-// mergeResourceIDOverrides merges Options overrides with config overrides.
-// Options overrides take precedence.
-func mergeResourceIDOverrides(optsOverrides map[string]string, cfg *config.TransformConfig) map[string]string {
-	if cfg == nil || len(cfg.ResourceIDOverrides) == 0 {
-		return optsOverrides
-	}
-
-	merged := make(map[string]string, len(cfg.ResourceIDOverrides)+len(optsOverrides))
-	for k, v := range cfg.ResourceIDOverrides {
-		merged[k] = v
-	}
-
-	for k, v := range optsOverrides {
-		merged[k] = v
-	}
-
-	return merged
-}
-
 // toTransformSchemaOverrides converts public SchemaOverride to internal.
 func toTransformSchemaOverrides(overrides map[string]SchemaOverride) map[string]transform.SchemaOverride {
 	result := make(map[string]transform.SchemaOverride, len(overrides))
@@ -660,186 +577,30 @@ func toTransformSchemaOverrides(overrides map[string]SchemaOverride) map[string]
 	return result
 }
 
-// configToSchemaOverrides converts config schema overrides to internal.
-func configToSchemaOverrides(overrides map[string]config.SchemaOverride) map[string]transform.SchemaOverride {
-	result := make(map[string]transform.SchemaOverride, len(overrides))
-	for path, o := range overrides {
-		result[path] = transform.SchemaOverride{
-			Type:    o.Type,
-			Default: o.Default,
-		}
+// runAudit runs the default audit checks for the security level.
+func runAudit(ctx context.Context, level string, resources []*k8s.Resource) ([]AuditFinding, error) {
+	if level == "" {
+		level = string(harden.SecurityLevelRestricted)
 	}
 
-	return result
-}
-
-// applyHardening applies security hardening to the transformation result.
-func applyHardening(ctx context.Context, opts *options, result *transform.Result) (*HardenSummary, error) {
-	secLevel, err := harden.ParseSecurityLevel(opts.securityLevel)
+	secLevel, err := harden.ParseSecurityLevel(level)
 	if err != nil {
 		return nil, err
 	}
 
-	hardenCfg := harden.Config{
-		SecurityLevel:           secLevel,
-		GenerateNetworkPolicies: opts.generateNetworkPolicies,
-		GenerateRBAC:            opts.generateRBAC,
-		ResolveDigests:          opts.resolveDigests,
-		ResourceIDs:             result.ResourceIDs,
-	}
+	result := audit.New(audit.DefaultChecks(secLevel)...).Run(ctx, resources)
 
-	if len(opts.transformConfigData) > 0 {
-		if fileCfg, parseErr := harden.ParseFileConfig(opts.transformConfigData); parseErr == nil && fileCfg != nil {
-			hardenCfg.ImagePolicy = fileCfg.ToImagePolicyConfig()
-			hardenCfg.ResourceDefaults = fileCfg.ToResourceDefaultsConfig()
-		}
+	findings := make([]AuditFinding, 0, len(result.Findings))
+	for _, f := range result.Findings {
+		findings = append(findings, AuditFinding{
+			RuleID:       f.RuleID,
+			Severity:     f.Severity.String(),
+			ResourceKind: f.ResourceKind,
+			Resource:     f.ResourceID,
+			Message:      f.Message,
+			Remediation:  f.Remediation,
+		})
 	}
 
-	hardener := harden.New(hardenCfg)
-
-	hardenResult, err := hardener.Harden(ctx, result.Resources)
-	if err != nil {
-		return nil, err
-	}
-
-	result.Resources = hardenResult.Resources
-
-	return &HardenSummary{
-		Changes:  len(hardenResult.Changes),
-		Warnings: hardenResult.Warnings,
-	}, nil
-}
-
-// buildFilterChain constructs the filter chain from options.
-func buildFilterChain(opts *options, meta *chartmeta.ChartMeta, mergedVals map[string]interface{}, resources []*k8s.Resource) (*filter.Chain, error) {
-	var filters []filter.Filter
-
-	// 1. Profile-based filters.
-	if opts.profile != "" {
-		if filter.IsMinimalProfile(opts.profile) {
-			filters = append(filters, filter.NewMinimalFilter())
-		} else {
-			var customProfiles map[string]filter.ProfileConfig
-			if len(opts.transformConfigData) > 0 {
-				customProfiles, _ = filter.ParseCustomProfiles(opts.transformConfigData)
-			}
-
-			p, err := filter.ResolveProfile(opts.profile, customProfiles)
-			if err != nil {
-				return nil, err
-			}
-
-			profileFilters, err := filter.BuildFiltersFromProfile(p)
-			if err != nil {
-				return nil, fmt.Errorf("building filters from profile %q: %w", opts.profile, err)
-			}
-
-			filters = append(filters, profileFilters...)
-		}
-	}
-
-	// 2. Smart external pattern detection.
-	for _, name := range opts.useExternalPattern {
-		pattern, found := filter.DetectExternalPatternsForSubchart(meta, mergedVals, name)
-		if !found {
-			return nil, fmt.Errorf("no external pattern detected for subchart %q", name)
-		}
-
-		patternFilters := filter.BuildFiltersFromPattern(pattern)
-		filters = append(filters, patternFilters...)
-	}
-
-	// 3. Explicit subchart exclusion.
-	if len(opts.excludeSubcharts) > 0 {
-		filters = append(filters, filter.NewSubchartFilter(opts.excludeSubcharts))
-	}
-
-	// 4. Kind exclusion.
-	if len(opts.excludeKinds) > 0 {
-		filters = append(filters, filter.NewKindFilter(opts.excludeKinds))
-	}
-
-	// 5. Label exclusion.
-	if opts.excludeLabels != "" {
-		lf, err := filter.NewLabelFilter(opts.excludeLabels)
-		if err != nil {
-			return nil, fmt.Errorf("parsing label selector: %w", err)
-		}
-
-		filters = append(filters, lf)
-	}
-
-	// 6. Resource ID exclusion.
-	if len(opts.excludeResources) > 0 {
-		tempIDs, err := transform.AssignResourceIDs(resources, nil)
-		if err != nil {
-			return nil, fmt.Errorf("assigning IDs for resource exclusion: %w", err)
-		}
-
-		filters = append(filters, filter.NewResourceIDFilter(opts.excludeResources, tempIDs))
-	}
-
-	// 7. ExternalRef promotion.
-	var mappings []filter.ExternalMapping
-
-	for _, expr := range opts.externalizeSecret {
-		m, err := filter.ParseExternalMapping("Secret", expr)
-		if err != nil {
-			return nil, err
-		}
-
-		mappings = append(mappings, m)
-	}
-
-	for _, expr := range opts.externalizeService {
-		m, err := filter.ParseExternalMapping("Service", expr)
-		if err != nil {
-			return nil, err
-		}
-
-		mappings = append(mappings, m)
-	}
-
-	if len(mappings) > 0 {
-		filters = append(filters, filter.NewExternalRefFilter(mappings))
-	}
-
-	if len(filters) == 0 {
-		return nil, nil //nolint:nilnil // no filters needed
-	}
-
-	return filter.NewChain(filters...), nil
-}
-
-// assignResourceSourcePaths sets SourcePath on resources that match sourced manifests.
-// Each sourced manifest may contain multiple YAML documents, so we parse each
-// manifest's content and build a Kind/Name → path lookup.
-func assignResourceSourcePaths(resources []*k8s.Resource, sourced []renderer.SourcedManifest) {
-	pathLookup := make(map[string]string)
-
-	for _, sm := range sourced {
-		docs := parser.SplitDocuments([]byte(sm.Content))
-		for _, doc := range docs {
-			var obj map[string]interface{}
-			if err := sigsyaml.Unmarshal(doc, &obj); err != nil {
-				continue
-			}
-
-			kind, _ := obj["kind"].(string)
-
-			meta, _ := obj["metadata"].(map[string]interface{})
-			name, _ := meta["name"].(string)
-
-			if kind != "" && name != "" {
-				pathLookup[kind+"/"+name] = sm.TemplatePath
-			}
-		}
-	}
-
-	for _, res := range resources {
-		key := res.Kind() + "/" + res.Name
-		if path, ok := pathLookup[key]; ok {
-			res.SourcePath = path
-		}
-	}
+	return findings, nil
 }
@@ -2,6 +2,8 @@ package chart2kro_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -86,6 +88,73 @@ func TestConvert_SchemaOverrides(t *testing.T) {
 	assert.NotEmpty(t, result.YAML)
 }
 
+func TestConvert_DerivedValues(t *testing.T) {
+	cfg := []byte(`
+derivedValues:
+  inputs:
+    size:
+      type: string
+      default: "\"small\""
+  values:
+    replicaCount:
+      expression: 'schema.spec.size == "large" ? 3 : 1'
+`)
+
+	result, err := chart2kro.Convert(context.Background(), "../../testdata/charts/simple",
+		chart2kro.WithTransformConfigData(cfg),
+	)
+	require.NoError(t, err)
+
+	yaml := string(result.YAML)
+	assert.Contains(t, yaml, `${schema.spec.size == "large" ? 3 : 1}`)
+	assert.Contains(t, yaml, `size: string | default="small"`)
+	assert.NotContains(t, yaml, "replicaCount:")
+}
+
+func TestConvert_VerifyRequiresPackagedChart(t *testing.T) {
+	_, err := chart2kro.Convert(context.Background(), "../../testdata/charts/simple",
+		chart2kro.WithVerify(filepath.Join(t.TempDir(), "pubring.gpg")),
+	)
+	require.ErrorContains(t, err, "cannot verify chart directory")
+}
+
+func TestConvert_OrderedHooks(t *testing.T) {
+	result, err := chart2kro.Convert(context.Background(), "../../testdata/charts/with-hooks",
+		chart2kro.WithOrderedHooks(),
+	)
+	require.NoError(t, err)
+
+	yaml := string(result.YAML)
+	assert.Contains(t, yaml, "kind: Job")
+	assert.NotContains(t, yaml, "helm.sh/hook")
+	assert.Contains(t, yaml, "dependsOn:\n    - job")
+	assert.Contains(t, yaml, "${self.status.succeeded > 0}")
+}
+
+func TestConvert_StatusConfig(t *testing.T) {
+	cfg := []byte(`
+status:
+  disableDefaults: true
+  fields:
+    - name: endpoint
+      celExpression: "${service.spec.clusterIP}"
+  readiness:
+    enabled: true
+`)
+
+	result, err := chart2kro.Convert(context.Background(), "../../testdata/charts/simple",
+		chart2kro.WithTransformConfigData(cfg),
+	)
+	require.NoError(t, err)
+
+	spec := result.RGDMap["spec"].(map[string]interface{})
+	status := spec["schema"].(map[string]interface{})["status"].(map[string]interface{})
+
+	assert.Len(t, status, 2)
+	assert.Equal(t, "${service.spec.clusterIP}", status["endpoint"])
+	assert.Equal(t, "${deployment.status.availableReplicas == deployment.status.replicas && service.spec.clusterIP != \"\"}", status["ready"])
+}
+
 func TestConvert_MultipleOptions(t *testing.T) {
 	result, err := chart2kro.Convert(context.Background(), "../../testdata/charts/simple",
 		chart2kro.WithReleaseName("multi"),
@@ -122,3 +191,101 @@ func TestResult_HasExpectedStructure(t *testing.T) {
 	_, ok = result.RGDMap["spec"]
 	assert.True(t, ok, "RGDMap should have spec")
 }
+
+func TestConvert_KustomizeOverlay(t *testing.T) {
+	overlay := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(overlay, "kustomization.yaml"), []byte(`patches:
+  - target:
+      kind: Deployment
+    patch: |-
+      - op: add
+        path: /spec/template/spec/containers/-
+        value:
+          name: proxy
+          image: envoyproxy/envoy:v1.30
+`), 0o644))
+
+	result, err := chart2kro.Convert(context.Background(), "../../testdata/charts/simple",
+		chart2kro.WithKustomizeOverlay(overlay),
+	)
+	require.NoError(t, err)
+
+	yaml := string(result.YAML)
+	assert.Contains(t, yaml, "image: envoyproxy/envoy:v1.30")
+	assert.Contains(t, yaml, "image: ${schema.spec.image.repository}:${schema.spec.image.tag}")
+}
+
+func TestConvert_PostRendererNotFound(t *testing.T) {
+	_, err := chart2kro.Convert(context.Background(), "../../testdata/charts/simple",
+		chart2kro.WithPostRenderer(filepath.Join(t.TempDir(), "missing")),
+	)
+	require.ErrorContains(t, err, "post-renderer")
+}
+
+func TestConvert_Audit(t *testing.T) {
+	result, err := chart2kro.Convert(context.Background(), "../../testdata/charts/simple",
+		chart2kro.WithAudit(""),
+	)
+	require.NoError(t, err)
+	require.NotEmpty(t, result.AuditFindings)
+
+	f := result.AuditFindings[0]
+	assert.NotEmpty(t, f.RuleID)
+	assert.NotEmpty(t, f.Severity)
+	assert.NotEmpty(t, f.Resource)
+
+	_, err = chart2kro.Convert(context.Background(), "../../testdata/charts/simple",
+		chart2kro.WithAudit("paranoid"),
+	)
+	require.ErrorContains(t, err, "auditing")
+}
+
+func TestConvert_NestedSubcharts(t *testing.T) {
+	result, err := chart2kro.Convert(context.Background(), "../../testdata/charts/with-aliases",
+		chart2kro.WithNestedSubcharts(),
+	)
+	require.NoError(t, err)
+
+	require.Len(t, result.Nested, 2)
+	assert.Equal(t, "queue", result.Nested[0].Path)
+	assert.Equal(t, "sessions", result.Nested[1].Path)
+	assert.Contains(t, string(result.Nested[0].YAML), "name: with-aliases-queue")
+
+	assert.Contains(t, string(result.YAML), "kind: WithAliasesQueue")
+	assert.Contains(t, string(result.YAML), "kind: WithAliasesSessions")
+}
+
+func TestConvert_NestedSubchartsFlatSchema(t *testing.T) {
+	_, err := chart2kro.Convert(context.Background(), "../../testdata/charts/with-aliases",
+		chart2kro.WithNestedSubcharts(),
+		chart2kro.WithFlatSchema(),
+	)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "flat schema")
+}
+
+func TestConvert_Conformance(t *testing.T) {
+	result, err := chart2kro.Convert(context.Background(), "../../testdata/charts/with-hooks",
+		chart2kro.WithConformance(),
+	)
+	require.NoError(t, err)
+
+	conformance := string(result.ConformanceYAML)
+	assert.Contains(t, conformance, "name: with-hooks-conformance")
+	assert.Contains(t, conformance, "kind: Pod")
+	assert.NotContains(t, string(result.YAML), "helm.sh/hook")
+
+	result, err = chart2kro.Convert(context.Background(), "../../testdata/charts/with-hooks")
+	require.NoError(t, err)
+	assert.Nil(t, result.ConformanceYAML)
+}
+
+func TestConvert_HardenProvenance(t *testing.T) {
+	result, err := chart2kro.Convert(context.Background(), "../../testdata/charts/simple", chart2kro.WithHarden())
+	require.NoError(t, err)
+
+	metadata, _ := result.RGDMap["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	assert.Equal(t, "../../testdata/charts/simple", annotations["chart2kro.io/source"])
+	assert.Contains(t, annotations, "chart2kro.io/provenance")
+}
@@ -0,0 +1,557 @@
+package chart2kro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hupe1980/chart2kro/internal/helm/cache"
+	"github.com/hupe1980/chart2kro/internal/helm/loader"
+	"github.com/hupe1980/chart2kro/internal/helm/postrender"
+	"github.com/hupe1980/chart2kro/internal/helm/renderer"
+	"github.com/hupe1980/chart2kro/internal/k8s"
+	"github.com/hupe1980/chart2kro/internal/kro"
+	"github.com/hupe1980/chart2kro/internal/pipeline"
+	"github.com/hupe1980/chart2kro/internal/transform"
+)
+
+// Stage names a conversion pipeline stage.
+type Stage string
+
+// Pipeline stages, in the order they run.
+const (
+	// StageLoad loads the chart and analyzes its dependencies.
+	StageLoad Stage = Stage(pipeline.StageLoad)
+	// StageRender merges the values, renders and post-renders the
+	// templates, handles hooks, and parses the resources.
+	StageRender Stage = Stage(pipeline.StageRender)
+	// StageFilter applies the resource filters and, with WithAudit, audits
+	// the remaining resources.
+	StageFilter Stage = Stage(pipeline.StageFilter)
+	// StageTransform detects parameters and builds the schema, CEL
+	// references, and dependency graph.
+	StageTransform Stage = Stage(pipeline.StageTransform)
+	// StageHarden applies security hardening when WithHarden is set.
+	StageHarden Stage = Stage(pipeline.StageHarden)
+	// StageGenerate generates the RGD.
+	StageGenerate Stage = Stage(pipeline.StageGenerate)
+)
+
+// Hook runs between pipeline stages. It can inspect the artifacts of the
+// stages run so far and modify the resources, e.g. through
+// Resource.Object. An error aborts the pipeline.
+type Hook func(ctx context.Context, p *Pipeline) error
+
+// WithHook runs hook after the stage. Hooks of the same stage run in the
+// order they were added.
+func WithHook(after Stage, hook Hook) Option {
+	return func(o *options) {
+		if o.hooks == nil {
+			o.hooks = make(map[Stage][]Hook)
+		}
+
+		o.hooks[after] = append(o.hooks[after], hook)
+	}
+}
+
+// Pipeline converts a chart in stages, giving access to the intermediate
+// artifacts. It runs the same pipeline as the chart2kro CLI.
+//
+// Each stage method runs the earlier stages that have not run yet; calling
+// a stage that has run is a no-op. Once a stage or hook fails, every
+// further call returns its error.
+//
+//	p, err := chart2kro.NewPipeline("path/to/chart", chart2kro.WithProfile("app-only"))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err := p.Filter(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, ex := range p.FilterResult().Excluded {
+//	    fmt.Println(ex.Resource.Kind, ex.Resource.Name, ex.Reason)
+//	}
+//	result, err := p.Run(ctx)
+type Pipeline struct {
+	p             *pipeline.Pipeline
+	opts          *options
+	auditFindings []AuditFinding
+}
+
+// NewPipeline returns a pipeline converting chartRef. The chartRef can be a
+// local directory, .tgz archive path, OCI registry URL, or a chart name
+// (with WithRepoURL set).
+func NewPipeline(chartRef string, opts ...Option) (*Pipeline, error) {
+	if chartRef == "" {
+		return nil, errors.New("chart reference must not be empty")
+	}
+
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	o.applyDefaults()
+
+	p, err := pipeline.New(chartRef, o.pipelineOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pipeline{p: p, opts: o}, nil
+}
+
+// Load runs the load stage.
+func (p *Pipeline) Load(ctx context.Context) error { return p.runTo(ctx, StageLoad) }
+
+// Render runs the stages up to render.
+func (p *Pipeline) Render(ctx context.Context) error { return p.runTo(ctx, StageRender) }
+
+// Filter runs the stages up to filter.
+func (p *Pipeline) Filter(ctx context.Context) error { return p.runTo(ctx, StageFilter) }
+
+// Transform runs the stages up to transform.
+func (p *Pipeline) Transform(ctx context.Context) error { return p.runTo(ctx, StageTransform) }
+
+// Harden runs the stages up to harden.
+func (p *Pipeline) Harden(ctx context.Context) error { return p.runTo(ctx, StageHarden) }
+
+// Generate runs the stages up to generate.
+func (p *Pipeline) Generate(ctx context.Context) error { return p.runTo(ctx, StageGenerate) }
+
+// Run runs the remaining stages and returns the conversion result.
+func (p *Pipeline) Run(ctx context.Context) (*Result, error) {
+	if err := p.Generate(ctx); err != nil {
+		return nil, err
+	}
+
+	yamlBytes, rgdMap, err := pipeline.Normalize(p.p.RGDMap)
+	if err != nil {
+		return nil, err
+	}
+
+	var hardenSummary *HardenSummary
+	if hr := p.p.HardenResult; hr != nil {
+		hardenSummary = &HardenSummary{Changes: len(hr.Changes), Warnings: hr.Warnings}
+	}
+
+	nested, err := nestedRGDs(p.p.NestedRGDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var conformanceYAML []byte
+
+	if suite := p.p.Conformance; suite != nil && len(suite.Tests) > 0 {
+		rgd, rgdErr := suite.RGD(p.generatorConfig())
+		if rgdErr != nil {
+			return nil, rgdErr
+		}
+
+		if conformanceYAML, _, err = pipeline.Normalize(rgd.ToMap()); err != nil {
+			return nil, err
+		}
+	}
+
+	result := p.p.Result
+
+	return &Result{
+		YAML:             yamlBytes,
+		RGDMap:           rgdMap,
+		ChartName:        p.p.Meta.Name,
+		ChartVersion:     p.p.Meta.Version,
+		ResourceCount:    len(result.Resources),
+		SchemaFieldCount: len(result.SchemaFields),
+		DependencyEdges:  result.DependencyGraph.EdgeCount(),
+		HardenResult:     hardenSummary,
+		Verification:     verificationResult(p.p.Loaded.Verification),
+		AuditFindings:    p.auditFindings,
+		BrokenEdges:      brokenEdges(result.BrokenEdges),
+		Nested:           nested,
+		ConformanceYAML:  conformanceYAML,
+	}, nil
+}
+
+// nestedRGDs serializes the subchart RGDs.
+func nestedRGDs(rgds []pipeline.NestedRGD) ([]NestedRGD, error) {
+	if len(rgds) == 0 {
+		return nil, nil
+	}
+
+	out := make([]NestedRGD, len(rgds))
+
+	for i, n := range rgds {
+		yamlBytes, rgdMap, err := pipeline.Normalize(n.RGDMap)
+		if err != nil {
+			return nil, fmt.Errorf("subchart %s: %w", n.Path, err)
+		}
+
+		out[i] = NestedRGD{Path: n.Path, YAML: yamlBytes, RGDMap: rgdMap}
+	}
+
+	return out, nil
+}
+
+// generatorConfig returns the RGD settings of the conformance RGD.
+func (p *Pipeline) generatorConfig() kro.GeneratorConfig {
+	return kro.GeneratorConfig{
+		Name:             p.p.RGDName(),
+		ChartName:        p.p.Meta.Name,
+		ChartVersion:     p.p.Meta.Version,
+		SchemaKind:       p.opts.kind,
+		SchemaAPIVersion: p.opts.apiVersion,
+		SchemaGroup:      p.opts.group,
+	}
+}
+
+func (p *Pipeline) runTo(ctx context.Context, stage Stage) error {
+	return p.p.RunTo(ctx, pipeline.Stage(stage), func(done pipeline.Stage) error {
+		if done == pipeline.StageFilter && p.opts.audit {
+			findings, err := runAudit(ctx, p.opts.auditLevel, p.p.Resources)
+			if err != nil {
+				return fmt.Errorf("auditing: %w", err)
+			}
+
+			p.auditFindings = findings
+		}
+
+		for _, hook := range p.opts.hooks[Stage(done)] {
+			if err := hook(ctx, p); err != nil {
+				return fmt.Errorf("%s hook: %w", done, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// ChartInfo describes the loaded chart.
+type ChartInfo struct {
+	Name       string
+	Version    string
+	AppVersion string
+	// Digest is the "sha256:" digest of the chart archive, empty for chart
+	// directories.
+	Digest string
+}
+
+// Chart returns the loaded chart, or nil before the load stage.
+func (p *Pipeline) Chart() *ChartInfo {
+	if p.p.Meta == nil {
+		return nil
+	}
+
+	return &ChartInfo{
+		Name:       p.p.Meta.Name,
+		Version:    p.p.Meta.Version,
+		AppVersion: p.p.Meta.AppVersion,
+		Digest:     p.p.Loaded.Digest,
+	}
+}
+
+// Values returns the merged values, or nil before the render stage.
+func (p *Pipeline) Values() map[string]interface{} { return p.p.Values }
+
+// RenderedManifests returns the rendered and post-rendered manifests,
+// including hooks, or nil before the render stage.
+func (p *Pipeline) RenderedManifests() []byte { return p.p.Manifests }
+
+// Resource is a Kubernetes resource of the pipeline.
+type Resource struct {
+	// ID is the resource ID in the RGD, empty before the transform stage.
+	ID         string
+	APIVersion string
+	Kind       string
+	Name       string
+	Namespace  string
+	// SourcePath is the template that rendered the resource, if known.
+	SourcePath string
+	// Object is the resource manifest. Changes made by hooks carry over to
+	// the following stages.
+	Object map[string]interface{}
+}
+
+// Resources returns the current resources: the parsed resources after the
+// render stage, the included resources after the filter stage, and the
+// parameterised and hardened resources after the transform and harden
+// stages.
+func (p *Pipeline) Resources() []*Resource { return p.resources(p.p.Resources) }
+
+// ExcludedResource is a resource removed by a filter.
+type ExcludedResource struct {
+	Resource *Resource
+	// Reason describes the filter that excluded the resource.
+	Reason string
+}
+
+// ExternalizedResource is a resource replaced by an externalRef.
+type ExternalizedResource struct {
+	Resource *Resource
+	// ExternalRef is the externalRef entry of the RGD.
+	ExternalRef map[string]interface{}
+}
+
+// FilterResult is the outcome of the resource filters.
+type FilterResult struct {
+	Included     []*Resource
+	Excluded     []ExcludedResource
+	Externalized []ExternalizedResource
+}
+
+// FilterResult returns the outcome of the filters, or nil before the filter
+// stage and when no filters are configured.
+func (p *Pipeline) FilterResult() *FilterResult {
+	fr := p.p.FilterResult
+	if fr == nil {
+		return nil
+	}
+
+	out := &FilterResult{Included: p.resources(fr.Included)}
+
+	for _, ex := range fr.Excluded {
+		out.Excluded = append(out.Excluded, ExcludedResource{Resource: p.resource(ex.Resource), Reason: ex.Reason})
+	}
+
+	for _, ext := range fr.Externalized {
+		out.Externalized = append(out.Externalized, ExternalizedResource{Resource: p.resource(ext.Resource), ExternalRef: ext.ExternalRef})
+	}
+
+	return out
+}
+
+// FieldMapping maps a Helm values path to a resource field.
+type FieldMapping struct {
+	// ValuesPath is the dotted values path, e.g. "image.tag".
+	ValuesPath string
+	ResourceID string
+	// FieldPath is the field path within the resource, e.g.
+	// "spec.template.spec.containers[0].image".
+	FieldPath string
+	// Interpolated is true when the value is part of a larger string.
+	Interpolated bool
+}
+
+// FieldMappings returns the detected parameter mappings, or nil before the
+// transform stage.
+func (p *Pipeline) FieldMappings() []FieldMapping {
+	if len(p.p.FieldMappings) == 0 {
+		return nil
+	}
+
+	out := make([]FieldMapping, len(p.p.FieldMappings))
+	for i, m := range p.p.FieldMappings {
+		out[i] = FieldMapping{
+			ValuesPath:   m.ValuesPath,
+			ResourceID:   m.ResourceID,
+			FieldPath:    m.FieldPath,
+			Interpolated: m.MatchType == transform.MatchSubstring,
+		}
+	}
+
+	return out
+}
+
+// DependencyEdge is an edge of the dependency graph.
+type DependencyEdge struct {
+	// From depends on To.
+	From string
+	To   string
+	// Reasons are the reasons the edge was detected, e.g. "selector".
+	Reasons []string
+}
+
+// DependencyGraph is the dependency graph of the RGD resources.
+type DependencyGraph struct {
+	// Nodes are the resource IDs, sorted.
+	Nodes []string
+	Edges []DependencyEdge
+	// Order is the topological order of the resources, dependencies first.
+	Order []string
+}
+
+// DependencyGraph returns the dependency graph, or nil before the transform
+// stage.
+func (p *Pipeline) DependencyGraph() *DependencyGraph {
+	if p.p.Result == nil || p.p.Result.DependencyGraph == nil {
+		return nil
+	}
+
+	g := p.p.Result.DependencyGraph
+	out := &DependencyGraph{Nodes: g.Nodes()}
+
+	for _, from := range out.Nodes {
+		for _, to := range g.DependenciesOf(from) {
+			edge := DependencyEdge{From: from, To: to}
+			for _, r := range g.EdgeReasons(from, to) {
+				edge.Reasons = append(edge.Reasons, string(r))
+			}
+
+			out.Edges = append(out.Edges, edge)
+		}
+	}
+
+	if order, err := g.TopologicalSort(); err == nil {
+		out.Order = order
+	}
+
+	return out
+}
+
+// HardenChange is a change made by security hardening.
+type HardenChange struct {
+	ResourceID string
+	FieldPath  string
+	OldValue   string
+	NewValue   string
+	Reason     string
+}
+
+// HardenChanges returns the hardening changes, or nil before the harden
+// stage and without WithHarden.
+func (p *Pipeline) HardenChanges() []HardenChange {
+	hr := p.p.HardenResult
+	if hr == nil || len(hr.Changes) == 0 {
+		return nil
+	}
+
+	out := make([]HardenChange, len(hr.Changes))
+	for i, c := range hr.Changes {
+		out[i] = HardenChange{
+			ResourceID: c.ResourceID,
+			FieldPath:  c.FieldPath,
+			OldValue:   c.OldValue,
+			NewValue:   c.NewValue,
+			Reason:     c.Reason,
+		}
+	}
+
+	return out
+}
+
+// AuditFindings returns the audit findings, or nil before the filter stage
+// and without WithAudit.
+func (p *Pipeline) AuditFindings() []AuditFinding { return p.auditFindings }
+
+// ConformanceManifests resolves the Helm test hooks extracted with
+// WithConformance against instance, an instance of the generated RGD, and
+// returns them as plain manifests in test order. It fails before the
+// generate stage and when the chart has no test hooks.
+func (p *Pipeline) ConformanceManifests(instance map[string]interface{}) ([]map[string]interface{}, error) {
+	if p.p.Conformance == nil {
+		return nil, errors.New("no conformance suite: run the generate stage with WithConformance")
+	}
+
+	return p.p.Conformance.Manifests(instance)
+}
+
+// RGDMap returns the generated RGD, or nil before the generate stage.
+// Changes made by hooks are included in the result of Run.
+func (p *Pipeline) RGDMap() map[string]interface{} { return p.p.RGDMap }
+
+// resources converts resources to the public type.
+func (p *Pipeline) resources(rs []*k8s.Resource) []*Resource {
+	out := make([]*Resource, len(rs))
+	for i, r := range rs {
+		out[i] = p.resource(r)
+	}
+
+	return out
+}
+
+// resource converts a resource to the public type.
+func (p *Pipeline) resource(r *k8s.Resource) *Resource {
+	ids := p.p.ResourceIDs
+	if p.p.Result != nil {
+		ids = p.p.Result.ResourceIDs
+	}
+
+	out := &Resource{
+		ID:         ids[r],
+		APIVersion: r.APIVersion(),
+		Kind:       r.Kind(),
+		Name:       r.Name,
+		Namespace:  r.Namespace,
+		SourcePath: r.SourcePath,
+	}
+
+	if r.Object != nil {
+		out.Object = r.Object.Object
+	}
+
+	return out
+}
+
+// pipelineOptions maps the options to internal pipeline options.
+func (o *options) pipelineOptions() pipeline.Options {
+	var chartCache *cache.Cache
+	if o.cacheDir != "" {
+		chartCache = cache.New(o.cacheDir)
+	}
+
+	var schemaOverrides map[string]transform.SchemaOverride
+	if len(o.schemaOverrides) > 0 {
+		schemaOverrides = toTransformSchemaOverrides(o.schemaOverrides)
+	}
+
+	return pipeline.Options{
+		Load: loader.LoadOptions{
+			Version:  o.version,
+			RepoURL:  o.repoURL,
+			Username: o.username,
+			Password: o.password,
+			CaFile:   o.caFile,
+			CertFile: o.certFile,
+			KeyFile:  o.keyFile,
+
+			PlainHTTP:           o.plainHTTP,
+			ResolveDependencies: o.resolveDependencies,
+			Cache:               chartCache,
+			Offline:             o.offline,
+			Verify:              o.verify,
+			Keyring:             o.keyring,
+			PublicKey:           o.publicKey,
+		},
+		ReleaseName: o.releaseName,
+		Namespace:   o.namespace,
+		Strict:      o.strict,
+		Timeout:     o.timeout,
+		PostRender: postrender.Options{
+			Exec:         o.postRenderer,
+			ExecArgs:     o.postRendererArgs,
+			KustomizeDir: o.kustomizeDir,
+		},
+		Values: renderer.ValuesOptions{
+			ValueFiles:   o.valueFiles,
+			Values:       o.values,
+			StringValues: o.stringValues,
+			FileValues:   o.fileValues,
+		},
+		HookMode:                o.hookMode(),
+		Profile:                 o.profile,
+		ExcludeKinds:            o.excludeKinds,
+		ExcludeResources:        o.excludeResources,
+		ExcludeSubcharts:        o.excludeSubcharts,
+		NestedSubcharts:         o.nestedSubcharts,
+		ExcludeLabels:           o.excludeLabels,
+		ExternalizeSecret:       o.externalizeSecret,
+		ExternalizeService:      o.externalizeService,
+		UseExternalPattern:      o.useExternalPattern,
+		ConfigData:              o.transformConfigData,
+		ResourceIDOverrides:     o.resourceIDOverrides,
+		SchemaOverrides:         schemaOverrides,
+		ReadyConditions:         o.readyConditions,
+		IncludeAllValues:        o.includeAllValues,
+		FlatSchema:              o.flatSchema,
+		Fast:                    o.fast,
+		ExtractTests:            o.conformance,
+		Harden:                  o.harden,
+		SecurityLevel:           o.securityLevel,
+		GenerateNetworkPolicies: o.generateNetworkPolicies,
+		GenerateRBAC:            o.generateRBAC,
+		ResolveDigests:          o.resolveDigests,
+		Kind:                    o.kind,
+		APIVersion:              o.apiVersion,
+		Group:                   o.group,
+		AlwaysSchema:            o.nestedSubcharts,
+	}
+}
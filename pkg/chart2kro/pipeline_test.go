@@ -0,0 +1,220 @@
+package chart2kro_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/chart2kro/pkg/chart2kro"
+)
+
+const simpleChart = "../../testdata/charts/simple"
+
+func TestNewPipeline_EmptyRef(t *testing.T) {
+	_, err := chart2kro.NewPipeline("")
+	require.ErrorContains(t, err, "chart reference must not be empty")
+}
+
+func TestPipeline_Stages(t *testing.T) {
+	ctx := context.Background()
+
+	p, err := chart2kro.NewPipeline(simpleChart)
+	require.NoError(t, err)
+
+	assert.Nil(t, p.Chart())
+	assert.Nil(t, p.RenderedManifests())
+
+	require.NoError(t, p.Load(ctx))
+	require.NotNil(t, p.Chart())
+	assert.Equal(t, "simple", p.Chart().Name)
+	assert.Equal(t, "1.0.0", p.Chart().Version)
+	assert.Nil(t, p.Values())
+
+	require.NoError(t, p.Render(ctx))
+	assert.Contains(t, string(p.RenderedManifests()), "kind: Deployment")
+	assert.EqualValues(t, 1, p.Values()["replicaCount"])
+	require.Len(t, p.Resources(), 2)
+	assert.Empty(t, p.Resources()[0].ID, "IDs are assigned by the transform stage")
+	assert.Nil(t, p.FieldMappings())
+	assert.Nil(t, p.DependencyGraph())
+
+	require.NoError(t, p.Transform(ctx))
+	assert.Nil(t, p.FilterResult(), "no filters configured")
+
+	var kinds []string
+	for _, r := range p.Resources() {
+		assert.NotEmpty(t, r.ID)
+		assert.NotEmpty(t, r.Object)
+
+		kinds = append(kinds, r.Kind)
+	}
+
+	assert.ElementsMatch(t, []string{"Deployment", "Service"}, kinds)
+
+	var paths []string
+	for _, m := range p.FieldMappings() {
+		paths = append(paths, m.ValuesPath)
+	}
+
+	assert.Contains(t, paths, "replicaCount")
+
+	graph := p.DependencyGraph()
+	require.NotNil(t, graph)
+	assert.Len(t, graph.Nodes, 2)
+	assert.Len(t, graph.Order, 2)
+
+	assert.Nil(t, p.RGDMap())
+
+	result, err := p.Run(ctx)
+	require.NoError(t, err)
+	assert.NotNil(t, p.RGDMap())
+
+	want, err := chart2kro.Convert(ctx, simpleChart)
+	require.NoError(t, err)
+	assert.Equal(t, string(want.YAML), string(result.YAML))
+}
+
+func TestPipeline_CompletedStageIsNoOp(t *testing.T) {
+	ctx := context.Background()
+
+	var calls int
+
+	p, err := chart2kro.NewPipeline(simpleChart, chart2kro.WithHook(chart2kro.StageLoad, func(context.Context, *chart2kro.Pipeline) error {
+		calls++
+
+		return nil
+	}))
+	require.NoError(t, err)
+
+	require.NoError(t, p.Transform(ctx))
+	require.NoError(t, p.Load(ctx))
+	require.NoError(t, p.Render(ctx))
+
+	assert.Equal(t, 1, calls)
+}
+
+func TestPipeline_Hooks(t *testing.T) {
+	var stages []chart2kro.Stage
+
+	record := func(stage chart2kro.Stage) chart2kro.Option {
+		return chart2kro.WithHook(stage, func(context.Context, *chart2kro.Pipeline) error {
+			stages = append(stages, stage)
+
+			return nil
+		})
+	}
+
+	// Hooks run after their stage, in the order they were added.
+	result, err := chart2kro.Convert(context.Background(), simpleChart,
+		record(chart2kro.StageGenerate),
+		record(chart2kro.StageRender),
+		record(chart2kro.StageLoad),
+		record(chart2kro.StageFilter),
+		record(chart2kro.StageHarden),
+		record(chart2kro.StageTransform),
+		record(chart2kro.StageRender),
+		chart2kro.WithHook(chart2kro.StageFilter, func(_ context.Context, p *chart2kro.Pipeline) error {
+			for _, r := range p.Resources() {
+				meta, _ := r.Object["metadata"].(map[string]interface{})
+				meta["labels"] = map[string]interface{}{"team": "platform"}
+			}
+
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []chart2kro.Stage{
+		chart2kro.StageLoad,
+		chart2kro.StageRender,
+		chart2kro.StageRender,
+		chart2kro.StageFilter,
+		chart2kro.StageTransform,
+		chart2kro.StageHarden,
+		chart2kro.StageGenerate,
+	}, stages)
+	assert.Contains(t, string(result.YAML), "team: platform")
+}
+
+func TestPipeline_HookError(t *testing.T) {
+	ctx := context.Background()
+	errStop := errors.New("stop")
+
+	p, err := chart2kro.NewPipeline(simpleChart, chart2kro.WithHook(chart2kro.StageRender, func(context.Context, *chart2kro.Pipeline) error {
+		return errStop
+	}))
+	require.NoError(t, err)
+
+	err = p.Filter(ctx)
+	require.ErrorIs(t, err, errStop)
+	assert.Contains(t, err.Error(), "render hook")
+	assert.NotNil(t, p.RenderedManifests())
+
+	_, err = p.Run(ctx)
+	require.ErrorIs(t, err, errStop)
+}
+
+func TestPipeline_FilterResult(t *testing.T) {
+	p, err := chart2kro.NewPipeline(simpleChart, chart2kro.WithExcludeKinds([]string{"Service"}))
+	require.NoError(t, err)
+	require.NoError(t, p.Filter(context.Background()))
+
+	fr := p.FilterResult()
+	require.NotNil(t, fr)
+	require.Len(t, fr.Included, 1)
+	assert.Equal(t, "Deployment", fr.Included[0].Kind)
+	require.Len(t, fr.Excluded, 1)
+	assert.Equal(t, "Service", fr.Excluded[0].Resource.Kind)
+	assert.NotEmpty(t, fr.Excluded[0].Reason)
+	assert.Len(t, p.Resources(), 1)
+}
+
+func TestPipeline_HardenChanges(t *testing.T) {
+	p, err := chart2kro.NewPipeline(simpleChart, chart2kro.WithHarden(), chart2kro.WithSecurityLevel("restricted"))
+	require.NoError(t, err)
+
+	require.NoError(t, p.Transform(context.Background()))
+	assert.Nil(t, p.HardenChanges())
+
+	require.NoError(t, p.Harden(context.Background()))
+	require.NotEmpty(t, p.HardenChanges())
+
+	c := p.HardenChanges()[0]
+	assert.NotEmpty(t, c.ResourceID)
+	assert.NotEmpty(t, c.FieldPath)
+	assert.NotEmpty(t, c.Reason)
+}
+
+func TestPipeline_AuditFindings(t *testing.T) {
+	p, err := chart2kro.NewPipeline(simpleChart, chart2kro.WithAudit(""))
+	require.NoError(t, err)
+
+	require.NoError(t, p.Render(context.Background()))
+	assert.Nil(t, p.AuditFindings())
+
+	require.NoError(t, p.Filter(context.Background()))
+	assert.NotEmpty(t, p.AuditFindings())
+}
+
+func TestPipeline_ConformanceManifests(t *testing.T) {
+	p, err := chart2kro.NewPipeline("../../testdata/charts/with-hooks", chart2kro.WithConformance())
+	require.NoError(t, err)
+
+	_, err = p.ConformanceManifests(nil)
+	require.Error(t, err)
+
+	require.NoError(t, p.Generate(context.Background()))
+
+	manifests, err := p.ConformanceManifests(map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "demo", "namespace": "smoke"},
+	})
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+	assert.Equal(t, "Pod", manifests[0]["kind"])
+
+	metadata, _ := manifests[0]["metadata"].(map[string]interface{})
+	assert.Equal(t, "smoke", metadata["namespace"])
+}